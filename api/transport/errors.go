@@ -50,6 +50,13 @@ func IsTimeoutError(err error) bool {
 	return ok
 }
 
+// IsBodyTooLargeError returns true if a request or response failed because
+// its body was larger than the limit configured for it, either locally or by
+// the remote service.
+func IsBodyTooLargeError(err error) bool {
+	return errors.IsBodyTooLargeError(err)
+}
+
 // UnrecognizedProcedureError returns an error for the given request,
 // such that IsUnrecognizedProcedureError can distinguish it from other errors
 // coming out of router.Choose.
@@ -22,7 +22,6 @@ package transport
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"runtime/debug"
 	"time"
@@ -97,6 +96,8 @@ type OnewayHandler interface {
 
 // DispatchUnaryHandler calls the handler h, recovering panics and timeout errors,
 // converting them to yarpc errors. All other errors are passed trough.
+//
+// Panics that the Dispatcher was configured to re-panic are not recovered.
 func DispatchUnaryHandler(
 	ctx context.Context,
 	h UnaryHandler,
@@ -106,8 +107,11 @@ func DispatchUnaryHandler(
 ) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
+			if u, ok := r.(errors.UnrecoverablePanic); ok {
+				panic(u.Value)
+			}
 			log.Printf("Unary handler panicked: %v\n%s", r, debug.Stack())
-			err = fmt.Errorf("panic: %v", r)
+			err = errors.HandlerPanicError(r)
 		}
 	}()
 
@@ -124,6 +128,8 @@ func DispatchUnaryHandler(
 
 // DispatchOnewayHandler calls the oneway handler, recovering from panics as
 // errors
+//
+// Panics that the Dispatcher was configured to re-panic are not recovered.
 func DispatchOnewayHandler(
 	ctx context.Context,
 	h OnewayHandler,
@@ -131,8 +137,11 @@ func DispatchOnewayHandler(
 ) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
+			if u, ok := r.(errors.UnrecoverablePanic); ok {
+				panic(u.Value)
+			}
 			log.Printf("Oneway handler panicked: %v\n%s", r, debug.Stack())
-			err = fmt.Errorf("panic: %v", r)
+			err = errors.HandlerPanicError(r)
 		}
 	}()
 
@@ -26,6 +26,8 @@ import (
 	"testing"
 	"time"
 
+	"go.uber.org/yarpc/internal/errors"
+
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap/zapcore"
 )
@@ -100,3 +102,28 @@ func TestDispatchOnewayHandlerWithPanic(t *testing.T) {
 	expectMsg := fmt.Sprintf("panic: %s", msg)
 	assert.Equal(t, err.Error(), expectMsg)
 }
+
+func TestDispatchHandlerWithUnrecoverablePanic(t *testing.T) {
+	value := "I'm panicking and was already handled!"
+	unary := func(context.Context, *Request, ResponseWriter) error {
+		panic(errors.UnrecoverablePanic{Value: value})
+	}
+	oneway := func(context.Context, *Request) error {
+		panic(errors.UnrecoverablePanic{Value: value})
+	}
+
+	assert.PanicsWithValue(t, value, func() {
+		DispatchUnaryHandler(
+			context.Background(),
+			unaryHandlerFunc(unary),
+			time.Now(),
+			&Request{},
+			nil)
+	}, "expected unary handler panic to propagate")
+	assert.PanicsWithValue(t, value, func() {
+		DispatchOnewayHandler(
+			context.Background(),
+			onewayHandlerFunc(oneway),
+			nil)
+	}, "expected oneway handler panic to propagate")
+}
@@ -142,6 +142,9 @@ const pageHTML = `
 {{range .Dispatchers}}
 	<hr />
 	<h2>Dispatcher "{{.Name}}" <small>({{.ID}})</small></h2>
+	<p>Handler panics: {{.Panics}}</p>
+	{{range .Services}}
+	<h3>Service "{{.Name}}"</h3>
 	<table>
 		<tr>
 			<th>Procedure</th>
@@ -158,6 +161,7 @@ const pageHTML = `
 		</tr>
 		{{end}}
 	</table>
+	{{end}}
 	<h3>Inbounds</h3>
 	<table>
 		<tr>
@@ -184,6 +188,7 @@ const pageHTML = `
 			<th>Endpoint</th>
 			<th>State</th>
 			<th colspan="3">Chooser</th>
+			<th>Routes</th>
 		</tr>
 		<tr>
 			<th></th>
@@ -194,6 +199,7 @@ const pageHTML = `
 			<th>Name</th>
 			<th>State</th>
 			<th>Peers</th>
+			<th></th>
 		</tr>
 		</thead>
 		<tbody>
@@ -214,6 +220,13 @@ const pageHTML = `
 				{{end}}
 				</ul>
 			</td>
+			<td>
+				<ul>
+				{{range .Routes}}
+					<li>{{.Match}}: {{.Outbound}} ({{.Status.Transport}} {{.Status.Endpoint}})</li>
+				{{end}}
+				</ul>
+			</td>
 		</tr>
 		</tbody>
 		{{end}}
@@ -31,6 +31,7 @@ import (
 	"go.uber.org/yarpc/internal/inboundmiddleware"
 	"go.uber.org/yarpc/internal/observerware"
 	"go.uber.org/yarpc/internal/outboundmiddleware"
+	"go.uber.org/yarpc/internal/outboundswap"
 	"go.uber.org/yarpc/internal/request"
 	intsync "go.uber.org/yarpc/internal/sync"
 
@@ -70,9 +71,30 @@ type Config struct {
 	// RouterMiddleware is middleware to control how requests are routed.
 	RouterMiddleware middleware.Router
 
+	// Relay configures the Dispatcher to forward requests that it does not
+	// handle itself through its outbounds. Requests are not relayed if this
+	// is nil.
+	Relay *RelayConfig
+
 	// ZapLogger provides a logger for the dispatcher. The default logger is a
 	// no-op.
 	ZapLogger *zap.Logger
+
+	// Logging customizes the levels, sampling and payloads of the entries
+	// the Dispatcher logs for each request.
+	Logging LoggingConfig
+
+	// Panics configures how the Dispatcher handles panics in the handlers of
+	// its procedures.
+	Panics PanicConfig
+
+	// Services configures other services hosted by the Dispatcher, keyed by
+	// their names. An entry may also be added for Name to configure the
+	// service the Dispatcher is named after.
+	//
+	// This may be nil if the Dispatcher only hosts the service it is named
+	// after.
+	Services map[string]ServiceConfig
 }
 
 // Inbounds contains a list of inbound transports. Each inbound transport
@@ -110,6 +132,11 @@ func NewDispatcher(cfg Config) *Dispatcher {
 	if err := internal.ValidateServiceName(cfg.Name); err != nil {
 		panic("yarpc.NewDispatcher expects a valid service name: %s" + err.Error())
 	}
+	for name := range cfg.Services {
+		if err := internal.ValidateServiceName(name); err != nil {
+			panic(fmt.Sprintf("yarpc.NewDispatcher expects valid names for hosted services: %v", err))
+		}
+	}
 
 	logger := zap.NewNop()
 	if cfg.ZapLogger != nil {
@@ -119,20 +146,43 @@ func NewDispatcher(cfg Config) *Dispatcher {
 		)
 		cfg = addObservingMiddleware(cfg, logger)
 	}
+	if len(cfg.Services) > 0 {
+		cfg = addHostedServiceCaller(cfg)
+	}
+
+	for outboundKey, outs := range cfg.Outbounds {
+		if outs.Unary == nil && outs.Oneway == nil {
+			panic(fmt.Sprintf("no outbound set for outbound key %q in dispatcher", outboundKey))
+		}
+	}
 
-	return &Dispatcher{
-		name:              cfg.Name,
-		table:             middleware.ApplyRouteTable(NewMapRouter(cfg.Name), cfg.RouterMiddleware),
-		inbounds:          cfg.Inbounds,
-		outbounds:         convertOutbounds(cfg.Outbounds, cfg.OutboundMiddleware),
-		transports:        collectTransports(cfg.Inbounds, cfg.Outbounds),
-		inboundMiddleware: cfg.InboundMiddleware,
-		log:               logger,
+	swaps := make(map[string]*swappableOutbounds, len(cfg.Outbounds))
+	for outboundKey, outs := range cfg.Outbounds {
+		swaps[outboundKey] = newSwappableOutbounds(outs)
 	}
+
+	d := &Dispatcher{
+		name:               cfg.Name,
+		table:              middleware.ApplyRouteTable(NewMapRouter(cfg.Name), cfg.RouterMiddleware),
+		inbounds:           cfg.Inbounds,
+		outbounds:          convertOutbounds(cfg.Outbounds, swaps, cfg.OutboundMiddleware),
+		swaps:              swaps,
+		transports:         collectTransports(cfg.Inbounds, cfg.Outbounds),
+		inboundMiddleware:  cfg.InboundMiddleware,
+		outboundMiddleware: cfg.OutboundMiddleware,
+		services:           cfg.Services,
+		panics:             newPanicHandler(cfg.Panics, logger),
+		log:                logger,
+	}
+	if cfg.Relay != nil {
+		d.table = middleware.ApplyRouteTable(d.table, relayRouter{d: d, cfg: *cfg.Relay})
+	}
+	return d
 }
 
 func addObservingMiddleware(cfg Config, logger *zap.Logger) Config {
-	observer := observerware.New(logger, observerware.NewNopContextExtractor())
+	extract, logCfg := cfg.Logging.observerware()
+	observer := observerware.NewWithConfig(logger, extract, logCfg)
 
 	cfg.InboundMiddleware.Unary = inboundmiddleware.UnaryChain(observer, cfg.InboundMiddleware.Unary)
 	cfg.InboundMiddleware.Oneway = inboundmiddleware.OnewayChain(observer, cfg.InboundMiddleware.Oneway)
@@ -143,29 +193,56 @@ func addObservingMiddleware(cfg Config, logger *zap.Logger) Config {
 	return cfg
 }
 
+// addHostedServiceCaller adds outbound middleware which sets the caller of
+// requests made while handling requests to hosted services. It runs before
+// all other middleware so that they observe the final caller.
+func addHostedServiceCaller(cfg Config) Config {
+	caller := hostedServiceCaller{name: cfg.Name}
+	cfg.OutboundMiddleware.Unary = outboundmiddleware.UnaryChain(caller, cfg.OutboundMiddleware.Unary)
+	cfg.OutboundMiddleware.Oneway = outboundmiddleware.OnewayChain(caller, cfg.OutboundMiddleware.Oneway)
+	return cfg
+}
+
+// swappableOutbounds holds the outbounds for an outbound key. The underlying
+// outbounds may be replaced by Dispatcher.UpdateOutbounds without
+// invalidating the ClientConfigs that were already handed out.
+type swappableOutbounds struct {
+	Unary  *outboundswap.UnaryOutbound
+	Oneway *outboundswap.OnewayOutbound
+}
+
+func newSwappableOutbounds(outs transport.Outbounds) *swappableOutbounds {
+	var s swappableOutbounds
+	if outs.Unary != nil {
+		s.Unary = outboundswap.NewUnaryOutbound(outs.Unary)
+	}
+	if outs.Oneway != nil {
+		s.Oneway = outboundswap.NewOnewayOutbound(outs.Oneway)
+	}
+	return &s
+}
+
 // convertOutbounds applys outbound middleware and creates validator outbounds
-func convertOutbounds(outbounds Outbounds, mw OutboundMiddleware) Outbounds {
+// over the swappable outbounds for each outbound key.
+func convertOutbounds(outbounds Outbounds, swaps map[string]*swappableOutbounds, mw OutboundMiddleware) Outbounds {
 	outboundSpecs := make(Outbounds, len(outbounds))
 
 	for outboundKey, outs := range outbounds {
-		if outs.Unary == nil && outs.Oneway == nil {
-			panic(fmt.Sprintf("no outbound set for outbound key %q in dispatcher", outboundKey))
-		}
-
 		var (
 			unaryOutbound  transport.UnaryOutbound
 			onewayOutbound transport.OnewayOutbound
 		)
 		serviceName := outboundKey
+		swap := swaps[outboundKey]
 
 		// apply outbound middleware and create ValidatorOutbounds
 		if outs.Unary != nil {
-			unaryOutbound = middleware.ApplyUnaryOutbound(outs.Unary, mw.Unary)
+			unaryOutbound = middleware.ApplyUnaryOutbound(swap.Unary, mw.Unary)
 			unaryOutbound = request.UnaryValidatorOutbound{UnaryOutbound: unaryOutbound}
 		}
 
 		if outs.Oneway != nil {
-			onewayOutbound = middleware.ApplyOnewayOutbound(outs.Oneway, mw.Oneway)
+			onewayOutbound = middleware.ApplyOnewayOutbound(swap.Oneway, mw.Oneway)
 			onewayOutbound = request.OnewayValidatorOutbound{OnewayOutbound: onewayOutbound}
 		}
 
@@ -217,13 +294,28 @@ func collectTransports(inbounds Inbounds, outbounds Outbounds) []transport.Trans
 // Dispatcher encapsulates a YARPC application. It acts as the entry point to
 // send and receive YARPC requests in a transport and encoding agnostic way.
 type Dispatcher struct {
-	table      transport.RouteTable
-	name       string
-	inbounds   Inbounds
-	outbounds  Outbounds
-	transports []transport.Transport
+	table    transport.RouteTable
+	name     string
+	inbounds Inbounds
+
+	// lifecycleMu serializes Start, Stop, and UpdateOutbounds, except while
+	// UpdateOutbounds drains replaced outbounds. outboundsMu guards
+	// outbounds, swaps, and transports, which may only be changed while
+	// lifecycleMu is also held.
+	lifecycleMu sync.Mutex
+	outboundsMu sync.RWMutex
+	running     bool
+	outbounds   Outbounds
+	swaps       map[string]*swappableOutbounds
+	transports  []transport.Transport
+
+	inboundMiddleware  InboundMiddleware
+	outboundMiddleware OutboundMiddleware
+
+	services map[string]ServiceConfig
 
-	inboundMiddleware InboundMiddleware
+	// panics recovers panics in the handlers of registered procedures.
+	panics *panicHandler
 
 	// TODO (shah): add a *pally.Registry too.
 	log *zap.Logger
@@ -246,28 +338,228 @@ func (d *Dispatcher) Inbounds() Inbounds {
 // 	keyvalueClient := json.New(dispatcher.ClientConfig("keyvalue"))
 //
 // This function panics if the outboundKey is not known.
+//
+// The ClientConfig remains valid if the outbounds for the outboundKey are
+// replaced with UpdateOutbounds. Requests made with it after its outboundKey
+// has been removed will fail.
 func (d *Dispatcher) ClientConfig(outboundKey string) transport.ClientConfig {
+	d.outboundsMu.RLock()
+	defer d.outboundsMu.RUnlock()
+
 	if rs, ok := d.outbounds[outboundKey]; ok {
 		return clientconfig.MultiOutbound(d.name, rs.ServiceName, rs)
 	}
 	panic(noOutboundForOutboundKey{OutboundKey: outboundKey})
 }
 
+// UpdateOutbounds replaces the outbounds of this Dispatcher with the given
+// Outbounds without restarting it.
+//
+// Outbound keys whose unary and oneway outbounds did not change are left
+// untouched. If the Dispatcher is running, new outbounds and any transports
+// they introduce are started before they take effect, and the Dispatcher is
+// left unchanged if any of them fail to start. ClientConfigs retrieved
+// earlier switch to the new outbounds immediately. UpdateOutbounds then waits
+// for requests in flight on the replaced outbounds to finish before stopping
+// them along with any transports that are no longer used. Other calls to
+// Start, Stop, and UpdateOutbounds may proceed while it waits.
+//
+// ClientConfigs retrieved before an update continue to report the service
+// name they were retrieved with. Call ClientConfig again to pick up a new
+// service name or an RPC type that was not previously configured.
+func (d *Dispatcher) UpdateOutbounds(outbounds Outbounds) error {
+	for outboundKey, outs := range outbounds {
+		if outs.Unary == nil && outs.Oneway == nil {
+			return fmt.Errorf("no outbound set for outbound key %q in dispatcher", outboundKey)
+		}
+	}
+
+	d.lifecycleMu.Lock()
+	d.log.Info("Updating outbounds.")
+	transports := collectTransports(d.inbounds, outbounds)
+	if d.running {
+		if err := d.startNewOutbounds(outbounds, transports); err != nil {
+			d.lifecycleMu.Unlock()
+			d.log.Error("Failed to update outbounds.", zap.Error(err))
+			return err
+		}
+	}
+
+	d.outboundsMu.Lock()
+	var (
+		replaced []transport.Lifecycle
+		waits    []func()
+	)
+	for outboundKey := range outbounds {
+		if _, ok := d.swaps[outboundKey]; !ok {
+			d.swaps[outboundKey] = &swappableOutbounds{}
+		}
+	}
+	for outboundKey, swap := range d.swaps {
+		outs := outbounds[outboundKey]
+		if swap.Unary == nil && outs.Unary != nil {
+			swap.Unary = outboundswap.NewUnaryOutbound(outs.Unary)
+		} else if swap.Unary != nil && swap.Unary.Current() != outs.Unary {
+			prev, wait := swap.Unary.Swap(outs.Unary)
+			if prev != nil {
+				replaced = append(replaced, prev)
+			}
+			waits = append(waits, wait)
+		}
+		if swap.Oneway == nil && outs.Oneway != nil {
+			swap.Oneway = outboundswap.NewOnewayOutbound(outs.Oneway)
+		} else if swap.Oneway != nil && swap.Oneway.Current() != outs.Oneway {
+			prev, wait := swap.Oneway.Swap(outs.Oneway)
+			if prev != nil {
+				replaced = append(replaced, prev)
+			}
+			waits = append(waits, wait)
+		}
+	}
+	oldTransports := d.transports
+	d.outbounds = convertOutbounds(outbounds, d.swaps, d.outboundMiddleware)
+	d.transports = transports
+	d.outboundsMu.Unlock()
+
+	running := d.running
+	d.lifecycleMu.Unlock()
+	if !running {
+		d.log.Info("Updated outbounds.")
+		return nil
+	}
+
+	// Requests on the replaced outbounds may take a while, so they are
+	// drained without holding lifecycleMu. The outbounds and transports in
+	// use are only determined afterwards since they may have changed.
+	d.log.Debug("Waiting for requests on replaced outbounds.")
+	for _, wait := range waits {
+		wait()
+	}
+
+	d.lifecycleMu.Lock()
+	defer d.lifecycleMu.Unlock()
+	if err := d.stopUnused(replaced, oldTransports); err != nil {
+		d.log.Error("Failed to stop replaced outbounds.", zap.Error(err))
+		return err
+	}
+	d.log.Info("Updated outbounds.")
+	return nil
+}
+
+// startNewOutbounds starts the outbounds and transports in the given
+// configuration which are not already in use by the Dispatcher. If any of
+// them fail to start, those that were started are stopped again.
+func (d *Dispatcher) startNewOutbounds(outbounds Outbounds, transports []transport.Transport) error {
+	inUse := d.inUse()
+
+	// NOTE: Transports MUST be started before the outbounds that use them.
+	pending := transportLifecycles(transports)
+	for _, outs := range outbounds {
+		if outs.Unary != nil {
+			pending = append(pending, outs.Unary)
+		}
+		if outs.Oneway != nil {
+			pending = append(pending, outs.Oneway)
+		}
+	}
+
+	var started []transport.Lifecycle
+	for _, l := range pending {
+		if _, ok := inUse[l]; ok {
+			continue
+		}
+		if err := l.Start(); err != nil {
+			errs := []error{err}
+			for i := len(started) - 1; i >= 0; i-- {
+				errs = append(errs, started[i].Stop())
+			}
+			return multierr.Combine(errs...)
+		}
+		inUse[l] = struct{}{}
+		started = append(started, l)
+	}
+	return nil
+}
+
+// inUse returns the outbounds and transports currently used by the
+// Dispatcher. lifecycleMu must be held.
+func (d *Dispatcher) inUse() map[transport.Lifecycle]struct{} {
+	inUse := make(map[transport.Lifecycle]struct{})
+	for _, t := range d.transports {
+		inUse[t] = struct{}{}
+	}
+	for _, swap := range d.swaps {
+		if swap.Unary != nil && swap.Unary.Current() != nil {
+			inUse[swap.Unary.Current()] = struct{}{}
+		}
+		if swap.Oneway != nil && swap.Oneway.Current() != nil {
+			inUse[swap.Oneway.Current()] = struct{}{}
+		}
+	}
+	return inUse
+}
+
+// stopUnused stops the replaced outbounds and the old transports which are no
+// longer used by the Dispatcher. lifecycleMu must be held.
+func (d *Dispatcher) stopUnused(replaced []transport.Lifecycle, oldTransports []transport.Transport) error {
+	inUse := d.inUse()
+
+	// NOTE: Outbounds MUST be stopped before the transports they use.
+	var errs []error
+	for _, group := range [][]transport.Lifecycle{replaced, transportLifecycles(oldTransports)} {
+		wait := intsync.ErrorWaiter{}
+		for _, l := range group {
+			if _, ok := inUse[l]; ok {
+				continue
+			}
+			inUse[l] = struct{}{} // stop each only once
+			wait.Submit(l.Stop)
+		}
+		errs = append(errs, wait.Wait()...)
+	}
+	return multierr.Combine(errs...)
+}
+
+func transportLifecycles(transports []transport.Transport) []transport.Lifecycle {
+	ls := make([]transport.Lifecycle, len(transports))
+	for i, t := range transports {
+		ls[i] = t
+	}
+	return ls
+}
+
 // Register registers zero or more procedures with this dispatcher. Incoming
 // requests to these procedures will be routed to the handlers specified in
 // the given Procedures.
+//
+// Procedures of services hosted through Config.Services are wrapped with the
+// inbound middleware of their service.
 func (d *Dispatcher) Register(rs []transport.Procedure) {
 	procedures := make([]transport.Procedure, 0, len(rs))
 
 	for _, r := range rs {
+		service := r.Service
+		if service == "" {
+			service = d.name
+		}
+		svc, hosted := d.services[service]
+
 		switch r.HandlerSpec.Type() {
 		case transport.Unary:
-			h := middleware.ApplyUnaryInbound(r.HandlerSpec.Unary(),
-				d.inboundMiddleware.Unary)
+			h := middleware.ApplyUnaryInbound(d.panics.Unary(r.HandlerSpec.Unary()),
+				svc.InboundMiddleware.Unary)
+			h = middleware.ApplyUnaryInbound(h, d.inboundMiddleware.Unary)
+			if hosted {
+				h = hostedServiceUnaryHandler{h: h, service: service}
+			}
 			r.HandlerSpec = transport.NewUnaryHandlerSpec(h)
 		case transport.Oneway:
-			h := middleware.ApplyOnewayInbound(r.HandlerSpec.Oneway(),
-				d.inboundMiddleware.Oneway)
+			h := middleware.ApplyOnewayInbound(d.panics.Oneway(r.HandlerSpec.Oneway()),
+				svc.InboundMiddleware.Oneway)
+			h = middleware.ApplyOnewayInbound(h, d.inboundMiddleware.Oneway)
+			if hosted {
+				h = hostedServiceOnewayHandler{h: h, service: service}
+			}
 			r.HandlerSpec = transport.NewOnewayHandlerSpec(h)
 		default:
 			panic(fmt.Sprintf("unknown handler type %q for service %q, procedure %q",
@@ -305,6 +597,9 @@ func (d *Dispatcher) Start() error {
 	// If the inbounds are started before the outbounds, an inbound request
 	// might result in an outbound call before the outbound is ready.
 
+	d.lifecycleMu.Lock()
+	defer d.lifecycleMu.Unlock()
+
 	var (
 		mu         sync.Mutex
 		allStarted []transport.Lifecycle
@@ -385,6 +680,7 @@ func (d *Dispatcher) Start() error {
 	addDispatcherToDebugPages(d)
 	d.log.Debug("Registered debug pages.")
 
+	d.running = true
 	d.log.Info("Started up.")
 	return nil
 }
@@ -405,7 +701,11 @@ func (d *Dispatcher) Stop() error {
 	// If the transports are stopped before the outbounds, the peers contained
 	// in the outbound might be deleted from the transport's perspective and
 	// cause issues.
+	d.lifecycleMu.Lock()
+	defer d.lifecycleMu.Unlock()
+
 	var allErrs []error
+	d.running = false
 	d.log.Info("Starting shutdown.")
 
 	// Stop Inbounds
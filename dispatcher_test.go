@@ -21,9 +21,12 @@
 package yarpc_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	. "go.uber.org/yarpc"
 	"go.uber.org/yarpc/api/transport"
@@ -35,6 +38,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func basicDispatcher(t *testing.T) *Dispatcher {
@@ -357,3 +362,231 @@ func TestClientConfigWithOutboundServiceNameOverride(t *testing.T) {
 	assert.Equal(t, "test", cc.Caller())
 	assert.Equal(t, "my-real-service", cc.Service())
 }
+
+func TestUpdateOutbounds(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	oldTransport := transporttest.NewMockTransport(mockCtrl)
+	newTransport := transporttest.NewMockTransport(mockCtrl)
+
+	oldFoo := transporttest.NewMockUnaryOutbound(mockCtrl)
+	oldFoo.EXPECT().Transports().Return([]transport.Transport{oldTransport}).AnyTimes()
+	newFoo := transporttest.NewMockUnaryOutbound(mockCtrl)
+	newFoo.EXPECT().Transports().Return([]transport.Transport{newTransport}).AnyTimes()
+	bar := transporttest.NewMockUnaryOutbound(mockCtrl)
+	bar.EXPECT().Transports().Return([]transport.Transport{oldTransport}).AnyTimes()
+	baz := transporttest.NewMockOnewayOutbound(mockCtrl)
+	baz.EXPECT().Transports().AnyTimes()
+	qux := transporttest.NewMockUnaryOutbound(mockCtrl)
+	qux.EXPECT().Transports().AnyTimes()
+
+	dispatcher := NewDispatcher(Config{
+		Name: "test",
+		Outbounds: Outbounds{
+			"foo": {Unary: oldFoo},
+			"bar": {Unary: bar},
+			"qux": {Unary: qux},
+		},
+	})
+
+	gomock.InOrder(
+		oldTransport.EXPECT().Start().Return(nil),
+		oldFoo.EXPECT().Start().Return(nil),
+	)
+	bar.EXPECT().Start().Return(nil)
+	qux.EXPECT().Start().Return(nil)
+	require.NoError(t, dispatcher.Start())
+
+	fooConfig := dispatcher.ClientConfig("foo")
+	quxConfig := dispatcher.ClientConfig("qux")
+
+	gomock.InOrder(
+		newTransport.EXPECT().Start().Return(nil),
+		newFoo.EXPECT().Start().Return(nil),
+		oldFoo.EXPECT().Stop().Return(nil),
+	)
+	baz.EXPECT().Start().Return(nil)
+	qux.EXPECT().Stop().Return(nil)
+	require.NoError(t, dispatcher.UpdateOutbounds(Outbounds{
+		"foo": {Unary: newFoo},
+		"bar": {Unary: bar},
+		"baz": {Oneway: baz},
+	}))
+
+	// ClientConfigs retrieved before the update use the new outbounds.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req := &transport.Request{
+		Caller:    "test",
+		Service:   "foo",
+		Procedure: "hello",
+		Encoding:  "raw",
+		Body:      &bytes.Buffer{},
+	}
+	newFoo.EXPECT().Call(gomock.Any(), req).Return(&transport.Response{}, nil)
+	_, err := fooConfig.GetUnaryOutbound().Call(ctx, req)
+	require.NoError(t, err)
+
+	req.Service = "qux"
+	_, err = quxConfig.GetUnaryOutbound().Call(ctx, req)
+	assert.EqualError(t, err, `unary outbound for service "qux" has been removed`)
+	assert.Panics(t, func() { dispatcher.ClientConfig("qux") })
+	assert.NotNil(t, dispatcher.ClientConfig("baz").GetOnewayOutbound())
+
+	newFoo.EXPECT().Stop().Return(nil)
+	bar.EXPECT().Stop().Return(nil)
+	baz.EXPECT().Stop().Return(nil)
+	oldTransport.EXPECT().Stop().Return(nil)
+	newTransport.EXPECT().Stop().Return(nil)
+	require.NoError(t, dispatcher.Stop())
+}
+
+func TestUpdateOutboundsDrainConcurrently(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	oldFoo := transporttest.NewMockUnaryOutbound(mockCtrl)
+	oldFoo.EXPECT().Transports().AnyTimes()
+	newFoo := transporttest.NewMockUnaryOutbound(mockCtrl)
+	newFoo.EXPECT().Transports().AnyTimes()
+	bar := transporttest.NewMockUnaryOutbound(mockCtrl)
+	bar.EXPECT().Transports().AnyTimes()
+
+	dispatcher := NewDispatcher(Config{
+		Name:      "test",
+		Outbounds: Outbounds{"foo": {Unary: oldFoo}},
+	})
+	oldFoo.EXPECT().Start().Return(nil)
+	require.NoError(t, dispatcher.Start())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req := &transport.Request{
+		Caller:    "test",
+		Service:   "foo",
+		Procedure: "hello",
+		Encoding:  "raw",
+		Body:      &bytes.Buffer{},
+	}
+
+	// Keep a request in flight on the old outbound until bar was added.
+	calling, release := make(chan struct{}), make(chan struct{})
+	oldFoo.EXPECT().Call(gomock.Any(), req).
+		Do(func(context.Context, *transport.Request) {
+			close(calling)
+			<-release
+		}).
+		Return(&transport.Response{}, nil)
+	callDone := make(chan error)
+	go func() {
+		_, err := dispatcher.ClientConfig("foo").GetUnaryOutbound().Call(ctx, req)
+		callDone <- err
+	}()
+	<-calling
+
+	started := make(chan struct{})
+	newFoo.EXPECT().Start().Do(func() { close(started) }).Return(nil)
+	updateDone := make(chan error)
+	go func() {
+		updateDone <- dispatcher.UpdateOutbounds(Outbounds{"foo": {Unary: newFoo}})
+	}()
+	<-started
+
+	// Other updates are not blocked while the old outbound drains.
+	bar.EXPECT().Start().Return(nil)
+	require.NoError(t, dispatcher.UpdateOutbounds(Outbounds{
+		"foo": {Unary: newFoo},
+		"bar": {Unary: bar},
+	}))
+
+	oldFoo.EXPECT().Stop().Return(nil)
+	close(release)
+	require.NoError(t, <-callDone)
+	require.NoError(t, <-updateDone)
+
+	newFoo.EXPECT().Stop().Return(nil)
+	bar.EXPECT().Stop().Return(nil)
+	require.NoError(t, dispatcher.Stop())
+}
+
+func TestUpdateOutboundsStartFailure(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	newTransport := transporttest.NewMockTransport(mockCtrl)
+
+	oldFoo := transporttest.NewMockUnaryOutbound(mockCtrl)
+	oldFoo.EXPECT().Transports().AnyTimes()
+	newFoo := transporttest.NewMockUnaryOutbound(mockCtrl)
+	newFoo.EXPECT().Transports().Return([]transport.Transport{newTransport}).AnyTimes()
+
+	dispatcher := NewDispatcher(Config{
+		Name:      "test",
+		Outbounds: Outbounds{"foo": {Unary: oldFoo}},
+	})
+
+	oldFoo.EXPECT().Start().Return(nil)
+	require.NoError(t, dispatcher.Start())
+
+	gomock.InOrder(
+		newTransport.EXPECT().Start().Return(nil),
+		newFoo.EXPECT().Start().Return(errors.New("great sadness")),
+		newTransport.EXPECT().Stop().Return(nil),
+	)
+	err := dispatcher.UpdateOutbounds(Outbounds{"foo": {Unary: newFoo}})
+	assert.EqualError(t, err, "great sadness")
+
+	// The old outbound remains in use.
+	oldFoo.EXPECT().Stop().Return(nil)
+	require.NoError(t, dispatcher.Stop())
+}
+
+func TestUpdateOutboundsNoOutbound(t *testing.T) {
+	dispatcher := NewDispatcher(Config{Name: "test"})
+	err := dispatcher.UpdateOutbounds(Outbounds{"foo": {}})
+	assert.EqualError(t, err, `no outbound set for outbound key "foo" in dispatcher`)
+}
+
+func TestDispatcherLogging(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	out.EXPECT().Transports().Return(nil).AnyTimes()
+	out.EXPECT().Call(gomock.Any(), gomock.Any()).
+		Return(&transport.Response{ApplicationError: true}, nil).
+		Times(2)
+
+	warn := zapcore.WarnLevel
+	core, logs := observer.New(zapcore.DebugLevel)
+	dispatcher := NewDispatcher(Config{
+		Name:      "test",
+		Outbounds: Outbounds{"service": {Unary: out}},
+		ZapLogger: zap.New(core),
+		Logging: LoggingConfig{
+			ContextExtractor: func(context.Context) zapcore.Field {
+				return zap.String("traceID", "abc")
+			},
+			ApplicationErrorLevel: &warn,
+			Sampling: &LogSampling{
+				Procedures: map[string]float64{"sampled": 1},
+			},
+		},
+	})
+
+	unary := dispatcher.ClientConfig("service").GetUnaryOutbound()
+	for _, procedure := range []string{"sampled", "unsampled"} {
+		_, err := unary.Call(context.Background(), &transport.Request{
+			Service:   "service",
+			Procedure: procedure,
+			Body:      &bytes.Buffer{},
+		})
+		require.NoError(t, err)
+	}
+
+	entries := logs.TakeAll()
+	require.Equal(t, 1, len(entries), "Expected only sampled requests to be logged.")
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level, "Unexpected log level.")
+	assert.Equal(t, "abc", entries[0].ContextMap()["yarpc"].(map[string]interface{})["traceID"])
+}
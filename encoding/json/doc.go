@@ -52,4 +52,11 @@
 //  dispatcher.Register(json.OnewayProcedure("setValue", SetValue))
 //  dispatcher.Register(json.OnewayProcedure("runTask", RunTask))
 //
+// Unary handlers may report business failures by returning an *Error with a
+// code, message and details. Clients receive it back as an *Error.
+//
+// 	if jerr, ok := err.(*json.Error); ok && jerr.Code == "not-found" {
+// 		// ...
+// 	}
+//
 package json
@@ -0,0 +1,99 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Error is a structured application error. Handlers return an *Error to
+// report a business failure to their callers, in the same way that Thrift
+// services throw exceptions.
+//
+// 	return nil, &json.Error{
+// 		Code:    "not-found",
+// 		Message: "no such user",
+// 		Details: map[string]string{"userID": userID},
+// 	}
+//
+// The error is sent as the response body and the response is marked as an
+// application error. Clients return it to the caller as an *Error, which may
+// be inspected with a type assertion.
+//
+// 	if jerr, ok := err.(*json.Error); ok && jerr.Code == "not-found" {
+// 		// ...
+// 	}
+type Error struct {
+	// Code identifies the kind of error. It is required.
+	Code string `json:"code"`
+
+	// Message is a human-readable description of the error.
+	Message string `json:"message,omitempty"`
+
+	// Details holds additional information about the error. It must be
+	// serializable with encoding/json.
+	//
+	// Errors received by clients hold the details as a json.RawMessage. Use
+	// DecodeDetails to decode them into a value.
+	Details interface{} `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("application error %q", e.Code)
+	}
+	return fmt.Sprintf("application error %q: %v", e.Code, e.Message)
+}
+
+// DecodeDetails decodes the details of the error into the given pointer, as
+// with json.Unmarshal.
+func (e *Error) DecodeDetails(v interface{}) error {
+	raw, ok := e.Details.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(e.Details); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// decodeError decodes an application error received by a client.
+func decodeError(d *json.Decoder) (*Error, error) {
+	var body struct {
+		Code    string          `json:"code"`
+		Message string          `json:"message"`
+		Details json.RawMessage `json:"details"`
+	}
+	if err := d.Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Code == "" {
+		return nil, fmt.Errorf("application error has no code")
+	}
+
+	e := &Error{Code: body.Code, Message: body.Message}
+	if len(body.Details) > 0 {
+		e.Details = body.Details
+	}
+	return e, nil
+}
@@ -23,6 +23,7 @@ package json
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"reflect"
 
 	encodingapi "go.uber.org/yarpc/api/encoding"
@@ -37,8 +38,9 @@ import (
 //
 // 	f(ctx context.Context, body $reqBody) ($resBody, error)
 type jsonHandler struct {
-	reader  requestReader
-	handler reflect.Value
+	reader       requestReader
+	handler      reflect.Value
+	interceptors []Interceptor
 }
 
 func (h jsonHandler) Handle(ctx context.Context, treq *transport.Request, rw transport.ResponseWriter) error {
@@ -56,17 +58,32 @@ func (h jsonHandler) Handle(ctx context.Context, treq *transport.Request, rw tra
 		return encoding.RequestBodyDecodeError(treq, err)
 	}
 
-	results := h.handler.Call([]reflect.Value{reflect.ValueOf(ctx), reqBody})
-
-	if err := results[1].Interface(); err != nil {
-		return err.(error)
+	var result interface{}
+	if len(h.interceptors) == 0 {
+		result, err = h.callUnary(ctx, reqBody)
+	} else {
+		handle := chainUnaryInbound(h.interceptors, func(ctx context.Context, reqBody interface{}) (interface{}, error) {
+			v, err := h.value(reqBody)
+			if err != nil {
+				return nil, err
+			}
+			return h.callUnary(ctx, v)
+		})
+		result, err = handle(ctx, reqBody.Interface())
+	}
+	appErr, isAppErr := err.(*Error)
+	if err != nil && !isAppErr {
+		return err
 	}
 
 	if err := call.WriteToResponse(rw); err != nil {
 		return err
 	}
 
-	result := results[0].Interface()
+	if isAppErr {
+		rw.SetApplicationError()
+		result = appErr
+	}
 	if err := json.NewEncoder(rw).Encode(result); err != nil {
 		return encoding.ResponseBodyEncodeError(treq, err)
 	}
@@ -89,15 +106,50 @@ func (h jsonHandler) HandleOneway(ctx context.Context, treq *transport.Request)
 		return encoding.RequestBodyDecodeError(treq, err)
 	}
 
+	if len(h.interceptors) == 0 {
+		return h.callOneway(ctx, reqBody)
+	}
+	handle := chainOnewayInbound(h.interceptors, func(ctx context.Context, reqBody interface{}) error {
+		v, err := h.value(reqBody)
+		if err != nil {
+			return err
+		}
+		return h.callOneway(ctx, v)
+	})
+	return handle(ctx, reqBody.Interface())
+}
+
+func (h jsonHandler) callUnary(ctx context.Context, reqBody reflect.Value) (interface{}, error) {
 	results := h.handler.Call([]reflect.Value{reflect.ValueOf(ctx), reqBody})
+	if err := results[1].Interface(); err != nil {
+		return nil, err.(error)
+	}
+	return results[0].Interface(), nil
+}
 
+func (h jsonHandler) callOneway(ctx context.Context, reqBody reflect.Value) error {
+	results := h.handler.Call([]reflect.Value{reflect.ValueOf(ctx), reqBody})
 	if err := results[0].Interface(); err != nil {
 		return err.(error)
 	}
-
 	return nil
 }
 
+// value converts a request body passed on by an interceptor back into an
+// argument for the handler. It fails if the interceptor passed on a value of
+// a type the handler does not accept.
+func (h jsonHandler) value(reqBody interface{}) (reflect.Value, error) {
+	reqType := h.handler.Type().In(1)
+	if reqBody == nil {
+		return reflect.Zero(reqType), nil
+	}
+	v := reflect.ValueOf(reqBody)
+	if !v.Type().AssignableTo(reqType) {
+		return reflect.Value{}, fmt.Errorf("expected a value of type %v but received %T", reqType, reqBody)
+	}
+	return v, nil
+}
+
 // requestReader is used to parse a JSON request argument from a JSON decoder.
 type requestReader interface {
 	Read(*json.Decoder) (reflect.Value, error)
@@ -139,6 +139,36 @@ func TestHandleSuccessWithResponseHeaders(t *testing.T) {
 	assert.Equal(t, transport.NewHeaders().With("foo", "bar"), resw.Headers)
 }
 
+func TestHandleApplicationError(t *testing.T) {
+	h := func(ctx context.Context, _ *simpleRequest) (*simpleResponse, error) {
+		require.NoError(t, yarpc.CallFromContext(ctx).WriteResponseHeader("foo", "bar"))
+		return nil, &Error{
+			Code:    "not-found",
+			Message: "no such user",
+			Details: map[string]string{"name": "foo"},
+		}
+	}
+
+	handler := jsonHandler{
+		reader:  structReader{reflect.TypeOf(simpleRequest{})},
+		handler: reflect.ValueOf(h),
+	}
+
+	resw := new(transporttest.FakeResponseWriter)
+	err := handler.Handle(context.Background(), &transport.Request{
+		Procedure: "simpleCall",
+		Encoding:  "json",
+		Body:      jsonBody(`{"name": "foo"}`),
+	}, resw)
+	require.NoError(t, err)
+
+	assert.True(t, resw.IsApplicationError)
+	assert.Equal(t, transport.NewHeaders().With("foo", "bar"), resw.Headers)
+	assert.JSONEq(t,
+		`{"code": "not-found", "message": "no such user", "details": {"name": "foo"}}`,
+		resw.Body.String())
+}
+
 func jsonBody(s string) io.Reader {
 	return bytes.NewReader([]byte(s))
 }
@@ -0,0 +1,159 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package json
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+type clientConfig struct {
+	Interceptors []Interceptor
+}
+
+// ClientOption customizes the behavior of a JSON client.
+type ClientOption interface {
+	applyClientOption(*clientConfig)
+}
+
+type registerConfig struct {
+	Interceptors []Interceptor
+}
+
+// RegisterOption customizes the behavior of a JSON handler during
+// registration.
+type RegisterOption interface {
+	applyRegisterOption(*registerConfig)
+}
+
+// Option unifies options that apply to both, JSON clients and handlers.
+type Option interface {
+	ClientOption
+	RegisterOption
+}
+
+// UnaryHandlerFunc calls the next interceptor or the JSON handler with a
+// decoded request body.
+type UnaryHandlerFunc func(ctx context.Context, reqBody interface{}) (interface{}, error)
+
+// OnewayHandlerFunc calls the next interceptor or the oneway JSON handler with
+// a decoded request body.
+type OnewayHandlerFunc func(ctx context.Context, reqBody interface{}) error
+
+// UnaryCallFunc calls the next interceptor or sends the request.
+type UnaryCallFunc func(ctx context.Context, reqBody interface{}, resBodyOut interface{}) error
+
+// OnewayCallFunc calls the next interceptor or sends the oneway request.
+type OnewayCallFunc func(ctx context.Context, reqBody interface{}) (transport.Ack, error)
+
+// Interceptor intercepts JSON requests and responses in their decoded form.
+// This allows validation, auditing or redaction to be written once for all
+// procedures of a service without decoding bodies again in middleware.
+//
+// Each hook must call the function it is given to continue processing the
+// request. Any of the hooks may be nil. Values passed on to the handler must
+// have the same type as the value received.
+//
+// The procedure being handled is available to inbound hooks through
+// yarpc.CallFromContext.
+type Interceptor struct {
+	UnaryInbound   func(ctx context.Context, reqBody interface{}, handler UnaryHandlerFunc) (interface{}, error)
+	OnewayInbound  func(ctx context.Context, reqBody interface{}, handler OnewayHandlerFunc) error
+	UnaryOutbound  func(ctx context.Context, procedure string, reqBody interface{}, resBodyOut interface{}, call UnaryCallFunc) error
+	OnewayOutbound func(ctx context.Context, procedure string, reqBody interface{}, call OnewayCallFunc) (transport.Ack, error)
+}
+
+// Intercept is an option that installs the given interceptors. Interceptors
+// are called in the order given.
+//
+// It may be specified on the client side when the client is constructed,
+//
+// 	client := json.New(clientConfig, json.Intercept(validator))
+//
+// and on the server side when procedures are built.
+//
+// 	dispatcher.Register(json.Procedure("get", get, json.Intercept(validator)))
+func Intercept(interceptors ...Interceptor) Option {
+	return interceptOption(interceptors)
+}
+
+type interceptOption []Interceptor
+
+func (o interceptOption) applyClientOption(c *clientConfig) {
+	c.Interceptors = append(c.Interceptors, o...)
+}
+
+func (o interceptOption) applyRegisterOption(c *registerConfig) {
+	c.Interceptors = append(c.Interceptors, o...)
+}
+
+func chainUnaryInbound(interceptors []Interceptor, h UnaryHandlerFunc) UnaryHandlerFunc {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		intercept, next := interceptors[i].UnaryInbound, h
+		if intercept == nil {
+			continue
+		}
+		h = func(ctx context.Context, reqBody interface{}) (interface{}, error) {
+			return intercept(ctx, reqBody, next)
+		}
+	}
+	return h
+}
+
+func chainOnewayInbound(interceptors []Interceptor, h OnewayHandlerFunc) OnewayHandlerFunc {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		intercept, next := interceptors[i].OnewayInbound, h
+		if intercept == nil {
+			continue
+		}
+		h = func(ctx context.Context, reqBody interface{}) error {
+			return intercept(ctx, reqBody, next)
+		}
+	}
+	return h
+}
+
+func chainUnaryOutbound(interceptors []Interceptor, procedure string, call UnaryCallFunc) UnaryCallFunc {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		intercept, next := interceptors[i].UnaryOutbound, call
+		if intercept == nil {
+			continue
+		}
+		call = func(ctx context.Context, reqBody interface{}, resBodyOut interface{}) error {
+			return intercept(ctx, procedure, reqBody, resBodyOut, next)
+		}
+	}
+	return call
+}
+
+func chainOnewayOutbound(interceptors []Interceptor, procedure string, call OnewayCallFunc) OnewayCallFunc {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		intercept, next := interceptors[i].OnewayOutbound, call
+		if intercept == nil {
+			continue
+		}
+		call = func(ctx context.Context, reqBody interface{}) (transport.Ack, error) {
+			return intercept(ctx, procedure, reqBody, next)
+		}
+	}
+	return call
+}
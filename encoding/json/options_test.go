@@ -0,0 +1,198 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package json
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clientconfig"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingInterceptor appends its name to calls before and after calling
+// the next interceptor.
+func recordingInterceptor(name string, calls *[]string) Interceptor {
+	return Interceptor{
+		UnaryInbound: func(ctx context.Context, reqBody interface{}, handler UnaryHandlerFunc) (interface{}, error) {
+			*calls = append(*calls, name+" "+yarpc.CallFromContext(ctx).Procedure())
+			res, err := handler(ctx, reqBody)
+			*calls = append(*calls, name+" done")
+			return res, err
+		},
+		OnewayInbound: func(ctx context.Context, reqBody interface{}, handler OnewayHandlerFunc) error {
+			*calls = append(*calls, name+" "+yarpc.CallFromContext(ctx).Procedure())
+			return handler(ctx, reqBody)
+		},
+		UnaryOutbound: func(ctx context.Context, procedure string, reqBody, resBodyOut interface{}, call UnaryCallFunc) error {
+			*calls = append(*calls, name+" "+procedure)
+			return call(ctx, reqBody, resBodyOut)
+		},
+		OnewayOutbound: func(ctx context.Context, procedure string, reqBody interface{}, call OnewayCallFunc) (transport.Ack, error) {
+			*calls = append(*calls, name+" "+procedure)
+			return call(ctx, reqBody)
+		},
+	}
+}
+
+func TestInterceptInbound(t *testing.T) {
+	var calls []string
+	redact := Interceptor{
+		UnaryInbound: func(ctx context.Context, reqBody interface{}, handler UnaryHandlerFunc) (interface{}, error) {
+			req := reqBody.(*simpleRequest)
+			if req.Name == "" {
+				return nil, errors.New("name is required")
+			}
+			res, err := handler(ctx, &simpleRequest{Name: req.Name + "!"})
+			if err != nil {
+				return nil, err
+			}
+			res.(*simpleResponse).Success = false
+			return res, nil
+		},
+	}
+
+	procs := Procedure("hello", func(ctx context.Context, req *simpleRequest) (*simpleResponse, error) {
+		calls = append(calls, "handler "+req.Name)
+		return &simpleResponse{Success: true}, nil
+	}, Intercept(recordingInterceptor("first", &calls), recordingInterceptor("second", &calls)), Intercept(redact))
+	require.Len(t, procs, 1)
+	handler := procs[0].HandlerSpec.Unary()
+
+	resw := new(transporttest.FakeResponseWriter)
+	require.NoError(t, handler.Handle(context.Background(), &transport.Request{
+		Procedure: "hello",
+		Encoding:  "json",
+		Body:      jsonBody(`{"name": "foo"}`),
+	}, resw))
+	assert.JSONEq(t, `{"Success": false}`, resw.Body.String())
+	assert.Equal(t, []string{"first hello", "second hello", "handler foo!", "second done", "first done"}, calls)
+
+	err := handler.Handle(context.Background(), &transport.Request{
+		Procedure: "hello",
+		Encoding:  "json",
+		Body:      jsonBody(`{}`),
+	}, new(transporttest.FakeResponseWriter))
+	assert.EqualError(t, err, "name is required")
+}
+
+func TestInterceptInboundOneway(t *testing.T) {
+	var calls []string
+	procs := OnewayProcedure("notify", func(ctx context.Context, req map[string]interface{}) error {
+		calls = append(calls, "handler")
+		assert.Nil(t, req, "Expected nil request body.")
+		return nil
+	}, Intercept(recordingInterceptor("first", &calls), Interceptor{
+		OnewayInbound: func(ctx context.Context, reqBody interface{}, handler OnewayHandlerFunc) error {
+			return handler(ctx, nil)
+		},
+	}))
+	require.Len(t, procs, 1)
+
+	require.NoError(t, procs[0].HandlerSpec.Oneway().HandleOneway(context.Background(), &transport.Request{
+		Procedure: "notify",
+		Encoding:  "json",
+		Body:      jsonBody(`{"foo": "bar"}`),
+	}))
+	assert.Equal(t, []string{"first notify", "handler"}, calls)
+}
+
+func TestInterceptInboundWrongType(t *testing.T) {
+	wrongType := Intercept(Interceptor{
+		UnaryInbound: func(ctx context.Context, reqBody interface{}, handler UnaryHandlerFunc) (interface{}, error) {
+			return handler(ctx, "foo")
+		},
+		OnewayInbound: func(ctx context.Context, reqBody interface{}, handler OnewayHandlerFunc) error {
+			return handler(ctx, "foo")
+		},
+	})
+
+	procs := Procedure("hello", func(ctx context.Context, req *simpleRequest) (*simpleResponse, error) {
+		t.Fatal("handler must not be called")
+		return nil, nil
+	}, wrongType)
+	require.Len(t, procs, 1)
+	err := procs[0].HandlerSpec.Unary().Handle(context.Background(), &transport.Request{
+		Procedure: "hello",
+		Encoding:  "json",
+		Body:      jsonBody(`{"name": "foo"}`),
+	}, new(transporttest.FakeResponseWriter))
+	assert.EqualError(t, err, "expected a value of type *json.simpleRequest but received string")
+
+	procs = OnewayProcedure("notify", func(ctx context.Context, req map[string]interface{}) error {
+		t.Fatal("handler must not be called")
+		return nil
+	}, wrongType)
+	require.Len(t, procs, 1)
+	err = procs[0].HandlerSpec.Oneway().HandleOneway(context.Background(), &transport.Request{
+		Procedure: "notify",
+		Encoding:  "json",
+		Body:      jsonBody(`{"foo": "bar"}`),
+	})
+	assert.EqualError(t, err, "expected a value of type map[string]interface {} but received string")
+}
+
+func TestInterceptOutbound(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	unary := transporttest.NewMockUnaryOutbound(mockCtrl)
+	oneway := transporttest.NewMockOnewayOutbound(mockCtrl)
+	var calls []string
+	client := New(clientconfig.MultiOutbound("caller", "service", transport.Outbounds{
+		Unary:  unary,
+		Oneway: oneway,
+	}), Intercept(recordingInterceptor("first", &calls), Interceptor{
+		UnaryOutbound: func(ctx context.Context, procedure string, reqBody, resBodyOut interface{}, call UnaryCallFunc) error {
+			if err := call(ctx, reqBody, resBodyOut); err != nil {
+				return err
+			}
+			if resBodyOut.(*simpleResponse).Success {
+				calls = append(calls, "success")
+			}
+			return nil
+		},
+	}))
+
+	unary.EXPECT().Call(gomock.Any(), transporttest.NewRequestMatcher(t, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: "hello",
+		Encoding:  Encoding,
+		Body:      jsonBody(`{"Name":"foo","Attributes":null}`),
+	})).Return(&transport.Response{Body: ioutil.NopCloser(jsonBody(`{"success": true}`))}, nil)
+	oneway.EXPECT().CallOneway(gomock.Any(), gomock.Any()).Return(&successAck{}, nil)
+
+	var res simpleResponse
+	require.NoError(t, client.Call(context.Background(), "hello", &simpleRequest{Name: "foo"}, &res))
+	_, err := client.CallOneway(context.Background(), "notify", &simpleRequest{Name: "foo"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"first hello", "success", "first notify"}, calls)
+}
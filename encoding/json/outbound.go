@@ -44,19 +44,46 @@ type Client interface {
 }
 
 // New builds a new JSON client.
-func New(c transport.ClientConfig) Client {
-	return jsonClient{cc: c}
+func New(c transport.ClientConfig, opts ...ClientOption) Client {
+	var cc clientConfig
+	for _, opt := range opts {
+		opt.applyClientOption(&cc)
+	}
+	return jsonClient{cc: c, interceptors: cc.Interceptors}
 }
 
 func init() {
-	yarpc.RegisterClientBuilder(New)
+	yarpc.RegisterClientBuilder(func(c transport.ClientConfig) Client {
+		return New(c)
+	})
 }
 
 type jsonClient struct {
-	cc transport.ClientConfig
+	cc           transport.ClientConfig
+	interceptors []Interceptor
 }
 
 func (c jsonClient) Call(ctx context.Context, procedure string, reqBody interface{}, resBodyOut interface{}, opts ...yarpc.CallOption) error {
+	if len(c.interceptors) == 0 {
+		return c.call(ctx, procedure, reqBody, resBodyOut, opts)
+	}
+	call := chainUnaryOutbound(c.interceptors, procedure, func(ctx context.Context, reqBody interface{}, resBodyOut interface{}) error {
+		return c.call(ctx, procedure, reqBody, resBodyOut, opts)
+	})
+	return call(ctx, reqBody, resBodyOut)
+}
+
+func (c jsonClient) CallOneway(ctx context.Context, procedure string, reqBody interface{}, opts ...yarpc.CallOption) (transport.Ack, error) {
+	if len(c.interceptors) == 0 {
+		return c.callOneway(ctx, procedure, reqBody, opts)
+	}
+	call := chainOnewayOutbound(c.interceptors, procedure, func(ctx context.Context, reqBody interface{}) (transport.Ack, error) {
+		return c.callOneway(ctx, procedure, reqBody, opts)
+	})
+	return call(ctx, reqBody)
+}
+
+func (c jsonClient) call(ctx context.Context, procedure string, reqBody interface{}, resBodyOut interface{}, opts []yarpc.CallOption) error {
 	call := encodingapi.NewOutboundCall(encoding.FromOptions(opts)...)
 	treq := transport.Request{
 		Caller:    c.cc.Caller(),
@@ -80,19 +107,27 @@ func (c jsonClient) Call(ctx context.Context, procedure string, reqBody interfac
 	if err != nil {
 		return err
 	}
+	defer tres.Body.Close()
 
 	if _, err = call.ReadFromResponse(ctx, tres); err != nil {
 		return err
 	}
 
+	if tres.ApplicationError {
+		appErr, err := decodeError(json.NewDecoder(tres.Body))
+		if err != nil {
+			return encoding.ResponseBodyDecodeError(&treq, err)
+		}
+		return appErr
+	}
+
 	if err := json.NewDecoder(tres.Body).Decode(resBodyOut); err != nil {
 		return encoding.ResponseBodyDecodeError(&treq, err)
 	}
-
-	return tres.Body.Close()
+	return nil
 }
 
-func (c jsonClient) CallOneway(ctx context.Context, procedure string, reqBody interface{}, opts ...yarpc.CallOption) (transport.Ack, error) {
+func (c jsonClient) callOneway(ctx context.Context, procedure string, reqBody interface{}, opts []yarpc.CallOption) (transport.Ack, error) {
 	call := encodingapi.NewOutboundCall(encoding.FromOptions(opts)...)
 	treq := transport.Request{
 		Caller:    c.cc.Caller(),
@@ -23,8 +23,9 @@ package json
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
-	"io/ioutil"
+	"io"
 	"reflect"
 	"testing"
 
@@ -55,6 +56,7 @@ func TestCall(t *testing.T) {
 		body            interface{}
 		encodedRequest  string
 		encodedResponse string
+		applicationErr  bool
 
 		// whether the outbound receives the request
 		noCall bool
@@ -64,6 +66,7 @@ func TestCall(t *testing.T) {
 		wantHeaders map[string]string
 		wantType    reflect.Type // type of response body
 		wantErr     string       // error message
+		wantAppErr  *Error       // expected application error
 	}{
 		{
 			procedure:       "foo",
@@ -96,6 +99,28 @@ func TestCall(t *testing.T) {
 			want:            map[string]interface{}{},
 			wantHeaders:     map[string]string{"success": "true"},
 		},
+		{
+			procedure:       "applicationError",
+			body:            map[string]interface{}{},
+			encodedRequest:  "{}",
+			encodedResponse: `{"code": "not-found", "message": "no such user", "details": {"id": 42}}`,
+			applicationErr:  true,
+			wantType:        _typeOfMapInterface,
+			wantAppErr: &Error{
+				Code:    "not-found",
+				Message: "no such user",
+				Details: json.RawMessage(`{"id": 42}`),
+			},
+		},
+		{
+			procedure:       "applicationErrorWithoutCode",
+			body:            map[string]interface{}{},
+			encodedRequest:  "{}",
+			encodedResponse: `{"message": "no such user"}`,
+			applicationErr:  true,
+			wantType:        _typeOfMapInterface,
+			wantErr:         `failed to decode "json" response body for procedure "applicationErrorWithoutCode" of service "service"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -105,6 +130,7 @@ func TestCall(t *testing.T) {
 				Unary: outbound,
 			}))
 
+		resBodyReader := &closeRecorder{Reader: bytes.NewReader([]byte(tt.encodedResponse))}
 		if !tt.noCall {
 			outbound.EXPECT().Call(gomock.Any(),
 				transporttest.NewRequestMatcher(t,
@@ -118,9 +144,9 @@ func TestCall(t *testing.T) {
 					}),
 			).Return(
 				&transport.Response{
-					Body: ioutil.NopCloser(
-						bytes.NewReader([]byte(tt.encodedResponse))),
-					Headers: transport.HeadersFromMap(tt.wantHeaders),
+					Body:             resBodyReader,
+					Headers:          transport.HeadersFromMap(tt.wantHeaders),
+					ApplicationError: tt.applicationErr,
 				}, nil)
 		}
 
@@ -144,7 +170,9 @@ func TestCall(t *testing.T) {
 		opts = append(opts, yarpc.ResponseHeaders(&resHeaders))
 
 		err := client.Call(ctx, tt.procedure, tt.body, &resBody, opts...)
-		if tt.wantErr != "" {
+		if tt.wantAppErr != nil {
+			assert.Equal(t, tt.wantAppErr, err)
+		} else if tt.wantErr != "" {
 			if assert.Error(t, err) {
 				assert.Contains(t, err.Error(), tt.wantErr)
 			}
@@ -154,9 +182,26 @@ func TestCall(t *testing.T) {
 				assert.Equal(t, tt.want, resBody)
 			}
 		}
+
+		if !tt.noCall {
+			assert.True(t, resBodyReader.closed,
+				"response body for %q must be closed", tt.procedure)
+		}
 	}
 }
 
+// closeRecorder is a response body which records whether it was closed.
+type closeRecorder struct {
+	io.Reader
+
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
 type successAck struct{}
 
 func (a successAck) String() string {
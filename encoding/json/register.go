@@ -51,14 +51,14 @@ func Register(r transport.RouteTable, rs []transport.Procedure) {
 //
 // Where $reqBody and $resBody are a map[string]interface{} or pointers to
 // structs.
-func Procedure(name string, handler interface{}) []transport.Procedure {
+func Procedure(name string, handler interface{}, opts ...RegisterOption) []transport.Procedure {
+	h := wrapUnaryHandler(name, handler)
+	h.interceptors = buildRegisterConfig(opts).Interceptors
 	return []transport.Procedure{
 		{
-			Name: name,
-			HandlerSpec: transport.NewUnaryHandlerSpec(
-				wrapUnaryHandler(name, handler),
-			),
-			Encoding: Encoding,
+			Name:        name,
+			HandlerSpec: transport.NewUnaryHandlerSpec(h),
+			Encoding:    Encoding,
 		},
 	}
 }
@@ -69,27 +69,28 @@ func Procedure(name string, handler interface{}) []transport.Procedure {
 // 	f(ctx context.Context, body $reqBody) error
 //
 // Where $reqBody is a map[string]interface{} or pointer to a struct.
-func OnewayProcedure(name string, handler interface{}) []transport.Procedure {
+func OnewayProcedure(name string, handler interface{}, opts ...RegisterOption) []transport.Procedure {
+	h := wrapOnewayHandler(name, handler)
+	h.interceptors = buildRegisterConfig(opts).Interceptors
 	return []transport.Procedure{
 		{
-			Name: name,
-			HandlerSpec: transport.NewOnewayHandlerSpec(
-				wrapOnewayHandler(name, handler)),
-			Encoding: Encoding,
+			Name:        name,
+			HandlerSpec: transport.NewOnewayHandlerSpec(h),
+			Encoding:    Encoding,
 		},
 	}
 }
 
 // wrapUnaryHandler takes a valid JSON handler function and converts it into a
 // transport.UnaryHandler.
-func wrapUnaryHandler(name string, handler interface{}) transport.UnaryHandler {
+func wrapUnaryHandler(name string, handler interface{}) jsonHandler {
 	reqBodyType := verifyUnarySignature(name, reflect.TypeOf(handler))
 	return newJSONHandler(reqBodyType, handler)
 }
 
 // wrapOnewayHandler takes a valid JSON handler function and converts it into a
 // transport.OnewayHandler.
-func wrapOnewayHandler(name string, handler interface{}) transport.OnewayHandler {
+func wrapOnewayHandler(name string, handler interface{}) jsonHandler {
 	reqBodyType := verifyOnewaySignature(name, reflect.TypeOf(handler))
 	return newJSONHandler(reqBodyType, handler)
 }
@@ -111,6 +112,14 @@ func newJSONHandler(reqBodyType reflect.Type, handler interface{}) jsonHandler {
 	}
 }
 
+func buildRegisterConfig(opts []RegisterOption) registerConfig {
+	var rc registerConfig
+	for _, opt := range opts {
+		opt.applyRegisterOption(&rc)
+	}
+	return rc
+}
+
 // verifyUnarySignature verifies that the given type matches what we expect from
 // JSON unary handlers and returns the request type.
 func verifyUnarySignature(n string, t reflect.Type) reflect.Type {
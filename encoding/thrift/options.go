@@ -20,12 +20,24 @@
 
 package thrift
 
-import "go.uber.org/thriftrw/protocol"
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/procedure"
+
+	"go.uber.org/thriftrw/envelope"
+	"go.uber.org/thriftrw/protocol"
+	"go.uber.org/thriftrw/wire"
+)
 
 type clientConfig struct {
-	Protocol    protocol.Protocol
-	Enveloping  bool
-	Multiplexed bool
+	Protocol     protocol.Protocol
+	Enveloping   bool
+	Multiplexed  bool
+	Interceptors []Interceptor
 }
 
 // ClientOption customizes the behavior of a Thrift client.
@@ -34,8 +46,9 @@ type ClientOption interface {
 }
 
 type registerConfig struct {
-	Protocol   protocol.Protocol
-	Enveloping bool
+	Protocol     protocol.Protocol
+	Enveloping   bool
+	Interceptors []Interceptor
 }
 
 // RegisterOption customizes the behavior of a Thrift handler during
@@ -117,3 +130,189 @@ func (p protocolOption) applyRegisterOption(c *registerConfig) {
 func Protocol(p protocol.Protocol) Option {
 	return protocolOption{Protocol: p}
 }
+
+// UnaryHandlerFunc calls the next interceptor or the handler with the decoded
+// arguments of a request. The Body of the Response is the result struct
+// generated for the method.
+type UnaryHandlerFunc func(ctx context.Context, args envelope.Enveloper) (Response, error)
+
+// OnewayHandlerFunc calls the next interceptor or the oneway handler with the
+// decoded arguments of a request.
+type OnewayHandlerFunc func(ctx context.Context, args envelope.Enveloper) error
+
+// UnaryCallFunc calls the next interceptor or sends the request. It returns
+// the decoded result struct generated for the method.
+type UnaryCallFunc func(ctx context.Context, args envelope.Enveloper) (envelope.Enveloper, error)
+
+// OnewayCallFunc calls the next interceptor or sends the oneway request.
+type OnewayCallFunc func(ctx context.Context, args envelope.Enveloper) (transport.Ack, error)
+
+// Interceptor intercepts Thrift requests and responses in their decoded form.
+// This allows validation, auditing or redaction to be written once for all
+// methods of a service.
+//
+// Arguments are the Args structs generated by thriftrw for the method, for
+// example *kv.KeyValue_GetValue_Args, and results are the generated Result
+// structs, for example *kv.KeyValue_GetValue_Result. Interceptors are run by
+// the code generated by thriftrw-plugin-yarpc.
+//
+// Each hook must call the function it is given to continue processing the
+// request. Any of the hooks may be nil. Arguments and results passed on must
+// have the same type as the ones received. The procedure being handled is
+// available to inbound hooks through yarpc.CallFromContext.
+type Interceptor struct {
+	UnaryInbound   func(ctx context.Context, args envelope.Enveloper, handler UnaryHandlerFunc) (Response, error)
+	OnewayInbound  func(ctx context.Context, args envelope.Enveloper, handler OnewayHandlerFunc) error
+	UnaryOutbound  func(ctx context.Context, procedure string, args envelope.Enveloper, call UnaryCallFunc) (envelope.Enveloper, error)
+	OnewayOutbound func(ctx context.Context, procedure string, args envelope.Enveloper, call OnewayCallFunc) (transport.Ack, error)
+}
+
+// Intercept is an option that installs the given interceptors on Thrift
+// clients and handlers. Interceptors are called in the order given.
+//
+// It may be specified on the client side when the client is constructed,
+//
+// 	client := myserviceclient.New(clientConfig, thrift.Intercept(validator))
+//
+// and on the server side when the handler is registered.
+//
+// 	dispatcher.Register(myserviceserver.New(handler, thrift.Intercept(validator)))
+func Intercept(interceptors ...Interceptor) Option {
+	return interceptOption(interceptors)
+}
+
+type interceptOption []Interceptor
+
+func (o interceptOption) applyClientOption(c *clientConfig) {
+	c.Interceptors = append(c.Interceptors, o...)
+}
+
+func (o interceptOption) applyRegisterOption(c *registerConfig) {
+	c.Interceptors = append(c.Interceptors, o...)
+}
+
+// Interceptors runs decoded requests through the interceptors installed with
+// the Intercept option. It is used by code generated by thriftrw-plugin-yarpc.
+type Interceptors struct {
+	service      string
+	interceptors []Interceptor
+}
+
+// ServerInterceptors returns the interceptors installed on a handler by the
+// given options.
+func ServerInterceptors(opts ...RegisterOption) Interceptors {
+	var rc registerConfig
+	for _, opt := range opts {
+		opt.applyRegisterOption(&rc)
+	}
+	return Interceptors{interceptors: rc.Interceptors}
+}
+
+// ClientInterceptors returns the interceptors installed by the given options
+// on a client for the given Thrift service.
+func ClientInterceptors(service string, opts ...ClientOption) Interceptors {
+	var cc clientConfig
+	for _, opt := range opts {
+		opt.applyClientOption(&cc)
+	}
+	return Interceptors{service: service, interceptors: cc.Interceptors}
+}
+
+// Result is a result struct generated by thriftrw for a Thrift method.
+type Result interface {
+	envelope.Enveloper
+
+	FromWire(wire.Value) error
+}
+
+// HandleUnary calls the handler with the decoded arguments of a unary request
+// through the inbound interceptors.
+func (is Interceptors) HandleUnary(ctx context.Context, args envelope.Enveloper, handler UnaryHandlerFunc) (Response, error) {
+	for i := len(is.interceptors) - 1; i >= 0; i-- {
+		intercept, next := is.interceptors[i].UnaryInbound, handler
+		if intercept == nil {
+			continue
+		}
+		handler = func(ctx context.Context, args envelope.Enveloper) (Response, error) {
+			return intercept(ctx, args, next)
+		}
+	}
+	return handler(ctx, args)
+}
+
+// HandleOneway calls the handler with the decoded arguments of a oneway
+// request through the inbound interceptors.
+func (is Interceptors) HandleOneway(ctx context.Context, args envelope.Enveloper, handler OnewayHandlerFunc) error {
+	for i := len(is.interceptors) - 1; i >= 0; i-- {
+		intercept, next := is.interceptors[i].OnewayInbound, handler
+		if intercept == nil {
+			continue
+		}
+		handler = func(ctx context.Context, args envelope.Enveloper) error {
+			return intercept(ctx, args, next)
+		}
+	}
+	return handler(ctx, args)
+}
+
+// CallUnary sends a unary request with the given arguments using the client
+// through the outbound interceptors. Responses are decoded into results built
+// by newResult.
+func (is Interceptors) CallUnary(
+	ctx context.Context,
+	c Client,
+	args envelope.Enveloper,
+	newResult func() Result,
+	opts ...yarpc.CallOption,
+) (envelope.Enveloper, error) {
+	call := func(ctx context.Context, args envelope.Enveloper) (envelope.Enveloper, error) {
+		body, err := c.Call(ctx, args, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		result := newResult()
+		if err := result.FromWire(body); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	name := procedure.ToName(is.service, args.MethodName())
+	for i := len(is.interceptors) - 1; i >= 0; i-- {
+		intercept, next := is.interceptors[i].UnaryOutbound, call
+		if intercept == nil {
+			continue
+		}
+		call = func(ctx context.Context, args envelope.Enveloper) (envelope.Enveloper, error) {
+			return intercept(ctx, name, args, next)
+		}
+	}
+	return call(ctx, args)
+}
+
+// CallOneway sends a oneway request with the given arguments using the
+// client through the outbound interceptors.
+func (is Interceptors) CallOneway(ctx context.Context, c Client, args envelope.Enveloper, opts ...yarpc.CallOption) (transport.Ack, error) {
+	call := func(ctx context.Context, args envelope.Enveloper) (transport.Ack, error) {
+		return c.CallOneway(ctx, args, opts...)
+	}
+
+	name := procedure.ToName(is.service, args.MethodName())
+	for i := len(is.interceptors) - 1; i >= 0; i-- {
+		intercept, next := is.interceptors[i].OnewayOutbound, call
+		if intercept == nil {
+			continue
+		}
+		call = func(ctx context.Context, args envelope.Enveloper) (transport.Ack, error) {
+			return intercept(ctx, name, args, next)
+		}
+	}
+	return call(ctx, args)
+}
+
+// CastError returns an error saying that generated code received a value of
+// an unexpected type, usually from an interceptor.
+func CastError(expectedType interface{}, actualType interface{}) error {
+	return fmt.Errorf("expected a value of type %T but received %T", expectedType, actualType)
+}
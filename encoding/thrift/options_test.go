@@ -0,0 +1,173 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thrift
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/thriftrw/envelope"
+	"go.uber.org/thriftrw/wire"
+)
+
+// fakeResult is a Result which records the value it was decoded from.
+type fakeResult struct {
+	fakeEnveloper
+
+	Body wire.Value
+}
+
+func (r *fakeResult) FromWire(w wire.Value) error {
+	r.Body = w
+	return nil
+}
+
+// fakeClient is a Client which returns the given body for unary calls and
+// the given error for oneway calls.
+type fakeClient struct {
+	body   wire.Value
+	err    error
+	called int
+}
+
+func (c *fakeClient) Call(context.Context, envelope.Enveloper, ...yarpc.CallOption) (wire.Value, error) {
+	c.called++
+	return c.body, nil
+}
+
+func (c *fakeClient) CallOneway(context.Context, envelope.Enveloper, ...yarpc.CallOption) (transport.Ack, error) {
+	c.called++
+	return nil, c.err
+}
+
+func TestInterceptInbound(t *testing.T) {
+	var (
+		calls []string
+		seen  []envelope.Enveloper
+	)
+	record := func(name string) Interceptor {
+		return Interceptor{
+			UnaryInbound: func(ctx context.Context, args envelope.Enveloper, handler UnaryHandlerFunc) (Response, error) {
+				calls = append(calls, name)
+				seen = append(seen, args)
+				return handler(ctx, args)
+			},
+			OnewayInbound: func(ctx context.Context, args envelope.Enveloper, handler OnewayHandlerFunc) error {
+				calls = append(calls, name)
+				seen = append(seen, args)
+				return handler(ctx, args)
+			},
+		}
+	}
+	reject := Interceptor{
+		OnewayInbound: func(context.Context, envelope.Enveloper, OnewayHandlerFunc) error {
+			return errors.New("rejected")
+		},
+	}
+
+	interceptors := ServerInterceptors(
+		Enveloped, Intercept(record("first"), Interceptor{}), Intercept(record("second"), reject))
+	args := fakeEnveloper(wire.Call)
+	result := &fakeResult{fakeEnveloper: fakeEnveloper(wire.Reply)}
+
+	res, err := interceptors.HandleUnary(context.Background(), args,
+		func(_ context.Context, got envelope.Enveloper) (Response, error) {
+			calls = append(calls, "handler")
+			assert.Equal(t, args, got)
+			return Response{Body: result, IsApplicationError: true}, nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, Response{Body: result, IsApplicationError: true}, res)
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+	assert.Equal(t, []envelope.Enveloper{args, args}, seen)
+
+	calls = nil
+	err = interceptors.HandleOneway(context.Background(), args,
+		func(context.Context, envelope.Enveloper) error {
+			calls = append(calls, "handler")
+			return nil
+		})
+	assert.EqualError(t, err, "rejected")
+	assert.Equal(t, []string{"first", "second"}, calls)
+}
+
+func TestInterceptOutbound(t *testing.T) {
+	body := wire.NewValueStruct(wire.Struct{})
+	var (
+		procedures []string
+		results    []envelope.Enveloper
+	)
+	interceptors := ClientInterceptors("MyService", Multiplexed, Intercept(
+		Interceptor{
+			UnaryOutbound: func(ctx context.Context, procedure string, args envelope.Enveloper, call UnaryCallFunc) (envelope.Enveloper, error) {
+				procedures = append(procedures, procedure)
+				result, err := call(ctx, args)
+				results = append(results, result)
+				return result, err
+			},
+			OnewayOutbound: func(ctx context.Context, procedure string, args envelope.Enveloper, call OnewayCallFunc) (transport.Ack, error) {
+				procedures = append(procedures, procedure)
+				return call(ctx, args)
+			},
+		},
+		Interceptor{
+			OnewayOutbound: func(context.Context, string, envelope.Enveloper, OnewayCallFunc) (transport.Ack, error) {
+				return nil, errors.New("rejected")
+			},
+		},
+	))
+
+	c := &fakeClient{body: body}
+	got, err := interceptors.CallUnary(context.Background(), c, fakeEnveloper(wire.Call),
+		func() Result { return new(fakeResult) })
+	require.NoError(t, err)
+	assert.Equal(t, &fakeResult{Body: body}, got)
+	assert.Equal(t, []envelope.Enveloper{got}, results)
+
+	_, err = interceptors.CallOneway(context.Background(), c, fakeEnveloper(wire.OneWay))
+	assert.EqualError(t, err, "rejected")
+	assert.Equal(t, []string{"MyService::someMethod", "MyService::someMethod"}, procedures)
+	assert.Equal(t, 1, c.called, "oneway request must not be sent")
+}
+
+func TestInterceptorsWithoutInterceptors(t *testing.T) {
+	c := &fakeClient{err: errors.New("great sadness")}
+	var interceptors Interceptors
+
+	_, err := interceptors.CallOneway(context.Background(), c, fakeEnveloper(wire.OneWay))
+	assert.EqualError(t, err, "great sadness")
+	assert.Equal(t, 1, c.called)
+
+	err = interceptors.HandleOneway(context.Background(), fakeEnveloper(wire.OneWay),
+		func(context.Context, envelope.Enveloper) error { return nil })
+	assert.NoError(t, err)
+}
+
+func TestCastError(t *testing.T) {
+	err := CastError((*fakeResult)(nil), fakeEnveloper(wire.Reply))
+	assert.EqualError(t, err, "expected a value of type *thrift.fakeResult but received thrift.fakeEnveloper")
+}
@@ -70,6 +70,7 @@ func New(c <$transport>.ClientConfig, opts ...<$thrift>.ClientOption) Interface
 			Service: "<.Name>",
 			ClientConfig: c,
 		}, opts...),
+		interceptors: <$thrift>.ClientInterceptors("<.Name>", opts...),
 		<if .Parent> Interface: <import .ParentClientPackagePath>.New(c, opts...),
 		<end>}
 }
@@ -86,6 +87,7 @@ type client struct {
 	<if .Parent><import .ParentClientPackagePath>.Interface
 	<end>
 	c <$thrift>.Client
+	interceptors <$thrift>.Interceptors
 }
 
 <$service := .>
@@ -100,24 +102,25 @@ func (c client) <.Name>(
 	opts ...<$yarpc>.CallOption,
 <if .OneWay>) (<$yarpc>.Ack, error) {
 	args := <$prefix>Helper.Args(<range .Arguments>_<.Name>, <end>)
-	return c.c.CallOneway(ctx, args, opts...)
+	return c.interceptors.CallOneway(ctx, c.c, args, opts...)
 }
 <else>) (<if .ReturnType>success <formatType .ReturnType>,<end> err error) {
-	<$wire := import "go.uber.org/thriftrw/wire">
+	<$envelope := import "go.uber.org/thriftrw/envelope">
 	args := <$prefix>Helper.Args(<range .Arguments>_<.Name>, <end>)
 
-	var body <$wire>.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body <$envelope>.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() <$thrift>.Result { return new(<$prefix>Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result <$prefix>Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*<$prefix>Result)
+	if !ok {
+		err = <$thrift>.CastError(result, body)
 		return
 	}
 
-	<if .ReturnType>success, <end>err = <$prefix>Helper.UnwrapResponse(&result)
+	<if .ReturnType>success, <end>err = <$prefix>Helper.UnwrapResponse(result)
 	return
 }
 <end>
@@ -6,7 +6,7 @@ package readonlystoreclient
 import (
 	"context"
 	"reflect"
-	"go.uber.org/thriftrw/wire"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/thrift"
 	"go.uber.org/yarpc/encoding/thrift/thriftrw-plugin-yarpc/internal/tests/atomic"
@@ -34,7 +34,8 @@ func New(c transport.ClientConfig, opts ...thrift.ClientOption) Interface {
 			Service:      "ReadOnlyStore",
 			ClientConfig: c,
 		}, opts...),
-		Interface: baseserviceclient.New(c, opts...),
+		interceptors: thrift.ClientInterceptors("ReadOnlyStore", opts...),
+		Interface:    baseserviceclient.New(c, opts...),
 	}
 }
 
@@ -49,7 +50,8 @@ func init() {
 type client struct {
 	baseserviceclient.Interface
 
-	c thrift.Client
+	c            thrift.Client
+	interceptors thrift.Interceptors
 }
 
 func (c client) Integer(
@@ -60,17 +62,18 @@ func (c client) Integer(
 
 	args := atomic.ReadOnlyStore_Integer_Helper.Args(_Key)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(atomic.ReadOnlyStore_Integer_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result atomic.ReadOnlyStore_Integer_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*atomic.ReadOnlyStore_Integer_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = atomic.ReadOnlyStore_Integer_Helper.UnwrapResponse(&result)
+	success, err = atomic.ReadOnlyStore_Integer_Helper.UnwrapResponse(result)
 	return
 }
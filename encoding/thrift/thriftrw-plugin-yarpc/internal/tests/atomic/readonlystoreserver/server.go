@@ -5,6 +5,7 @@ package readonlystoreserver
 
 import (
 	"context"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/thriftrw/wire"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/thrift"
@@ -28,7 +29,7 @@ type Interface interface {
 // 	handler := ReadOnlyStoreHandler{}
 // 	dispatcher.Register(readonlystoreserver.New(handler))
 func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
-	h := handler{impl}
+	h := handler{impl: impl, interceptors: thrift.ServerInterceptors(opts...)}
 	service := thrift.Service{
 		Name: "ReadOnlyStore",
 		Methods: []thrift.Method{
@@ -51,7 +52,10 @@ func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
 	return procedures
 }
 
-type handler struct{ impl Interface }
+type handler struct {
+	impl         Interface
+	interceptors thrift.Interceptors
+}
 
 func (h handler) Integer(ctx context.Context, body wire.Value) (thrift.Response, error) {
 	var args atomic.ReadOnlyStore_Integer_Args
@@ -59,6 +63,15 @@ func (h handler) Integer(ctx context.Context, body wire.Value) (thrift.Response,
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleInteger)
+}
+
+func (h handler) handleInteger(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*atomic.ReadOnlyStore_Integer_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.Integer(ctx, args.Key)
 
 	hadError := err != nil
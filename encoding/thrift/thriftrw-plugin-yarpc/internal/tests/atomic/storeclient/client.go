@@ -6,7 +6,7 @@ package storeclient
 import (
 	"context"
 	"reflect"
-	"go.uber.org/thriftrw/wire"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/thrift"
 	"go.uber.org/yarpc/encoding/thrift/thriftrw-plugin-yarpc/internal/tests/atomic"
@@ -47,7 +47,8 @@ func New(c transport.ClientConfig, opts ...thrift.ClientOption) Interface {
 			Service:      "Store",
 			ClientConfig: c,
 		}, opts...),
-		Interface: readonlystoreclient.New(c, opts...),
+		interceptors: thrift.ClientInterceptors("Store", opts...),
+		Interface:    readonlystoreclient.New(c, opts...),
 	}
 }
 
@@ -62,7 +63,8 @@ func init() {
 type client struct {
 	readonlystoreclient.Interface
 
-	c thrift.Client
+	c            thrift.Client
+	interceptors thrift.Interceptors
 }
 
 func (c client) CompareAndSwap(
@@ -73,18 +75,19 @@ func (c client) CompareAndSwap(
 
 	args := atomic.Store_CompareAndSwap_Helper.Args(_Request)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(atomic.Store_CompareAndSwap_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result atomic.Store_CompareAndSwap_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*atomic.Store_CompareAndSwap_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	err = atomic.Store_CompareAndSwap_Helper.UnwrapResponse(&result)
+	err = atomic.Store_CompareAndSwap_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -94,7 +97,7 @@ func (c client) Forget(
 	opts ...yarpc.CallOption,
 ) (yarpc.Ack, error) {
 	args := atomic.Store_Forget_Helper.Args(_Key)
-	return c.c.CallOneway(ctx, args, opts...)
+	return c.interceptors.CallOneway(ctx, c.c, args, opts...)
 }
 
 func (c client) Increment(
@@ -106,17 +109,18 @@ func (c client) Increment(
 
 	args := atomic.Store_Increment_Helper.Args(_Key, _Value)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(atomic.Store_Increment_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result atomic.Store_Increment_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*atomic.Store_Increment_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	err = atomic.Store_Increment_Helper.UnwrapResponse(&result)
+	err = atomic.Store_Increment_Helper.UnwrapResponse(result)
 	return
 }
@@ -5,6 +5,7 @@ package storeserver
 
 import (
 	"context"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/thriftrw/wire"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/thrift"
@@ -39,7 +40,7 @@ type Interface interface {
 // 	handler := StoreHandler{}
 // 	dispatcher.Register(storeserver.New(handler))
 func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
-	h := handler{impl}
+	h := handler{impl: impl, interceptors: thrift.ServerInterceptors(opts...)}
 	service := thrift.Service{
 		Name: "Store",
 		Methods: []thrift.Method{
@@ -82,7 +83,10 @@ func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
 	return procedures
 }
 
-type handler struct{ impl Interface }
+type handler struct {
+	impl         Interface
+	interceptors thrift.Interceptors
+}
 
 func (h handler) CompareAndSwap(ctx context.Context, body wire.Value) (thrift.Response, error) {
 	var args atomic.Store_CompareAndSwap_Args
@@ -90,6 +94,15 @@ func (h handler) CompareAndSwap(ctx context.Context, body wire.Value) (thrift.Re
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleCompareAndSwap)
+}
+
+func (h handler) handleCompareAndSwap(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*atomic.Store_CompareAndSwap_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	err := h.impl.CompareAndSwap(ctx, args.Request)
 
 	hadError := err != nil
@@ -109,6 +122,15 @@ func (h handler) Forget(ctx context.Context, body wire.Value) error {
 		return err
 	}
 
+	return h.interceptors.HandleOneway(ctx, &args, h.handleForget)
+}
+
+func (h handler) handleForget(ctx context.Context, body envelope.Enveloper) error {
+	args, ok := body.(*atomic.Store_Forget_Args)
+	if !ok {
+		return thrift.CastError(args, body)
+	}
+
 	return h.impl.Forget(ctx, args.Key)
 }
 
@@ -118,6 +140,15 @@ func (h handler) Increment(ctx context.Context, body wire.Value) (thrift.Respons
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleIncrement)
+}
+
+func (h handler) handleIncrement(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*atomic.Store_Increment_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	err := h.impl.Increment(ctx, args.Key, args.Value)
 
 	hadError := err != nil
@@ -6,7 +6,7 @@ package baseserviceclient
 import (
 	"context"
 	"reflect"
-	"go.uber.org/thriftrw/wire"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/thrift"
 	"go.uber.org/yarpc/encoding/thrift/thriftrw-plugin-yarpc/internal/tests/common"
@@ -30,6 +30,7 @@ func New(c transport.ClientConfig, opts ...thrift.ClientOption) Interface {
 			Service:      "BaseService",
 			ClientConfig: c,
 		}, opts...),
+		interceptors: thrift.ClientInterceptors("BaseService", opts...),
 	}
 }
 
@@ -42,7 +43,8 @@ func init() {
 }
 
 type client struct {
-	c thrift.Client
+	c            thrift.Client
+	interceptors thrift.Interceptors
 }
 
 func (c client) Healthy(
@@ -52,17 +54,18 @@ func (c client) Healthy(
 
 	args := common.BaseService_Healthy_Helper.Args()
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(common.BaseService_Healthy_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result common.BaseService_Healthy_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*common.BaseService_Healthy_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = common.BaseService_Healthy_Helper.UnwrapResponse(&result)
+	success, err = common.BaseService_Healthy_Helper.UnwrapResponse(result)
 	return
 }
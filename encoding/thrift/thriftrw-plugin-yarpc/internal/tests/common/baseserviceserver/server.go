@@ -5,6 +5,7 @@ package baseserviceserver
 
 import (
 	"context"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/thriftrw/wire"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/thrift"
@@ -24,7 +25,7 @@ type Interface interface {
 // 	handler := BaseServiceHandler{}
 // 	dispatcher.Register(baseserviceserver.New(handler))
 func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
-	h := handler{impl}
+	h := handler{impl: impl, interceptors: thrift.ServerInterceptors(opts...)}
 	service := thrift.Service{
 		Name: "BaseService",
 		Methods: []thrift.Method{
@@ -46,7 +47,10 @@ func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
 	return procedures
 }
 
-type handler struct{ impl Interface }
+type handler struct {
+	impl         Interface
+	interceptors thrift.Interceptors
+}
 
 func (h handler) Healthy(ctx context.Context, body wire.Value) (thrift.Response, error) {
 	var args common.BaseService_Healthy_Args
@@ -54,6 +58,15 @@ func (h handler) Healthy(ctx context.Context, body wire.Value) (thrift.Response,
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleHealthy)
+}
+
+func (h handler) handleHealthy(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*common.BaseService_Healthy_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.Healthy(ctx)
 
 	hadError := err != nil
@@ -23,6 +23,7 @@ func New(c transport.ClientConfig, opts ...thrift.ClientOption) Interface {
 			Service:      "EmptyService",
 			ClientConfig: c,
 		}, opts...),
+		interceptors: thrift.ClientInterceptors("EmptyService", opts...),
 	}
 }
 
@@ -35,5 +36,6 @@ func init() {
 }
 
 type client struct {
-	c thrift.Client
+	c            thrift.Client
+	interceptors thrift.Interceptors
 }
@@ -29,4 +29,7 @@ func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
 	return procedures
 }
 
-type handler struct{ impl Interface }
+type handler struct {
+	impl         Interface
+	interceptors thrift.Interceptors
+}
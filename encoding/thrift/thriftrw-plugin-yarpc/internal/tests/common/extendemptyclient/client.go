@@ -6,7 +6,7 @@ package extendemptyclient
 import (
 	"context"
 	"reflect"
-	"go.uber.org/thriftrw/wire"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/thrift"
 	"go.uber.org/yarpc/encoding/thrift/thriftrw-plugin-yarpc/internal/tests/common"
@@ -33,7 +33,8 @@ func New(c transport.ClientConfig, opts ...thrift.ClientOption) Interface {
 			Service:      "ExtendEmpty",
 			ClientConfig: c,
 		}, opts...),
-		Interface: emptyserviceclient.New(c, opts...),
+		interceptors: thrift.ClientInterceptors("ExtendEmpty", opts...),
+		Interface:    emptyserviceclient.New(c, opts...),
 	}
 }
 
@@ -48,7 +49,8 @@ func init() {
 type client struct {
 	emptyserviceclient.Interface
 
-	c thrift.Client
+	c            thrift.Client
+	interceptors thrift.Interceptors
 }
 
 func (c client) Hello(
@@ -58,17 +60,18 @@ func (c client) Hello(
 
 	args := common.ExtendEmpty_Hello_Helper.Args()
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(common.ExtendEmpty_Hello_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result common.ExtendEmpty_Hello_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*common.ExtendEmpty_Hello_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	err = common.ExtendEmpty_Hello_Helper.UnwrapResponse(&result)
+	err = common.ExtendEmpty_Hello_Helper.UnwrapResponse(result)
 	return
 }
@@ -5,6 +5,7 @@ package extendemptyserver
 
 import (
 	"context"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/thriftrw/wire"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/thrift"
@@ -27,7 +28,7 @@ type Interface interface {
 // 	handler := ExtendEmptyHandler{}
 // 	dispatcher.Register(extendemptyserver.New(handler))
 func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
-	h := handler{impl}
+	h := handler{impl: impl, interceptors: thrift.ServerInterceptors(opts...)}
 	service := thrift.Service{
 		Name: "ExtendEmpty",
 		Methods: []thrift.Method{
@@ -50,7 +51,10 @@ func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
 	return procedures
 }
 
-type handler struct{ impl Interface }
+type handler struct {
+	impl         Interface
+	interceptors thrift.Interceptors
+}
 
 func (h handler) Hello(ctx context.Context, body wire.Value) (thrift.Response, error) {
 	var args common.ExtendEmpty_Hello_Args
@@ -58,6 +62,15 @@ func (h handler) Hello(ctx context.Context, body wire.Value) (thrift.Response, e
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleHello)
+}
+
+func (h handler) handleHello(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*common.ExtendEmpty_Hello_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	err := h.impl.Hello(ctx)
 
 	hadError := err != nil
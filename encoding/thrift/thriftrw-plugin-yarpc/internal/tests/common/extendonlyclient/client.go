@@ -25,7 +25,8 @@ func New(c transport.ClientConfig, opts ...thrift.ClientOption) Interface {
 			Service:      "ExtendOnly",
 			ClientConfig: c,
 		}, opts...),
-		Interface: baseserviceclient.New(c, opts...),
+		interceptors: thrift.ClientInterceptors("ExtendOnly", opts...),
+		Interface:    baseserviceclient.New(c, opts...),
 	}
 }
 
@@ -40,5 +41,6 @@ func init() {
 type client struct {
 	baseserviceclient.Interface
 
-	c thrift.Client
+	c            thrift.Client
+	interceptors thrift.Interceptors
 }
@@ -69,7 +69,7 @@ type Interface interface {
 // 	handler := <.Name>Handler{}
 // 	dispatcher.Register(<$pkgname>.New(handler))
 func New(impl Interface, opts ...<$thrift>.RegisterOption) []<$transport>.Procedure {
-	<if .Functions>h := handler{impl}<end>
+	<if .Functions>h := handler{impl: impl, interceptors: <$thrift>.ServerInterceptors(opts...)}<end>
 	service := <$thrift>.Service{
 		Name: "<.Name>",
 		Methods: []<$thrift>.Method{
@@ -96,7 +96,10 @@ func New(impl Interface, opts ...<$thrift>.RegisterOption) []<$transport>.Proced
 	return procedures
 }
 
-type handler struct{ impl Interface }
+type handler struct{
+	impl         Interface
+	interceptors <$thrift>.Interceptors
+}
 
 <$service := .>
 <$module := .Module>
@@ -105,6 +108,8 @@ type handler struct{ impl Interface }
 <$prefix := printf "%s.%s_%s_" (import $module.ImportPath) $service.Name .Name>
 
 <$wire := import "go.uber.org/thriftrw/wire">
+<$envelope := import "go.uber.org/thriftrw/envelope">
+<$stdcontext := import "context">
 
 <if .OneWay>
 func (h handler) <.Name>(ctx <$context>.Context, body <$wire>.Value) error {
@@ -113,6 +118,15 @@ func (h handler) <.Name>(ctx <$context>.Context, body <$wire>.Value) error {
 		return err
 	}
 
+	return h.interceptors.HandleOneway(ctx, &args, h.handle<.Name>)
+}
+
+func (h handler) handle<.Name>(ctx <$stdcontext>.Context, body <$envelope>.Enveloper) error {
+	args, ok := body.(*<$prefix>Args)
+	if !ok {
+		return <$thrift>.CastError(args, body)
+	}
+
 	return h.impl.<.Name>(ctx, <range .Arguments>args.<.Name>,<end>)
 }
 <else>
@@ -122,6 +136,15 @@ func (h handler) <.Name>(ctx <$context>.Context, body <$wire>.Value) (<$thrift>.
 		return <$thrift>.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handle<.Name>)
+}
+
+func (h handler) handle<.Name>(ctx <$stdcontext>.Context, body <$envelope>.Enveloper) (<$thrift>.Response, error) {
+	args, ok := body.(*<$prefix>Args)
+	if !ok {
+		return <$thrift>.Response{}, <$thrift>.CastError(args, body)
+	}
+
 	<if .ReturnType>
 		success, err := h.impl.<.Name>(ctx, <range .Arguments>args.<.Name>,<end>)
 	<else>
@@ -0,0 +1,132 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package protobuf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/encoding/x/protobuf/internal/wirepb"
+	"go.uber.org/yarpc/internal/clientconfig"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMessage() proto.Message { return &wirepb.Error{} }
+
+func TestInterceptInbound(t *testing.T) {
+	var calls []string
+	validate := Interceptor{
+		UnaryInbound: func(ctx context.Context, request proto.Message, handler UnaryHandlerFunc) (proto.Message, error) {
+			calls = append(calls, "validate "+yarpc.CallFromContext(ctx).Procedure())
+			if request.(*wirepb.Error).Message == "" {
+				return nil, errors.New("message is required")
+			}
+			return handler(ctx, request)
+		},
+	}
+	redact := Interceptor{
+		UnaryInbound: func(ctx context.Context, request proto.Message, handler UnaryHandlerFunc) (proto.Message, error) {
+			response, err := handler(ctx, request)
+			calls = append(calls, "redact")
+			if response != nil {
+				response = &wirepb.Error{Message: "[redacted]"}
+			}
+			return response, err
+		},
+	}
+	handler := NewUnaryHandler(
+		func(ctx context.Context, request proto.Message) (proto.Message, error) {
+			calls = append(calls, "handle "+request.(*wirepb.Error).Message)
+			return request, nil
+		},
+		newMessage,
+		Intercept(validate, Interceptor{}),
+		Intercept(redact),
+	)
+
+	body, err := proto.Marshal(&wirepb.Error{Message: "secret"})
+	require.NoError(t, err)
+	resw := new(transporttest.FakeResponseWriter)
+	require.NoError(t, handler.Handle(context.Background(), &transport.Request{
+		Procedure: "Foo::bar",
+		Encoding:  Encoding,
+		Body:      bytes.NewReader(body),
+	}, resw))
+
+	var wireResponse wirepb.Response
+	require.NoError(t, proto.Unmarshal(resw.Body.Bytes(), &wireResponse))
+	var response wirepb.Error
+	require.NoError(t, proto.Unmarshal(wireResponse.Payload, &response))
+	assert.Equal(t, "[redacted]", response.Message)
+	assert.Equal(t, []string{"validate Foo::bar", "handle secret", "redact"}, calls)
+
+	resw = new(transporttest.FakeResponseWriter)
+	require.NoError(t, handler.Handle(context.Background(), &transport.Request{
+		Procedure: "Foo::bar",
+		Encoding:  Encoding,
+		Body:      bytes.NewReader(nil),
+	}, resw))
+	assert.True(t, resw.IsApplicationError, "Expected the interceptor's error to be an application error.")
+}
+
+func TestInterceptOutbound(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	var procedures []string
+	client := NewClient("Foo", clientconfig.MultiOutbound("caller", "service", transport.Outbounds{
+		Unary: out,
+	}), Intercept(Interceptor{
+		UnaryOutbound: func(ctx context.Context, procedure string, request proto.Message, call UnaryCallFunc) (proto.Message, error) {
+			procedures = append(procedures, procedure)
+			return call(ctx, &wirepb.Error{Message: "rewritten"})
+		},
+	}))
+
+	out.EXPECT().Call(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+			var request wirepb.Error
+			body, err := ioutil.ReadAll(req.Body)
+			require.NoError(t, err)
+			require.NoError(t, proto.Unmarshal(body, &request))
+			assert.Equal(t, "rewritten", request.Message)
+			return &transport.Response{
+				Headers: getRawResponseHeaders(),
+				Body:    ioutil.NopCloser(bytes.NewReader(body)),
+			}, nil
+		})
+
+	response, err := client.Call(context.Background(), "bar", &wirepb.Error{Message: "original"}, newMessage)
+	require.NoError(t, err)
+	assert.Equal(t, "rewritten", response.(*wirepb.Error).Message)
+	assert.Equal(t, []string{"Foo::bar"}, procedures)
+}
@@ -38,10 +38,11 @@ import (
 type client struct {
 	serviceName  string
 	clientConfig transport.ClientConfig
+	interceptors []Interceptor
 }
 
 func newClient(serviceName string, clientConfig transport.ClientConfig) *client {
-	return &client{serviceName, clientConfig}
+	return &client{serviceName: serviceName, clientConfig: clientConfig}
 }
 
 func (c *client) Call(
@@ -50,6 +51,45 @@ func (c *client) Call(
 	request proto.Message,
 	newResponse func() proto.Message,
 	options ...yarpc.CallOption,
+) (proto.Message, error) {
+	if len(c.interceptors) == 0 {
+		return c.call(ctx, requestMethodName, request, newResponse, options)
+	}
+	call := chainUnaryOutbound(
+		c.interceptors,
+		procedure.ToName(c.serviceName, requestMethodName),
+		func(ctx context.Context, request proto.Message) (proto.Message, error) {
+			return c.call(ctx, requestMethodName, request, newResponse, options)
+		},
+	)
+	return call(ctx, request)
+}
+
+func (c *client) CallOneway(
+	ctx context.Context,
+	requestMethodName string,
+	request proto.Message,
+	options ...yarpc.CallOption,
+) (transport.Ack, error) {
+	if len(c.interceptors) == 0 {
+		return c.callOneway(ctx, requestMethodName, request, options)
+	}
+	call := chainOnewayOutbound(
+		c.interceptors,
+		procedure.ToName(c.serviceName, requestMethodName),
+		func(ctx context.Context, request proto.Message) (transport.Ack, error) {
+			return c.callOneway(ctx, requestMethodName, request, options)
+		},
+	)
+	return call(ctx, request)
+}
+
+func (c *client) call(
+	ctx context.Context,
+	requestMethodName string,
+	request proto.Message,
+	newResponse func() proto.Message,
+	options []yarpc.CallOption,
 ) (proto.Message, error) {
 	transportRequest, err := c.buildTransportRequest(requestMethodName, request)
 	if err != nil {
@@ -104,11 +144,11 @@ func (c *client) Call(
 	return response, nil
 }
 
-func (c *client) CallOneway(
+func (c *client) callOneway(
 	ctx context.Context,
 	requestMethodName string,
 	request proto.Message,
-	options ...yarpc.CallOption,
+	options []yarpc.CallOption,
 ) (transport.Ack, error) {
 	transportRequest, err := c.buildTransportRequest(requestMethodName, request)
 	if err != nil {
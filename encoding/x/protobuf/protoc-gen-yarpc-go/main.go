@@ -79,8 +79,8 @@ type {{$service.GetName}}YarpcClient interface {
 }
 
 // New{{$service.GetName}}YarpcClient builds a new yarpc client for the {{$service.GetName}} service.
-func New{{$service.GetName}}YarpcClient(clientConfig transport.ClientConfig) {{$service.GetName}}YarpcClient {
-	return &_{{$service.GetName}}YarpcCaller{protobuf.NewClient("{{trimPrefixPeriod $service.FQSN}}", clientConfig)}
+func New{{$service.GetName}}YarpcClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) {{$service.GetName}}YarpcClient {
+	return &_{{$service.GetName}}YarpcCaller{protobuf.NewClient("{{trimPrefixPeriod $service.FQSN}}", clientConfig, options...)}
 }
 
 // {{$service.GetName}}YarpcServer is the yarpc server-side interface for the {{$service.GetName}} service.
@@ -92,16 +92,16 @@ type {{$service.GetName}}YarpcServer interface {
 }
 
 // Build{{$service.GetName}}YarpcProcedures prepares an implementation of the {{$service.GetName}} service for yarpc registration.
-func Build{{$service.GetName}}YarpcProcedures(server {{$service.GetName}}YarpcServer) []transport.Procedure {
+func Build{{$service.GetName}}YarpcProcedures(server {{$service.GetName}}YarpcServer, options ...protobuf.RegisterOption) []transport.Procedure {
 	handler := &_{{$service.GetName}}YarpcHandler{server}
 	return protobuf.BuildProcedures(
 		"{{trimPrefixPeriod $service.FQSN}}",
 		map[string]transport.UnaryHandler{
-		{{range $method := unaryMethods $service}}"{{$method.GetName}}": protobuf.NewUnaryHandler(handler.{{$method.GetName}}, new{{$service.GetName}}_{{$method.GetName}}YarpcRequest),
+		{{range $method := unaryMethods $service}}"{{$method.GetName}}": protobuf.NewUnaryHandler(handler.{{$method.GetName}}, new{{$service.GetName}}_{{$method.GetName}}YarpcRequest, options...),
 		{{end}}
 		},
 		map[string]transport.OnewayHandler{
-		{{range $method := onewayMethods $service}}"{{$method.GetName}}": protobuf.NewOnewayHandler(handler.{{$method.GetName}}, new{{$service.GetName}}_{{$method.GetName}}YarpcRequest),
+		{{range $method := onewayMethods $service}}"{{$method.GetName}}": protobuf.NewOnewayHandler(handler.{{$method.GetName}}, new{{$service.GetName}}_{{$method.GetName}}YarpcRequest, options...),
 		{{end}}
 		},
 	)
@@ -103,24 +103,34 @@ type Client interface {
 }
 
 // NewClient creates a new client.
-func NewClient(serviceName string, clientConfig transport.ClientConfig) Client {
-	return newClient(serviceName, clientConfig)
+func NewClient(serviceName string, clientConfig transport.ClientConfig, options ...ClientOption) Client {
+	var co clientOptions
+	for _, option := range options {
+		option.applyClientOption(&co)
+	}
+	c := newClient(serviceName, clientConfig)
+	c.interceptors = co.Interceptors
+	return c
 }
 
 // NewUnaryHandler returns a new UnaryHandler.
 func NewUnaryHandler(
 	handle func(context.Context, proto.Message) (proto.Message, error),
 	newRequest func() proto.Message,
+	options ...RegisterOption,
 ) transport.UnaryHandler {
-	return newUnaryHandler(handle, newRequest)
+	ro := buildRegisterOptions(options)
+	return newUnaryHandler(chainUnaryInbound(ro.Interceptors, handle), newRequest)
 }
 
 // NewOnewayHandler returns a new OnewayHandler.
 func NewOnewayHandler(
 	handleOneway func(context.Context, proto.Message) error,
 	newRequest func() proto.Message,
+	options ...RegisterOption,
 ) transport.OnewayHandler {
-	return newOnewayHandler(handleOneway, newRequest)
+	ro := buildRegisterOptions(options)
+	return newOnewayHandler(chainOnewayInbound(ro.Interceptors, handleOneway), newRequest)
 }
 
 // CastError returns an error saying that generated code could not properly cast a proto.Message to it's expected type.
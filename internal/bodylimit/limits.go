@@ -0,0 +1,146 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package bodylimit limits the sizes of request and response bodies read by
+// transports.
+package bodylimit
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Limits holds the maximum sizes of bodies in bytes, by procedure. A limit of
+// zero or less means that bodies are not limited. A nil Limits does not limit
+// any bodies.
+type Limits struct {
+	// Limit for procedures that do not have one of their own.
+	Default int64
+
+	procedures map[string]int64
+	exceeded   int64 // atomic
+}
+
+// SetProcedure sets the limit for the given procedure.
+func (l *Limits) SetProcedure(procedure string, limit int64) {
+	if l.procedures == nil {
+		l.procedures = make(map[string]int64)
+	}
+	l.procedures[procedure] = limit
+}
+
+// Copy returns a copy of the limits. Bodies that exceed the limits of the
+// copy are counted separately.
+func (l *Limits) Copy() *Limits {
+	c := &Limits{}
+	if l == nil {
+		return c
+	}
+	c.Default = l.Default
+	for procedure, limit := range l.procedures {
+		c.SetProcedure(procedure, limit)
+	}
+	return c
+}
+
+// For returns the limit for the given procedure.
+func (l *Limits) For(procedure string) int64 {
+	if l == nil {
+		return 0
+	}
+	if limit, ok := l.procedures[procedure]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// Exceeded returns the number of bodies that were rejected because they
+// exceeded their limits.
+func (l *Limits) Exceeded() int64 {
+	if l == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&l.exceeded)
+}
+
+// Check returns err if the given size, as advertised by the peer, exceeds
+// the limit for the given procedure. Sizes less than zero are unknown and
+// are never rejected.
+func (l *Limits) Check(procedure string, size int64, err error) error {
+	if limit := l.For(procedure); limit > 0 && size > limit {
+		atomic.AddInt64(&l.exceeded, 1)
+		return err
+	}
+	return nil
+}
+
+// Reader wraps the given reader so that it fails with err once more bytes
+// than the limit for the given procedure have been read from it.
+func (l *Limits) Reader(procedure string, r io.Reader, err error) *Reader {
+	limit := l.For(procedure)
+	return &Reader{r: r, limited: limit > 0, remaining: limit + 1, err: err, limits: l}
+}
+
+// Reader is an io.Reader that fails once more bytes than its limit have been
+// read from it.
+type Reader struct {
+	r         io.Reader
+	limited   bool
+	remaining int64
+	err       error
+	limits    *Limits
+	exceeded  bool
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if !r.limited {
+		return r.r.Read(p)
+	}
+	if r.exceeded {
+		return 0, r.err
+	}
+
+	// Read at most one byte past the limit to find out whether it was
+	// exceeded.
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.r.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining <= 0 {
+		r.exceeded = true
+		atomic.AddInt64(&r.limits.exceeded, 1)
+		return n - 1, r.err
+	}
+	return n, err
+}
+
+// Exceeded returns true if more bytes than the limit were read.
+func (r *Reader) Exceeded() bool {
+	return r.exceeded
+}
+
+// Close closes the underlying reader if it is an io.Closer.
+func (r *Reader) Close() error {
+	if c, ok := r.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bodylimit
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReader(t *testing.T) {
+	errTooLarge := errors.New("too large")
+
+	var limits Limits
+	limits.Default = 4
+	limits.SetProcedure("big", 8)
+	limits.SetProcedure("unlimited", 0)
+
+	tests := []struct {
+		procedure string
+		body      string
+		wantErr   bool
+	}{
+		{procedure: "small", body: "abcd"},
+		{procedure: "small", body: "abcde", wantErr: true},
+		{procedure: "big", body: "abcdefgh"},
+		{procedure: "big", body: "abcdefghi", wantErr: true},
+		{procedure: "unlimited", body: "abcdefghijklmnop"},
+	}
+
+	var wantExceeded int64
+	for _, tt := range tests {
+		r := limits.Reader(tt.procedure, bytes.NewReader([]byte(tt.body)), errTooLarge)
+		got, err := ioutil.ReadAll(r)
+		if tt.wantErr {
+			wantExceeded++
+			assert.Equal(t, errTooLarge, err, "procedure %q body %q", tt.procedure, tt.body)
+			assert.True(t, r.Exceeded())
+			assert.Len(t, got, int(limits.For(tt.procedure)))
+
+			// Reads after the limit was exceeded keep failing.
+			_, err = r.Read(make([]byte, 1))
+			assert.Equal(t, errTooLarge, err)
+		} else {
+			assert.NoError(t, err, "procedure %q body %q", tt.procedure, tt.body)
+			assert.False(t, r.Exceeded())
+			assert.Equal(t, tt.body, string(got))
+		}
+		assert.NoError(t, r.Close())
+	}
+	assert.Equal(t, wantExceeded, limits.Exceeded())
+}
+
+func TestCheck(t *testing.T) {
+	errTooLarge := errors.New("too large")
+	limits := Limits{Default: 10}
+
+	assert.NoError(t, limits.Check("foo", 10, errTooLarge))
+	assert.NoError(t, limits.Check("foo", -1, errTooLarge))
+	assert.Equal(t, errTooLarge, limits.Check("foo", 11, errTooLarge))
+	assert.Equal(t, int64(1), limits.Exceeded())
+}
+
+func TestCopy(t *testing.T) {
+	errTooLarge := errors.New("too large")
+	limits := Limits{Default: 4}
+	limits.SetProcedure("big", 8)
+
+	c := limits.Copy()
+	assert.Equal(t, int64(4), c.For("foo"))
+	assert.Equal(t, int64(8), c.For("big"))
+
+	// Changes to the copy don't affect the original.
+	c.Default = 2
+	c.SetProcedure("big", 16)
+	assert.Equal(t, int64(4), limits.For("foo"))
+	assert.Equal(t, int64(8), limits.For("big"))
+
+	// Bodies are counted separately.
+	assert.Equal(t, errTooLarge, c.Check("foo", 3, errTooLarge))
+	assert.Equal(t, int64(1), c.Exceeded())
+	assert.Equal(t, int64(0), limits.Exceeded())
+
+	var nilLimits *Limits
+	assert.Equal(t, int64(0), nilLimits.Copy().For("foo"))
+}
@@ -99,6 +99,11 @@ func CreateOnewayDispatcher(t crossdock.T, handler raw.OnewayHandler) (*yarpc.Di
 	switch trans {
 	case "http":
 		outbound = httpTransport.NewSingleOutbound(fmt.Sprintf("http://%s:8084", server))
+	case "tchannel":
+		tchannelTransport, err := tchannel.NewChannelTransport(tchannel.ServiceName("oneway-client"))
+		fatals.NoError(err, "Failed to build ChannelTransport")
+
+		outbound = tchannelTransport.NewSingleOutbound(server + ":8086")
 	case "redis":
 		outbound = redis.NewOnewayOutbound(
 			redis.NewRedis5Client("redis:6379"),
@@ -99,6 +99,12 @@ func TestCreateOneway(t *testing.T) {
 				"transport_oneway": "http",
 			},
 		},
+		{
+			params: crossdock.Params{
+				"server_oneway":    "localhost",
+				"transport_oneway": "tchannel",
+			},
+		},
 	}
 
 	for _, tt := range tests {
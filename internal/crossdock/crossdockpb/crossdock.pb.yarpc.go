@@ -39,8 +39,8 @@ type EchoYarpcClient interface {
 }
 
 // NewEchoYarpcClient builds a new yarpc client for the Echo service.
-func NewEchoYarpcClient(clientConfig transport.ClientConfig) EchoYarpcClient {
-	return &_EchoYarpcCaller{protobuf.NewClient("uber.yarpc.internal.crossdock.Echo", clientConfig)}
+func NewEchoYarpcClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) EchoYarpcClient {
+	return &_EchoYarpcCaller{protobuf.NewClient("uber.yarpc.internal.crossdock.Echo", clientConfig, options...)}
 }
 
 // EchoYarpcServer is the yarpc server-side interface for the Echo service.
@@ -49,12 +49,12 @@ type EchoYarpcServer interface {
 }
 
 // BuildEchoYarpcProcedures prepares an implementation of the Echo service for yarpc registration.
-func BuildEchoYarpcProcedures(server EchoYarpcServer) []transport.Procedure {
+func BuildEchoYarpcProcedures(server EchoYarpcServer, options ...protobuf.RegisterOption) []transport.Procedure {
 	handler := &_EchoYarpcHandler{server}
 	return protobuf.BuildProcedures(
 		"uber.yarpc.internal.crossdock.Echo",
 		map[string]transport.UnaryHandler{
-			"Echo": protobuf.NewUnaryHandler(handler.Echo, newEcho_EchoYarpcRequest),
+			"Echo": protobuf.NewUnaryHandler(handler.Echo, newEcho_EchoYarpcRequest, options...),
 		},
 		map[string]transport.OnewayHandler{},
 	)
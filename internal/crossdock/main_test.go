@@ -146,7 +146,7 @@ func TestCrossdock(t *testing.T) {
 			},
 			axes: axes{
 				"encoding":         []string{"raw", "json", "thrift"},
-				"transport_oneway": []string{"http"},
+				"transport_oneway": []string{"http", "tchannel"},
 			},
 		},
 		{
@@ -155,7 +155,7 @@ func TestCrossdock(t *testing.T) {
 				"server_oneway": "127.0.0.1",
 			},
 			axes: axes{
-				"transport_oneway": []string{"http"},
+				"transport_oneway": []string{"http", "tchannel"},
 			},
 		},
 	}
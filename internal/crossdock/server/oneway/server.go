@@ -32,6 +32,7 @@ import (
 	"go.uber.org/yarpc/encoding/raw"
 	"go.uber.org/yarpc/internal/crossdock/thrift/oneway/onewayserver"
 	"go.uber.org/yarpc/transport/http"
+	"go.uber.org/yarpc/transport/tchannel"
 	"go.uber.org/yarpc/transport/x/cherami"
 	"go.uber.org/yarpc/transport/x/redis"
 
@@ -44,7 +45,18 @@ var dispatcher *yarpc.Dispatcher
 // Start starts the test server that clients will make requests to
 func Start() {
 	httpTransport := http.NewTransport()
-	inbounds := []transport.Inbound{httpTransport.NewInbound(":8084")}
+	tchannelTransport, err := tchannel.NewChannelTransport(
+		tchannel.ServiceName("oneway-server"),
+		tchannel.ListenAddr(":8086"),
+	)
+	if err != nil {
+		log.Panicf("failed to build ChannelTransport: %v", err)
+	}
+
+	inbounds := []transport.Inbound{
+		httpTransport.NewInbound(":8084"),
+		tchannelTransport.NewInbound(),
+	}
 
 	if useRedis() {
 		rds := redis.NewInbound(
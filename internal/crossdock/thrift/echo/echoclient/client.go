@@ -26,7 +26,7 @@ package echoclient
 import (
 	"context"
 	"reflect"
-	"go.uber.org/thriftrw/wire"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/crossdock/thrift/echo"
 	"go.uber.org/yarpc/encoding/thrift"
@@ -51,6 +51,7 @@ func New(c transport.ClientConfig, opts ...thrift.ClientOption) Interface {
 			Service:      "Echo",
 			ClientConfig: c,
 		}, opts...),
+		interceptors: thrift.ClientInterceptors("Echo", opts...),
 	}
 }
 
@@ -63,7 +64,8 @@ func init() {
 }
 
 type client struct {
-	c thrift.Client
+	c            thrift.Client
+	interceptors thrift.Interceptors
 }
 
 func (c client) Echo(
@@ -74,17 +76,18 @@ func (c client) Echo(
 
 	args := echo.Echo_Echo_Helper.Args(_Ping)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(echo.Echo_Echo_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result echo.Echo_Echo_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*echo.Echo_Echo_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = echo.Echo_Echo_Helper.UnwrapResponse(&result)
+	success, err = echo.Echo_Echo_Helper.UnwrapResponse(result)
 	return
 }
@@ -25,6 +25,7 @@ package echoserver
 
 import (
 	"context"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/thriftrw/wire"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/crossdock/thrift/echo"
@@ -45,7 +46,7 @@ type Interface interface {
 // 	handler := EchoHandler{}
 // 	dispatcher.Register(echoserver.New(handler))
 func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
-	h := handler{impl}
+	h := handler{impl: impl, interceptors: thrift.ServerInterceptors(opts...)}
 	service := thrift.Service{
 		Name: "Echo",
 		Methods: []thrift.Method{
@@ -67,7 +68,10 @@ func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
 	return procedures
 }
 
-type handler struct{ impl Interface }
+type handler struct {
+	impl         Interface
+	interceptors thrift.Interceptors
+}
 
 func (h handler) Echo(ctx context.Context, body wire.Value) (thrift.Response, error) {
 	var args echo.Echo_Echo_Args
@@ -75,6 +79,15 @@ func (h handler) Echo(ctx context.Context, body wire.Value) (thrift.Response, er
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleEcho)
+}
+
+func (h handler) handleEcho(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*echo.Echo_Echo_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.Echo(ctx, args.Ping)
 
 	hadError := err != nil
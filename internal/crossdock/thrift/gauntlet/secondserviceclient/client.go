@@ -26,7 +26,7 @@ package secondserviceclient
 import (
 	"context"
 	"reflect"
-	"go.uber.org/thriftrw/wire"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/crossdock/thrift/gauntlet"
 	"go.uber.org/yarpc/encoding/thrift"
@@ -56,6 +56,7 @@ func New(c transport.ClientConfig, opts ...thrift.ClientOption) Interface {
 			Service:      "SecondService",
 			ClientConfig: c,
 		}, opts...),
+		interceptors: thrift.ClientInterceptors("SecondService", opts...),
 	}
 }
 
@@ -68,7 +69,8 @@ func init() {
 }
 
 type client struct {
-	c thrift.Client
+	c            thrift.Client
+	interceptors thrift.Interceptors
 }
 
 func (c client) BlahBlah(
@@ -78,18 +80,19 @@ func (c client) BlahBlah(
 
 	args := gauntlet.SecondService_BlahBlah_Helper.Args()
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.SecondService_BlahBlah_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.SecondService_BlahBlah_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.SecondService_BlahBlah_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	err = gauntlet.SecondService_BlahBlah_Helper.UnwrapResponse(&result)
+	err = gauntlet.SecondService_BlahBlah_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -101,17 +104,18 @@ func (c client) SecondtestString(
 
 	args := gauntlet.SecondService_SecondtestString_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.SecondService_SecondtestString_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.SecondService_SecondtestString_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.SecondService_SecondtestString_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.SecondService_SecondtestString_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.SecondService_SecondtestString_Helper.UnwrapResponse(result)
 	return
 }
@@ -25,6 +25,7 @@ package secondserviceserver
 
 import (
 	"context"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/thriftrw/wire"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/crossdock/thrift/gauntlet"
@@ -49,7 +50,7 @@ type Interface interface {
 // 	handler := SecondServiceHandler{}
 // 	dispatcher.Register(secondserviceserver.New(handler))
 func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
-	h := handler{impl}
+	h := handler{impl: impl, interceptors: thrift.ServerInterceptors(opts...)}
 	service := thrift.Service{
 		Name: "SecondService",
 		Methods: []thrift.Method{
@@ -81,7 +82,10 @@ func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
 	return procedures
 }
 
-type handler struct{ impl Interface }
+type handler struct {
+	impl         Interface
+	interceptors thrift.Interceptors
+}
 
 func (h handler) BlahBlah(ctx context.Context, body wire.Value) (thrift.Response, error) {
 	var args gauntlet.SecondService_BlahBlah_Args
@@ -89,6 +93,15 @@ func (h handler) BlahBlah(ctx context.Context, body wire.Value) (thrift.Response
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleBlahBlah)
+}
+
+func (h handler) handleBlahBlah(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.SecondService_BlahBlah_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	err := h.impl.BlahBlah(ctx)
 
 	hadError := err != nil
@@ -108,6 +121,15 @@ func (h handler) SecondtestString(ctx context.Context, body wire.Value) (thrift.
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleSecondtestString)
+}
+
+func (h handler) handleSecondtestString(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.SecondService_SecondtestString_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.SecondtestString(ctx, args.Thing)
 
 	hadError := err != nil
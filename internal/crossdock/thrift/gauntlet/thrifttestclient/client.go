@@ -26,7 +26,7 @@ package thrifttestclient
 import (
 	"context"
 	"reflect"
-	"go.uber.org/thriftrw/wire"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/crossdock/thrift/gauntlet"
 	"go.uber.org/yarpc/encoding/thrift"
@@ -176,6 +176,7 @@ func New(c transport.ClientConfig, opts ...thrift.ClientOption) Interface {
 			Service:      "ThriftTest",
 			ClientConfig: c,
 		}, opts...),
+		interceptors: thrift.ClientInterceptors("ThriftTest", opts...),
 	}
 }
 
@@ -188,7 +189,8 @@ func init() {
 }
 
 type client struct {
-	c thrift.Client
+	c            thrift.Client
+	interceptors thrift.Interceptors
 }
 
 func (c client) TestBinary(
@@ -199,18 +201,19 @@ func (c client) TestBinary(
 
 	args := gauntlet.ThriftTest_TestBinary_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestBinary_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestBinary_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestBinary_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestBinary_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestBinary_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -222,18 +225,19 @@ func (c client) TestByte(
 
 	args := gauntlet.ThriftTest_TestByte_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestByte_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestByte_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestByte_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestByte_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestByte_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -245,18 +249,19 @@ func (c client) TestDouble(
 
 	args := gauntlet.ThriftTest_TestDouble_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestDouble_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestDouble_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestDouble_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestDouble_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestDouble_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -268,18 +273,19 @@ func (c client) TestEnum(
 
 	args := gauntlet.ThriftTest_TestEnum_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestEnum_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestEnum_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestEnum_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestEnum_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestEnum_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -291,18 +297,19 @@ func (c client) TestException(
 
 	args := gauntlet.ThriftTest_TestException_Helper.Args(_Arg)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestException_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestException_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestException_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	err = gauntlet.ThriftTest_TestException_Helper.UnwrapResponse(&result)
+	err = gauntlet.ThriftTest_TestException_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -314,18 +321,19 @@ func (c client) TestI32(
 
 	args := gauntlet.ThriftTest_TestI32_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestI32_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestI32_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestI32_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestI32_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestI32_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -337,18 +345,19 @@ func (c client) TestI64(
 
 	args := gauntlet.ThriftTest_TestI64_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestI64_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestI64_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestI64_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestI64_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestI64_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -360,18 +369,19 @@ func (c client) TestInsanity(
 
 	args := gauntlet.ThriftTest_TestInsanity_Helper.Args(_Argument)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestInsanity_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestInsanity_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestInsanity_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestInsanity_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestInsanity_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -383,18 +393,19 @@ func (c client) TestList(
 
 	args := gauntlet.ThriftTest_TestList_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestList_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestList_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestList_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestList_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestList_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -406,18 +417,19 @@ func (c client) TestMap(
 
 	args := gauntlet.ThriftTest_TestMap_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestMap_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestMap_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestMap_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestMap_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestMap_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -429,18 +441,19 @@ func (c client) TestMapMap(
 
 	args := gauntlet.ThriftTest_TestMapMap_Helper.Args(_Hello)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestMapMap_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestMapMap_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestMapMap_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestMapMap_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestMapMap_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -457,18 +470,19 @@ func (c client) TestMulti(
 
 	args := gauntlet.ThriftTest_TestMulti_Helper.Args(_Arg0, _Arg1, _Arg2, _Arg3, _Arg4, _Arg5)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestMulti_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestMulti_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestMulti_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestMulti_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestMulti_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -481,18 +495,19 @@ func (c client) TestMultiException(
 
 	args := gauntlet.ThriftTest_TestMultiException_Helper.Args(_Arg0, _Arg1)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestMultiException_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestMultiException_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestMultiException_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestMultiException_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestMultiException_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -504,18 +519,19 @@ func (c client) TestNest(
 
 	args := gauntlet.ThriftTest_TestNest_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestNest_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestNest_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestNest_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestNest_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestNest_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -525,7 +541,7 @@ func (c client) TestOneway(
 	opts ...yarpc.CallOption,
 ) (yarpc.Ack, error) {
 	args := gauntlet.ThriftTest_TestOneway_Helper.Args(_SecondsToSleep)
-	return c.c.CallOneway(ctx, args, opts...)
+	return c.interceptors.CallOneway(ctx, c.c, args, opts...)
 }
 
 func (c client) TestSet(
@@ -536,18 +552,19 @@ func (c client) TestSet(
 
 	args := gauntlet.ThriftTest_TestSet_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestSet_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestSet_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestSet_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestSet_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestSet_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -559,18 +576,19 @@ func (c client) TestString(
 
 	args := gauntlet.ThriftTest_TestString_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestString_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestString_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestString_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestString_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestString_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -582,18 +600,19 @@ func (c client) TestStringMap(
 
 	args := gauntlet.ThriftTest_TestStringMap_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestStringMap_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestStringMap_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestStringMap_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestStringMap_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestStringMap_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -605,18 +624,19 @@ func (c client) TestStruct(
 
 	args := gauntlet.ThriftTest_TestStruct_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestStruct_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestStruct_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestStruct_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestStruct_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestStruct_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -628,18 +648,19 @@ func (c client) TestTypedef(
 
 	args := gauntlet.ThriftTest_TestTypedef_Helper.Args(_Thing)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestTypedef_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestTypedef_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestTypedef_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = gauntlet.ThriftTest_TestTypedef_Helper.UnwrapResponse(&result)
+	success, err = gauntlet.ThriftTest_TestTypedef_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -650,17 +671,18 @@ func (c client) TestVoid(
 
 	args := gauntlet.ThriftTest_TestVoid_Helper.Args()
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(gauntlet.ThriftTest_TestVoid_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result gauntlet.ThriftTest_TestVoid_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*gauntlet.ThriftTest_TestVoid_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	err = gauntlet.ThriftTest_TestVoid_Helper.UnwrapResponse(&result)
+	err = gauntlet.ThriftTest_TestVoid_Helper.UnwrapResponse(result)
 	return
 }
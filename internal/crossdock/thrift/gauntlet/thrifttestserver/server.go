@@ -25,6 +25,7 @@ package thrifttestserver
 
 import (
 	"context"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/thriftrw/wire"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/crossdock/thrift/gauntlet"
@@ -150,7 +151,7 @@ type Interface interface {
 // 	handler := ThriftTestHandler{}
 // 	dispatcher.Register(thrifttestserver.New(handler))
 func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
-	h := handler{impl}
+	h := handler{impl: impl, interceptors: thrift.ServerInterceptors(opts...)}
 	service := thrift.Service{
 		Name: "ThriftTest",
 		Methods: []thrift.Method{
@@ -372,7 +373,10 @@ func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
 	return procedures
 }
 
-type handler struct{ impl Interface }
+type handler struct {
+	impl         Interface
+	interceptors thrift.Interceptors
+}
 
 func (h handler) TestBinary(ctx context.Context, body wire.Value) (thrift.Response, error) {
 	var args gauntlet.ThriftTest_TestBinary_Args
@@ -380,6 +384,15 @@ func (h handler) TestBinary(ctx context.Context, body wire.Value) (thrift.Respon
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestBinary)
+}
+
+func (h handler) handleTestBinary(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestBinary_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestBinary(ctx, args.Thing)
 
 	hadError := err != nil
@@ -399,6 +412,15 @@ func (h handler) TestByte(ctx context.Context, body wire.Value) (thrift.Response
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestByte)
+}
+
+func (h handler) handleTestByte(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestByte_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestByte(ctx, args.Thing)
 
 	hadError := err != nil
@@ -418,6 +440,15 @@ func (h handler) TestDouble(ctx context.Context, body wire.Value) (thrift.Respon
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestDouble)
+}
+
+func (h handler) handleTestDouble(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestDouble_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestDouble(ctx, args.Thing)
 
 	hadError := err != nil
@@ -437,6 +468,15 @@ func (h handler) TestEnum(ctx context.Context, body wire.Value) (thrift.Response
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestEnum)
+}
+
+func (h handler) handleTestEnum(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestEnum_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestEnum(ctx, args.Thing)
 
 	hadError := err != nil
@@ -456,6 +496,15 @@ func (h handler) TestException(ctx context.Context, body wire.Value) (thrift.Res
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestException)
+}
+
+func (h handler) handleTestException(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestException_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	err := h.impl.TestException(ctx, args.Arg)
 
 	hadError := err != nil
@@ -475,6 +524,15 @@ func (h handler) TestI32(ctx context.Context, body wire.Value) (thrift.Response,
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestI32)
+}
+
+func (h handler) handleTestI32(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestI32_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestI32(ctx, args.Thing)
 
 	hadError := err != nil
@@ -494,6 +552,15 @@ func (h handler) TestI64(ctx context.Context, body wire.Value) (thrift.Response,
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestI64)
+}
+
+func (h handler) handleTestI64(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestI64_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestI64(ctx, args.Thing)
 
 	hadError := err != nil
@@ -513,6 +580,15 @@ func (h handler) TestInsanity(ctx context.Context, body wire.Value) (thrift.Resp
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestInsanity)
+}
+
+func (h handler) handleTestInsanity(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestInsanity_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestInsanity(ctx, args.Argument)
 
 	hadError := err != nil
@@ -532,6 +608,15 @@ func (h handler) TestList(ctx context.Context, body wire.Value) (thrift.Response
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestList)
+}
+
+func (h handler) handleTestList(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestList_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestList(ctx, args.Thing)
 
 	hadError := err != nil
@@ -551,6 +636,15 @@ func (h handler) TestMap(ctx context.Context, body wire.Value) (thrift.Response,
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestMap)
+}
+
+func (h handler) handleTestMap(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestMap_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestMap(ctx, args.Thing)
 
 	hadError := err != nil
@@ -570,6 +664,15 @@ func (h handler) TestMapMap(ctx context.Context, body wire.Value) (thrift.Respon
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestMapMap)
+}
+
+func (h handler) handleTestMapMap(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestMapMap_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestMapMap(ctx, args.Hello)
 
 	hadError := err != nil
@@ -589,6 +692,15 @@ func (h handler) TestMulti(ctx context.Context, body wire.Value) (thrift.Respons
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestMulti)
+}
+
+func (h handler) handleTestMulti(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestMulti_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestMulti(ctx, args.Arg0, args.Arg1, args.Arg2, args.Arg3, args.Arg4, args.Arg5)
 
 	hadError := err != nil
@@ -608,6 +720,15 @@ func (h handler) TestMultiException(ctx context.Context, body wire.Value) (thrif
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestMultiException)
+}
+
+func (h handler) handleTestMultiException(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestMultiException_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestMultiException(ctx, args.Arg0, args.Arg1)
 
 	hadError := err != nil
@@ -627,6 +748,15 @@ func (h handler) TestNest(ctx context.Context, body wire.Value) (thrift.Response
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestNest)
+}
+
+func (h handler) handleTestNest(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestNest_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestNest(ctx, args.Thing)
 
 	hadError := err != nil
@@ -646,6 +776,15 @@ func (h handler) TestOneway(ctx context.Context, body wire.Value) error {
 		return err
 	}
 
+	return h.interceptors.HandleOneway(ctx, &args, h.handleTestOneway)
+}
+
+func (h handler) handleTestOneway(ctx context.Context, body envelope.Enveloper) error {
+	args, ok := body.(*gauntlet.ThriftTest_TestOneway_Args)
+	if !ok {
+		return thrift.CastError(args, body)
+	}
+
 	return h.impl.TestOneway(ctx, args.SecondsToSleep)
 }
 
@@ -655,6 +794,15 @@ func (h handler) TestSet(ctx context.Context, body wire.Value) (thrift.Response,
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestSet)
+}
+
+func (h handler) handleTestSet(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestSet_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestSet(ctx, args.Thing)
 
 	hadError := err != nil
@@ -674,6 +822,15 @@ func (h handler) TestString(ctx context.Context, body wire.Value) (thrift.Respon
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestString)
+}
+
+func (h handler) handleTestString(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestString_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestString(ctx, args.Thing)
 
 	hadError := err != nil
@@ -693,6 +850,15 @@ func (h handler) TestStringMap(ctx context.Context, body wire.Value) (thrift.Res
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestStringMap)
+}
+
+func (h handler) handleTestStringMap(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestStringMap_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestStringMap(ctx, args.Thing)
 
 	hadError := err != nil
@@ -712,6 +878,15 @@ func (h handler) TestStruct(ctx context.Context, body wire.Value) (thrift.Respon
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestStruct)
+}
+
+func (h handler) handleTestStruct(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestStruct_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestStruct(ctx, args.Thing)
 
 	hadError := err != nil
@@ -731,6 +906,15 @@ func (h handler) TestTypedef(ctx context.Context, body wire.Value) (thrift.Respo
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestTypedef)
+}
+
+func (h handler) handleTestTypedef(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestTypedef_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.TestTypedef(ctx, args.Thing)
 
 	hadError := err != nil
@@ -750,6 +934,15 @@ func (h handler) TestVoid(ctx context.Context, body wire.Value) (thrift.Response
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleTestVoid)
+}
+
+func (h handler) handleTestVoid(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*gauntlet.ThriftTest_TestVoid_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	err := h.impl.TestVoid(ctx)
 
 	hadError := err != nil
@@ -50,6 +50,7 @@ func New(c transport.ClientConfig, opts ...thrift.ClientOption) Interface {
 			Service:      "Oneway",
 			ClientConfig: c,
 		}, opts...),
+		interceptors: thrift.ClientInterceptors("Oneway", opts...),
 	}
 }
 
@@ -62,7 +63,8 @@ func init() {
 }
 
 type client struct {
-	c thrift.Client
+	c            thrift.Client
+	interceptors thrift.Interceptors
 }
 
 func (c client) Echo(
@@ -71,5 +73,5 @@ func (c client) Echo(
 	opts ...yarpc.CallOption,
 ) (yarpc.Ack, error) {
 	args := oneway.Oneway_Echo_Helper.Args(_Token)
-	return c.c.CallOneway(ctx, args, opts...)
+	return c.interceptors.CallOneway(ctx, c.c, args, opts...)
 }
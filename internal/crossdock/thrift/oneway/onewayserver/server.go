@@ -25,6 +25,7 @@ package onewayserver
 
 import (
 	"context"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/thriftrw/wire"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/crossdock/thrift/oneway"
@@ -45,7 +46,7 @@ type Interface interface {
 // 	handler := OnewayHandler{}
 // 	dispatcher.Register(onewayserver.New(handler))
 func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
-	h := handler{impl}
+	h := handler{impl: impl, interceptors: thrift.ServerInterceptors(opts...)}
 	service := thrift.Service{
 		Name: "Oneway",
 		Methods: []thrift.Method{
@@ -67,7 +68,10 @@ func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
 	return procedures
 }
 
-type handler struct{ impl Interface }
+type handler struct {
+	impl         Interface
+	interceptors thrift.Interceptors
+}
 
 func (h handler) Echo(ctx context.Context, body wire.Value) error {
 	var args oneway.Oneway_Echo_Args
@@ -75,5 +79,14 @@ func (h handler) Echo(ctx context.Context, body wire.Value) error {
 		return err
 	}
 
+	return h.interceptors.HandleOneway(ctx, &args, h.handleEcho)
+}
+
+func (h handler) handleEcho(ctx context.Context, body envelope.Enveloper) error {
+	args, ok := body.(*oneway.Oneway_Echo_Args)
+	if !ok {
+		return thrift.CastError(args, body)
+	}
+
 	return h.impl.Echo(ctx, args.Token)
 }
@@ -0,0 +1,80 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package errors
+
+import "fmt"
+
+// BodyTooLargeError is a failure because the body of a request or response
+// was larger than the limit configured for it.
+type BodyTooLargeError struct {
+	// Kind of body: "request" or "response".
+	Kind string
+
+	Service   string
+	Procedure string
+
+	// Maximum size of the body in bytes.
+	Limit int64
+}
+
+func (e BodyTooLargeError) Error() string {
+	return fmt.Sprintf("%v body for procedure %q of service %q exceeds the limit of %d bytes",
+		e.Kind, e.Procedure, e.Service, e.Limit)
+}
+
+// AsHandlerError reports request bodies that are too large as a bad request.
+func (e BodyTooLargeError) AsHandlerError() HandlerError {
+	return HandlerBadRequestError(e)
+}
+
+type remoteBodyTooLargeError string
+
+var _ BadRequestError = remoteBodyTooLargeError("")
+
+// RemoteBodyTooLargeError builds a new BadRequestError with the given
+// message.
+//
+// It represents a failure from a remote service which rejected a request
+// because its body was larger than the limit configured for it.
+func RemoteBodyTooLargeError(message string) BadRequestError {
+	return remoteBodyTooLargeError(message)
+}
+
+func (remoteBodyTooLargeError) badRequestError() {}
+
+func (e remoteBodyTooLargeError) Error() string {
+	return string(e)
+}
+
+// IsBodyTooLargeError returns true if the given error is a
+// BodyTooLargeError, a BadRequestError caused by one, or a remote failure
+// because a request body was too large.
+func IsBodyTooLargeError(err error) bool {
+	switch e := err.(type) {
+	case handlerBadRequestError:
+		_, ok := e.Reason.(BodyTooLargeError)
+		return ok
+	case BodyTooLargeError, remoteBodyTooLargeError:
+		return true
+	default:
+		return false
+	}
+}
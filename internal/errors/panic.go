@@ -0,0 +1,55 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package errors
+
+import "fmt"
+
+// handlerPanicError represents a panic recovered from a handler.
+type handlerPanicError struct {
+	Value interface{}
+}
+
+// HandlerPanicError constructs the error reported to the caller when the
+// handler of a request panics with the given value.
+//
+// Transports report it to the caller as an UnexpectedError.
+func HandlerPanicError(value interface{}) error {
+	return handlerPanicError{Value: value}
+}
+
+// IsHandlerPanicError returns true if the given error was constructed by
+// HandlerPanicError.
+func IsHandlerPanicError(err error) bool {
+	_, ok := err.(handlerPanicError)
+	return ok
+}
+
+func (e handlerPanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// UnrecoverablePanic wraps the value of a panic which was already handled
+// and must be propagated instead of being recovered again. Code which
+// recovers panics from handlers must panic with Value when it recovers an
+// UnrecoverablePanic.
+type UnrecoverablePanic struct {
+	Value interface{}
+}
@@ -41,8 +41,8 @@ type KeyValueYarpcClient interface {
 }
 
 // NewKeyValueYarpcClient builds a new yarpc client for the KeyValue service.
-func NewKeyValueYarpcClient(clientConfig transport.ClientConfig) KeyValueYarpcClient {
-	return &_KeyValueYarpcCaller{protobuf.NewClient("uber.yarpc.internal.examples.protobuf.example.KeyValue", clientConfig)}
+func NewKeyValueYarpcClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) KeyValueYarpcClient {
+	return &_KeyValueYarpcCaller{protobuf.NewClient("uber.yarpc.internal.examples.protobuf.example.KeyValue", clientConfig, options...)}
 }
 
 // KeyValueYarpcServer is the yarpc server-side interface for the KeyValue service.
@@ -52,13 +52,13 @@ type KeyValueYarpcServer interface {
 }
 
 // BuildKeyValueYarpcProcedures prepares an implementation of the KeyValue service for yarpc registration.
-func BuildKeyValueYarpcProcedures(server KeyValueYarpcServer) []transport.Procedure {
+func BuildKeyValueYarpcProcedures(server KeyValueYarpcServer, options ...protobuf.RegisterOption) []transport.Procedure {
 	handler := &_KeyValueYarpcHandler{server}
 	return protobuf.BuildProcedures(
 		"uber.yarpc.internal.examples.protobuf.example.KeyValue",
 		map[string]transport.UnaryHandler{
-			"GetValue": protobuf.NewUnaryHandler(handler.GetValue, newKeyValue_GetValueYarpcRequest),
-			"SetValue": protobuf.NewUnaryHandler(handler.SetValue, newKeyValue_SetValueYarpcRequest),
+			"GetValue": protobuf.NewUnaryHandler(handler.GetValue, newKeyValue_GetValueYarpcRequest, options...),
+			"SetValue": protobuf.NewUnaryHandler(handler.SetValue, newKeyValue_SetValueYarpcRequest, options...),
 		},
 		map[string]transport.OnewayHandler{},
 	)
@@ -157,8 +157,8 @@ type SinkYarpcClient interface {
 }
 
 // NewSinkYarpcClient builds a new yarpc client for the Sink service.
-func NewSinkYarpcClient(clientConfig transport.ClientConfig) SinkYarpcClient {
-	return &_SinkYarpcCaller{protobuf.NewClient("uber.yarpc.internal.examples.protobuf.example.Sink", clientConfig)}
+func NewSinkYarpcClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) SinkYarpcClient {
+	return &_SinkYarpcCaller{protobuf.NewClient("uber.yarpc.internal.examples.protobuf.example.Sink", clientConfig, options...)}
 }
 
 // SinkYarpcServer is the yarpc server-side interface for the Sink service.
@@ -167,13 +167,13 @@ type SinkYarpcServer interface {
 }
 
 // BuildSinkYarpcProcedures prepares an implementation of the Sink service for yarpc registration.
-func BuildSinkYarpcProcedures(server SinkYarpcServer) []transport.Procedure {
+func BuildSinkYarpcProcedures(server SinkYarpcServer, options ...protobuf.RegisterOption) []transport.Procedure {
 	handler := &_SinkYarpcHandler{server}
 	return protobuf.BuildProcedures(
 		"uber.yarpc.internal.examples.protobuf.example.Sink",
 		map[string]transport.UnaryHandler{},
 		map[string]transport.OnewayHandler{
-			"Fire": protobuf.NewOnewayHandler(handler.Fire, newSink_FireYarpcRequest),
+			"Fire": protobuf.NewOnewayHandler(handler.Fire, newSink_FireYarpcRequest, options...),
 		},
 	)
 }
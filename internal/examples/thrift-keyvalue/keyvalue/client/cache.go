@@ -31,6 +31,9 @@ import (
 )
 
 // CacheOutboundMiddleware is a OutboundMiddleware
+//
+// This cache is unbounded and caches the responses of all procedures. See
+// go.uber.org/yarpc/x/cache for middleware suitable for production use.
 type CacheOutboundMiddleware interface {
 	middleware.UnaryOutbound
 
@@ -26,7 +26,7 @@ package keyvalueclient
 import (
 	"context"
 	"reflect"
-	"go.uber.org/thriftrw/wire"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/examples/thrift-keyvalue/keyvalue/kv"
 	"go.uber.org/yarpc/encoding/thrift"
@@ -58,6 +58,7 @@ func New(c transport.ClientConfig, opts ...thrift.ClientOption) Interface {
 			Service:      "KeyValue",
 			ClientConfig: c,
 		}, opts...),
+		interceptors: thrift.ClientInterceptors("KeyValue", opts...),
 	}
 }
 
@@ -70,7 +71,8 @@ func init() {
 }
 
 type client struct {
-	c thrift.Client
+	c            thrift.Client
+	interceptors thrift.Interceptors
 }
 
 func (c client) GetValue(
@@ -81,18 +83,19 @@ func (c client) GetValue(
 
 	args := kv.KeyValue_GetValue_Helper.Args(_Key)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(kv.KeyValue_GetValue_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result kv.KeyValue_GetValue_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*kv.KeyValue_GetValue_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	success, err = kv.KeyValue_GetValue_Helper.UnwrapResponse(&result)
+	success, err = kv.KeyValue_GetValue_Helper.UnwrapResponse(result)
 	return
 }
 
@@ -105,17 +108,18 @@ func (c client) SetValue(
 
 	args := kv.KeyValue_SetValue_Helper.Args(_Key, _Value)
 
-	var body wire.Value
-	body, err = c.c.Call(ctx, args, opts...)
+	var body envelope.Enveloper
+	body, err = c.interceptors.CallUnary(ctx, c.c, args, func() thrift.Result { return new(kv.KeyValue_SetValue_Result) }, opts...)
 	if err != nil {
 		return
 	}
 
-	var result kv.KeyValue_SetValue_Result
-	if err = result.FromWire(body); err != nil {
+	result, ok := body.(*kv.KeyValue_SetValue_Result)
+	if !ok {
+		err = thrift.CastError(result, body)
 		return
 	}
 
-	err = kv.KeyValue_SetValue_Helper.UnwrapResponse(&result)
+	err = kv.KeyValue_SetValue_Helper.UnwrapResponse(result)
 	return
 }
@@ -25,6 +25,7 @@ package keyvalueserver
 
 import (
 	"context"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/thriftrw/wire"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/examples/thrift-keyvalue/keyvalue/kv"
@@ -51,7 +52,7 @@ type Interface interface {
 // 	handler := KeyValueHandler{}
 // 	dispatcher.Register(keyvalueserver.New(handler))
 func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
-	h := handler{impl}
+	h := handler{impl: impl, interceptors: thrift.ServerInterceptors(opts...)}
 	service := thrift.Service{
 		Name: "KeyValue",
 		Methods: []thrift.Method{
@@ -83,7 +84,10 @@ func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
 	return procedures
 }
 
-type handler struct{ impl Interface }
+type handler struct {
+	impl         Interface
+	interceptors thrift.Interceptors
+}
 
 func (h handler) GetValue(ctx context.Context, body wire.Value) (thrift.Response, error) {
 	var args kv.KeyValue_GetValue_Args
@@ -91,6 +95,15 @@ func (h handler) GetValue(ctx context.Context, body wire.Value) (thrift.Response
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleGetValue)
+}
+
+func (h handler) handleGetValue(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*kv.KeyValue_GetValue_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	success, err := h.impl.GetValue(ctx, args.Key)
 
 	hadError := err != nil
@@ -110,6 +123,15 @@ func (h handler) SetValue(ctx context.Context, body wire.Value) (thrift.Response
 		return thrift.Response{}, err
 	}
 
+	return h.interceptors.HandleUnary(ctx, &args, h.handleSetValue)
+}
+
+func (h handler) handleSetValue(ctx context.Context, body envelope.Enveloper) (thrift.Response, error) {
+	args, ok := body.(*kv.KeyValue_SetValue_Args)
+	if !ok {
+		return thrift.Response{}, thrift.CastError(args, body)
+	}
+
 	err := h.impl.SetValue(ctx, args.Key, args.Value)
 
 	hadError := err != nil
@@ -50,6 +50,7 @@ func New(c transport.ClientConfig, opts ...thrift.ClientOption) Interface {
 			Service:      "Hello",
 			ClientConfig: c,
 		}, opts...),
+		interceptors: thrift.ClientInterceptors("Hello", opts...),
 	}
 }
 
@@ -62,7 +63,8 @@ func init() {
 }
 
 type client struct {
-	c thrift.Client
+	c            thrift.Client
+	interceptors thrift.Interceptors
 }
 
 func (c client) Sink(
@@ -71,5 +73,5 @@ func (c client) Sink(
 	opts ...yarpc.CallOption,
 ) (yarpc.Ack, error) {
 	args := sink.Hello_Sink_Helper.Args(_Snk)
-	return c.c.CallOneway(ctx, args, opts...)
+	return c.interceptors.CallOneway(ctx, c.c, args, opts...)
 }
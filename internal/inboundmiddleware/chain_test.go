@@ -66,6 +66,7 @@ func TestUnaryChain(t *testing.T) {
 	}{
 		{"flat chain", UnaryChain(before, retryUnaryInbound, after)},
 		{"nested chain", UnaryChain(before, UnaryChain(retryUnaryInbound, after))},
+		{"nil middleware", UnaryChain(before, nil, retryUnaryInbound, nil, after)},
 	}
 
 	for _, tt := range tests {
@@ -116,6 +117,7 @@ func TestOnewayChain(t *testing.T) {
 	}{
 		{"flat chain", OnewayChain(before, retryOnewayInbound, after)},
 		{"nested chain", OnewayChain(before, OnewayChain(retryOnewayInbound, after))},
+		{"nil middleware", OnewayChain(before, nil, retryOnewayInbound, nil, after)},
 	}
 
 	for _, tt := range tests {
@@ -26,4 +26,8 @@
 // render-time. Variable names can also be in the form "${foo:bar}" where
 // everything after the ":" is the default value for that variable if the
 // VariableResolver did not have a value for that variable.
+//
+// Variables in the form "${source:key}" may instead be resolved by looking up
+// the key in a named Source, if "source" is a known one. This is used to read
+// values from places other than the VariableResolver, like files.
 package interpolate
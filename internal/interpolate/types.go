@@ -24,6 +24,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strings"
 )
 
 // We represent the user-defined string as a series of terms. Each term is
@@ -53,6 +54,14 @@ func (variable) term() {}
 // fail.
 type VariableResolver func(name string) (value string, ok bool)
 
+// Source looks up the value of a key in a named source of values.
+//
+// Variables in the form "${source:key}" whose name is a known source are
+// resolved by looking up the key in that source instead of treating it as a
+// default. For example, if "file" is the name of a Source that reads files,
+// "${file:/etc/secret}" is replaced by the contents of /etc/secret.
+type Source func(key string) (value string, err error)
+
 // String is a string that supports interpolation given some source of
 // variable values.
 //
@@ -63,8 +72,14 @@ type String []term
 // be used to determine values for the different variables mentioned in the
 // string.
 func (s String) Render(resolve VariableResolver) (string, error) {
+	return s.RenderWithSources(resolve, nil)
+}
+
+// RenderWithSources renders and returns the string, resolving variables that
+// reference one of the given sources with that Source.
+func (s String) RenderWithSources(resolve VariableResolver, sources map[string]Source) (string, error) {
 	var buff bytes.Buffer
-	if err := s.RenderTo(&buff, resolve); err != nil {
+	if err := s.render(&buff, resolve, sources); err != nil {
 		return "", err
 	}
 	return buff.String(), nil
@@ -73,30 +88,63 @@ func (s String) Render(resolve VariableResolver) (string, error) {
 // RenderTo renders the string into the given writer. The provided
 // VariableResolver will be used to determine values for the different
 // variables mentioned in the string.
+//
+// If some variables have neither a value nor a default, an
+// UnresolvedVariablesError listing all of them is returned.
 func (s String) RenderTo(w io.Writer, resolve VariableResolver) error {
+	return s.render(w, resolve, nil)
+}
+
+func (s String) render(w io.Writer, resolve VariableResolver, sources map[string]Source) error {
+	var unresolved []string
 	for _, term := range s {
 		var value string
 		switch t := term.(type) {
 		case literal:
 			value = string(t)
 		case variable:
-			if val, ok := resolve(t.Name); ok {
+			if source, ok := sources[t.Name]; ok && t.HasDefault {
+				val, err := source(t.Default)
+				if err != nil {
+					return fmt.Errorf("failed to read %q from %q: %v", t.Default, t.Name, err)
+				}
+				value = val
+			} else if val, ok := resolve(t.Name); ok {
 				value = val
 			} else if t.HasDefault {
 				value = t.Default
 			} else {
-				return errUnknownVariable{Name: t.Name}
+				unresolved = append(unresolved, t.Name)
+				continue
 			}
 		}
 		if _, err := io.WriteString(w, value); err != nil {
 			return err
 		}
 	}
+
+	if len(unresolved) > 0 {
+		return UnresolvedVariablesError{Names: unresolved}
+	}
 	return nil
 }
 
-type errUnknownVariable struct{ Name string }
+// UnresolvedVariablesError is returned when rendering a string that
+// references variables which have neither a value nor a default.
+type UnresolvedVariablesError struct {
+	// Names of the unresolved variables, in the order in which they appear
+	// in the string.
+	Names []string
+}
 
-func (e errUnknownVariable) Error() string {
-	return fmt.Sprintf("variable %q does not have a value or a default", e.Name)
+func (e UnresolvedVariablesError) Error() string {
+	if len(e.Names) == 1 {
+		return fmt.Sprintf("variable %q does not have a value or a default", e.Names[0])
+	}
+
+	quoted := make([]string, len(e.Names))
+	for i, name := range e.Names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return fmt.Sprintf("variables %v do not have values or defaults", strings.Join(quoted, ", "))
 }
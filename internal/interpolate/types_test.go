@@ -21,6 +21,7 @@
 package interpolate
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -48,10 +49,41 @@ func TestRender(t *testing.T) {
 			vars:    map[string]string{"foo": "bar"},
 			wantErr: `variable "baz" does not have a value or a default`,
 		},
+		{
+			give:    String{variable{Name: "foo"}, literal(":"), variable{Name: "bar"}, variable{Name: "baz"}},
+			vars:    map[string]string{"bar": "qux"},
+			wantErr: `variables "foo", "baz" do not have values or defaults`,
+		},
+		{
+			give: String{literal("secret: "), variable{Name: "file", Default: "/etc/secret", HasDefault: true}},
+			want: "secret: contents of /etc/secret",
+		},
+		{
+			give:    String{variable{Name: "file", Default: "/etc/missing", HasDefault: true}},
+			wantErr: `failed to read "/etc/missing" from "file": great sadness`,
+		},
+		{
+			give: String{variable{Name: "file"}},
+			vars: map[string]string{"file": "not a source reference"},
+			want: "not a source reference",
+		},
+		{
+			give: String{variable{Name: "other", Default: "default", HasDefault: true}},
+			want: "default",
+		},
+	}
+
+	sources := map[string]Source{
+		"file": func(key string) (string, error) {
+			if key == "/etc/missing" {
+				return "", errors.New("great sadness")
+			}
+			return "contents of " + key, nil
+		},
 	}
 
 	for _, tt := range tests {
-		got, err := tt.give.Render(mapResolver(tt.vars))
+		got, err := tt.give.RenderWithSources(mapResolver(tt.vars), sources)
 		if tt.wantErr != "" {
 			if assert.Error(t, err) {
 				assert.Contains(t, err.Error(), tt.wantErr)
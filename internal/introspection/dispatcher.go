@@ -26,7 +26,9 @@ type DispatcherStatus struct {
 	Name            string           `json:"name"`
 	ID              string           `json:"id"`
 	Procedures      []Procedure      `json:"procedures"`
+	Services        []ServiceStatus  `json:"services"`
 	Inbounds        []InboundStatus  `json:"inbounds"`
 	Outbounds       []OutboundStatus `json:"outbounds"`
 	PackageVersions []PackageVersion `json:"packageVersions"`
+	Panics          int64            `json:"panics"`
 }
@@ -30,4 +30,7 @@ type InboundStatus struct {
 	Transport string `json:"transport"`
 	Endpoint  string `json:"endpoint"`
 	State     string `json:"state"`
+
+	// Number of requests rejected because their bodies were too large.
+	OversizedRequests int64 `json:"oversizedRequests,omitempty"`
 }
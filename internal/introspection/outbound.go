@@ -34,6 +34,18 @@ type OutboundStatus struct {
 	Chooser     ChooserStatus `json:"chooser"`
 	Service     string        `json:"service"`
 	OutboundKey string        `json:"outboundkey"`
+	Routes      []RouteStatus `json:"routes,omitempty"`
+
+	// Number of responses rejected because their bodies were too large.
+	OversizedResponses int64 `json:"oversizedResponses,omitempty"`
+}
+
+// RouteStatus describes one of the outbounds to which an outbound that
+// routes requests may send them.
+type RouteStatus struct {
+	Match    string         `json:"match"`
+	Outbound string         `json:"outbound"`
+	Status   OutboundStatus `json:"status"`
 }
 
 // OutboundStatusNotSupported is returned when not valid OutboundStatus can be
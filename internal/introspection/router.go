@@ -26,6 +26,7 @@ import (
 
 // Procedure represent a registered procedure on a dispatcher.
 type Procedure struct {
+	Service   string `json:"service"`
 	Name      string `json:"name"`
 	Encoding  string `json:"encoding"`
 	Signature string `json:"signature"`
@@ -39,6 +40,7 @@ func IntrospectProcedures(routerProcs []transport.Procedure) []Procedure {
 	procedures := make([]Procedure, 0, len(routerProcs))
 	for _, p := range routerProcs {
 		procedures = append(procedures, Procedure{
+			Service:   p.Service,
 			Name:      p.Name,
 			Encoding:  string(p.Encoding),
 			Signature: p.Signature,
@@ -47,3 +49,26 @@ func IntrospectProcedures(routerProcs []transport.Procedure) []Procedure {
 	}
 	return procedures
 }
+
+// ServiceStatus lists the procedures registered for a service.
+type ServiceStatus struct {
+	Name       string      `json:"name"`
+	Procedures []Procedure `json:"procedures"`
+}
+
+// IntrospectServices groups the given procedures by service, preserving
+// their order.
+func IntrospectServices(procedures []Procedure) []ServiceStatus {
+	var services []ServiceStatus
+	index := make(map[string]int)
+	for _, p := range procedures {
+		i, ok := index[p.Service]
+		if !ok {
+			i = len(services)
+			index[p.Service] = i
+			services = append(services, ServiceStatus{Name: p.Service})
+		}
+		services[i].Procedures = append(services[i].Procedures, p)
+	}
+	return services
+}
@@ -26,6 +26,8 @@ import (
 	"net/http"
 	"sync"
 
+	"go.uber.org/yarpc/peer/unixsocket"
+
 	"go.uber.org/atomic"
 )
 
@@ -64,7 +66,8 @@ func (h *HTTPServer) Listener() net.Listener {
 
 // ListenAndServe starts the given HTTP server up in the background and
 // returns immediately. The server listens on the configured Addr or ":http"
-// if unconfigured.
+// if unconfigured. Addresses of the form "unix:///path" listen on a Unix
+// domain socket at the given path.
 //
 // An error is returned if the server failed to start up, if the server was
 // already listening, or if the server was stopped with Stop().
@@ -85,8 +88,13 @@ func (h *HTTPServer) ListenAndServe() error {
 		return errAlreadyListening
 	}
 
+	network := "tcp"
+	if path, ok := unixsocket.ParseAddress(addr); ok {
+		network, addr = "unix", path
+	}
+
 	var err error
-	h.listener, err = net.Listen("tcp", addr)
+	h.listener, err = net.Listen(network, addr)
 	if err != nil {
 		return err
 	}
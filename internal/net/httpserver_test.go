@@ -21,9 +21,11 @@
 package net
 
 import (
+	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
 	"time"
@@ -48,6 +50,25 @@ func TestStartAndStop(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestStartAndStopUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpc-httpserver")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "server.sock")
+
+	server := NewHTTPServer(&http.Server{Addr: "unix://" + path})
+	require.NoError(t, server.ListenAndServe())
+	assert.Equal(t, "unix", server.Listener().Addr().Network())
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	require.NoError(t, server.Stop())
+	_, err = net.Dial("unix", path)
+	require.Error(t, err)
+}
+
 func TestStartAddrInUse(t *testing.T) {
 	s1 := NewHTTPServer(&http.Server{Addr: ":0"})
 	require.NoError(t, s1.ListenAndServe())
@@ -22,6 +22,8 @@ package observerware
 
 import (
 	"context"
+	"io/ioutil"
+	"strings"
 	"time"
 
 	"go.uber.org/yarpc/api/transport"
@@ -68,3 +70,16 @@ func stubTime() func() {
 	_timeNow = func() time.Time { return time.Time{} }
 	return func() { _timeNow = prev }
 }
+
+type bodyOutbound struct {
+	transport.Outbound
+
+	body string
+}
+
+func (o bodyOutbound) Call(_ context.Context, req *transport.Request) (*transport.Response, error) {
+	if _, err := ioutil.ReadAll(req.Body); err != nil {
+		return nil, err
+	}
+	return &transport.Response{Body: ioutil.NopCloser(strings.NewReader(o.body))}, nil
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package observerware
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+
+	"go.uber.org/yarpc/api/transport"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	_defaultMaxPayloadSize = 4096
+	_redacted              = "[redacted]"
+)
+
+// Config customizes the entries written by Middleware. The zero value logs
+// every request at DebugLevel without payloads.
+type Config struct {
+	// Levels at which requests are logged when they succeed, fail with an
+	// application error, or fail with any other error. Each defaults to
+	// DebugLevel.
+	SuccessLevel          *zapcore.Level
+	ApplicationErrorLevel *zapcore.Level
+	FailureLevel          *zapcore.Level
+
+	// Sample reports whether a request to the given procedure should be
+	// logged. All requests are logged if this is nil.
+	Sample func(procedure string) bool
+
+	// Payloads enables logging of the raw request and response bodies and
+	// headers. Bodies are not decoded.
+	Payloads bool
+
+	// MaxPayloadSize is the maximum number of bytes of each body that are
+	// logged. Defaults to 4096.
+	MaxPayloadSize int
+
+	// RedactedHeaders are the names of headers whose values are not logged.
+	// Names are case-insensitive.
+	RedactedHeaders []string
+}
+
+// NewSampler builds a Config.Sample function which logs the given fraction
+// of requests. Rates for individual procedures take precedence over the
+// default rate.
+func NewSampler(rate float64, procedures map[string]float64) func(string) bool {
+	s := &sampler{
+		rand:       rand.New(rand.NewSource(rand.Int63())),
+		rate:       rate,
+		procedures: make(map[string]float64, len(procedures)),
+	}
+	for p, r := range procedures {
+		s.procedures[p] = r
+	}
+	return s.sample
+}
+
+type sampler struct {
+	mu         sync.Mutex
+	rand       *rand.Rand
+	rate       float64
+	procedures map[string]float64
+}
+
+func (s *sampler) sample(procedure string) bool {
+	rate, ok := s.procedures[procedure]
+	if !ok {
+		rate = s.rate
+	}
+	switch {
+	case rate >= 1:
+		return true
+	case rate <= 0:
+		return false
+	}
+	s.mu.Lock()
+	f := s.rand.Float64()
+	s.mu.Unlock()
+	return f < rate
+}
+
+func levelOrDefault(l *zapcore.Level) zapcore.Level {
+	if l == nil {
+		return zapcore.DebugLevel
+	}
+	return *l
+}
+
+// headers logs transport headers with redacted values.
+type headers struct {
+	headers  transport.Headers
+	redacted map[string]struct{}
+}
+
+func (h headers) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for k, v := range h.headers.Items() {
+		if _, ok := h.redacted[strings.ToLower(k)]; ok {
+			v = _redacted
+		}
+		enc.AddString(k, v)
+	}
+	return nil
+}
+
+// payloadBuffer records up to max bytes written to it while accepting and
+// discarding the rest.
+type payloadBuffer struct {
+	max       int
+	buf       []byte
+	truncated bool
+}
+
+func (b *payloadBuffer) Write(p []byte) (int, error) {
+	if room := b.max - len(b.buf); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf = append(b.buf, p[:room]...)
+		}
+		return len(p), nil
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// fields logs the recorded bytes under the given key without decoding them.
+// JSON payloads are logged as text; everything else is logged as binary.
+func (b *payloadBuffer) fields(key string, enc transport.Encoding) []zapcore.Field {
+	var f zapcore.Field
+	if enc == "json" {
+		f = zap.String(key, string(b.buf))
+	} else {
+		f = zap.Binary(key, b.buf)
+	}
+	if b.truncated {
+		return []zapcore.Field{f, zap.Bool(key+"Truncated", true)}
+	}
+	return []zapcore.Field{f}
+}
@@ -21,12 +21,17 @@
 package observerware
 
 import (
+	"bytes"
 	"context"
+	"io"
+	"io/ioutil"
+	"strings"
 	"time"
 
 	"go.uber.org/yarpc/api/transport"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // For tests.
@@ -36,57 +41,239 @@ var _timeNow = time.Now
 type Middleware struct {
 	logger  *zap.Logger
 	extract ContextExtractor
+
+	successLevel  zapcore.Level
+	appErrorLevel zapcore.Level
+	failureLevel  zapcore.Level
+	sample        func(string) bool
+
+	payloads       bool
+	maxPayloadSize int
+	redacted       map[string]struct{}
 }
 
-// New constructs a Middleware.
+// New constructs a Middleware which logs every request at DebugLevel.
 func New(logger *zap.Logger, extract ContextExtractor) *Middleware {
-	return &Middleware{
-		logger:  logger,
-		extract: extract,
+	return NewWithConfig(logger, extract, Config{})
+}
+
+// NewWithConfig constructs a Middleware with customized levels, sampling and
+// payload logging.
+func NewWithConfig(logger *zap.Logger, extract ContextExtractor, cfg Config) *Middleware {
+	m := &Middleware{
+		logger:         logger,
+		extract:        extract,
+		successLevel:   levelOrDefault(cfg.SuccessLevel),
+		appErrorLevel:  levelOrDefault(cfg.ApplicationErrorLevel),
+		failureLevel:   levelOrDefault(cfg.FailureLevel),
+		sample:         cfg.Sample,
+		payloads:       cfg.Payloads,
+		maxPayloadSize: cfg.MaxPayloadSize,
+		redacted:       make(map[string]struct{}, len(cfg.RedactedHeaders)),
+	}
+	if m.maxPayloadSize <= 0 {
+		m.maxPayloadSize = _defaultMaxPayloadSize
+	}
+	for _, h := range cfg.RedactedHeaders {
+		m.redacted[strings.ToLower(h)] = struct{}{}
 	}
+	return m
 }
 
 // Handle implements middleware.UnaryInbound.
 func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	if !m.sampled(req) {
+		return h.Handle(ctx, req, w)
+	}
 	start := _timeNow()
-	err := h.Handle(ctx, req, w)
-	m.log(ctx, "Handled inbound request.", "unary", req, _timeNow().Sub(start), err)
+	req, reqBody := m.recordRequest(req)
+	rw := &responseWriter{ResponseWriter: w}
+	if m.payloads {
+		rw.body = &payloadBuffer{max: m.maxPayloadSize}
+	}
+	err := h.Handle(ctx, req, rw)
+
+	e := entry{
+		rpcType:     "unary",
+		req:         req,
+		elapsed:     _timeNow().Sub(start),
+		err:         err,
+		appErr:      rw.appErr,
+		reqBody:     reqBody,
+		resHeaders:  rw.headers,
+		resBody:     rw.body,
+		hasResponse: true,
+	}
+	m.log(ctx, "Handled inbound request.", e)
 	return err
 }
 
 // Call implements middleware.UnaryOutbound.
 func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	if !m.sampled(req) {
+		return out.Call(ctx, req)
+	}
 	start := _timeNow()
+	req, reqBody := m.recordRequest(req)
 	res, err := out.Call(ctx, req)
-	m.log(ctx, "Made outbound call.", "unary", req, _timeNow().Sub(start), err)
-	return res, err
+	elapsed := _timeNow().Sub(start)
+
+	e := entry{rpcType: "unary", req: req, elapsed: elapsed, err: err, reqBody: reqBody}
+	if err == nil && res != nil {
+		e.appErr = res.ApplicationError
+		if m.payloads {
+			e.hasResponse = true
+			e.resHeaders = res.Headers
+			// The entry is written before the caller reads the response, so
+			// the body must be buffered to be logged.
+			e.resBody, err = m.bufferResponse(res)
+			e.err = err
+		}
+	}
+	m.log(ctx, "Made outbound call.", e)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
 // HandleOneway implements middleware.OnewayInbound.
 func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	if !m.sampled(req) {
+		return h.HandleOneway(ctx, req)
+	}
 	start := _timeNow()
+	req, reqBody := m.recordRequest(req)
 	err := h.HandleOneway(ctx, req)
-	m.log(ctx, "Handled inbound request.", "oneway", req, _timeNow().Sub(start), err)
+	e := entry{rpcType: "oneway", req: req, elapsed: _timeNow().Sub(start), err: err, reqBody: reqBody}
+	m.log(ctx, "Handled inbound request.", e)
 	return err
 }
 
 // CallOneway implements middleware.OnewayOutbound.
 func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	if !m.sampled(req) {
+		return out.CallOneway(ctx, req)
+	}
 	start := _timeNow()
+	req, reqBody := m.recordRequest(req)
 	ack, err := out.CallOneway(ctx, req)
-	m.log(ctx, "Made outbound call.", "oneway", req, _timeNow().Sub(start), err)
+	e := entry{rpcType: "oneway", req: req, elapsed: _timeNow().Sub(start), err: err, reqBody: reqBody}
+	m.log(ctx, "Made outbound call.", e)
 	return ack, err
 }
 
-func (m *Middleware) log(ctx context.Context, msg, rpcType string, req *transport.Request, elapsed time.Duration, err error) {
-	if ce := m.logger.Check(zap.DebugLevel, msg); ce != nil {
-		ce.Write(
-			zap.String("rpcType", rpcType),
-			zap.Object("request", req),
-			zap.Duration("latency", elapsed),
-			zap.Bool("successful", err == nil),
-			zap.Error(err),
-			m.extract(ctx),
-		)
+func (m *Middleware) sampled(req *transport.Request) bool {
+	return m.sample == nil || m.sample(req.Procedure)
+}
+
+// recordRequest returns a copy of the request whose body records the bytes
+// read from it, if payload logging is enabled.
+func (m *Middleware) recordRequest(req *transport.Request) (*transport.Request, *payloadBuffer) {
+	if !m.payloads || req.Body == nil {
+		return req, nil
+	}
+	buf := &payloadBuffer{max: m.maxPayloadSize}
+	r := *req
+	r.Body = io.TeeReader(req.Body, buf)
+	return &r, buf
+}
+
+func (m *Middleware) bufferResponse(res *transport.Response) (*payloadBuffer, error) {
+	if res.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if cerr := res.Body.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
 	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	buf := &payloadBuffer{max: m.maxPayloadSize}
+	buf.Write(body)
+	return buf, nil
+}
+
+// entry holds everything known about a request once it has finished.
+type entry struct {
+	rpcType string
+	req     *transport.Request
+	elapsed time.Duration
+	err     error
+	appErr  bool
+
+	reqBody     *payloadBuffer
+	hasResponse bool
+	resHeaders  transport.Headers
+	resBody     *payloadBuffer
+}
+
+func (m *Middleware) log(ctx context.Context, msg string, e entry) {
+	level := m.successLevel
+	switch {
+	case e.err != nil:
+		level = m.failureLevel
+	case e.appErr:
+		level = m.appErrorLevel
+	}
+
+	ce := m.logger.Check(level, msg)
+	if ce == nil {
+		return
+	}
+	fields := []zapcore.Field{
+		zap.String("rpcType", e.rpcType),
+		zap.Object("request", e.req),
+		zap.Duration("latency", e.elapsed),
+		zap.Bool("successful", e.err == nil),
+		zap.Error(e.err),
+		m.extract(ctx),
+	}
+	if e.appErr {
+		fields = append(fields, zap.Bool("applicationError", true))
+	}
+	if m.payloads {
+		fields = append(fields, zap.Object("requestHeaders", headers{e.req.Headers, m.redacted}))
+		if e.reqBody != nil {
+			fields = append(fields, e.reqBody.fields("requestBody", e.req.Encoding)...)
+		}
+		if e.hasResponse {
+			fields = append(fields, zap.Object("responseHeaders", headers{e.resHeaders, m.redacted}))
+		}
+		if e.resBody != nil {
+			fields = append(fields, e.resBody.fields("responseBody", e.req.Encoding)...)
+		}
+	}
+	ce.Write(fields...)
+}
+
+// responseWriter records application errors, headers and, optionally, the
+// body written by inbound handlers.
+type responseWriter struct {
+	transport.ResponseWriter
+
+	appErr  bool
+	headers transport.Headers
+	body    *payloadBuffer
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.body != nil {
+		w.body.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *responseWriter) AddHeaders(h transport.Headers) {
+	for k, v := range h.Items() {
+		w.headers = w.headers.With(k, v)
+	}
+	w.ResponseWriter.AddHeaders(h)
+}
+
+func (w *responseWriter) SetApplicationError() {
+	w.appErr = true
+	w.ResponseWriter.SetApplicationError()
 }
@@ -21,8 +21,10 @@
 package observerware
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io/ioutil"
 	"strings"
 	"testing"
 	"time"
@@ -155,3 +157,120 @@ func TestUnaryInboundMiddleware(t *testing.T) {
 		})
 	}
 }
+
+type appErrorHandler struct{}
+
+func (appErrorHandler) Handle(_ context.Context, req *transport.Request, w transport.ResponseWriter) error {
+	if _, err := ioutil.ReadAll(req.Body); err != nil {
+		return err
+	}
+	w.AddHeaders(transport.NewHeaders().With("token", "secret").With("foo", "bar"))
+	w.SetApplicationError()
+	_, err := w.Write([]byte(`{"error":"not found"}`))
+	return err
+}
+
+type fakeResponseWriter struct {
+	bytes.Buffer
+	appErr bool
+}
+
+func (w *fakeResponseWriter) AddHeaders(transport.Headers) {}
+func (w *fakeResponseWriter) SetApplicationError()         { w.appErr = true }
+
+func TestMiddlewareLevels(t *testing.T) {
+	warn, errLevel := zapcore.WarnLevel, zapcore.ErrorLevel
+	cfg := Config{ApplicationErrorLevel: &warn, FailureLevel: &errLevel}
+
+	tests := []struct {
+		desc    string
+		handler transport.UnaryHandler
+		want    zapcore.Level
+	}{
+		{"success", fakeHandler{}, zapcore.DebugLevel},
+		{"application error", appErrorHandler{}, zapcore.WarnLevel},
+		{"failure", fakeHandler{errors.New("fail")}, zapcore.ErrorLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			mw := NewWithConfig(zap.New(core), NewNopContextExtractor(), cfg)
+			req := &transport.Request{Procedure: "procedure", Body: strings.NewReader("body")}
+			mw.Handle(context.Background(), req, &fakeResponseWriter{}, tt.handler)
+
+			entries := logs.TakeAll()
+			require.Equal(t, 1, len(entries), "Unexpected number of log entries.")
+			assert.Equal(t, tt.want, entries[0].Level, "Unexpected log level.")
+		})
+	}
+}
+
+func TestMiddlewarePayloads(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	mw := NewWithConfig(zap.New(core), NewNopContextExtractor(), Config{
+		Payloads:        true,
+		MaxPayloadSize:  10,
+		RedactedHeaders: []string{"Token"},
+	})
+	req := &transport.Request{
+		Encoding:  "json",
+		Procedure: "procedure",
+		Headers:   transport.NewHeaders().With("token", "secret"),
+		Body:      strings.NewReader(`{"id":"foo"}`),
+	}
+	w := &fakeResponseWriter{}
+	require.NoError(t, mw.Handle(context.Background(), req, w, appErrorHandler{}))
+	assert.Equal(t, `{"error":"not found"}`, w.String(), "Response body was not passed through.")
+	assert.True(t, w.appErr, "Application error was not passed through.")
+
+	entries := logs.TakeAll()
+	require.Equal(t, 1, len(entries), "Unexpected number of log entries.")
+	fields := entries[0].ContextMap()
+	assert.Equal(t, true, fields["applicationError"])
+	assert.Equal(t, map[string]interface{}{"token": "[redacted]"}, fields["requestHeaders"])
+	assert.Equal(t, `{"id":"foo`, fields["requestBody"])
+	assert.Equal(t, true, fields["requestBodyTruncated"])
+	assert.Equal(t, map[string]interface{}{"token": "[redacted]", "foo": "bar"}, fields["responseHeaders"])
+	assert.Equal(t, `{"error":"`, fields["responseBody"])
+}
+
+func TestMiddlewareOutboundPayloads(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	mw := NewWithConfig(zap.New(core), NewNopContextExtractor(), Config{Payloads: true})
+	req := &transport.Request{Encoding: "raw", Procedure: "procedure", Body: strings.NewReader("request")}
+	out := bodyOutbound{body: "response"}
+
+	res, err := mw.Call(context.Background(), req, out)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "response", string(body), "Response body was not passed through.")
+
+	entries := logs.TakeAll()
+	require.Equal(t, 1, len(entries), "Unexpected number of log entries.")
+	fields := entries[0].ContextMap()
+	assert.Equal(t, []byte("request"), fields["requestBody"])
+	assert.Equal(t, []byte("response"), fields["responseBody"])
+}
+
+func TestMiddlewareSampling(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	mw := NewWithConfig(zap.New(core), NewNopContextExtractor(), Config{
+		Sample: NewSampler(0, map[string]float64{"sampled": 1}),
+	})
+
+	for _, procedure := range []string{"sampled", "unsampled"} {
+		req := &transport.Request{Procedure: procedure}
+		mw.Handle(context.Background(), req, nil, fakeHandler{})
+		mw.Call(context.Background(), req, fakeOutbound{})
+		mw.HandleOneway(context.Background(), req, fakeHandler{})
+		mw.CallOneway(context.Background(), req, fakeOutbound{})
+	}
+
+	entries := logs.TakeAll()
+	require.Equal(t, 4, len(entries), "Unexpected number of log entries.")
+	for _, e := range entries {
+		assert.Equal(t, "sampled", e.ContextMap()["request"].(map[string]interface{})["procedure"])
+	}
+}
@@ -68,6 +68,7 @@ func TestUnaryChain(t *testing.T) {
 	}{
 		{"flat chain", UnaryChain(before, retryUnaryOutbound, after)},
 		{"nested chain", UnaryChain(before, UnaryChain(retryUnaryOutbound, after))},
+		{"nil middleware", UnaryChain(before, nil, retryUnaryOutbound, nil, after)},
 	}
 
 	for _, tt := range tests {
@@ -122,6 +123,7 @@ func TestOnewayChain(t *testing.T) {
 	}{
 		{"flat chain", OnewayChain(before, retryOnewayOutbound, after)},
 		{"flat chain", OnewayChain(before, OnewayChain(retryOnewayOutbound, after))},
+		{"nil middleware", OnewayChain(before, nil, retryOnewayOutbound, nil, after)},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,245 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package outboundswap provides outbounds whose underlying outbound may be
+// replaced at runtime without dropping requests that are already in flight.
+package outboundswap
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+)
+
+var (
+	_ transport.UnaryOutbound  = (*UnaryOutbound)(nil)
+	_ transport.OnewayOutbound = (*OnewayOutbound)(nil)
+)
+
+// generation tracks the requests in flight through one underlying outbound.
+type generation struct {
+	calls sync.WaitGroup
+}
+
+// UnaryOutbound is a transport.UnaryOutbound that forwards requests to an
+// underlying outbound which may be replaced with Swap.
+//
+// Requests made after the underlying outbound is removed fail with an error.
+type UnaryOutbound struct {
+	mu  sync.RWMutex
+	o   transport.UnaryOutbound
+	gen *generation
+}
+
+// NewUnaryOutbound builds a new UnaryOutbound which forwards requests to the
+// given outbound.
+func NewUnaryOutbound(o transport.UnaryOutbound) *UnaryOutbound {
+	return &UnaryOutbound{o: o, gen: &generation{}}
+}
+
+// Current returns the outbound to which requests are currently forwarded, or
+// nil if the outbound was removed.
+func (s *UnaryOutbound) Current() transport.UnaryOutbound {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.o
+}
+
+// Swap replaces the underlying outbound with the given outbound. The new
+// outbound may be nil, in which case future requests will fail.
+//
+// Swap returns the previous outbound and a function which blocks until all
+// requests that were sent through the previous outbound have finished.
+func (s *UnaryOutbound) Swap(o transport.UnaryOutbound) (prev transport.UnaryOutbound, wait func()) {
+	s.mu.Lock()
+	prev, gen := s.o, s.gen
+	s.o, s.gen = o, &generation{}
+	s.mu.Unlock()
+	return prev, gen.calls.Wait
+}
+
+func (s *UnaryOutbound) acquire() (transport.UnaryOutbound, *generation) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.o == nil {
+		return nil, nil
+	}
+	s.gen.calls.Add(1)
+	return s.o, s.gen
+}
+
+// Call forwards the request to the current outbound.
+func (s *UnaryOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	o, gen := s.acquire()
+	if o == nil {
+		return nil, removedError{RPCType: "unary", Service: req.Service}
+	}
+	defer gen.calls.Done()
+	return o.Call(ctx, req)
+}
+
+// Transports returns the transports used by the current outbound.
+func (s *UnaryOutbound) Transports() []transport.Transport {
+	if o := s.Current(); o != nil {
+		return o.Transports()
+	}
+	return nil
+}
+
+// Start starts the current outbound.
+func (s *UnaryOutbound) Start() error {
+	if o := s.Current(); o != nil {
+		return o.Start()
+	}
+	return nil
+}
+
+// Stop stops the current outbound.
+func (s *UnaryOutbound) Stop() error {
+	if o := s.Current(); o != nil {
+		return o.Stop()
+	}
+	return nil
+}
+
+// IsRunning returns whether the current outbound is running.
+func (s *UnaryOutbound) IsRunning() bool {
+	if o := s.Current(); o != nil {
+		return o.IsRunning()
+	}
+	return false
+}
+
+// Introspect returns the introspection status of the current outbound.
+func (s *UnaryOutbound) Introspect() introspection.OutboundStatus {
+	if o, ok := s.Current().(introspection.IntrospectableOutbound); ok {
+		return o.Introspect()
+	}
+	return introspection.OutboundStatusNotSupported
+}
+
+// OnewayOutbound is a transport.OnewayOutbound that forwards requests to an
+// underlying outbound which may be replaced with Swap.
+//
+// Requests made after the underlying outbound is removed fail with an error.
+type OnewayOutbound struct {
+	mu  sync.RWMutex
+	o   transport.OnewayOutbound
+	gen *generation
+}
+
+// NewOnewayOutbound builds a new OnewayOutbound which forwards requests to
+// the given outbound.
+func NewOnewayOutbound(o transport.OnewayOutbound) *OnewayOutbound {
+	return &OnewayOutbound{o: o, gen: &generation{}}
+}
+
+// Current returns the outbound to which requests are currently forwarded, or
+// nil if the outbound was removed.
+func (s *OnewayOutbound) Current() transport.OnewayOutbound {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.o
+}
+
+// Swap replaces the underlying outbound with the given outbound. The new
+// outbound may be nil, in which case future requests will fail.
+//
+// Swap returns the previous outbound and a function which blocks until all
+// requests that were sent through the previous outbound have finished.
+func (s *OnewayOutbound) Swap(o transport.OnewayOutbound) (prev transport.OnewayOutbound, wait func()) {
+	s.mu.Lock()
+	prev, gen := s.o, s.gen
+	s.o, s.gen = o, &generation{}
+	s.mu.Unlock()
+	return prev, gen.calls.Wait
+}
+
+func (s *OnewayOutbound) acquire() (transport.OnewayOutbound, *generation) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.o == nil {
+		return nil, nil
+	}
+	s.gen.calls.Add(1)
+	return s.o, s.gen
+}
+
+// CallOneway forwards the request to the current outbound.
+func (s *OnewayOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	o, gen := s.acquire()
+	if o == nil {
+		return nil, removedError{RPCType: "oneway", Service: req.Service}
+	}
+	defer gen.calls.Done()
+	return o.CallOneway(ctx, req)
+}
+
+// Transports returns the transports used by the current outbound.
+func (s *OnewayOutbound) Transports() []transport.Transport {
+	if o := s.Current(); o != nil {
+		return o.Transports()
+	}
+	return nil
+}
+
+// Start starts the current outbound.
+func (s *OnewayOutbound) Start() error {
+	if o := s.Current(); o != nil {
+		return o.Start()
+	}
+	return nil
+}
+
+// Stop stops the current outbound.
+func (s *OnewayOutbound) Stop() error {
+	if o := s.Current(); o != nil {
+		return o.Stop()
+	}
+	return nil
+}
+
+// IsRunning returns whether the current outbound is running.
+func (s *OnewayOutbound) IsRunning() bool {
+	if o := s.Current(); o != nil {
+		return o.IsRunning()
+	}
+	return false
+}
+
+// Introspect returns the introspection status of the current outbound.
+func (s *OnewayOutbound) Introspect() introspection.OutboundStatus {
+	if o, ok := s.Current().(introspection.IntrospectableOutbound); ok {
+		return o.Introspect()
+	}
+	return introspection.OutboundStatusNotSupported
+}
+
+type removedError struct {
+	RPCType string
+	Service string
+}
+
+func (e removedError) Error() string {
+	return fmt.Sprintf("%s outbound for service %q has been removed", e.RPCType, e.Service)
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package outboundswap
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnaryOutboundSwap(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	first := transporttest.NewMockUnaryOutbound(mockCtrl)
+	second := transporttest.NewMockUnaryOutbound(mockCtrl)
+	o := NewUnaryOutbound(first)
+	ctx := context.Background()
+	req := &transport.Request{Service: "foo", Procedure: "bar", Body: &bytes.Buffer{}}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	first.EXPECT().Call(ctx, req).Do(func(context.Context, *transport.Request) {
+		close(started)
+		<-release
+	}).Return(&transport.Response{}, nil)
+
+	done := make(chan error)
+	go func() {
+		_, err := o.Call(ctx, req)
+		done <- err
+	}()
+	<-started
+
+	prev, wait := o.Swap(second)
+	assert.Equal(t, first, prev)
+	assert.Equal(t, second, o.Current())
+
+	drained := make(chan struct{})
+	go func() {
+		wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		t.Fatal("previous outbound drained while a request was in flight")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	second.EXPECT().Call(ctx, req).Return(&transport.Response{}, nil)
+	_, err := o.Call(ctx, req)
+	require.NoError(t, err)
+
+	close(release)
+	require.NoError(t, <-done)
+	<-drained
+}
+
+func TestUnaryOutboundRemoved(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	o := NewUnaryOutbound(out)
+
+	prev, wait := o.Swap(nil)
+	assert.Equal(t, out, prev)
+	wait()
+
+	_, err := o.Call(context.Background(), &transport.Request{Service: "foo"})
+	assert.EqualError(t, err, `unary outbound for service "foo" has been removed`)
+	assert.False(t, o.IsRunning())
+	assert.Empty(t, o.Transports())
+	assert.NoError(t, o.Start())
+	assert.NoError(t, o.Stop())
+}
+
+func TestOnewayOutboundSwap(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	first := transporttest.NewMockOnewayOutbound(mockCtrl)
+	second := transporttest.NewMockOnewayOutbound(mockCtrl)
+	o := NewOnewayOutbound(first)
+	ctx := context.Background()
+	req := &transport.Request{Service: "foo", Procedure: "bar", Body: &bytes.Buffer{}}
+
+	first.EXPECT().CallOneway(ctx, req).Return(nil, nil)
+	_, err := o.CallOneway(ctx, req)
+	require.NoError(t, err)
+
+	prev, wait := o.Swap(second)
+	assert.Equal(t, first, prev)
+	wait()
+
+	second.EXPECT().Start().Return(nil)
+	second.EXPECT().IsRunning().Return(true)
+	second.EXPECT().CallOneway(ctx, req).Return(nil, nil)
+	require.NoError(t, o.Start())
+	assert.True(t, o.IsRunning())
+	_, err = o.CallOneway(ctx, req)
+	require.NoError(t, err)
+
+	o.Swap(nil)
+	_, err = o.CallOneway(ctx, req)
+	assert.EqualError(t, err, `oneway outbound for service "foo" has been removed`)
+}
@@ -46,6 +46,9 @@ func (d *Dispatcher) Introspect() introspection.DispatcherStatus {
 		}
 		inbounds = append(inbounds, status)
 	}
+	d.outboundsMu.RLock()
+	defer d.outboundsMu.RUnlock()
+
 	var outbounds []introspection.OutboundStatus
 	for outboundKey, o := range d.outbounds {
 		if o.Unary != nil {
@@ -78,9 +81,11 @@ func (d *Dispatcher) Introspect() introspection.DispatcherStatus {
 		Name:            d.name,
 		ID:              fmt.Sprintf("%p", d),
 		Procedures:      procedures,
+		Services:        introspection.IntrospectServices(procedures),
 		Inbounds:        inbounds,
 		Outbounds:       outbounds,
 		PackageVersions: PackageVersions,
+		Panics:          d.panics.Count(),
 	}
 }
 
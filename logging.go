@@ -0,0 +1,100 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/internal/observerware"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ContextExtractor extracts request-scoped information, like trace IDs, from
+// a context so that it may be added to the entries logged for each request.
+type ContextExtractor func(context.Context) zapcore.Field
+
+// LoggingConfig customizes how the Dispatcher logs the requests it handles
+// and makes. It has no effect unless a ZapLogger is provided.
+type LoggingConfig struct {
+	// ContextExtractor adds fields from the request context to every
+	// request's log entry.
+	ContextExtractor ContextExtractor
+
+	// Levels at which requests are logged when they succeed, fail with an
+	// application error, or fail with any other error. Each defaults to
+	// DebugLevel.
+	SuccessLevel          *zapcore.Level
+	ApplicationErrorLevel *zapcore.Level
+	FailureLevel          *zapcore.Level
+
+	// Sampling limits the fraction of requests that are logged. All
+	// requests are logged if this is nil.
+	Sampling *LogSampling
+
+	// Payloads enables logging of request and response bodies and headers.
+	// Bodies are logged as they were sent over the wire and are not decoded:
+	// bodies of JSON requests are logged as text and all other bodies, such
+	// as those of Thrift and protobuf requests, as base64-encoded binary. Use
+	// the Intercept options of the encodings to log decoded values.
+	//
+	// Outbound response bodies are buffered in memory so that they may be
+	// logged before they are returned to the caller.
+	Payloads bool
+
+	// MaxPayloadSize is the maximum number of bytes of each body that are
+	// logged. Longer bodies are truncated. Defaults to 4096.
+	MaxPayloadSize int
+
+	// RedactedHeaders are the names of headers whose values must never be
+	// logged. Names are case-insensitive.
+	RedactedHeaders []string
+}
+
+// LogSampling specifies the fraction of requests that are logged, as a
+// number between 0 and 1.
+type LogSampling struct {
+	// Rate applies to all procedures not listed in Procedures.
+	Rate float64
+
+	// Procedures overrides Rate for individual procedures.
+	Procedures map[string]float64
+}
+
+func (c LoggingConfig) observerware() (observerware.ContextExtractor, observerware.Config) {
+	extract := observerware.NewNopContextExtractor()
+	if c.ContextExtractor != nil {
+		extract = observerware.ContextExtractor(c.ContextExtractor)
+	}
+
+	cfg := observerware.Config{
+		SuccessLevel:          c.SuccessLevel,
+		ApplicationErrorLevel: c.ApplicationErrorLevel,
+		FailureLevel:          c.FailureLevel,
+		Payloads:              c.Payloads,
+		MaxPayloadSize:        c.MaxPayloadSize,
+		RedactedHeaders:       c.RedactedHeaders,
+	}
+	if s := c.Sampling; s != nil {
+		cfg.Sample = observerware.NewSampler(s.Rate, s.Procedures)
+	}
+	return extract, cfg
+}
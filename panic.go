@@ -0,0 +1,142 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/errors"
+
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+// PanicConfig configures how a Dispatcher handles panics in the handlers of
+// its procedures.
+//
+// Panics are always logged to the ZapLogger of the Dispatcher with the stack
+// of the panic and counted in the Panics field of its introspection status.
+// Unless Repanic is set, the caller receives an UnexpectedError.
+type PanicConfig struct {
+	// Hook, if non-nil, is called with every panic recovered from a handler
+	// after it has been logged, for example to report it to a crash
+	// reporting service.
+	Hook func(context.Context, Panic)
+
+	// Repanic propagates panics after they have been logged and passed to
+	// Hook instead of returning an error to the caller. Whether this crashes
+	// the process depends on the transport; the HTTP server, for example,
+	// recovers panics in its handlers.
+	Repanic bool
+}
+
+// Panic describes a panic recovered from a handler.
+type Panic struct {
+	// Request is the request which was being handled. Its body may have
+	// been partially or fully consumed.
+	Request *transport.Request
+
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine at the time of the panic.
+	Stack []byte
+}
+
+// panicHandler applies the PanicConfig of a Dispatcher to its handlers.
+type panicHandler struct {
+	cfg   PanicConfig
+	log   *zap.Logger
+	count atomic.Int64
+}
+
+func newPanicHandler(cfg PanicConfig, log *zap.Logger) *panicHandler {
+	return &panicHandler{cfg: cfg, log: log}
+}
+
+// Unary wraps the given handler to recover its panics.
+func (p *panicHandler) Unary(h transport.UnaryHandler) transport.UnaryHandler {
+	return panicUnaryHandler{h: h, p: p}
+}
+
+// Oneway wraps the given handler to recover its panics.
+func (p *panicHandler) Oneway(h transport.OnewayHandler) transport.OnewayHandler {
+	return panicOnewayHandler{h: h, p: p}
+}
+
+// Count returns the number of panics recovered so far.
+func (p *panicHandler) Count() int64 {
+	return p.count.Load()
+}
+
+// recovered handles a panic with the given value, returning the error that
+// the handler should fail with.
+func (p *panicHandler) recovered(ctx context.Context, req *transport.Request, value interface{}) error {
+	stack := debug.Stack()
+	p.count.Inc()
+	p.log.Error("Handler panicked.",
+		zap.String("caller", req.Caller),
+		zap.String("service", req.Service),
+		zap.String("encoding", string(req.Encoding)),
+		zap.String("procedure", req.Procedure),
+		zap.String("panic", fmt.Sprint(value)),
+		zap.String("stack", string(stack)),
+	)
+
+	if p.cfg.Hook != nil {
+		p.cfg.Hook(ctx, Panic{Request: req, Value: value, Stack: stack})
+	}
+	if p.cfg.Repanic {
+		panic(errors.UnrecoverablePanic{Value: value})
+	}
+	return errors.HandlerPanicError(value)
+}
+
+type panicUnaryHandler struct {
+	h transport.UnaryHandler
+	p *panicHandler
+}
+
+func (h panicUnaryHandler) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = h.p.recovered(ctx, req, r)
+		}
+	}()
+	return h.h.Handle(ctx, req, resw)
+}
+
+type panicOnewayHandler struct {
+	h transport.OnewayHandler
+	p *panicHandler
+}
+
+func (h panicOnewayHandler) HandleOneway(ctx context.Context, req *transport.Request) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = h.p.recovered(ctx, req, r)
+		}
+	}()
+	return h.h.HandleOneway(ctx, req)
+}
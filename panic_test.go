@@ -0,0 +1,140 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	. "go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/encoding/raw"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDispatcherPanics(t *testing.T) {
+	tests := []struct {
+		desc    string
+		repanic bool
+		hook    bool
+	}{
+		{desc: "default"},
+		{desc: "hook", hook: true},
+		{desc: "repanic", repanic: true},
+		{desc: "hook and repanic", hook: true, repanic: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var panics []Panic
+			cfg := PanicConfig{Repanic: tt.repanic}
+			if tt.hook {
+				cfg.Hook = func(ctx context.Context, p Panic) {
+					panics = append(panics, p)
+				}
+			}
+
+			core, logs := observer.New(zapcore.ErrorLevel)
+			d := NewDispatcher(Config{
+				Name:      "test",
+				ZapLogger: zap.New(core),
+				Panics:    cfg,
+			})
+			d.Register(raw.Procedure("panic", func(context.Context, []byte) ([]byte, error) {
+				panic("great sadness")
+			}))
+			d.Register(raw.OnewayProcedure("panic-oneway", func(context.Context, []byte) error {
+				panic("great sadness")
+			}))
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			unaryReq := &transport.Request{
+				Caller:    "caller",
+				Service:   "test",
+				Encoding:  raw.Encoding,
+				Procedure: "panic",
+				Body:      &bytes.Buffer{},
+			}
+			unarySpec, err := d.Router().Choose(ctx, unaryReq)
+			require.NoError(t, err)
+			callUnary := func() error {
+				return transport.DispatchUnaryHandler(ctx, unarySpec.Unary(), time.Now(), unaryReq, new(transporttest.FakeResponseWriter))
+			}
+
+			onewayReq := &transport.Request{
+				Caller:    "caller",
+				Service:   "test",
+				Encoding:  raw.Encoding,
+				Procedure: "panic-oneway",
+				Body:      &bytes.Buffer{},
+			}
+			onewaySpec, err := d.Router().Choose(ctx, onewayReq)
+			require.NoError(t, err)
+			callOneway := func() error {
+				return transport.DispatchOnewayHandler(ctx, onewaySpec.Oneway(), onewayReq)
+			}
+
+			for _, call := range []func() error{callUnary, callOneway} {
+				if tt.repanic {
+					assert.PanicsWithValue(t, "great sadness", func() { call() })
+					continue
+				}
+				err := call()
+				require.Error(t, err)
+				assert.Equal(t, "panic: great sadness", err.Error())
+			}
+
+			assert.Equal(t, int64(2), d.Introspect().Panics, "expected panics to be counted")
+
+			entries := logs.TakeAll()
+			require.Len(t, entries, 2, "expected a log entry for each panic")
+			for i, procedure := range []string{"panic", "panic-oneway"} {
+				assert.Equal(t, "Handler panicked.", entries[i].Message)
+				fields := entries[i].ContextMap()["yarpc"].(map[string]interface{})
+				assert.Equal(t, "caller", fields["caller"])
+				assert.Equal(t, procedure, fields["procedure"])
+				assert.Equal(t, "great sadness", fields["panic"])
+				assert.Contains(t, fields["stack"], "panic_test.go")
+			}
+
+			if !tt.hook {
+				return
+			}
+			require.Len(t, panics, 2, "expected hook to be called for each panic")
+			assert.Equal(t, unaryReq, panics[0].Request)
+			assert.Equal(t, onewayReq, panics[1].Request)
+			for _, p := range panics {
+				assert.Equal(t, "great sadness", p.Value)
+				assert.NotEmpty(t, p.Stack)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package unixsocket identifies peers listening on Unix domain sockets.
+//
+// Addresses of Unix domain sockets are written as URLs with the "unix"
+// scheme and the absolute path of the socket,
+//
+// 	unix:///var/run/proxy.sock
+//
+// The HTTP transport accepts such addresses for its inbounds, and dials
+// peers identified by a PeerIdentifier.
+//
+// 	chooser := peer.NewSingle(unixsocket.PeerIdentifier("/var/run/proxy.sock"), httpTransport)
+package unixsocket
+
+import "strings"
+
+// Prefix is the prefix of addresses that refer to Unix domain sockets.
+const Prefix = "unix://"
+
+// PeerIdentifier identifies a peer by the path of its Unix domain socket.
+type PeerIdentifier string
+
+// Identifier returns the address of the socket, with the "unix://" prefix.
+func (p PeerIdentifier) Identifier() string {
+	return Prefix + string(p)
+}
+
+// Path returns the path of the socket.
+func (p PeerIdentifier) Path() string {
+	return string(p)
+}
+
+// ParseAddress returns the path of the socket referred to by the given
+// address, and whether the address refers to a socket at all.
+func ParseAddress(addr string) (path string, ok bool) {
+	if !strings.HasPrefix(addr, Prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(addr, Prefix), true
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package unixsocket
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerIdentifier(t *testing.T) {
+	pid := PeerIdentifier("/var/run/proxy.sock")
+	assert.Equal(t, "unix:///var/run/proxy.sock", pid.Identifier())
+	assert.Equal(t, "/var/run/proxy.sock", pid.Path())
+
+	path, ok := ParseAddress(pid.Identifier())
+	assert.True(t, ok, "identifier must be parsed as a socket address")
+	assert.Equal(t, pid.Path(), path)
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		addr     string
+		wantPath string
+		wantOK   bool
+	}{
+		{addr: "unix:///tmp/yarpc.sock", wantPath: "/tmp/yarpc.sock", wantOK: true},
+		{addr: "unix://relative.sock", wantPath: "relative.sock", wantOK: true},
+		{addr: "127.0.0.1:8080"},
+		{addr: ":8080"},
+		{addr: "http://localhost"},
+	}
+
+	for _, tt := range tests {
+		path, ok := ParseAddress(tt.addr)
+		assert.Equal(t, tt.wantOK, ok, "unexpected result for %q", tt.addr)
+		assert.Equal(t, tt.wantPath, path, "unexpected path for %q", tt.addr)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"io"
+	"sort"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// RelayConfig configures a Dispatcher to relay requests that it does not
+// handle itself through its outbounds.
+//
+// A request is relayed if it names a routing delegate other than this
+// service, or if no procedures are registered for its service. Such requests
+// are sent through the outbound with the same key as the routing delegate or,
+// if no routing delegate is set, the service of the request. The request
+// body, headers, shard key, routing key, and deadline are forwarded as-is
+// without decoding, so requests may be relayed between different transports.
+//
+// Requests relayed through an outbound key with only a unary or only a
+// oneway outbound are relayed as requests of that type. The type of requests
+// relayed through outbound keys with both is declared with Types.
+//
+// TChannel inbounds only accept requests for services that have procedures
+// registered, or that have an outbound with the same key when the Dispatcher
+// is started. Requests for other services can only be relayed through
+// DefaultOutboundKey by inbounds of other transports.
+type RelayConfig struct {
+	// DefaultOutboundKey is the key of the outbound through which requests
+	// are relayed if there is no outbound for their routing delegate or
+	// service. If empty, such requests fail as if relaying was disabled.
+	DefaultOutboundKey string
+
+	// Types declares the type of the requests relayed through outbound keys
+	// which have both unary and oneway outbounds. Requests relayed through
+	// such outbound keys are unary unless declared otherwise.
+	Types map[string]transport.Type
+}
+
+// RelayProcedureName is the name of the procedure listed by the Router of a
+// Dispatcher for each service to which it relays requests.
+const RelayProcedureName = "*"
+
+// relayRouter is router middleware which relays requests that the Dispatcher
+// cannot handle through its outbounds.
+type relayRouter struct {
+	d   *Dispatcher
+	cfg RelayConfig
+}
+
+var _ middleware.Router = relayRouter{}
+
+// Procedures lists the registered procedures followed by a procedure named
+// RelayProcedureName for each outbound key which has no registered
+// procedures. Transports like TChannel need these to accept requests for the
+// relayed services.
+func (r relayRouter) Procedures(router transport.Router) []transport.Procedure {
+	procs := router.Procedures()
+
+	local := make(map[string]struct{})
+	for _, p := range procs {
+		local[p.Service] = struct{}{}
+	}
+
+	r.d.outboundsMu.RLock()
+	keys := make([]string, 0, len(r.d.outbounds))
+	for k := range r.d.outbounds {
+		if _, ok := local[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	r.d.outboundsMu.RUnlock()
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if spec, ok := r.relay(k); ok {
+			procs = append(procs, transport.Procedure{
+				Name:        RelayProcedureName,
+				Service:     k,
+				HandlerSpec: spec,
+			})
+		}
+	}
+	return procs
+}
+
+func (r relayRouter) Choose(ctx context.Context, req *transport.Request, router transport.Router) (transport.HandlerSpec, error) {
+	if delegate := req.RoutingDelegate; delegate != "" && delegate != r.d.name {
+		if spec, ok := r.relay(delegate); ok {
+			return spec, nil
+		}
+	}
+
+	spec, err := router.Choose(ctx, req)
+	if err == nil || !transport.IsUnrecognizedProcedureError(err) {
+		return spec, err
+	}
+
+	service := req.Service
+	if service == "" {
+		service = r.d.name
+	}
+	for _, p := range router.Procedures() {
+		if p.Service == service {
+			// The service is handled locally and does not recognize this
+			// procedure.
+			return spec, err
+		}
+	}
+
+	if relaySpec, ok := r.relay(service); ok {
+		return relaySpec, nil
+	}
+	return spec, err
+}
+
+// relay returns a HandlerSpec which forwards requests through the outbound
+// with the given key, or the default outbound if there is no such outbound.
+func (r relayRouter) relay(outboundKey string) (transport.HandlerSpec, bool) {
+	r.d.outboundsMu.RLock()
+	outs, ok := r.d.outbounds[outboundKey]
+	if !ok && r.cfg.DefaultOutboundKey != "" {
+		outboundKey = r.cfg.DefaultOutboundKey
+		outs, ok = r.d.outbounds[outboundKey]
+	}
+	r.d.outboundsMu.RUnlock()
+	if !ok {
+		return transport.HandlerSpec{}, false
+	}
+
+	if outs.Oneway == nil || (outs.Unary != nil && r.cfg.Types[outboundKey] != transport.Oneway) {
+		h := middleware.ApplyUnaryInbound(
+			relayUnaryHandler{name: r.d.name, o: outs.Unary}, r.d.inboundMiddleware.Unary)
+		return transport.NewUnaryHandlerSpec(h), true
+	}
+	h := middleware.ApplyOnewayInbound(
+		relayOnewayHandler{name: r.d.name, o: outs.Oneway}, r.d.inboundMiddleware.Oneway)
+	return transport.NewOnewayHandlerSpec(h), true
+}
+
+// relayRequest returns a copy of the request to send to the next hop. If
+// this service was the routing delegate, the delegate is dropped so that the
+// next hop handles the request itself.
+func relayRequest(name string, req *transport.Request) *transport.Request {
+	r := *req
+	if r.RoutingDelegate == name {
+		r.RoutingDelegate = ""
+	}
+	return &r
+}
+
+type relayUnaryHandler struct {
+	name string
+	o    transport.UnaryOutbound
+}
+
+func (h relayUnaryHandler) Handle(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+	res, err := h.o.Call(ctx, relayRequest(h.name, req))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.ApplicationError {
+		rw.SetApplicationError()
+	}
+	rw.AddHeaders(res.Headers)
+	_, err = io.Copy(rw, res.Body)
+	return err
+}
+
+type relayOnewayHandler struct {
+	name string
+	o    transport.OnewayOutbound
+}
+
+func (h relayOnewayHandler) HandleOneway(ctx context.Context, req *transport.Request) error {
+	_, err := h.o.CallOneway(ctx, relayRequest(h.name, req))
+	return err
+}
@@ -0,0 +1,400 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	. "go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/transport/http"
+	"go.uber.org/yarpc/transport/tchannel"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayHTTP(t *testing.T) {
+	httpTransport := http.NewTransport()
+
+	backendInbound := httpTransport.NewInbound("127.0.0.1:0")
+	backend := NewDispatcher(Config{
+		Name:     "backend",
+		Inbounds: Inbounds{backendInbound},
+	})
+	backend.Register(raw.Procedure("echo", func(ctx context.Context, body []byte) ([]byte, error) {
+		call := CallFromContext(ctx)
+		if err := call.WriteResponseHeader("caller", call.Caller()); err != nil {
+			return nil, err
+		}
+		if err := call.WriteResponseHeader("foo", call.Header("foo")); err != nil {
+			return nil, err
+		}
+		if err := call.WriteResponseHeader("shard", call.ShardKey()); err != nil {
+			return nil, err
+		}
+		deadline, _ := ctx.Deadline()
+		return []byte(fmt.Sprintf("%s (ttl<=%v)", body, deadline.Sub(time.Now()) <= time.Second)), nil
+	}))
+	require.NoError(t, backend.Start())
+	defer backend.Stop()
+
+	relayInbound := httpTransport.NewInbound("127.0.0.1:0")
+	relay := NewDispatcher(Config{
+		Name:     "relay",
+		Inbounds: Inbounds{relayInbound},
+		Outbounds: Outbounds{
+			"backend": {
+				Unary: httpTransport.NewSingleOutbound(
+					fmt.Sprintf("http://%v", backendInbound.Addr())),
+			},
+		},
+		Relay: &RelayConfig{},
+	})
+	relay.Register(raw.Procedure("local", func(ctx context.Context, body []byte) ([]byte, error) {
+		return body, nil
+	}))
+	require.NoError(t, relay.Start())
+	defer relay.Stop()
+
+	relayURL := fmt.Sprintf("http://%v", relayInbound.Addr())
+	client := NewDispatcher(Config{
+		Name: "client",
+		Outbounds: Outbounds{
+			"backend": {
+				Unary: httpTransport.NewSingleOutbound(relayURL),
+			},
+			"unknown": {
+				Unary: httpTransport.NewSingleOutbound(relayURL),
+			},
+			"relay": {
+				Unary: httpTransport.NewSingleOutbound(relayURL),
+			},
+		},
+	})
+	require.NoError(t, client.Start())
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var headers map[string]string
+	body, err := raw.New(client.ClientConfig("backend")).Call(ctx, "echo", []byte("hello"),
+		WithHeader("foo", "bar"), WithShardKey("42"), ResponseHeaders(&headers))
+	require.NoError(t, err)
+	assert.Equal(t, "hello (ttl<=true)", string(body))
+	assert.Equal(t, map[string]string{"caller": "client", "foo": "bar", "shard": "42"}, headers)
+
+	_, err = raw.New(client.ClientConfig("unknown")).Call(ctx, "echo", []byte("hello"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unrecognized procedure "echo" for service "unknown"`)
+
+	// Requests for local services with unknown procedures are not relayed.
+	_, err = raw.New(client.ClientConfig("relay")).Call(ctx, "echo", []byte("hello"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unrecognized procedure "echo" for service "relay"`)
+
+	body, err = raw.New(client.ClientConfig("relay")).Call(ctx, "local", []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+}
+
+func TestRelayRoutingDelegate(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	proxy := transporttest.NewMockUnaryOutbound(mockCtrl)
+	proxy.EXPECT().Transports().AnyTimes()
+	backend := transporttest.NewMockOnewayOutbound(mockCtrl)
+	backend.EXPECT().Transports().AnyTimes()
+
+	dispatcher := NewDispatcher(Config{
+		Name: "relay",
+		Outbounds: Outbounds{
+			"proxy":   {Unary: proxy},
+			"backend": {Oneway: backend},
+		},
+		Relay: &RelayConfig{DefaultOutboundKey: "proxy"},
+	})
+	dispatcher.Register(raw.Procedure("echo", func(ctx context.Context, body []byte) ([]byte, error) {
+		return body, nil
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Requests delegated to another service go through its outbound even if
+	// the service is handled locally.
+	req := &transport.Request{
+		Caller:          "caller",
+		Service:         "relay",
+		Procedure:       "echo",
+		Encoding:        raw.Encoding,
+		RoutingDelegate: "backend",
+		Body:            bytes.NewReader([]byte("hello")),
+	}
+	spec, err := dispatcher.Router().Choose(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, transport.Oneway, spec.Type())
+	backend.EXPECT().CallOneway(gomock.Any(), transporttest.NewRequestMatcher(t, req)).Return(nil, nil)
+	require.NoError(t, spec.Oneway().HandleOneway(ctx, req))
+
+	// Requests delegated to this service are sent to their service through
+	// the default outbound without the delegate.
+	req = &transport.Request{
+		Caller:          "caller",
+		Service:         "elsewhere",
+		Procedure:       "echo",
+		Encoding:        raw.Encoding,
+		RoutingDelegate: "relay",
+		Body:            bytes.NewReader([]byte("hello")),
+	}
+	spec, err = dispatcher.Router().Choose(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, transport.Unary, spec.Type())
+	proxy.EXPECT().Call(gomock.Any(), transporttest.NewRequestMatcher(t, &transport.Request{
+		Caller:    "caller",
+		Service:   "elsewhere",
+		Procedure: "echo",
+		Encoding:  raw.Encoding,
+		Body:      bytes.NewReader([]byte("hello")),
+	})).Return(&transport.Response{
+		Headers:          transport.NewHeaders().With("foo", "bar"),
+		Body:             ioutil.NopCloser(bytes.NewReader([]byte("world"))),
+		ApplicationError: true,
+	}, nil)
+
+	rw := new(transporttest.FakeResponseWriter)
+	require.NoError(t, spec.Unary().Handle(ctx, req, rw))
+	assert.True(t, rw.IsApplicationError)
+	assert.Equal(t, transport.NewHeaders().With("foo", "bar"), rw.Headers)
+	assert.Equal(t, "world", rw.Body.String())
+}
+
+func TestRelayProcedures(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	out.EXPECT().Transports().AnyTimes()
+
+	dispatcher := NewDispatcher(Config{
+		Name: "relay",
+		Outbounds: Outbounds{
+			"relay": {Unary: out},
+			"zed":   {Unary: out},
+			"alpha": {Unary: out},
+		},
+		Relay: &RelayConfig{},
+	})
+	dispatcher.Register(raw.Procedure("echo", func(ctx context.Context, body []byte) ([]byte, error) {
+		return body, nil
+	}))
+
+	var got []string
+	for _, p := range dispatcher.Router().Procedures() {
+		got = append(got, p.Service+"::"+p.Name)
+	}
+	assert.Equal(t, []string{"relay::echo", "alpha::*", "zed::*"}, got)
+}
+
+func TestRelayTypes(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	unary := transporttest.NewMockUnaryOutbound(mockCtrl)
+	unary.EXPECT().Transports().AnyTimes()
+	oneway := transporttest.NewMockOnewayOutbound(mockCtrl)
+	oneway.EXPECT().Transports().AnyTimes()
+
+	dispatcher := NewDispatcher(Config{
+		Name: "relay",
+		Outbounds: Outbounds{
+			"unary":      {Unary: unary},
+			"oneway":     {Oneway: oneway},
+			"both":       {Unary: unary, Oneway: oneway},
+			"bothOneway": {Unary: unary, Oneway: oneway},
+		},
+		Relay: &RelayConfig{Types: map[string]transport.Type{
+			"bothOneway": transport.Oneway,
+		}},
+	})
+
+	tests := []struct {
+		service string
+		want    transport.Type
+	}{
+		{"unary", transport.Unary},
+		{"oneway", transport.Oneway},
+		{"both", transport.Unary},
+		{"bothOneway", transport.Oneway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			// The type does not depend on whether the request has a deadline.
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			spec, err := dispatcher.Router().Choose(ctx, &transport.Request{
+				Caller:    "caller",
+				Service:   tt.service,
+				Procedure: "echo",
+				Encoding:  raw.Encoding,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, spec.Type())
+		})
+	}
+}
+
+func TestRelayAcrossTransports(t *testing.T) {
+	type newInboundFunc func(t *testing.T, name string) (inbound transport.Inbound, addr func() string)
+	type newOutboundFunc func(t *testing.T, caller, addr string) transport.Outbounds
+
+	newHTTPInbound := func(t *testing.T, name string) (transport.Inbound, func() string) {
+		inbound := http.NewTransport().NewInbound("127.0.0.1:0")
+		return inbound, func() string { return fmt.Sprintf("http://%v", inbound.Addr()) }
+	}
+	newHTTPOutbound := func(t *testing.T, caller, addr string) transport.Outbounds {
+		out := http.NewTransport().NewSingleOutbound(addr)
+		return transport.Outbounds{Unary: out, Oneway: out}
+	}
+	newTChannelInbound := func(t *testing.T, name string) (transport.Inbound, func() string) {
+		trans, err := tchannel.NewChannelTransport(
+			tchannel.ServiceName(name), tchannel.ListenAddr("127.0.0.1:0"))
+		require.NoError(t, err)
+		return trans.NewInbound(), trans.ListenAddr
+	}
+	newTChannelOutbound := func(t *testing.T, caller, addr string) transport.Outbounds {
+		trans, err := tchannel.NewChannelTransport(tchannel.ServiceName(caller))
+		require.NoError(t, err)
+		out := trans.NewSingleOutbound(addr)
+		return transport.Outbounds{Unary: out, Oneway: out}
+	}
+
+	tests := []struct {
+		desc        string
+		newInbound  newInboundFunc
+		newOutbound newOutboundFunc
+
+		relayInbound  newInboundFunc
+		relayOutbound newOutboundFunc
+	}{
+		{
+			desc:          "http to tchannel",
+			relayInbound:  newHTTPInbound,
+			newOutbound:   newHTTPOutbound,
+			newInbound:    newTChannelInbound,
+			relayOutbound: newTChannelOutbound,
+		},
+		{
+			desc:          "tchannel to http",
+			relayInbound:  newTChannelInbound,
+			newOutbound:   newTChannelOutbound,
+			newInbound:    newHTTPInbound,
+			relayOutbound: newHTTPOutbound,
+		},
+	}
+
+	for _, tt := range tests {
+		for _, rpcType := range []transport.Type{transport.Unary, transport.Oneway} {
+			t.Run(tt.desc+" "+rpcType.String(), func(t *testing.T) {
+				received := make(chan string, 1)
+				backendInbound, backendAddr := tt.newInbound(t, "backend")
+				backend := NewDispatcher(Config{
+					Name:     "backend",
+					Inbounds: Inbounds{backendInbound},
+				})
+				backend.Register(raw.Procedure("echo", func(ctx context.Context, body []byte) ([]byte, error) {
+					call := CallFromContext(ctx)
+					if err := call.WriteResponseHeader("foo", call.Header("foo")); err != nil {
+						return nil, err
+					}
+					if err := call.WriteResponseHeader("shard", call.ShardKey()); err != nil {
+						return nil, err
+					}
+					return body, nil
+				}))
+				backend.Register(raw.OnewayProcedure("notify", func(ctx context.Context, body []byte) error {
+					received <- string(body)
+					return nil
+				}))
+				require.NoError(t, backend.Start())
+				defer backend.Stop()
+
+				// Requests for the backend are relayed as requests of the
+				// type declared for it.
+				relayInbound, relayAddr := tt.relayInbound(t, "relay")
+				relay := NewDispatcher(Config{
+					Name:     "relay",
+					Inbounds: Inbounds{relayInbound},
+					Outbounds: Outbounds{
+						"backend": tt.relayOutbound(t, "relay", backendAddr()),
+					},
+					Relay: &RelayConfig{Types: map[string]transport.Type{"backend": rpcType}},
+				})
+				require.NoError(t, relay.Start())
+				defer relay.Stop()
+
+				client := NewDispatcher(Config{
+					Name: "client",
+					Outbounds: Outbounds{
+						"backend": tt.newOutbound(t, "client", relayAddr()),
+					},
+				})
+				require.NoError(t, client.Start())
+				defer client.Stop()
+
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+
+				if rpcType == transport.Oneway {
+					_, err := raw.New(client.ClientConfig("backend")).CallOneway(ctx, "notify", []byte("hello"))
+					require.NoError(t, err)
+					select {
+					case body := <-received:
+						assert.Equal(t, "hello", body)
+					case <-ctx.Done():
+						t.Fatal("oneway request was not relayed to the backend")
+					}
+					return
+				}
+
+				var headers map[string]string
+				body, err := raw.New(client.ClientConfig("backend")).Call(ctx, "echo", []byte("hello"),
+					WithHeader("foo", "bar"), WithShardKey("42"), ResponseHeaders(&headers))
+				require.NoError(t, err)
+				assert.Equal(t, "hello", string(body))
+				assert.Equal(t, "bar", headers["foo"])
+				assert.Equal(t, "42", headers["shard"])
+			})
+		}
+	}
+}
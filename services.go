@@ -0,0 +1,100 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// ServiceConfig configures a service hosted by a Dispatcher in addition to
+// the service it is named after.
+//
+// Procedures are registered for a hosted service by setting their Service to
+// its name. Requests to these procedures go through the inbound middleware of
+// the service inside the InboundMiddleware of the Dispatcher, so that
+// policies like rate limiting or authorization may be applied to each
+// service separately.
+//
+// Requests made through the Dispatcher's outbounds with the context of a
+// request to a hosted service use the name of that service as the caller.
+type ServiceConfig struct {
+	// InboundMiddleware is applied to requests to the procedures of this
+	// service only.
+	//
+	// This may be empty if the service has no middleware of its own.
+	InboundMiddleware InboundMiddleware
+}
+
+type hostedServiceKey struct{} // context key for the name of a hosted service
+
+// hostedServiceUnaryHandler records the name of the hosted service to which
+// a request was made in its context.
+type hostedServiceUnaryHandler struct {
+	h       transport.UnaryHandler
+	service string
+}
+
+func (h hostedServiceUnaryHandler) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return h.h.Handle(context.WithValue(ctx, hostedServiceKey{}, h.service), req, resw)
+}
+
+type hostedServiceOnewayHandler struct {
+	h       transport.OnewayHandler
+	service string
+}
+
+func (h hostedServiceOnewayHandler) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return h.h.HandleOneway(context.WithValue(ctx, hostedServiceKey{}, h.service), req)
+}
+
+// hostedServiceCaller is outbound middleware which replaces the caller of
+// requests made with the context of a request to a hosted service with the
+// name of that service.
+type hostedServiceCaller struct {
+	// name of the Dispatcher, which is the caller set by clients
+	name string
+}
+
+var (
+	_ middleware.UnaryOutbound  = hostedServiceCaller{}
+	_ middleware.OnewayOutbound = hostedServiceCaller{}
+)
+
+func (c hostedServiceCaller) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	return out.Call(ctx, c.request(ctx, req))
+}
+
+func (c hostedServiceCaller) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	return out.CallOneway(ctx, c.request(ctx, req))
+}
+
+func (c hostedServiceCaller) request(ctx context.Context, req *transport.Request) *transport.Request {
+	service, ok := ctx.Value(hostedServiceKey{}).(string)
+	if !ok || req.Caller != c.name {
+		return req
+	}
+	r := *req
+	r.Caller = service
+	return &r
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/transport/x/loopback"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostedServices(t *testing.T) {
+	trans := loopback.NewTransport()
+
+	backend := NewDispatcher(Config{
+		Name:     "backend",
+		Inbounds: Inbounds{trans.NewInbound("backend")},
+	})
+	backend.Register(raw.Procedure("whoami", func(ctx context.Context, body []byte) ([]byte, error) {
+		return []byte(CallFromContext(ctx).Caller()), nil
+	}))
+	require.NoError(t, backend.Start())
+	defer backend.Stop()
+
+	var usersRequests int
+	countUsers := middleware.UnaryInboundFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+		usersRequests++
+		return h.Handle(ctx, req, resw)
+	})
+
+	frontend := NewDispatcher(Config{
+		Name:     "frontend",
+		Inbounds: Inbounds{trans.NewInbound("frontend")},
+		Outbounds: Outbounds{
+			"backend": {Unary: trans.NewSingleOutbound("backend")},
+		},
+		Services: map[string]ServiceConfig{
+			"users": {InboundMiddleware: InboundMiddleware{Unary: countUsers}},
+		},
+	})
+	backendClient := raw.New(frontend.ClientConfig("backend"))
+	whoami := func(ctx context.Context, body []byte) ([]byte, error) {
+		return backendClient.Call(ctx, "whoami", nil)
+	}
+	frontend.Register(raw.Procedure("whoami", whoami))
+	users := raw.Procedure("whoami", whoami)
+	users[0].Service = "users"
+	frontend.Register(users)
+	require.NoError(t, frontend.Start())
+	defer frontend.Stop()
+
+	client := NewDispatcher(Config{
+		Name: "client",
+		Outbounds: Outbounds{
+			"frontend": {Unary: trans.NewSingleOutbound("frontend")},
+			"users":    {Unary: trans.NewSingleOutbound("frontend")},
+		},
+	})
+	require.NoError(t, client.Start())
+	defer client.Stop()
+
+	tests := []struct {
+		service       string
+		wantCaller    string
+		usersRequests int
+	}{
+		{service: "frontend", wantCaller: "frontend", usersRequests: 0},
+		{service: "users", wantCaller: "users", usersRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			usersRequests = 0
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			body, err := raw.New(client.ClientConfig(tt.service)).Call(ctx, "whoami", nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCaller, string(body), "unexpected caller for outbound request")
+			assert.Equal(t, tt.usersRequests, usersRequests, "unexpected number of requests through users middleware")
+		})
+	}
+
+	services := frontend.Introspect().Services
+	require.Len(t, services, 2)
+	for i, name := range []string{"frontend", "users"} {
+		assert.Equal(t, name, services[i].Name)
+		require.Len(t, services[i].Procedures, 1)
+		assert.Equal(t, name, services[i].Procedures[0].Service)
+		assert.Equal(t, "whoami", services[i].Procedures[0].Name)
+	}
+}
+
+func TestHostedServicesInvalidName(t *testing.T) {
+	assert.Panics(t, func() {
+		NewDispatcher(Config{
+			Name:     "test",
+			Services: map[string]ServiceConfig{"not a service": {}},
+		})
+	})
+}
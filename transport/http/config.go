@@ -0,0 +1,151 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"errors"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	peerchooser "go.uber.org/yarpc/peer"
+	"go.uber.org/yarpc/peer/unixsocket"
+	"go.uber.org/yarpc/x/config"
+)
+
+// TransportSpec returns a TransportSpec for the HTTP transport, which may be
+// registered against a config.Configurator. The given options are applied to
+// all transports, inbounds and outbounds built from configuration.
+//
+// 	transports:
+// 	  http:
+// 	    keepAlive: 10s
+// 	    maxIdleConnsPerHost: 4
+// 	inbounds:
+// 	  http:
+// 	    address: unix:///var/run/myservice.sock
+// 	outbounds:
+// 	  myservice:
+// 	    http:
+// 	      url: http://127.0.0.1:8080
+// 	  proxy:
+// 	    http:
+// 	      url: http://proxy/rpc
+// 	      socket: /var/run/proxy.sock
+//
+// See TransportConfig, InboundConfig and OutboundConfig for details on the
+// accepted parameters.
+func TransportSpec(opts ...Option) config.TransportSpec {
+	var ts transportSpec
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case TransportOption:
+			ts.transportOptions = append(ts.transportOptions, o)
+		case InboundOption:
+			ts.inboundOptions = append(ts.inboundOptions, o)
+		case OutboundOption:
+			ts.outboundOptions = append(ts.outboundOptions, o)
+		}
+	}
+	return config.TransportSpec{
+		Name:                "http",
+		BuildTransport:      ts.buildTransport,
+		BuildInbound:        ts.buildInbound,
+		BuildUnaryOutbound:  ts.buildUnaryOutbound,
+		BuildOnewayOutbound: ts.buildOnewayOutbound,
+	}
+}
+
+// TransportConfig configures the shared HTTP transport.
+type TransportConfig struct {
+	// Keep-alive period for connections. Defaults to 30 seconds.
+	KeepAlive time.Duration `config:"keepAlive,interpolate"`
+
+	// Number of idle connections kept per host. Defaults to 2.
+	MaxIdleConnsPerHost int `config:"maxIdleConnsPerHost,interpolate"`
+}
+
+// InboundConfig configures an HTTP inbound.
+type InboundConfig struct {
+	// Address to listen on, either "host:port" or the path of a Unix domain
+	// socket in the form "unix:///path/to/socket". Required.
+	Address string `config:"address,interpolate"`
+}
+
+// OutboundConfig configures an HTTP outbound.
+type OutboundConfig struct {
+	// URL to which requests are sent. It may also be the address of a Unix
+	// domain socket in the form "unix:///path/to/socket". Required unless
+	// Socket is set.
+	URL string `config:"url,interpolate"`
+
+	// Path of a Unix domain socket to send requests to. If set, requests for
+	// URL are sent over the socket rather than to the host named in the URL.
+	Socket string `config:"socket,interpolate"`
+}
+
+type transportSpec struct {
+	transportOptions []TransportOption
+	inboundOptions   []InboundOption
+	outboundOptions  []OutboundOption
+}
+
+func (ts *transportSpec) buildTransport(tc *TransportConfig, k *config.Kit) (transport.Transport, error) {
+	opts := append([]TransportOption(nil), ts.transportOptions...)
+	if tc.KeepAlive > 0 {
+		opts = append(opts, KeepAlive(tc.KeepAlive))
+	}
+	if tc.MaxIdleConnsPerHost > 0 {
+		opts = append(opts, MaxIdleConnsPerHost(tc.MaxIdleConnsPerHost))
+	}
+	return NewTransport(opts...), nil
+}
+
+func (ts *transportSpec) buildInbound(ic *InboundConfig, t transport.Transport, k *config.Kit) (transport.Inbound, error) {
+	if ic.Address == "" {
+		return nil, errors.New("inbound address is required")
+	}
+	return t.(*Transport).NewInbound(ic.Address, ts.inboundOptions...), nil
+}
+
+func (ts *transportSpec) buildOutbound(oc *OutboundConfig, t transport.Transport, k *config.Kit) (*Outbound, error) {
+	x := t.(*Transport)
+	if oc.Socket == "" {
+		if oc.URL == "" {
+			return nil, errors.New("outbound url is required")
+		}
+		return x.NewSingleOutbound(oc.URL, ts.outboundOptions...), nil
+	}
+
+	opts := append([]OutboundOption(nil), ts.outboundOptions...)
+	if oc.URL != "" {
+		opts = append(opts, URLTemplate(oc.URL))
+	}
+	chooser := peerchooser.NewSingle(unixsocket.PeerIdentifier(oc.Socket), x)
+	return x.NewOutbound(chooser, opts...), nil
+}
+
+func (ts *transportSpec) buildUnaryOutbound(oc *OutboundConfig, t transport.Transport, k *config.Kit) (transport.UnaryOutbound, error) {
+	return ts.buildOutbound(oc, t, k)
+}
+
+func (ts *transportSpec) buildOnewayOutbound(oc *OutboundConfig, t transport.Transport, k *config.Kit) (transport.OnewayOutbound, error) {
+	return ts.buildOutbound(oc, t, k)
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/yarpc/x/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportSpec(t *testing.T) {
+	tests := []struct {
+		desc    string
+		cfg     map[string]interface{}
+		wantErr string
+
+		wantInbound  string // address of the inbound
+		wantPeer     string // identifier of the outbound peer
+		wantTemplate string // URL template of the outbound
+	}{
+		{
+			desc: "tcp",
+			cfg: map[string]interface{}{
+				"transports": map[string]interface{}{
+					"http": map[string]interface{}{"keepAlive": "10s"},
+				},
+				"inbounds": map[string]interface{}{
+					"http": map[string]interface{}{"address": ":8080"},
+				},
+				"outbounds": map[string]interface{}{
+					"bar": map[string]interface{}{
+						"http": map[string]interface{}{"url": "http://127.0.0.1:8081/rpc"},
+					},
+				},
+			},
+			wantInbound:  ":8080",
+			wantPeer:     "127.0.0.1:8081",
+			wantTemplate: "http://127.0.0.1:8081/rpc",
+		},
+		{
+			desc: "unix socket url",
+			cfg: map[string]interface{}{
+				"inbounds": map[string]interface{}{
+					"http": map[string]interface{}{"address": "unix:///var/run/foo.sock"},
+				},
+				"outbounds": map[string]interface{}{
+					"bar": map[string]interface{}{
+						"http": map[string]interface{}{"url": "unix:///var/run/bar.sock"},
+					},
+				},
+			},
+			wantInbound:  "unix:///var/run/foo.sock",
+			wantPeer:     "unix:///var/run/bar.sock",
+			wantTemplate: "http://localhost",
+		},
+		{
+			desc: "unix socket with url",
+			cfg: map[string]interface{}{
+				"outbounds": map[string]interface{}{
+					"bar": map[string]interface{}{
+						"http": map[string]interface{}{
+							"url":    "http://proxy/rpc",
+							"socket": "/var/run/proxy.sock",
+						},
+					},
+				},
+			},
+			wantPeer:     "unix:///var/run/proxy.sock",
+			wantTemplate: "http://proxy/rpc",
+		},
+		{
+			desc: "missing url",
+			cfg: map[string]interface{}{
+				"outbounds": map[string]interface{}{
+					"bar": map[string]interface{}{
+						"http": map[string]interface{}{},
+					},
+				},
+			},
+			wantErr: "outbound url is required",
+		},
+		{
+			desc: "missing address",
+			cfg: map[string]interface{}{
+				"inbounds": map[string]interface{}{
+					"http": map[string]interface{}{},
+				},
+			},
+			wantErr: "inbound address is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			configurator := config.New()
+			require.NoError(t, configurator.RegisterTransport(TransportSpec()))
+
+			cfg, err := configurator.LoadConfig("foo", tt.cfg)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			if tt.wantInbound != "" {
+				require.Len(t, cfg.Inbounds, 1)
+				assert.Equal(t, tt.wantInbound, cfg.Inbounds[0].(*Inbound).addr)
+			}
+
+			out, ok := cfg.Outbounds["bar"].Unary.(*Outbound)
+			require.True(t, ok, "expected an HTTP unary outbound")
+			assert.Equal(t, tt.wantTemplate, out.urlTemplate.String())
+			assert.IsType(t, &Outbound{}, cfg.Outbounds["bar"].Oneway)
+
+			require.NoError(t, out.Start())
+			defer out.Stop()
+			p, onFinish, err := out.getPeerForRequest(context.Background(), nil)
+			require.NoError(t, err)
+			onFinish(nil)
+			assert.Equal(t, tt.wantPeer, p.Identifier())
+		})
+	}
+}
+
+func TestTransportSpecOptions(t *testing.T) {
+	spec := TransportSpec(MaxResponseSize(1024), MaxRequestSize(2048), KeepAlive(0))
+	configurator := config.New()
+	require.NoError(t, configurator.RegisterTransport(spec))
+
+	cfg, err := configurator.LoadConfig("foo", map[string]interface{}{
+		"inbounds": map[string]interface{}{
+			"http": map[string]interface{}{"address": ":8080"},
+		},
+		"outbounds": map[string]interface{}{
+			"bar": map[string]interface{}{
+				"http": map[string]interface{}{"socket": "/var/run/bar.sock"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2048), cfg.Inbounds[0].(*Inbound).requestLimits.Default)
+	out := cfg.Outbounds["bar"].Unary.(*Outbound)
+	assert.Equal(t, int64(1024), out.responseLimits.Default)
+	assert.Equal(t, defaultURLTemplate, out.urlTemplate)
+}
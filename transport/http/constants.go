@@ -56,6 +56,10 @@ const (
 
 	// Whether the response body contains an application error.
 	ApplicationStatusHeader = "Rpc-Status"
+
+	// Reason for which a request failed, for failures that cannot be told
+	// apart by their status codes alone.
+	ErrorReasonHeader = "Rpc-Error-Reason"
 )
 
 // Valid values for the Rpc-Status header.
@@ -67,6 +71,12 @@ const (
 	ApplicationErrorStatus = "error"
 )
 
+// Valid values for the Rpc-Error-Reason header.
+const (
+	// The request body was larger than the limit configured on the inbound.
+	BodyTooLargeErrorReason = "body-too-large"
+)
+
 // ApplicationHeaderPrefix is the prefix added to application header keys to
 // send them in requests or responses.
 const ApplicationHeaderPrefix = "Rpc-Header-"
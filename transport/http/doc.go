@@ -48,6 +48,12 @@
 // 		},
 // 	})
 //
+// Inbounds and outbounds may also use Unix domain sockets, with addresses in
+// the form "unix:///path/to/socket".
+//
+// 	myInbound := httpTransport.NewInbound("unix:///var/run/myservice.sock")
+// 	proxyOutbound := httpTransport.NewSingleOutbound("unix:///var/run/proxy.sock")
+//
 // Note that stopping an HTTP transport does NOT immediately terminate ongoing
 // requests. Connections will remain open until all clients have disconnected.
 //
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodylimit"
 	"go.uber.org/yarpc/internal/errors"
 	"go.uber.org/yarpc/internal/iopool"
 	"go.uber.org/yarpc/internal/request"
@@ -45,6 +46,7 @@ func popHeader(h http.Header, n string) string {
 type handler struct {
 	router transport.Router
 	tracer opentracing.Tracer
+	limits *bodylimit.Limits
 }
 
 func (h handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
@@ -66,7 +68,10 @@ func (h handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	err = errors.AsHandlerError(service, procedure, err)
 	status := http.StatusInternalServerError
-	if transport.IsBadRequestError(err) {
+	if errors.IsBodyTooLargeError(err) {
+		status = http.StatusRequestEntityTooLarge
+		w.Header().Set(ErrorReasonHeader, BodyTooLargeErrorReason)
+	} else if transport.IsBadRequestError(err) {
 		status = http.StatusBadRequest
 	} else if transport.IsTimeoutError(err) {
 		status = http.StatusGatewayTimeout
@@ -76,17 +81,34 @@ func (h handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 func (h handler) callHandler(w http.ResponseWriter, req *http.Request, start time.Time) error {
 	treq := &transport.Request{
-		Caller:    popHeader(req.Header, CallerHeader),
-		Service:   popHeader(req.Header, ServiceHeader),
-		Procedure: popHeader(req.Header, ProcedureHeader),
-		Encoding:  transport.Encoding(popHeader(req.Header, EncodingHeader)),
-		Headers:   applicationHeaders.FromHTTPHeaders(req.Header, transport.Headers{}),
-		Body:      req.Body,
+		Caller:          popHeader(req.Header, CallerHeader),
+		Service:         popHeader(req.Header, ServiceHeader),
+		Procedure:       popHeader(req.Header, ProcedureHeader),
+		Encoding:        transport.Encoding(popHeader(req.Header, EncodingHeader)),
+		ShardKey:        popHeader(req.Header, ShardKeyHeader),
+		RoutingKey:      popHeader(req.Header, RoutingKeyHeader),
+		RoutingDelegate: popHeader(req.Header, RoutingDelegateHeader),
+		Headers:         applicationHeaders.FromHTTPHeaders(req.Header, transport.Headers{}),
+		Body:            req.Body,
 	}
 	if err := transport.ValidateRequest(treq); err != nil {
 		return err
 	}
 
+	// Reject bodies that are too large before they reach the handler, and
+	// report the failure even if the handler wrapped the read error.
+	tooLarge := errors.HandlerBadRequestError(errors.BodyTooLargeError{
+		Kind:      "request",
+		Service:   treq.Service,
+		Procedure: treq.Procedure,
+		Limit:     h.limits.For(treq.Procedure),
+	})
+	if err := h.limits.Check(treq.Procedure, req.ContentLength, tooLarge); err != nil {
+		return err
+	}
+	body := h.limits.Reader(treq.Procedure, req.Body, tooLarge)
+	treq.Body = body
+
 	ctx := req.Context()
 	ctx, cancel, parseTTLErr := parseTTL(ctx, treq, popHeader(req.Header, TTLMSHeader))
 	// parseTTLErr != nil is a problem only if the request is unary.
@@ -118,6 +140,9 @@ func (h handler) callHandler(w http.ResponseWriter, req *http.Request, start tim
 		err = errors.UnsupportedTypeError{Transport: "HTTP", Type: spec.Type().String()}
 	}
 
+	if err != nil && body.Exceeded() {
+		err = tooLarge
+	}
 	updateSpanWithErr(span, err)
 	return err
 }
@@ -52,6 +52,9 @@ func TestHandlerSuccess(t *testing.T) {
 	headers.Set(TTLMSHeader, "1000")
 	headers.Set(ProcedureHeader, "nyuck")
 	headers.Set(ServiceHeader, "curly")
+	headers.Set(ShardKeyHeader, "shard")
+	headers.Set(RoutingKeyHeader, "routekey")
+	headers.Set(RoutingDelegateHeader, "routedelegate")
 
 	router := transporttest.NewMockRouter(mockCtrl)
 	rpcHandler := transporttest.NewMockUnaryHandler(mockCtrl)
@@ -68,11 +71,14 @@ func TestHandlerSuccess(t *testing.T) {
 		),
 		transporttest.NewRequestMatcher(
 			t, &transport.Request{
-				Caller:    "moe",
-				Service:   "curly",
-				Encoding:  raw.Encoding,
-				Procedure: "nyuck",
-				Body:      bytes.NewReader([]byte("Nyuck Nyuck")),
+				Caller:          "moe",
+				Service:         "curly",
+				Encoding:        raw.Encoding,
+				Procedure:       "nyuck",
+				ShardKey:        "shard",
+				RoutingKey:      "routekey",
+				RoutingDelegate: "routedelegate",
+				Body:            bytes.NewReader([]byte("Nyuck Nyuck")),
 			},
 		),
 		gomock.Any(),
@@ -397,3 +403,100 @@ func TestResponseWriter(t *testing.T) {
 	assert.Equal(t, "123", recorder.Header().Get("rpc-header-shard-key"))
 	assert.Equal(t, "hello", recorder.Body.String())
 }
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, r *transport.Request, w transport.ResponseWriter) error {
+	return f(ctx, r, w)
+}
+
+func TestHandlerBodyTooLarge(t *testing.T) {
+	tests := []struct {
+		desc          string
+		procedure     string
+		body          string
+		contentLength int64
+		wantCode      int
+		wantHandled   bool
+	}{
+		{
+			desc:          "within limit",
+			procedure:     "small",
+			body:          "abcd",
+			contentLength: 4,
+			wantCode:      http.StatusOK,
+			wantHandled:   true,
+		},
+		{
+			desc:          "content length too large",
+			procedure:     "small",
+			body:          "abcdef",
+			contentLength: 6,
+			wantCode:      http.StatusRequestEntityTooLarge,
+		},
+		{
+			desc:          "streamed body too large",
+			procedure:     "small",
+			body:          "abcdef",
+			contentLength: -1,
+			wantCode:      http.StatusRequestEntityTooLarge,
+			wantHandled:   true,
+		},
+		{
+			desc:          "procedure limit",
+			procedure:     "big",
+			body:          "abcdef",
+			contentLength: 6,
+			wantCode:      http.StatusOK,
+			wantHandled:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			headers := make(http.Header)
+			headers.Set(CallerHeader, "moe")
+			headers.Set(EncodingHeader, "raw")
+			headers.Set(TTLMSHeader, "1000")
+			headers.Set(ProcedureHeader, tt.procedure)
+			headers.Set(ServiceHeader, "curly")
+
+			handled := false
+			router := transporttest.NewMockRouter(mockCtrl)
+			router.EXPECT().Choose(gomock.Any(), gomock.Any()).Return(
+				transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+					func(_ context.Context, req *transport.Request, _ transport.ResponseWriter) error {
+						handled = true
+						if _, err := ioutil.ReadAll(req.Body); err != nil {
+							// Handlers may wrap the error in their own.
+							return fmt.Errorf("failed to read body: %v", err)
+						}
+						return nil
+					})), nil).AnyTimes()
+
+			inbound := NewTransport().NewInbound(":0", MaxRequestSize(4), ProcedureMaxRequestSize("big", 16))
+			httpHandler := handler{router: router, tracer: &opentracing.NoopTracer{}, limits: &inbound.requestLimits}
+			req := &http.Request{
+				Method:        "POST",
+				Header:        headers,
+				ContentLength: tt.contentLength,
+				Body:          ioutil.NopCloser(bytes.NewReader([]byte(tt.body))),
+			}
+			rw := httptest.NewRecorder()
+			httpHandler.ServeHTTP(rw, req)
+
+			assert.Equal(t, tt.wantCode, rw.Code)
+			assert.Equal(t, tt.wantHandled, handled)
+			if tt.wantCode == http.StatusRequestEntityTooLarge {
+				assert.Equal(t,
+					`BadRequest: request body for procedure "small" of service "curly" exceeds the limit of 4 bytes`+"\n",
+					rw.Body.String())
+				assert.Equal(t, BodyTooLargeErrorReason, rw.Header().Get(ErrorReasonHeader))
+				assert.Equal(t, int64(1), inbound.requestLimits.Exceeded())
+			}
+		})
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"net/http"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodylimit"
 	"go.uber.org/yarpc/internal/errors"
 	"go.uber.org/yarpc/internal/introspection"
 	intnet "go.uber.org/yarpc/internal/net"
@@ -51,8 +52,30 @@ func Mux(pattern string, mux *http.ServeMux) InboundOption {
 	}
 }
 
+// MaxRequestSize limits the size of request bodies accepted by the inbound to
+// the given number of bytes. Requests with larger bodies are rejected with a
+// bad request error and the HTTP status 413 before or while they are read,
+// so handlers never see more than this many bytes. Bodies are not limited by
+// default.
+func MaxRequestSize(bytes int64) InboundOption {
+	return func(i *Inbound) {
+		i.requestLimits.Default = bytes
+	}
+}
+
+// ProcedureMaxRequestSize limits the size of request bodies for the given
+// procedure, overriding MaxRequestSize. A limit of zero disables the limit
+// for the procedure.
+func ProcedureMaxRequestSize(procedure string, bytes int64) InboundOption {
+	return func(i *Inbound) {
+		i.requestLimits.SetProcedure(procedure, bytes)
+	}
+}
+
 // NewInbound builds a new HTTP inbound that listens on the given address and
-// sharing this transport.
+// sharing this transport. The address is either a TCP address in the form
+// "host:port", or the path of a Unix domain socket in the form
+// "unix:///path/to/socket".
 func (t *Transport) NewInbound(addr string, opts ...InboundOption) *Inbound {
 	i := &Inbound{
 		once:      sync.Once(),
@@ -77,6 +100,8 @@ type Inbound struct {
 	tracer     opentracing.Tracer
 	transport  *Transport
 
+	requestLimits bodylimit.Limits
+
 	once sync.LifecycleOnce
 }
 
@@ -112,6 +137,7 @@ func (i *Inbound) start() error {
 	var httpHandler http.Handler = handler{
 		router: i.router,
 		tracer: i.tracer,
+		limits: &i.requestLimits,
 	}
 	if i.mux != nil {
 		i.mux.Handle(i.muxPattern, httpHandler)
@@ -169,8 +195,9 @@ func (i *Inbound) Introspect() introspection.InboundStatus {
 		state = "Started"
 	}
 	return introspection.InboundStatus{
-		Transport: "http",
-		Endpoint:  i.Addr().String(),
-		State:     state,
+		Transport:         "http",
+		Endpoint:          i.Addr().String(),
+		State:             state,
+		OversizedRequests: i.requestLimits.Exceeded(),
 	}
 }
@@ -28,6 +28,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
 	"time"
@@ -89,6 +90,40 @@ func TestInboundStartAndStop(t *testing.T) {
 	assert.NoError(t, i.Stop())
 }
 
+func TestInboundUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpc-http")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	addr := "unix://" + filepath.Join(dir, "inbound.sock")
+
+	x := NewTransport()
+	i := x.NewInbound(addr)
+	i.SetRouter(transporttest.EchoRouter{})
+	require.NoError(t, i.Start())
+	defer i.Stop()
+	assert.Equal(t, "unix", i.Addr().Network())
+
+	o := x.NewSingleOutbound(addr)
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res, err := o.Call(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  raw.Encoding,
+		Procedure: "echo",
+		Body:      bytes.NewReader([]byte("hello")),
+	})
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+}
+
 func TestInboundStartError(t *testing.T) {
 	x := NewTransport()
 	i := x.NewInbound("invalid")
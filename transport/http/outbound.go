@@ -32,11 +32,13 @@ import (
 
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodylimit"
 	"go.uber.org/yarpc/internal/errors"
 	"go.uber.org/yarpc/internal/introspection"
 	"go.uber.org/yarpc/internal/sync"
 	peerchooser "go.uber.org/yarpc/peer"
 	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/unixsocket"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
@@ -66,6 +68,25 @@ func URLTemplate(template string) OutboundOption {
 	}
 }
 
+// MaxResponseSize limits the size of response bodies accepted by the
+// outbound to the given number of bytes. Reading a larger response body
+// fails with an error once the limit is exceeded, or immediately if the
+// server reported the size of the body. Bodies are not limited by default.
+func MaxResponseSize(bytes int64) OutboundOption {
+	return func(o *Outbound) {
+		o.responseLimits.Default = bytes
+	}
+}
+
+// ProcedureMaxResponseSize limits the size of response bodies for the given
+// procedure, overriding MaxResponseSize. A limit of zero disables the limit
+// for the procedure.
+func ProcedureMaxResponseSize(procedure string, bytes int64) OutboundOption {
+	return func(o *Outbound) {
+		o.responseLimits.SetProcedure(procedure, bytes)
+	}
+}
+
 // NewOutbound builds an HTTP outbound which sends requests to peers supplied
 // by the given peer.Chooser. The URL template for used for the different
 // peers may be customized using the URLTemplate option.
@@ -101,8 +122,23 @@ func NewOutbound(chooser peer.Chooser, opts ...OutboundOption) *Outbound {
 // NewSingleOutbound builds an outbound which sends YARPC requests over HTTP
 // to the specified URL.
 //
+// The URL may also be the address of a Unix domain socket, in the form
+// "unix:///path/to/socket", in which case requests are sent to
+// "http://localhost". To send requests to a different URL over a socket, use
+// NewOutbound with a unixsocket.PeerIdentifier and the URLTemplate option.
+//
 // The URLTemplate option has no effect in this form.
 func (t *Transport) NewSingleOutbound(uri string, opts ...OutboundOption) *Outbound {
+	if path, ok := unixsocket.ParseAddress(uri); ok {
+		chooser := peerchooser.NewSingle(unixsocket.PeerIdentifier(path), t)
+		o := t.NewOutbound(chooser)
+		for _, opt := range opts {
+			opt(o)
+		}
+		o.urlTemplate = defaultURLTemplate
+		return o
+	}
+
 	parsedURL, err := url.Parse(uri)
 	if err != nil {
 		panic(err.Error())
@@ -128,6 +164,8 @@ type Outbound struct {
 	tracer      opentracing.Tracer
 	transport   *Transport
 
+	responseLimits bodylimit.Limits
+
 	once sync.LifecycleOnce
 }
 
@@ -257,9 +295,21 @@ func (o *Outbound) callWithPeer(
 		appHeaders := applicationHeaders.FromHTTPHeaders(
 			response.Header, transport.NewHeaders())
 		appError := response.Header.Get(ApplicationStatusHeader) == ApplicationErrorStatus
+
+		tooLarge := errors.BodyTooLargeError{
+			Kind:      "response",
+			Service:   treq.Service,
+			Procedure: treq.Procedure,
+			Limit:     o.responseLimits.For(treq.Procedure),
+		}
+		if err := o.responseLimits.Check(treq.Procedure, response.ContentLength, tooLarge); err != nil {
+			response.Body.Close()
+			return nil, err
+		}
+
 		return &transport.Response{
 			Headers:          appHeaders,
-			Body:             response.Body,
+			Body:             o.responseLimits.Reader(treq.Procedure, response.Body, tooLarge),
 			ApplicationError: appError,
 		}, nil
 	}
@@ -286,7 +336,10 @@ func (o *Outbound) getPeerForRequest(ctx context.Context, treq *transport.Reques
 
 func (o *Outbound) createRequest(p *hostport.Peer, treq *transport.Request) (*http.Request, error) {
 	newURL := *o.urlTemplate
-	newURL.Host = p.HostPort()
+	if _, ok := unixsocket.ParseAddress(p.HostPort()); !ok {
+		// Requests to Unix domain sockets keep the host of the template.
+		newURL.Host = p.HostPort()
+	}
 	return http.NewRequest("POST", newURL.String(), treq.Body)
 }
 
@@ -355,7 +408,7 @@ func (o *Outbound) getHTTPClient(p *hostport.Peer) (*http.Client, error) {
 			ExpectedType: "*http.Transport",
 		}
 	}
-	return t.client, nil
+	return t.clientFor(p), nil
 }
 
 func getErrFromResponse(response *http.Response) error {
@@ -372,6 +425,11 @@ func getErrFromResponse(response *http.Response) error {
 	// Trim the trailing newline from HTTP error messages
 	message := strings.TrimSuffix(string(contents), "\n")
 
+	if response.StatusCode == http.StatusRequestEntityTooLarge &&
+		response.Header.Get(ErrorReasonHeader) == BodyTooLargeErrorReason {
+		return errors.RemoteBodyTooLargeError(message)
+	}
+
 	if response.StatusCode >= 400 && response.StatusCode < 500 {
 		return errors.RemoteBadRequestError(message)
 	}
@@ -398,9 +456,10 @@ func (o *Outbound) Introspect() introspection.OutboundStatus {
 		}
 	}
 	return introspection.OutboundStatus{
-		Transport: "http",
-		Endpoint:  o.urlTemplate.String(),
-		State:     state,
-		Chooser:   chooser,
+		Transport:          "http",
+		Endpoint:           o.urlTemplate.String(),
+		State:              state,
+		Chooser:            chooser,
+		OversizedResponses: o.responseLimits.Exceeded(),
 	}
 }
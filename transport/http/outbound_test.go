@@ -24,8 +24,11 @@ import (
 	"bytes"
 	"context"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"testing"
@@ -33,6 +36,8 @@ import (
 
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/raw"
+	peerchooser "go.uber.org/yarpc/peer"
+	"go.uber.org/yarpc/peer/unixsocket"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -92,6 +97,80 @@ func TestCallSuccess(t *testing.T) {
 	}
 }
 
+func TestCallUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpc-http")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "proxy.sock")
+
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	var lock sync.Mutex
+	var gotURL string
+	go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		lock.Lock()
+		gotURL = "http://" + req.Host + req.URL.Path
+		lock.Unlock()
+		_, err := w.Write([]byte("great success"))
+		assert.NoError(t, err)
+	}))
+
+	tests := []struct {
+		desc    string
+		build   func(*Transport) *Outbound
+		wantURL string
+	}{
+		{
+			desc: "single outbound",
+			build: func(trans *Transport) *Outbound {
+				return trans.NewSingleOutbound("unix://" + path)
+			},
+			wantURL: "http://localhost/",
+		},
+		{
+			desc: "peer identifier",
+			build: func(trans *Transport) *Outbound {
+				chooser := peerchooser.NewSingle(unixsocket.PeerIdentifier(path), trans)
+				return trans.NewOutbound(chooser, URLTemplate("http://proxy/rpc"))
+			},
+			wantURL: "http://proxy/rpc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			trans := NewTransport()
+			require.NoError(t, trans.Start(), "failed to start transport")
+			defer trans.Stop()
+			out := tt.build(trans)
+			require.NoError(t, out.Start(), "failed to start outbound")
+			defer out.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			res, err := out.Call(ctx, &transport.Request{
+				Caller:    "caller",
+				Service:   "service",
+				Encoding:  raw.Encoding,
+				Procedure: "hello",
+				Body:      bytes.NewReader([]byte("world")),
+			})
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := ioutil.ReadAll(res.Body)
+			if assert.NoError(t, err) {
+				assert.Equal(t, []byte("great success"), body)
+			}
+			lock.Lock()
+			assert.Equal(t, tt.wantURL, gotURL, "request sent to the wrong URL")
+			lock.Unlock()
+		})
+	}
+}
+
 func TestOutboundHeaders(t *testing.T) {
 	tests := []struct {
 		desc    string
@@ -264,6 +343,48 @@ func TestCallFailures(t *testing.T) {
 	}
 }
 
+func TestCallRequestTooLarge(t *testing.T) {
+	tests := []struct {
+		desc             string
+		reason           string
+		wantBodyTooLarge bool
+	}{
+		{desc: "inbound limit", reason: BodyTooLargeErrorReason, wantBodyTooLarge: true},
+		{desc: "other server"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if tt.reason != "" {
+						w.Header().Set(ErrorReasonHeader, tt.reason)
+					}
+					http.Error(w, "too large", http.StatusRequestEntityTooLarge)
+				}))
+			defer server.Close()
+
+			out := NewTransport().NewSingleOutbound(server.URL)
+			require.NoError(t, out.Start(), "failed to start outbound")
+			defer out.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			_, err := out.Call(ctx, &transport.Request{
+				Caller:    "caller",
+				Service:   "service",
+				Encoding:  raw.Encoding,
+				Procedure: "wat",
+				Body:      bytes.NewReader([]byte("huh")),
+			})
+			require.Error(t, err)
+			assert.Equal(t, "too large", err.Error())
+			assert.True(t, transport.IsBadRequestError(err), "must be a bad request error")
+			assert.Equal(t, tt.wantBodyTooLarge, transport.IsBodyTooLargeError(err))
+		})
+	}
+}
+
 func TestStartMultiple(t *testing.T) {
 	httpTransport := NewTransport()
 	out := httpTransport.NewSingleOutbound("http://localhost:9999")
@@ -328,3 +449,54 @@ func TestCallWithoutStarting(t *testing.T) {
 
 	assert.Equal(t, context.DeadlineExceeded, err)
 }
+
+func TestCallResponseTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get(ProcedureHeader) == "streamed" {
+				// Flushing before writing the body hides its size from
+				// the client.
+				w.(http.Flusher).Flush()
+			}
+			_, err := w.Write([]byte("great success"))
+			assert.NoError(t, err)
+		},
+	))
+	defer server.Close()
+
+	out := NewTransport().NewSingleOutbound(server.URL,
+		MaxResponseSize(5), ProcedureMaxResponseSize("big", 100))
+	require.NoError(t, out.Start(), "failed to start outbound")
+	defer out.Stop()
+
+	call := func(procedure string) (*transport.Response, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		return out.Call(ctx, &transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Encoding:  raw.Encoding,
+			Procedure: procedure,
+			Body:      bytes.NewReader([]byte("world")),
+		})
+	}
+
+	_, err := call("hello")
+	require.Error(t, err)
+	assert.Equal(t, `response body for procedure "hello" of service "service" exceeds the limit of 5 bytes`, err.Error())
+
+	res, err := call("streamed")
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(res.Body)
+	assert.Error(t, err)
+	assert.NoError(t, res.Body.Close())
+
+	res, err = call("big")
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "great success", string(body))
+	assert.NoError(t, res.Body.Close())
+
+	assert.Equal(t, int64(2), out.Introspect().OversizedResponses)
+}
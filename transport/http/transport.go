@@ -30,6 +30,7 @@ import (
 	"go.uber.org/yarpc/api/transport"
 	intsync "go.uber.org/yarpc/internal/sync"
 	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/unixsocket"
 
 	"github.com/opentracing/opentracing-go"
 )
@@ -89,22 +90,38 @@ func NewTransport(opts ...TransportOption) *Transport {
 	}
 
 	return &Transport{
-		once:   intsync.Once(),
-		client: buildClient(&cfg),
-		peers:  make(map[string]*hostport.Peer),
-		tracer: cfg.tracer,
+		once:          intsync.Once(),
+		config:        cfg,
+		client:        buildClient(&cfg, ""),
+		socketClients: make(map[string]*http.Client),
+		peers:         make(map[string]*hostport.Peer),
+		tracer:        cfg.tracer,
 	}
 }
 
-func buildClient(cfg *transportConfig) *http.Client {
+// buildClient builds an HTTP client. If socket is non-empty, the client
+// connects to the Unix domain socket at that path regardless of the address
+// of the request.
+func buildClient(cfg *transportConfig, socket string) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: cfg.keepAlive,
+	}
+	dial := dialer.Dial
+	proxy := http.ProxyFromEnvironment
+	if socket != "" {
+		dial = func(string, string) (net.Conn, error) {
+			return dialer.Dial("unix", socket)
+		}
+		// Requests to sockets must never be sent to a proxy instead.
+		proxy = nil
+	}
+
 	return &http.Client{
 		Transport: &http.Transport{
 			// options lifted from https://golang.org/src/net/http/transport.go
-			Proxy: http.ProxyFromEnvironment,
-			Dial: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: cfg.keepAlive,
-			}).Dial,
+			Proxy:                 proxy,
+			Dial:                  dial,
 			TLSHandshakeTimeout:   10 * time.Second,
 			ExpectContinueTimeout: 1 * time.Second,
 			MaxIdleConnsPerHost:   cfg.maxIdleConnsPerHost,
@@ -119,9 +136,13 @@ type Transport struct {
 	lock sync.Mutex
 	once intsync.LifecycleOnce
 
+	config transportConfig
 	client *http.Client
 	peers  map[string]*hostport.Peer
 
+	// Clients for peers listening on Unix domain sockets, by socket path.
+	socketClients map[string]*http.Client
+
 	tracer opentracing.Tracer
 }
 
@@ -147,12 +168,20 @@ func (a *Transport) IsRunning() bool {
 }
 
 // RetainPeer gets or creates a Peer for the specified peer.Subscriber (usually a peer.Chooser)
+//
+// Peers may be identified by a hostport.PeerIdentifier, or by a
+// unixsocket.PeerIdentifier for peers listening on Unix domain sockets.
 func (a *Transport) RetainPeer(pid peer.Identifier, sub peer.Subscriber) (peer.Peer, error) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 
-	hppid, ok := pid.(hostport.PeerIdentifier)
-	if !ok {
+	var hppid hostport.PeerIdentifier
+	switch pid := pid.(type) {
+	case hostport.PeerIdentifier:
+		hppid = pid
+	case unixsocket.PeerIdentifier:
+		hppid = hostport.PeerIdentifier(pid.Identifier())
+	default:
 		return nil, peer.ErrInvalidPeerType{
 			ExpectedType:   "hostport.PeerIdentifier",
 			PeerIdentifier: pid,
@@ -172,6 +201,9 @@ func (a *Transport) getOrCreatePeer(pid hostport.PeerIdentifier) *hostport.Peer
 
 	p := hostport.NewPeer(pid, a)
 	p.SetStatus(peer.Available)
+	if path, ok := unixsocket.ParseAddress(p.HostPort()); ok {
+		a.socketClients[path] = buildClient(&a.config, path)
+	}
 
 	a.peers[p.Identifier()] = p
 
@@ -197,7 +229,27 @@ func (a *Transport) ReleasePeer(pid peer.Identifier, sub peer.Subscriber) error
 
 	if p.NumSubscribers() == 0 {
 		delete(a.peers, pid.Identifier())
+		if path, ok := unixsocket.ParseAddress(p.HostPort()); ok {
+			a.socketClients[path].Transport.(*http.Transport).CloseIdleConnections()
+			delete(a.socketClients, path)
+		}
 	}
 
 	return nil
 }
+
+// clientFor returns the HTTP client used to send requests to the given peer.
+func (a *Transport) clientFor(p *hostport.Peer) *http.Client {
+	path, ok := unixsocket.ParseAddress(p.HostPort())
+	if !ok {
+		return a.client
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if client, ok := a.socketClients[path]; ok {
+		return client
+	}
+	// The peer was released while a request to it was in flight.
+	return buildClient(&a.config, path)
+}
@@ -21,15 +21,18 @@
 package http
 
 import (
+	"net/http"
 	"testing"
 	"time"
 
 	"go.uber.org/yarpc/api/peer"
 	. "go.uber.org/yarpc/api/peer/peertest"
 	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/unixsocket"
 
 	"github.com/crossdock/crossdock-go/assert"
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
 )
 
 type peerExpectation struct {
@@ -276,6 +279,28 @@ func TestTransportRetainWithInvalidPeerIdentifierType(t *testing.T) {
 	assert.Equal(t, expectedErr, err, "did not return error on invalid peer identifier")
 }
 
+func TestTransportRetainUnixSocketPeer(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	transport := NewTransport()
+	pid := unixsocket.PeerIdentifier("/var/run/proxy.sock")
+	sub := NewMockSubscriber(mockCtrl)
+
+	p, err := transport.RetainPeer(pid, sub)
+	require.NoError(t, err)
+	assert.Equal(t, "unix:///var/run/proxy.sock", p.Identifier())
+	assert.Contains(t, transport.socketClients, "/var/run/proxy.sock")
+	assert.True(t, transport.clientFor(p.(*hostport.Peer)) != transport.client,
+		"socket peers must not use the shared client")
+	assert.Nil(t, transport.socketClients["/var/run/proxy.sock"].Transport.(*http.Transport).Proxy,
+		"requests to sockets must not use proxies")
+	assert.NotNil(t, transport.client.Transport.(*http.Transport).Proxy)
+
+	require.NoError(t, transport.ReleasePeer(pid, sub))
+	assert.Empty(t, transport.socketClients, "client must be released with the peer")
+}
+
 func TestTransportClient(t *testing.T) {
 	transport := NewTransport()
 
@@ -22,6 +22,7 @@ package tchannel
 
 import (
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodylimit"
 	"go.uber.org/yarpc/internal/introspection"
 	"go.uber.org/yarpc/internal/sync"
 )
@@ -33,6 +34,8 @@ import (
 type ChannelInbound struct {
 	transport *ChannelTransport
 
+	requestLimits *bodylimit.Limits
+
 	once sync.LifecycleOnce
 }
 
@@ -44,10 +47,11 @@ type ChannelInbound struct {
 // There should only be one inbound for TChannel since all outbounds send the
 // listening port over non-ephemeral connections so a service can deduplicate
 // locally- and remotely-initiated persistent connections.
-func (t *ChannelTransport) NewInbound() *ChannelInbound {
+func (t *ChannelTransport) NewInbound(opts ...InboundOption) *ChannelInbound {
 	return &ChannelInbound{
-		once:      sync.Once(),
-		transport: t,
+		once:          sync.Once(),
+		transport:     t,
+		requestLimits: inboundRequestLimits(t.requestLimits, opts),
 	}
 }
 
@@ -56,6 +60,7 @@ func (t *ChannelTransport) NewInbound() *ChannelInbound {
 // by a dispatcher when it starts.
 func (i *ChannelInbound) SetRouter(router transport.Router) {
 	i.transport.router = router
+	i.transport.requestLimits = i.requestLimits
 }
 
 // Transports returns a slice containing the ChannelInbound's underlying
@@ -90,8 +95,9 @@ func (i *ChannelInbound) IsRunning() bool {
 func (i *ChannelInbound) Introspect() introspection.InboundStatus {
 	c := i.transport.Channel()
 	return introspection.InboundStatus{
-		Transport: "tchannel",
-		Endpoint:  i.transport.ListenAddr(),
-		State:     c.State().String(),
+		Transport:         "tchannel",
+		Endpoint:          i.transport.ListenAddr(),
+		State:             c.State().String(),
+		OversizedRequests: i.requestLimits.Exceeded(),
 	}
 }
@@ -23,8 +23,10 @@ package tchannel
 import (
 	"context"
 	"io"
+	"time"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodylimit"
 	"go.uber.org/yarpc/internal/encoding"
 	"go.uber.org/yarpc/internal/errors"
 	"go.uber.org/yarpc/internal/introspection"
@@ -36,27 +38,30 @@ import (
 
 var (
 	_ transport.UnaryOutbound              = (*ChannelOutbound)(nil)
+	_ transport.OnewayOutbound             = (*ChannelOutbound)(nil)
 	_ introspection.IntrospectableOutbound = (*ChannelOutbound)(nil)
 )
 
 // NewOutbound builds a new TChannel outbound using the transport's shared
 // channel to make requests to any connected peer.
-func (t *ChannelTransport) NewOutbound() *ChannelOutbound {
+func (t *ChannelTransport) NewOutbound(opts ...OutboundOption) *ChannelOutbound {
 	return &ChannelOutbound{
-		once:      sync.Once(),
-		channel:   t.ch,
-		transport: t,
+		once:           sync.Once(),
+		channel:        t.ch,
+		transport:      t,
+		responseLimits: outboundResponseLimits(t.responseLimits, opts),
 	}
 }
 
 // NewSingleOutbound builds a new TChannel outbound using the transport's shared
 // channel to a specific peer.
-func (t *ChannelTransport) NewSingleOutbound(addr string) *ChannelOutbound {
+func (t *ChannelTransport) NewSingleOutbound(addr string, opts ...OutboundOption) *ChannelOutbound {
 	return &ChannelOutbound{
-		once:      sync.Once(),
-		channel:   t.ch,
-		transport: t,
-		addr:      addr,
+		once:           sync.Once(),
+		channel:        t.ch,
+		transport:      t,
+		addr:           addr,
+		responseLimits: outboundResponseLimits(t.responseLimits, opts),
 	}
 }
 
@@ -73,6 +78,8 @@ type ChannelOutbound struct {
 	// Otherwise, the global peer list of the Channel will be used.
 	addr string
 
+	responseLimits *bodylimit.Limits
+
 	once sync.LifecycleOnce
 }
 
@@ -184,11 +191,26 @@ func (o *ChannelOutbound) Call(ctx context.Context, req *transport.Request) (*tr
 
 	return &transport.Response{
 		Headers:          headers,
-		Body:             resBody,
+		Body:             limitResponseBody(o.responseLimits, req, resBody),
 		ApplicationError: res.ApplicationError(),
 	}, nil
 }
 
+// CallOneway sends a oneway RPC over this TChannel outbound. It returns as
+// soon as the server acknowledges receipt of the request; the request is
+// handled in the background.
+func (o *ChannelOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	res, err := o.Call(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := res.Body.Close(); err != nil {
+		return nil, err
+	}
+	return time.Now(), nil
+}
+
 // Introspect returns basic status about this outbound.
 func (o *ChannelOutbound) Introspect() introspection.OutboundStatus {
 	state := "Stopped"
@@ -196,9 +218,10 @@ func (o *ChannelOutbound) Introspect() introspection.OutboundStatus {
 		state = "Running"
 	}
 	return introspection.OutboundStatus{
-		Transport: "tchannel",
-		Endpoint:  o.addr,
-		State:     state,
+		Transport:          "tchannel",
+		Endpoint:           o.addr,
+		State:              state,
+		OversizedResponses: o.responseLimits.Exceeded(),
 	}
 }
 
@@ -226,6 +226,67 @@ func TestChannelCallSuccess(t *testing.T) {
 	}
 }
 
+func TestChannelCallOneway(t *testing.T) {
+	server := testutils.NewServer(t, nil)
+	defer server.Close()
+	serverHostPort := server.PeerInfo().HostPort
+
+	server.GetSubChannel("service").SetHandler(tchannel.HandlerFunc(
+		func(ctx context.Context, call *tchannel.InboundCall) {
+			headers, body, err := readArgs(call)
+			if assert.NoError(t, err, "failed to read request") {
+				assert.Equal(t, []byte{0x00, 0x00}, headers)
+				assert.Equal(t, []byte("world"), body)
+			}
+
+			if call.MethodString() == "fail" {
+				call.Response().SendSystemError(tchannel.NewSystemError(
+					tchannel.ErrCodeBadRequest, "unknown method"))
+				return
+			}
+
+			err = writeArgs(call.Response(), []byte{0x00, 0x00}, []byte{})
+			assert.NoError(t, err, "failed to write response")
+		}))
+
+	for _, constructor := range constructors {
+		t.Run(constructor.desc, func(t *testing.T) {
+			unary, err := constructor.new(testutils.NewClient(t, &testutils.ChannelOpts{
+				ServiceName: "caller",
+			}), serverHostPort)
+			require.NoError(t, err)
+			out, ok := unary.(transport.OnewayOutbound)
+			require.True(t, ok, "outbound must support oneway requests")
+			require.NoError(t, out.Start(), "failed to start outbound")
+			defer out.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			ack, err := out.CallOneway(ctx, &transport.Request{
+				Caller:    "caller",
+				Service:   "service",
+				Encoding:  raw.Encoding,
+				Procedure: "hello",
+				Body:      bytes.NewReader([]byte("world")),
+			})
+			require.NoError(t, err, "failed to make call")
+			assert.NotNil(t, ack)
+
+			_, err = out.CallOneway(ctx, &transport.Request{
+				Caller:    "caller",
+				Service:   "service",
+				Encoding:  raw.Encoding,
+				Procedure: "fail",
+				Body:      bytes.NewReader([]byte("world")),
+			})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), "unknown method")
+			}
+		})
+	}
+}
+
 func TestChannelCallFailures(t *testing.T) {
 	server := testutils.NewServer(t, nil)
 	defer server.Close()
@@ -24,6 +24,7 @@ import (
 	"errors"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodylimit"
 	"go.uber.org/yarpc/internal/sync"
 
 	"github.com/opentracing/opentracing-go"
@@ -73,6 +74,9 @@ func NewChannelTransport(opts ...TransportOption) (*ChannelTransport, error) {
 		ch:     ch,
 		addr:   config.addr,
 		tracer: config.tracer,
+
+		requestLimits:  &config.requestLimits,
+		responseLimits: &config.responseLimits,
 	}, err
 }
 
@@ -87,6 +91,9 @@ type ChannelTransport struct {
 	tracer opentracing.Tracer
 	router transport.Router
 
+	requestLimits  *bodylimit.Limits
+	responseLimits *bodylimit.Limits
+
 	once sync.LifecycleOnce
 }
 
@@ -122,7 +129,7 @@ func (t *ChannelTransport) start() error {
 		for s := range services {
 			sc := t.ch.GetSubChannel(s)
 			existing := sc.GetHandlers()
-			sc.SetHandler(handler{existing: existing, router: t.router, tracer: t.tracer})
+			sc.SetHandler(handler{existing: existing, router: t.router, tracer: t.tracer, limits: t.requestLimits})
 		}
 	}
 
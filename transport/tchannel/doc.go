@@ -19,7 +19,8 @@
 // THE SOFTWARE.
 
 // Package tchannel implements a YARPC transport based on the TChannel
-// protocol. The TChannel transport provides support for Unary RPCs only.
+// protocol. The TChannel transport provides support for Unary and Oneway
+// RPCs.
 //
 // Usage
 //
@@ -54,4 +55,15 @@
 // 			{Unary: myserviceOutbound},
 // 		},
 // 	})
+//
+// TChannel outbounds may also be used for Oneway RPCs. Oneway requests are
+// acknowledged by the server as soon as they are received, and handled in
+// the background.
+//
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		Outbounds: yarpc.Outbounds{
+// 			"otherservice": {Oneway: myserviceOutbound},
+// 		},
+// 	})
 package tchannel
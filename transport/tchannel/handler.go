@@ -21,13 +21,16 @@
 package tchannel
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"time"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodylimit"
 	"go.uber.org/yarpc/internal/encoding"
 	"go.uber.org/yarpc/internal/errors"
+	"go.uber.org/yarpc/internal/iopool"
 	"go.uber.org/yarpc/internal/request"
 
 	"github.com/opentracing/opentracing-go"
@@ -44,6 +47,9 @@ type inboundCall interface {
 	ServiceName() string
 	CallerName() string
 	MethodString() string
+	ShardKey() string
+	RoutingKey() string
+	RoutingDelegate() string
 	Format() tchannel.Format
 
 	Arg2Reader() (tchannel.ArgReader, error)
@@ -75,11 +81,13 @@ func (c tchannelCall) Response() inboundCallResponse {
 	return c.InboundCall.Response()
 }
 
-// handler wraps a transport.UnaryHandler into a TChannel Handler.
+// handler wraps transport.UnaryHandlers and transport.OnewayHandlers into a
+// TChannel Handler.
 type handler struct {
 	existing map[string]tchannel.Handler
 	router   transport.Router
 	tracer   opentracing.Tracer
+	limits   *bodylimit.Limits
 }
 
 func (h handler) Handle(ctx ncontext.Context, call *tchannel.InboundCall) {
@@ -118,10 +126,13 @@ func (h handler) callHandler(ctx context.Context, call inboundCall, start time.T
 	}
 
 	treq := &transport.Request{
-		Caller:    call.CallerName(),
-		Service:   call.ServiceName(),
-		Encoding:  transport.Encoding(call.Format()),
-		Procedure: call.MethodString(),
+		Caller:          call.CallerName(),
+		Service:         call.ServiceName(),
+		Encoding:        transport.Encoding(call.Format()),
+		Procedure:       call.MethodString(),
+		ShardKey:        call.ShardKey(),
+		RoutingKey:      call.RoutingKey(),
+		RoutingDelegate: call.RoutingDelegate(),
 	}
 
 	ctx, headers, err := readRequestHeaders(ctx, call.Format(), call.Arg2Reader)
@@ -135,11 +146,21 @@ func (h handler) callHandler(ctx context.Context, call inboundCall, start time.T
 		ctx = tchannel.ExtractInboundSpan(ctx, tcall.InboundCall, headers.Items(), tracer)
 	}
 
-	body, err := call.Arg3Reader()
+	arg3, err := call.Arg3Reader()
 	if err != nil {
 		return err
 	}
-	defer body.Close()
+	defer arg3.Close()
+
+	// Reject bodies that are too large before they reach the handler, and
+	// report the failure even if the handler wrapped the read error.
+	tooLarge := errors.HandlerBadRequestError(errors.BodyTooLargeError{
+		Kind:      "request",
+		Service:   treq.Service,
+		Procedure: treq.Procedure,
+		Limit:     h.limits.For(treq.Procedure),
+	})
+	body := h.limits.Reader(treq.Procedure, arg3, tooLarge)
 	treq.Body = body
 
 	rw := newResponseWriter(treq, call)
@@ -170,13 +191,54 @@ func (h handler) callHandler(ctx context.Context, call inboundCall, start time.T
 		}
 		err = transport.DispatchUnaryHandler(ctx, spec.Unary(), start, treq, rw)
 
+	case transport.Oneway:
+		err = handleOnewayRequest(ctx, treq, spec.Oneway(), rw)
+
 	default:
 		err = errors.UnsupportedTypeError{Transport: "TChannel", Type: spec.Type().String()}
 	}
 
+	if err != nil && body.Exceeded() {
+		err = tooLarge
+	}
 	return err
 }
 
+// handleOnewayRequest acknowledges a oneway request with an empty response
+// and calls its handler in the background.
+func handleOnewayRequest(
+	ctx context.Context,
+	treq *transport.Request,
+	onewayHandler transport.OnewayHandler,
+	rw *responseWriter,
+) error {
+	// we will lose access to the body unless we read all the bytes before
+	// returning from the request
+	var buff bytes.Buffer
+	if _, err := iopool.Copy(&buff, treq.Body); err != nil {
+		return err
+	}
+	treq.Body = &buff
+
+	// The acknowledgement is sent with an empty body once the call returns.
+	if _, err := rw.Write(nil); err != nil {
+		return err
+	}
+
+	// create a new context for oneway requests since TChannel cancels the
+	// context of the call when its handler returns
+	onewayCtx := context.Background()
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		onewayCtx = opentracing.ContextWithSpan(onewayCtx, span)
+	}
+
+	go func() {
+		// TODO: log error
+		_ = transport.DispatchOnewayHandler(onewayCtx, onewayHandler, treq)
+	}()
+	return nil
+}
+
 type responseWriter struct {
 	treq         *transport.Request
 	failedWith   error
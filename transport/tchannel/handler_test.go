@@ -25,6 +25,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"testing"
 	"time"
 
@@ -32,6 +33,7 @@ import (
 	"go.uber.org/yarpc/api/transport/transporttest"
 	"go.uber.org/yarpc/encoding/json"
 	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/bodylimit"
 	"go.uber.org/yarpc/internal/encoding"
 	"go.uber.org/yarpc/internal/routertest"
 
@@ -83,12 +85,15 @@ func TestHandlerErrors(t *testing.T) {
 			transporttest.NewContextMatcher(t),
 			transporttest.NewRequestMatcher(t,
 				&transport.Request{
-					Caller:    "caller",
-					Service:   "service",
-					Headers:   transport.HeadersFromMap(tt.wantHeaders),
-					Encoding:  transport.Encoding(tt.format),
-					Procedure: "hello",
-					Body:      bytes.NewReader([]byte("world")),
+					Caller:          "caller",
+					Service:         "service",
+					Headers:         transport.HeadersFromMap(tt.wantHeaders),
+					Encoding:        transport.Encoding(tt.format),
+					Procedure:       "hello",
+					ShardKey:        "shard",
+					RoutingKey:      "routekey",
+					RoutingDelegate: "routedelegate",
+					Body:            bytes.NewReader([]byte("world")),
 				}),
 			gomock.Any(),
 		).Return(nil)
@@ -98,19 +103,75 @@ func TestHandlerErrors(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 		defer cancel()
 		tchHandler.handle(ctx, &fakeInboundCall{
-			service: "service",
-			caller:  "caller",
-			format:  tt.format,
-			method:  "hello",
-			arg2:    tt.headers,
-			arg3:    []byte("world"),
-			resp:    respRecorder,
+			service:         "service",
+			caller:          "caller",
+			format:          tt.format,
+			method:          "hello",
+			shardKey:        "shard",
+			routingKey:      "routekey",
+			routingDelegate: "routedelegate",
+			arg2:            tt.headers,
+			arg3:            []byte("world"),
+			resp:            respRecorder,
 		})
 
 		assert.NoError(t, respRecorder.systemErr, "did not expect an error")
 	}
 }
 
+func TestHandlerOneway(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	onewayHandler := transporttest.NewMockOnewayHandler(mockCtrl)
+	router := transporttest.NewMockRouter(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), routertest.NewMatcher().
+		WithService("service").
+		WithProcedure("hello"),
+	).Return(transport.NewOnewayHandlerSpec(onewayHandler), nil)
+
+	handled := make(chan struct{})
+	onewayHandler.EXPECT().HandleOneway(
+		gomock.Any(),
+		transporttest.NewRequestMatcher(t,
+			&transport.Request{
+				Caller:    "caller",
+				Service:   "service",
+				Encoding:  raw.Encoding,
+				Procedure: "hello",
+				Body:      bytes.NewReader([]byte("world")),
+			}),
+	).Do(func(ctx context.Context, _ *transport.Request) {
+		// The handler must not be affected by the end of the call.
+		assert.NoError(t, ctx.Err())
+		close(handled)
+	}).Return(nil)
+
+	respRecorder := newResponseRecorder()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	handler{router: router}.handle(ctx, &fakeInboundCall{
+		service: "service",
+		caller:  "caller",
+		format:  tchannel.Raw,
+		method:  "hello",
+		arg2:    []byte{0x00, 0x00},
+		arg3:    []byte("world"),
+		resp:    respRecorder,
+	})
+	cancel()
+
+	assert.NoError(t, respRecorder.systemErr, "did not expect an error")
+	assert.Equal(t, []byte{0x00, 0x00}, respRecorder.arg2.Bytes())
+	assert.Empty(t, respRecorder.arg3.Bytes())
+
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("oneway handler was not called")
+	}
+}
+
 func TestHandlerFailures(t *testing.T) {
 	tests := []struct {
 		desc string
@@ -362,6 +423,44 @@ func TestHandlerFailures(t *testing.T) {
 	}
 }
 
+func TestHandlerBodyTooLarge(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	limits := bodylimit.Limits{Default: 4}
+
+	unaryHandler := transporttest.NewMockUnaryHandler(mockCtrl)
+	unaryHandler.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).Do(
+		func(_ context.Context, req *transport.Request, _ transport.ResponseWriter) {
+			_, err := ioutil.ReadAll(req.Body)
+			assert.Error(t, err)
+		}).Return(errors.New("failed to read request body"))
+
+	router := transporttest.NewMockRouter(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+		Return(transport.NewUnaryHandlerSpec(unaryHandler), nil)
+
+	resp := newResponseRecorder()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	handler{router: router, limits: &limits}.handle(ctx, &fakeInboundCall{
+		service: "service",
+		caller:  "caller",
+		format:  tchannel.Raw,
+		method:  "hello",
+		arg2:    []byte{0x00, 0x00},
+		arg3:    []byte("hello world"),
+		resp:    resp,
+	})
+
+	systemErr, ok := resp.systemErr.(tchannel.SystemError)
+	require.True(t, ok, "expected a system error, got %v", resp.systemErr)
+	assert.Equal(t, tchannel.ErrCodeBadRequest, systemErr.Code())
+	assert.Contains(t, systemErr.Error(),
+		`request body for procedure "hello" of service "service" exceeds the limit of 4 bytes`)
+	assert.Equal(t, int64(1), limits.Exceeded())
+}
+
 func TestResponseWriter(t *testing.T) {
 	tests := []struct {
 		format           tchannel.Format
@@ -22,6 +22,7 @@ package tchannel
 
 import (
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodylimit"
 	"go.uber.org/yarpc/internal/introspection"
 	"go.uber.org/yarpc/internal/sync"
 )
@@ -31,6 +32,8 @@ import (
 type Inbound struct {
 	once      sync.LifecycleOnce
 	transport *Transport
+
+	requestLimits *bodylimit.Limits
 }
 
 // NewInbound returns a new TChannel inbound backed by a shared TChannel
@@ -38,10 +41,11 @@ type Inbound struct {
 // There should only be one inbound for TChannel since all outbounds send the
 // listening port over non-ephemeral connections so a service can deduplicate
 // locally- and remotely-initiated persistent connections.
-func (t *Transport) NewInbound() *Inbound {
+func (t *Transport) NewInbound(opts ...InboundOption) *Inbound {
 	return &Inbound{
-		once:      sync.Once(),
-		transport: t,
+		once:          sync.Once(),
+		transport:     t,
+		requestLimits: inboundRequestLimits(t.requestLimits, opts),
 	}
 }
 
@@ -50,6 +54,7 @@ func (t *Transport) NewInbound() *Inbound {
 // by a dispatcher when it starts.
 func (i *Inbound) SetRouter(router transport.Router) {
 	i.transport.router = router
+	i.transport.requestLimits = i.requestLimits
 }
 
 // Transports returns a slice containing the Inbound's underlying
@@ -78,8 +83,9 @@ func (i *Inbound) IsRunning() bool {
 // Introspect returns the state of the inbound for introspection purposes.
 func (i *Inbound) Introspect() introspection.InboundStatus {
 	return introspection.InboundStatus{
-		Transport: "tchannel",
-		Endpoint:  i.transport.addr,
-		State:     i.transport.ch.State().String(),
+		Transport:         "tchannel",
+		Endpoint:          i.transport.addr,
+		State:             i.transport.ch.State().String(),
+		OversizedRequests: i.requestLimits.Exceeded(),
 	}
 }
@@ -194,3 +194,27 @@ func TestArbitraryInboundServiceOutboundCallerName(t *testing.T) {
 	require.NoError(t, i.Stop())
 	require.NoError(t, o.Stop())
 }
+
+func TestInboundMaxRequestSize(t *testing.T) {
+	x, err := NewTransport(ServiceName("foo"), MaxRequestSize(10), ProcedureMaxRequestSize("big", 20))
+	require.NoError(t, err)
+
+	i := x.NewInbound()
+	assert.True(t, i.requestLimits == x.requestLimits, "inbounds without options must share the transport's limits")
+
+	i = x.NewInbound(InboundMaxRequestSize(5), InboundProcedureMaxRequestSize("small", 1))
+	assert.Equal(t, int64(5), i.requestLimits.For("foo"))
+	assert.Equal(t, int64(20), i.requestLimits.For("big"))
+	assert.Equal(t, int64(1), i.requestLimits.For("small"))
+	assert.Equal(t, int64(10), x.requestLimits.For("foo"), "transport limits must not change")
+
+	i.SetRouter(yarpc.NewMapRouter("foo"))
+	assert.True(t, i.requestLimits == x.requestLimits, "the transport must use the inbound's limits")
+
+	ct, err := NewChannelTransport(ServiceName("foo"), MaxRequestSize(10))
+	require.NoError(t, err)
+	ci := ct.NewInbound(InboundMaxRequestSize(5))
+	assert.Equal(t, int64(5), ci.requestLimits.For("foo"))
+	ci.SetRouter(yarpc.NewMapRouter("foo"))
+	assert.True(t, ci.requestLimits == ct.requestLimits, "the transport must use the inbound's limits")
+}
@@ -20,7 +20,11 @@
 
 package tchannel
 
-import "github.com/opentracing/opentracing-go"
+import (
+	"go.uber.org/yarpc/internal/bodylimit"
+
+	"github.com/opentracing/opentracing-go"
+)
 
 // transportConfig is suitable for conveying options to TChannel transport
 // constructors.
@@ -35,6 +39,9 @@ type transportConfig struct {
 	tracer opentracing.Tracer
 	addr   string
 	name   string
+
+	requestLimits  bodylimit.Limits
+	responseLimits bodylimit.Limits
 }
 
 // TransportOption customizes the behavior of a TChannel Transport.
@@ -93,3 +100,124 @@ func ServiceName(name string) TransportOption {
 		t.name = name
 	}
 }
+
+// MaxRequestSize limits the size of request bodies accepted by the
+// transport's inbound to the given number of bytes. Requests with larger
+// bodies are rejected with a bad request error once the limit is exceeded,
+// so handlers never see more than this many bytes. Bodies are not limited by
+// default.
+func MaxRequestSize(bytes int64) TransportOption {
+	return func(t *transportConfig) {
+		t.requestLimits.Default = bytes
+	}
+}
+
+// ProcedureMaxRequestSize limits the size of request bodies for the given
+// procedure, overriding MaxRequestSize. A limit of zero disables the limit
+// for the procedure.
+func ProcedureMaxRequestSize(procedure string, bytes int64) TransportOption {
+	return func(t *transportConfig) {
+		t.requestLimits.SetProcedure(procedure, bytes)
+	}
+}
+
+// MaxResponseSize limits the size of response bodies accepted by the
+// transport's outbounds to the given number of bytes. Reading a larger
+// response body fails with an error once the limit is exceeded. Bodies are
+// not limited by default.
+func MaxResponseSize(bytes int64) TransportOption {
+	return func(t *transportConfig) {
+		t.responseLimits.Default = bytes
+	}
+}
+
+// ProcedureMaxResponseSize limits the size of response bodies for the given
+// procedure, overriding MaxResponseSize. A limit of zero disables the limit
+// for the procedure.
+func ProcedureMaxResponseSize(procedure string, bytes int64) TransportOption {
+	return func(t *transportConfig) {
+		t.responseLimits.SetProcedure(procedure, bytes)
+	}
+}
+
+type inboundConfig struct {
+	requestLimits *bodylimit.Limits
+}
+
+// InboundOption customizes the behavior of a TChannel Inbound or
+// ChannelInbound.
+type InboundOption func(*inboundConfig)
+
+// InboundMaxRequestSize limits the size of request bodies accepted by the
+// inbound to the given number of bytes, overriding the MaxRequestSize
+// option of the transport.
+func InboundMaxRequestSize(bytes int64) InboundOption {
+	return func(c *inboundConfig) {
+		c.requestLimits.Default = bytes
+	}
+}
+
+// InboundProcedureMaxRequestSize limits the size of request bodies accepted
+// by the inbound for the given procedure, overriding InboundMaxRequestSize
+// and the limits of the transport. A limit of zero disables the limit for
+// the procedure.
+func InboundProcedureMaxRequestSize(procedure string, bytes int64) InboundOption {
+	return func(c *inboundConfig) {
+		c.requestLimits.SetProcedure(procedure, bytes)
+	}
+}
+
+// inboundRequestLimits returns the request limits of an inbound built with
+// the given options. Inbounds without options share the limits of their
+// transport.
+func inboundRequestLimits(transportLimits *bodylimit.Limits, opts []InboundOption) *bodylimit.Limits {
+	if len(opts) == 0 {
+		return transportLimits
+	}
+	c := inboundConfig{requestLimits: transportLimits.Copy()}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c.requestLimits
+}
+
+type outboundConfig struct {
+	responseLimits *bodylimit.Limits
+}
+
+// OutboundOption customizes the behavior of a TChannel Outbound or
+// ChannelOutbound.
+type OutboundOption func(*outboundConfig)
+
+// OutboundMaxResponseSize limits the size of response bodies accepted by
+// the outbound to the given number of bytes, overriding the MaxResponseSize
+// option of the transport.
+func OutboundMaxResponseSize(bytes int64) OutboundOption {
+	return func(c *outboundConfig) {
+		c.responseLimits.Default = bytes
+	}
+}
+
+// OutboundProcedureMaxResponseSize limits the size of response bodies
+// accepted by the outbound for the given procedure, overriding
+// OutboundMaxResponseSize and the limits of the transport. A limit of zero
+// disables the limit for the procedure.
+func OutboundProcedureMaxResponseSize(procedure string, bytes int64) OutboundOption {
+	return func(c *outboundConfig) {
+		c.responseLimits.SetProcedure(procedure, bytes)
+	}
+}
+
+// outboundResponseLimits returns the response limits of an outbound built
+// with the given options. Outbounds without options share the limits of
+// their transport.
+func outboundResponseLimits(transportLimits *bodylimit.Limits, opts []OutboundOption) *bodylimit.Limits {
+	if len(opts) == 0 {
+		return transportLimits
+	}
+	c := outboundConfig{responseLimits: transportLimits.Copy()}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c.responseLimits
+}
@@ -22,10 +22,14 @@ package tchannel
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodylimit"
 	"go.uber.org/yarpc/internal/encoding"
+	"go.uber.org/yarpc/internal/errors"
 	"go.uber.org/yarpc/internal/introspection"
 	intsync "go.uber.org/yarpc/internal/sync"
 	peerchooser "go.uber.org/yarpc/peer"
@@ -36,6 +40,7 @@ import (
 
 var (
 	_ transport.UnaryOutbound              = (*Outbound)(nil)
+	_ transport.OnewayOutbound             = (*Outbound)(nil)
 	_ introspection.IntrospectableOutbound = (*Outbound)(nil)
 )
 
@@ -46,23 +51,26 @@ type Outbound struct {
 	transport *Transport
 	chooser   peer.Chooser
 	once      intsync.LifecycleOnce
+
+	responseLimits *bodylimit.Limits
 }
 
 // NewOutbound builds a new TChannel outbound that selects a peer for each
 // request using the given peer chooser.
-func (t *Transport) NewOutbound(chooser peer.Chooser) *Outbound {
+func (t *Transport) NewOutbound(chooser peer.Chooser, opts ...OutboundOption) *Outbound {
 	return &Outbound{
-		once:      intsync.Once(),
-		transport: t,
-		chooser:   chooser,
+		once:           intsync.Once(),
+		transport:      t,
+		chooser:        chooser,
+		responseLimits: outboundResponseLimits(t.responseLimits, opts),
 	}
 }
 
 // NewSingleOutbound builds a new TChannel outbound always using the peer with
 // the given address.
-func (t *Transport) NewSingleOutbound(addr string) *Outbound {
+func (t *Transport) NewSingleOutbound(addr string, opts ...OutboundOption) *Outbound {
 	chooser := peerchooser.NewSingle(hostport.PeerIdentifier(addr), t)
-	return t.NewOutbound(chooser)
+	return t.NewOutbound(chooser, opts...)
 }
 
 // Call sends an RPC over this TChannel outbound.
@@ -81,6 +89,21 @@ func (o *Outbound) Call(ctx context.Context, req *transport.Request) (*transport
 	return res, err
 }
 
+// CallOneway sends a oneway RPC over this TChannel outbound. It returns as
+// soon as the server acknowledges receipt of the request; the request is
+// handled in the background.
+func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	res, err := o.Call(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := res.Body.Close(); err != nil {
+		return nil, err
+	}
+	return time.Now(), nil
+}
+
 // callWithPeer sends a request with the chosen peer.
 func (o *Outbound) callWithPeer(ctx context.Context, req *transport.Request, peer *tchannel.Peer) (*transport.Response, error) {
 	// NB(abg): Under the current API, the local service's name is required
@@ -148,7 +171,7 @@ func (o *Outbound) callWithPeer(ctx context.Context, req *transport.Request, pee
 
 	return &transport.Response{
 		Headers:          headers,
-		Body:             resBody,
+		Body:             limitResponseBody(o.responseLimits, req, resBody),
 		ApplicationError: res.ApplicationError(),
 	}, nil
 }
@@ -205,8 +228,20 @@ func (o *Outbound) Introspect() introspection.OutboundStatus {
 		}
 	}
 	return introspection.OutboundStatus{
-		Transport: "tchannel",
-		State:     state,
-		Chooser:   chooser,
+		Transport:          "tchannel",
+		State:              state,
+		Chooser:            chooser,
+		OversizedResponses: o.responseLimits.Exceeded(),
 	}
 }
+
+// limitResponseBody limits the size of the response body read for the given
+// request.
+func limitResponseBody(limits *bodylimit.Limits, req *transport.Request, body io.ReadCloser) io.ReadCloser {
+	return limits.Reader(req.Procedure, body, errors.BodyTooLargeError{
+		Kind:      "response",
+		Service:   req.Service,
+		Procedure: req.Procedure,
+		Limit:     limits.For(req.Procedure),
+	})
+}
@@ -187,6 +187,48 @@ func TestCallSuccess(t *testing.T) {
 	assert.NoError(t, res.Body.Close(), "failed to close response body")
 }
 
+func TestCallOneway(t *testing.T) {
+	server := testutils.NewServer(t, nil)
+	defer server.Close()
+	serverHostPort := server.PeerInfo().HostPort
+
+	server.GetSubChannel("service").SetHandler(tchannel.HandlerFunc(
+		func(ctx context.Context, call *tchannel.InboundCall) {
+			headers, body, err := readArgs(call)
+			if assert.NoError(t, err, "failed to read request") {
+				assert.Equal(t, []byte{0x00, 0x00}, headers)
+				assert.Equal(t, []byte("world"), body)
+			}
+
+			err = writeArgs(call.Response(), []byte{0x00, 0x00}, []byte{})
+			assert.NoError(t, err, "failed to write response")
+		}))
+
+	x, err := NewTransport(ServiceName("caller"))
+	require.NoError(t, err)
+	require.NoError(t, x.Start(), "failed to start transport")
+	defer x.Stop()
+
+	out := x.NewSingleOutbound(serverHostPort)
+	require.NoError(t, out.Start(), "failed to start outbound")
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	ack, err := out.CallOneway(
+		ctx,
+		&transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Encoding:  raw.Encoding,
+			Procedure: "hello",
+			Body:      bytes.NewReader([]byte("world")),
+		},
+	)
+	require.NoError(t, err, "failed to make call")
+	assert.NotNil(t, ack)
+}
+
 func TestCallFailures(t *testing.T) {
 	server := testutils.NewServer(t, nil)
 	defer server.Close()
@@ -394,3 +436,23 @@ func TestCallWithoutStarting(t *testing.T) {
 
 	assert.Equal(t, context.DeadlineExceeded, err)
 }
+
+func TestOutboundMaxResponseSize(t *testing.T) {
+	x, err := NewTransport(ServiceName("foo"), MaxResponseSize(10))
+	require.NoError(t, err)
+
+	o := x.NewSingleOutbound("127.0.0.1:4040")
+	assert.True(t, o.responseLimits == x.responseLimits, "outbounds without options must share the transport's limits")
+
+	o = x.NewSingleOutbound("127.0.0.1:4040",
+		OutboundMaxResponseSize(5), OutboundProcedureMaxResponseSize("big", 20))
+	assert.Equal(t, int64(5), o.responseLimits.For("foo"))
+	assert.Equal(t, int64(20), o.responseLimits.For("big"))
+	assert.Equal(t, int64(10), x.responseLimits.For("foo"), "transport limits must not change")
+
+	ct, err := NewChannelTransport(ServiceName("foo"), MaxResponseSize(10))
+	require.NoError(t, err)
+	co := ct.NewSingleOutbound("127.0.0.1:4040", OutboundMaxResponseSize(5))
+	assert.Equal(t, int64(5), co.responseLimits.For("foo"))
+	assert.Equal(t, int64(10), ct.NewOutbound().responseLimits.For("foo"))
+}
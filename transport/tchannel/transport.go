@@ -26,6 +26,7 @@ import (
 
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodylimit"
 	intsync "go.uber.org/yarpc/internal/sync"
 	"go.uber.org/yarpc/peer/hostport"
 
@@ -49,6 +50,9 @@ type Transport struct {
 	name   string
 	addr   string
 
+	requestLimits  *bodylimit.Limits
+	responseLimits *bodylimit.Limits
+
 	peers map[string]*hostport.Peer
 }
 
@@ -80,6 +84,9 @@ func NewTransport(opts ...TransportOption) (*Transport, error) {
 		addr:   config.addr,
 		tracer: config.tracer,
 		peers:  make(map[string]*hostport.Peer),
+
+		requestLimits:  &config.requestLimits,
+		responseLimits: &config.responseLimits,
 	}, nil
 }
 
@@ -159,6 +166,7 @@ func (t *Transport) start() error {
 		Handler: handler{
 			router: t.router,
 			tracer: t.tracer,
+			limits: t.requestLimits,
 		},
 	}
 	ch, err := tchannel.NewChannel(t.name, &chopts)
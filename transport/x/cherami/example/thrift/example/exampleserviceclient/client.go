@@ -50,6 +50,7 @@ func New(c transport.ClientConfig, opts ...thrift.ClientOption) Interface {
 			Service:      "ExampleService",
 			ClientConfig: c,
 		}, opts...),
+		interceptors: thrift.ClientInterceptors("ExampleService", opts...),
 	}
 }
 
@@ -62,7 +63,8 @@ func init() {
 }
 
 type client struct {
-	c thrift.Client
+	c            thrift.Client
+	interceptors thrift.Interceptors
 }
 
 func (c client) Award(
@@ -71,5 +73,5 @@ func (c client) Award(
 	opts ...yarpc.CallOption,
 ) (yarpc.Ack, error) {
 	args := example.ExampleService_Award_Helper.Args(_Token)
-	return c.c.CallOneway(ctx, args, opts...)
+	return c.interceptors.CallOneway(ctx, c.c, args, opts...)
 }
@@ -25,6 +25,7 @@ package exampleserviceserver
 
 import (
 	"context"
+	"go.uber.org/thriftrw/envelope"
 	"go.uber.org/thriftrw/wire"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/transport/x/cherami/example/thrift/example"
@@ -45,7 +46,7 @@ type Interface interface {
 // 	handler := ExampleServiceHandler{}
 // 	dispatcher.Register(exampleserviceserver.New(handler))
 func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
-	h := handler{impl}
+	h := handler{impl: impl, interceptors: thrift.ServerInterceptors(opts...)}
 	service := thrift.Service{
 		Name: "ExampleService",
 		Methods: []thrift.Method{
@@ -67,7 +68,10 @@ func New(impl Interface, opts ...thrift.RegisterOption) []transport.Procedure {
 	return procedures
 }
 
-type handler struct{ impl Interface }
+type handler struct {
+	impl         Interface
+	interceptors thrift.Interceptors
+}
 
 func (h handler) Award(ctx context.Context, body wire.Value) error {
 	var args example.ExampleService_Award_Args
@@ -75,5 +79,14 @@ func (h handler) Award(ctx context.Context, body wire.Value) error {
 		return err
 	}
 
+	return h.interceptors.HandleOneway(ctx, &args, h.handleAward)
+}
+
+func (h handler) handleAward(ctx context.Context, body envelope.Enveloper) error {
+	args, ok := body.(*example.ExampleService_Award_Args)
+	if !ok {
+		return thrift.CastError(args, body)
+	}
+
 	return h.impl.Award(ctx, args.Token)
 }
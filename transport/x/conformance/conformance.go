@@ -0,0 +1,433 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// Time allowed for a successful call.
+	callTimeout = time.Second
+
+	// Time after which the sleep handler gives up waiting for its context.
+	sleepLimit = 5 * time.Second
+
+	defaultLargeBodySize = 4 * 1024 * 1024
+	defaultConcurrency   = 50
+)
+
+// Setup describes how to build the inbounds and outbounds of the transport
+// under test.
+type Setup struct {
+	// NewInbound returns a new inbound. The suite sets its router and starts
+	// it along with its transports. Required.
+	NewInbound func(t *testing.T) transport.Inbound
+
+	// NewOutbound returns a new outbound which sends requests to the given
+	// inbound. The inbound has already been started. The suite starts the
+	// outbound along with its transports. Required.
+	//
+	// Unary tests are skipped if the outbound is not a
+	// transport.UnaryOutbound, and oneway tests are skipped if it is not a
+	// transport.OnewayOutbound.
+	NewOutbound func(t *testing.T, inbound transport.Inbound) transport.Outbound
+
+	// NewPeerIdentifier returns the identifier of the peer for the given
+	// inbound. If set, transports used by the outbound which implement
+	// peer.Transport are checked to retain and release that peer correctly.
+	NewPeerIdentifier func(inbound transport.Inbound) peer.Identifier
+
+	// Size of the body sent by the LargeBody test. Defaults to 4 MiB.
+	LargeBodySize int
+
+	// Number of calls made at the same time by the ConcurrentCalls test.
+	// Defaults to 50.
+	Concurrency int
+
+	// Names of tests to skip, for behavior the transport does not support.
+	Skip []string
+}
+
+// Run runs the conformance suite against the transport described by the
+// given Setup. Each test is run as a subtest with its own inbound and
+// outbound.
+func Run(t *testing.T, s Setup) {
+	require.NotNil(t, s.NewInbound, "Setup.NewInbound is required")
+	require.NotNil(t, s.NewOutbound, "Setup.NewOutbound is required")
+	if s.LargeBodySize <= 0 {
+		s.LargeBodySize = defaultLargeBodySize
+	}
+	if s.Concurrency <= 0 {
+		s.Concurrency = defaultConcurrency
+	}
+
+	skip := make(map[string]struct{}, len(s.Skip))
+	for _, name := range s.Skip {
+		skip[name] = struct{}{}
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := skip[tt.name]; ok {
+				t.Skipf("%v is skipped by the setup", tt.name)
+			}
+			tt.run(t, s)
+		})
+	}
+}
+
+var tests = []struct {
+	name string
+	run  func(*testing.T, Setup)
+}{
+	{"HeadersRoundTrip", testHeadersRoundTrip},
+	{"CanonicalHeaderKeys", testCanonicalHeaderKeys},
+	{"Deadline", testDeadline},
+	{"Timeout", testTimeout},
+	{"ApplicationError", testApplicationError},
+	{"BadRequest", testBadRequest},
+	{"UnrecognizedProcedure", testUnrecognizedProcedure},
+	{"Oneway", testOneway},
+	{"LargeBody", testLargeBody},
+	{"ConcurrentCalls", testConcurrentCalls},
+	{"Lifecycle", testLifecycle},
+	{"Peers", testPeers},
+}
+
+// env holds the started components of a single test.
+type env struct {
+	t          *testing.T
+	router     *router
+	inbound    transport.Inbound
+	outbound   transport.Outbound
+	transports []transport.Transport
+
+	// Whether Start and Stop are checked to be idempotent.
+	checkIdempotence bool
+}
+
+func (s Setup) start(t *testing.T, checkIdempotence bool) *env {
+	e := &env{t: t, router: newRouter(), checkIdempotence: checkIdempotence}
+
+	e.inbound = s.NewInbound(t)
+	e.inbound.SetRouter(e.router)
+	e.startTransports(e.inbound.Transports())
+	e.startLifecycle("inbound", e.inbound)
+
+	e.outbound = s.NewOutbound(t, e.inbound)
+	e.startTransports(e.outbound.Transports())
+	e.startLifecycle("outbound", e.outbound)
+	return e
+}
+
+func (e *env) startTransports(transports []transport.Transport) {
+	for _, trans := range transports {
+		var started bool
+		for _, other := range e.transports {
+			started = started || other == trans
+		}
+		if !started {
+			e.startLifecycle(fmt.Sprintf("transport %T", trans), trans)
+			e.transports = append(e.transports, trans)
+		}
+	}
+}
+
+func (e *env) startLifecycle(name string, l transport.Lifecycle) {
+	require.NoError(e.t, l.Start(), "failed to start %v", name)
+	if e.checkIdempotence {
+		require.NoError(e.t, l.Start(), "starting %v again must succeed", name)
+		assert.True(e.t, l.IsRunning(), "%v must be running after Start", name)
+	}
+}
+
+func (e *env) stop() {
+	e.stopLifecycle("outbound", e.outbound)
+	e.stopLifecycle("inbound", e.inbound)
+	for i := len(e.transports) - 1; i >= 0; i-- {
+		trans := e.transports[i]
+		e.stopLifecycle(fmt.Sprintf("transport %T", trans), trans)
+	}
+}
+
+func (e *env) stopLifecycle(name string, l transport.Lifecycle) {
+	assert.NoError(e.t, l.Stop(), "failed to stop %v", name)
+	if e.checkIdempotence {
+		assert.NoError(e.t, l.Stop(), "stopping %v again must succeed", name)
+		assert.False(e.t, l.IsRunning(), "%v must not be running after Stop", name)
+	}
+}
+
+func (e *env) unaryOutbound() transport.UnaryOutbound {
+	out, ok := e.outbound.(transport.UnaryOutbound)
+	if !ok {
+		e.t.Skipf("%T is not a unary outbound", e.outbound)
+	}
+	return out
+}
+
+func (e *env) call(procedure string, headers transport.Headers, body []byte) (*transport.Response, []byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	return e.callContext(ctx, procedure, headers, body)
+}
+
+func (e *env) callContext(ctx context.Context, procedure string, headers transport.Headers, body []byte) (*transport.Response, []byte, error) {
+	res, err := e.unaryOutbound().Call(ctx, newRequest(procedure, headers, body))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	resBody, err := ioutil.ReadAll(res.Body)
+	return res, resBody, err
+}
+
+func newRequest(procedure string, headers transport.Headers, body []byte) *transport.Request {
+	return &transport.Request{
+		Caller:    callerName,
+		Service:   serviceName,
+		Encoding:  "raw",
+		Procedure: procedure,
+		Headers:   headers,
+		Body:      bytes.NewReader(body),
+	}
+}
+
+func testHeadersRoundTrip(t *testing.T, s Setup) {
+	e := s.start(t, false)
+	defer e.stop()
+
+	headers := transport.NewHeaders().With("foo", "bar").With("baz", "qux")
+	res, body, err := e.call(echoProcedure, headers, []byte("hello"))
+	require.NoError(t, err, "call failed")
+	assert.Equal(t, "hello", string(body), "body must be echoed")
+	for k, v := range headers.Items() {
+		got, ok := res.Headers.Get(k)
+		if assert.True(t, ok, "response header %q is missing", k) {
+			assert.Equal(t, v, got, "response header %q does not match", k)
+		}
+	}
+}
+
+func testCanonicalHeaderKeys(t *testing.T, s Setup) {
+	e := s.start(t, false)
+	defer e.stop()
+
+	headers := transport.NewHeaders().With("X-Mixed-CASE", "value")
+	res, _, err := e.call(echoProcedure, headers, nil)
+	require.NoError(t, err, "call failed")
+
+	for k := range res.Headers.Items() {
+		assert.Equal(t, strings.ToLower(k), k, "header key %q must be lower case", k)
+	}
+	got, ok := res.Headers.Get("x-MIXED-case")
+	assert.True(t, ok, "header must be found regardless of case")
+	assert.Equal(t, "value", got)
+}
+
+func testDeadline(t *testing.T, s Setup) {
+	e := s.start(t, false)
+	defer e.stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	_, body, err := e.callContext(ctx, deadlineProcedure, transport.Headers{}, nil)
+	require.NoError(t, err, "call failed")
+	got, err := time.Parse(time.RFC3339Nano, string(body))
+	require.NoError(t, err, "handler responded with an invalid deadline")
+	// Allow for time spent in transit and TTL rounding.
+	assert.WithinDuration(t, deadline, got, transporttest.DefaultTTLDelta,
+		"handler deadline must match the caller deadline")
+}
+
+func testTimeout(t *testing.T, s Setup) {
+	e := s.start(t, false)
+	defer e.stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, _, err := e.callContext(ctx, sleepProcedure, transport.Headers{}, nil)
+	require.Error(t, err, "call must fail once its deadline passes")
+	assert.True(t, transport.IsTimeoutError(err), "expected a timeout error, got %v", err)
+}
+
+func testApplicationError(t *testing.T, s Setup) {
+	e := s.start(t, false)
+	defer e.stop()
+
+	res, body, err := e.call(appErrorProcedure, transport.Headers{}, nil)
+	require.NoError(t, err, "application errors must not fail the call")
+	assert.True(t, res.ApplicationError, "response must be marked as an application error")
+	assert.Equal(t, "application error", string(body), "body must be delivered")
+}
+
+func testBadRequest(t *testing.T, s Setup) {
+	e := s.start(t, false)
+	defer e.stop()
+
+	_, _, err := e.call(badRequestProcedure, transport.Headers{}, nil)
+	require.Error(t, err, "call must fail")
+	assert.True(t, transport.IsBadRequestError(err), "expected a bad request error, got %v", err)
+	assert.Contains(t, err.Error(), "malformed request", "error message must be delivered")
+}
+
+func testUnrecognizedProcedure(t *testing.T, s Setup) {
+	e := s.start(t, false)
+	defer e.stop()
+
+	_, _, err := e.call("no-such-procedure", transport.Headers{}, nil)
+	require.Error(t, err, "call must fail")
+	assert.True(t, transport.IsBadRequestError(err), "expected a bad request error, got %v", err)
+	assert.Contains(t, err.Error(), "no-such-procedure", "error must name the procedure")
+}
+
+func testOneway(t *testing.T, s Setup) {
+	e := s.start(t, false)
+	defer e.stop()
+
+	out, ok := e.outbound.(transport.OnewayOutbound)
+	if !ok {
+		t.Skipf("%T is not a oneway outbound", e.outbound)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	headers := transport.NewHeaders().With("foo", "bar")
+	ack, err := out.CallOneway(ctx, newRequest(onewayProcedure, headers, []byte("hello")))
+	require.NoError(t, err, "oneway call failed")
+	assert.NotNil(t, ack, "oneway call must be acknowledged")
+
+	want := transporttest.NewRequestMatcher(t, newRequest(onewayProcedure, headers, []byte("hello")))
+	select {
+	case req := <-e.router.oneway:
+		assert.True(t, want.Matches(req), "oneway handler received %v, expected it to %v", req, want)
+	case <-time.After(callTimeout):
+		t.Errorf("oneway handler was not called within %v", callTimeout)
+	}
+}
+
+func testLargeBody(t *testing.T, s Setup) {
+	e := s.start(t, false)
+	defer e.stop()
+
+	body := make([]byte, s.LargeBodySize)
+	for i := range body {
+		body[i] = byte('a' + i%26)
+	}
+	_, got, err := e.call(echoProcedure, transport.Headers{}, body)
+	require.NoError(t, err, "call failed")
+	assert.Equal(t, len(body), len(got), "echoed body has the wrong size")
+	assert.True(t, bytes.Equal(body, got), "echoed body does not match")
+}
+
+func testConcurrentCalls(t *testing.T, s Setup) {
+	e := s.start(t, false)
+	defer e.stop()
+	e.unaryOutbound() // skip before any goroutines are started
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.Concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			want := fmt.Sprintf("call %d", i)
+			_, got, err := e.call(echoProcedure, transport.Headers{}, []byte(want))
+			if assert.NoError(t, err, "call %d failed", i) {
+				assert.Equal(t, want, string(got), "call %d received the wrong body", i)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// testLifecycle verifies that Start and Stop are idempotent on inbounds,
+// outbounds and transports, as with the LifecycleOnce used by the transports
+// that ship with YARPC.
+func testLifecycle(t *testing.T, s Setup) {
+	e := s.start(t, true)
+	defer e.stop()
+
+	_, body, err := e.call(echoProcedure, transport.Headers{}, []byte("hello"))
+	require.NoError(t, err, "call failed")
+	assert.Equal(t, "hello", string(body))
+}
+
+func testPeers(t *testing.T, s Setup) {
+	if s.NewPeerIdentifier == nil {
+		t.Skip("Setup.NewPeerIdentifier is not set")
+	}
+
+	e := s.start(t, false)
+	defer e.stop()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pid := s.NewPeerIdentifier(e.inbound)
+	var tested bool
+	for _, trans := range e.transports {
+		pt, ok := trans.(peer.Transport)
+		if !ok {
+			continue
+		}
+		tested = true
+
+		sub1 := peertest.NewMockSubscriber(mockCtrl)
+		sub1.EXPECT().NotifyStatusChanged(gomock.Any()).AnyTimes()
+		sub2 := peertest.NewMockSubscriber(mockCtrl)
+		sub2.EXPECT().NotifyStatusChanged(gomock.Any()).AnyTimes()
+
+		p1, err := pt.RetainPeer(pid, sub1)
+		require.NoError(t, err, "%T failed to retain peer", trans)
+		p2, err := pt.RetainPeer(pid, sub2)
+		require.NoError(t, err, "%T failed to retain peer again", trans)
+		assert.Equal(t, pid.Identifier(), p1.Identifier(), "%T retained the wrong peer", trans)
+		assert.Equal(t, p1.Identifier(), p2.Identifier(), "%T retained different peers", trans)
+
+		assert.NoError(t, pt.ReleasePeer(pid, sub1), "%T failed to release peer", trans)
+		assert.NoError(t, pt.ReleasePeer(pid, sub2), "%T failed to release peer", trans)
+		assert.Error(t, pt.ReleasePeer(pid, sub2), "%T must not release a peer twice", trans)
+	}
+	if !tested {
+		t.Skip("no transport implements peer.Transport")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package conformance
+
+import (
+	"net"
+	"testing"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/transport/http"
+	"go.uber.org/yarpc/transport/tchannel"
+	"go.uber.org/yarpc/transport/x/grpc"
+	"go.uber.org/yarpc/transport/x/loopback"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoopback(t *testing.T) {
+	// Transports cannot be restarted once stopped, so each test gets a new
+	// one, shared by its inbound and outbound.
+	var trans *loopback.Transport
+	Run(t, Setup{
+		NewInbound: func(*testing.T) transport.Inbound {
+			trans = loopback.NewTransport()
+			return trans.NewInbound("conformance")
+		},
+		NewOutbound: func(*testing.T, transport.Inbound) transport.Outbound {
+			return trans.NewSingleOutbound("conformance")
+		},
+		NewPeerIdentifier: func(transport.Inbound) peer.Identifier {
+			return hostport.PeerIdentifier("conformance")
+		},
+	})
+}
+
+func TestHTTP(t *testing.T) {
+	Run(t, Setup{
+		NewInbound: func(*testing.T) transport.Inbound {
+			return http.NewTransport().NewInbound("127.0.0.1:0")
+		},
+		NewOutbound: func(_ *testing.T, i transport.Inbound) transport.Outbound {
+			addr := i.(*http.Inbound).Addr().String()
+			return http.NewTransport().NewSingleOutbound("http://" + addr)
+		},
+		NewPeerIdentifier: func(i transport.Inbound) peer.Identifier {
+			return hostport.PeerIdentifier(i.(*http.Inbound).Addr().String())
+		},
+	})
+}
+
+func TestTChannel(t *testing.T) {
+	// The inbound transport is started before the outbound is built, so its
+	// address is known by then.
+	addr := func(i transport.Inbound) string {
+		return i.Transports()[0].(*tchannel.Transport).ListenAddr()
+	}
+	Run(t, Setup{
+		NewInbound: func(t *testing.T) transport.Inbound {
+			trans, err := tchannel.NewTransport(
+				tchannel.ServiceName(serviceName),
+				tchannel.ListenAddr("127.0.0.1:0"),
+			)
+			require.NoError(t, err, "failed to build inbound transport")
+			return trans.NewInbound()
+		},
+		NewOutbound: func(t *testing.T, i transport.Inbound) transport.Outbound {
+			// TChannel uses the name of the outbound transport as the caller.
+			trans, err := tchannel.NewTransport(tchannel.ServiceName(callerName))
+			require.NoError(t, err, "failed to build outbound transport")
+			return trans.NewSingleOutbound(addr(i))
+		},
+		NewPeerIdentifier: func(i transport.Inbound) peer.Identifier {
+			return hostport.PeerIdentifier(addr(i))
+		},
+	})
+}
+
+func TestGRPC(t *testing.T) {
+	// gRPC inbounds have no transport, so the listener of the current test is
+	// kept for its address.
+	var listener net.Listener
+	Run(t, Setup{
+		NewInbound: func(t *testing.T) transport.Inbound {
+			var err error
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err, "failed to listen")
+			return grpc.NewInbound(listener)
+		},
+		NewOutbound: func(*testing.T, transport.Inbound) transport.Outbound {
+			return grpc.NewTransport().NewSingleOutbound(listener.Addr().String())
+		},
+		NewPeerIdentifier: func(transport.Inbound) peer.Identifier {
+			return hostport.PeerIdentifier(listener.Addr().String())
+		},
+		// gRPC limits messages to 4 MiB by default, including their framing.
+		LargeBodySize: 1024 * 1024,
+		// gRPC inbounds add a header for the protobuf encoding to every
+		// request, so oneway requests do not arrive as they were sent.
+		Skip: []string{"Oneway"},
+	})
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package conformance verifies that custom transports behave like the
+// transports that ship with YARPC.
+//
+// Run executes a standard battery of tests against an inbound and outbound
+// built by the transport under test:
+//
+// 	func TestConformance(t *testing.T) {
+// 		conformance.Run(t, conformance.Setup{
+// 			NewInbound: func(t *testing.T) transport.Inbound {
+// 				return queue.NewTransport().NewInbound("myqueue")
+// 			},
+// 			NewOutbound: func(t *testing.T, i transport.Inbound) transport.Outbound {
+// 				return queue.NewTransport().NewSingleOutbound("myqueue")
+// 			},
+// 		})
+// 	}
+package conformance
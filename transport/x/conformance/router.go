@@ -0,0 +1,159 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+)
+
+// Names of the caller and service of requests made by the suite.
+const (
+	callerName  = "conformance-client"
+	serviceName = "conformance"
+)
+
+// Procedures served by the inbound under test.
+const (
+	echoProcedure       = "echo"
+	deadlineProcedure   = "deadline"
+	sleepProcedure      = "sleep"
+	appErrorProcedure   = "application-error"
+	badRequestProcedure = "bad-request"
+	onewayProcedure     = "oneway"
+)
+
+// router routes requests made by the suite to its handlers. Requests for
+// procedures it does not know are rejected as unrecognized.
+type router struct {
+	handlers map[string]transport.HandlerSpec
+
+	// Oneway requests received by the inbound, with their bodies read.
+	oneway chan *transport.Request
+}
+
+func newRouter() *router {
+	r := &router{oneway: make(chan *transport.Request, 1)}
+	r.handlers = map[string]transport.HandlerSpec{
+		echoProcedure:       transport.NewUnaryHandlerSpec(echoHandler{}),
+		deadlineProcedure:   transport.NewUnaryHandlerSpec(unaryHandlerFunc(handleDeadline)),
+		sleepProcedure:      transport.NewUnaryHandlerSpec(unaryHandlerFunc(handleSleep)),
+		appErrorProcedure:   transport.NewUnaryHandlerSpec(unaryHandlerFunc(handleApplicationError)),
+		badRequestProcedure: transport.NewUnaryHandlerSpec(unaryHandlerFunc(handleBadRequest)),
+		onewayProcedure:     transport.NewOnewayHandlerSpec(onewayHandlerFunc(r.handleOneway)),
+	}
+	return r
+}
+
+// Procedures lists the procedures of the suite for inbounds which register
+// them ahead of time, like gRPC.
+func (r *router) Procedures() []transport.Procedure {
+	procedures := make([]transport.Procedure, 0, len(r.handlers))
+	for name, spec := range r.handlers {
+		procedures = append(procedures, transport.Procedure{
+			Name:        name,
+			Service:     serviceName,
+			HandlerSpec: spec,
+			Encoding:    "raw",
+		})
+	}
+	return procedures
+}
+
+func (r *router) Choose(ctx context.Context, req *transport.Request) (transport.HandlerSpec, error) {
+	spec, ok := r.handlers[req.Procedure]
+	if !ok {
+		return transport.HandlerSpec{}, transport.UnrecognizedProcedureError(req)
+	}
+	return spec, nil
+}
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+	return f(ctx, req, rw)
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+// echoHandler echoes the request headers and body on the response.
+type echoHandler struct{}
+
+func (echoHandler) Handle(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+	rw.AddHeaders(req.Headers)
+	return transporttest.EchoHandler{}.Handle(ctx, req, rw)
+}
+
+// handleDeadline responds with the deadline of the handler context.
+func handleDeadline(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return errors.New("handler context has no deadline")
+	}
+	_, err := io.WriteString(rw, deadline.Format(time.RFC3339Nano))
+	return err
+}
+
+// handleSleep blocks until the handler context is done, giving up after
+// sleepLimit in case the transport does not propagate deadlines.
+func handleSleep(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(sleepLimit):
+		return errors.New("handler context was not cancelled")
+	}
+}
+
+func handleApplicationError(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+	rw.SetApplicationError()
+	_, err := io.WriteString(rw, "application error")
+	return err
+}
+
+func handleBadRequest(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+	return transport.InboundBadRequestError(errors.New("malformed request"))
+}
+
+// handleOneway passes oneway requests on to the test which made them. Their
+// bodies are read first because transports may reuse them once the handler
+// returns.
+func (r *router) handleOneway(ctx context.Context, req *transport.Request) error {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	received := *req
+	received.Body = bytes.NewReader(body)
+	r.oneway <- &received
+	return nil
+}
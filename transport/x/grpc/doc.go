@@ -21,4 +21,11 @@
 // Package grpc implements the grpc transport.
 //
 // This package is experimental and should not be used in production.
+//
+// Outbounds built from a Transport send requests to the peers selected by a
+// peer.Chooser, and share a connection to each peer.
+//
+// 	grpcTransport := grpc.NewTransport()
+// 	chooser := peer.Bind(roundrobin.New(grpcTransport), peer.BindPeers(peerIDs))
+// 	outbound := grpcTransport.NewOutbound(chooser)
 package grpc
@@ -31,8 +31,10 @@ import (
 	"go.uber.org/yarpc/internal/errors"
 	"go.uber.org/yarpc/internal/request"
 
+	"github.com/opentracing/opentracing-go"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -70,6 +72,18 @@ func (h *handler) handle(
 	if err != nil {
 		return nil, err
 	}
+	response, err := h.intercept(ctx, transportRequest, interceptor)
+	if err != nil {
+		return response, toGRPCError(ctx, transportRequest, err)
+	}
+	return response, nil
+}
+
+func (h *handler) intercept(
+	ctx context.Context,
+	transportRequest *transport.Request,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
 	if interceptor != nil {
 		return interceptor(
 			ctx,
@@ -137,6 +151,8 @@ func (h *handler) call(ctx context.Context, transportRequest *transport.Request)
 	switch handlerSpec.Type() {
 	case transport.Unary:
 		return h.callUnary(ctx, transportRequest, handlerSpec.Unary())
+	case transport.Oneway:
+		return h.callOneway(ctx, transportRequest, handlerSpec.Oneway())
 	default:
 		return nil, errors.UnsupportedTypeError{"grpc", handlerSpec.Type().String()}
 	}
@@ -150,7 +166,55 @@ func (h *handler) callUnary(ctx context.Context, transportRequest *transport.Req
 	// TODO: do we always want to return the data from responseWriter.Bytes, or return nil for the data if there is an error?
 	// For now, we are always returning the data
 	err := transport.DispatchUnaryHandler(ctx, unaryHandler, time.Now(), transportRequest, responseWriter)
+	if responseWriter.isApplicationError {
+		err = multierr.Append(err, addToMetadata(responseWriter.md, applicationErrorHeader, "true"))
+	}
 	err = multierr.Append(err, grpc.SendHeader(ctx, responseWriter.md))
 	data := responseWriter.Bytes()
 	return &data, err
 }
+
+// callOneway acknowledges the request with an empty response and calls the
+// oneway handler in the background.
+func (h *handler) callOneway(ctx context.Context, transportRequest *transport.Request, onewayHandler transport.OnewayHandler) (interface{}, error) {
+	// create a new context for oneway requests since gRPC cancels the
+	// context of the request when the handler returns
+	onewayCtx := context.Background()
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		onewayCtx = opentracing.ContextWithSpan(onewayCtx, span)
+	}
+
+	go func() {
+		// TODO: log error
+		_ = transport.DispatchOnewayHandler(onewayCtx, onewayHandler, transportRequest)
+	}()
+
+	data := []byte{}
+	return &data, nil
+}
+
+// toGRPCError converts an error returned while handling a request into a
+// gRPC error with the matching status code, recording its YARPC error type
+// in the trailers of the response so that YARPC clients can restore it.
+func toGRPCError(ctx context.Context, transportRequest *transport.Request, err error) error {
+	if grpc.Code(err) != codes.Unknown {
+		// already a gRPC error
+		return err
+	}
+
+	err = errors.AsHandlerError(transportRequest.Service, transportRequest.Procedure, err)
+	code, errorType := codes.Unknown, errorTypeUnexpected
+	switch {
+	case transport.IsBadRequestError(err):
+		code, errorType = codes.InvalidArgument, errorTypeBadRequest
+	case transport.IsTimeoutError(err):
+		code, errorType = codes.DeadlineExceeded, errorTypeTimeout
+	}
+
+	md := metadata.New(nil)
+	if mdErr := addToMetadata(md, errorTypeHeader, errorType); mdErr == nil {
+		// TODO: log error
+		_ = grpc.SetTrailer(ctx, md)
+	}
+	return grpc.Errorf(code, "%s", err.Error())
+}
@@ -37,6 +37,21 @@ const (
 	callerHeader            = reservedHeaderPrefix + "caller"
 	encodingHeader          = reservedHeaderPrefix + "encoding"
 	serviceHeader           = reservedHeaderPrefix + "service"
+
+	// applicationErrorHeader is set on responses with the value "true" when
+	// the handler marked the response as an application error.
+	applicationErrorHeader = reservedHeaderPrefix + "application-error"
+
+	// errorTypeHeader is set on the trailers of failed requests with the type
+	// of the YARPC error returned by the handler.
+	errorTypeHeader = reservedHeaderPrefix + "error-type"
+)
+
+// Values of errorTypeHeader.
+const (
+	errorTypeBadRequest = "bad-request"
+	errorTypeTimeout    = "timeout"
+	errorTypeUnexpected = "unexpected"
 )
 
 // transportRequestToMetadata will populate all reserved and application headers
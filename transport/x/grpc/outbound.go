@@ -24,13 +24,15 @@ import (
 	"bytes"
 	"context"
 	"io/ioutil"
-	"sync"
 	"time"
 
 	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/errors"
 	internalsync "go.uber.org/yarpc/internal/sync"
+	peerchooser "go.uber.org/yarpc/peer"
+	"go.uber.org/yarpc/peer/hostport"
 
 	"github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
 	"google.golang.org/grpc"
@@ -42,30 +44,58 @@ import (
 // http://www.grpc.io/docs/guides/wire.html#user-agents
 const UserAgent = "yarpc-go/" + yarpc.Version
 
-var _ transport.UnaryOutbound = (*Outbound)(nil)
+var (
+	_ transport.UnaryOutbound  = (*Outbound)(nil)
+	_ transport.OnewayOutbound = (*Outbound)(nil)
+)
 
-// Outbound is a transport.UnaryOutbound.
+// Outbound is a transport.UnaryOutbound and transport.OnewayOutbound which
+// sends requests to the peers selected by a peer.Chooser.
 type Outbound struct {
 	once            internalsync.LifecycleOnce
-	lock            sync.Mutex
-	address         string
+	transport       *Transport
+	chooser         peer.Chooser
 	outboundOptions *outboundOptions
-	clientConn      *grpc.ClientConn
 }
 
-// NewSingleOutbound returns a new Outbound for the given adrress.
+// NewSingleOutbound returns a new Outbound for the given adrress, using its
+// own Transport.
+//
+// Use Transport.NewSingleOutbound to share connections between outbounds.
 func NewSingleOutbound(address string, options ...OutboundOption) *Outbound {
-	return &Outbound{internalsync.Once(), sync.Mutex{}, address, newOutboundOptions(options), nil}
+	return NewTransport().NewSingleOutbound(address, options...)
+}
+
+// NewOutbound returns a new Outbound which sends requests to the peers
+// selected by the given peer.Chooser.
+func (t *Transport) NewOutbound(chooser peer.Chooser, options ...OutboundOption) *Outbound {
+	return &Outbound{
+		once:            internalsync.Once(),
+		transport:       t,
+		chooser:         chooser,
+		outboundOptions: newOutboundOptions(options),
+	}
+}
+
+// NewSingleOutbound returns a new Outbound which always sends requests to the
+// peer with the given address.
+func (t *Transport) NewSingleOutbound(address string, options ...OutboundOption) *Outbound {
+	return t.NewOutbound(peerchooser.NewSingle(hostport.PeerIdentifier(address), t), options...)
+}
+
+// Chooser returns the peer.Chooser of the Outbound.
+func (o *Outbound) Chooser() peer.Chooser {
+	return o.chooser
 }
 
 // Start implements transport.Lifecycle#Start.
 func (o *Outbound) Start() error {
-	return o.once.Start(o.start)
+	return o.once.Start(o.chooser.Start)
 }
 
 // Stop implements transport.Lifecycle#Stop.
 func (o *Outbound) Stop() error {
-	return o.once.Stop(o.stop)
+	return o.once.Stop(o.chooser.Stop)
 }
 
 // IsRunning implements transport.Lifecycle#IsRunning.
@@ -75,7 +105,7 @@ func (o *Outbound) IsRunning() bool {
 
 // Transports implements transport.Inbound#Transports.
 func (o *Outbound) Transports() []transport.Transport {
-	return []transport.Transport{}
+	return []transport.Transport{o.transport}
 }
 
 // Call implements transport.UnaryOutbound#Call.
@@ -83,18 +113,62 @@ func (o *Outbound) Call(ctx context.Context, request *transport.Request) (*trans
 	if err := o.once.WhenRunning(ctx); err != nil {
 		return nil, err
 	}
+	p, onFinish, err := o.getPeerForRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	res, err := o.callWithPeer(ctx, request, p)
+	onFinish(err)
+	return res, err
+}
+
+// CallOneway implements transport.OnewayOutbound#CallOneway. It returns as
+// soon as the server acknowledges receipt of the request; the request is
+// handled in the background.
+func (o *Outbound) CallOneway(ctx context.Context, request *transport.Request) (transport.Ack, error) {
+	res, err := o.Call(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Body.Close(); err != nil {
+		return nil, err
+	}
+	return time.Now(), nil
+}
+
+func (o *Outbound) getPeerForRequest(ctx context.Context, request *transport.Request) (*grpcPeer, func(error), error) {
+	p, onFinish, err := o.chooser.Choose(ctx, request)
+	if err != nil {
+		return nil, nil, err
+	}
+	gp, ok := p.(*grpcPeer)
+	if !ok {
+		return nil, nil, peer.ErrInvalidPeerConversion{
+			Peer:         p,
+			ExpectedType: "*grpcPeer",
+		}
+	}
+	return gp, onFinish, nil
+}
+
+func (o *Outbound) callWithPeer(ctx context.Context, request *transport.Request, p *grpcPeer) (*transport.Response, error) {
 	var responseBody []byte
 	responseMD := metadata.New(nil)
-	if err := o.invoke(ctx, request, &responseBody, &responseMD); err != nil {
+	if err := o.invoke(ctx, request, &responseBody, &responseMD, p.clientConn); err != nil {
 		return nil, err
 	}
 	responseHeaders, err := getApplicationHeaders(responseMD)
 	if err != nil {
 		return nil, err
 	}
+	applicationError, err := getFromMetadata(responseMD, applicationErrorHeader)
+	if err != nil {
+		return nil, err
+	}
 	return &transport.Response{
-		Body:    ioutil.NopCloser(bytes.NewBuffer(responseBody)),
-		Headers: responseHeaders,
+		Body:             ioutil.NopCloser(bytes.NewBuffer(responseBody)),
+		Headers:          responseHeaders,
+		ApplicationError: applicationError == "true",
 	}, nil
 }
 
@@ -103,6 +177,7 @@ func (o *Outbound) invoke(
 	request *transport.Request,
 	responseBody *[]byte,
 	responseMD *metadata.MD,
+	clientConn *grpc.ClientConn,
 ) error {
 	start := time.Now()
 	md, err := transportRequestToMetadata(request)
@@ -118,53 +193,40 @@ func (o *Outbound) invoke(
 	if err != nil {
 		return err
 	}
-	var callOptions []grpc.CallOption
+	responseTrailer := metadata.New(nil)
+	callOptions := []grpc.CallOption{grpc.Trailer(&responseTrailer)}
 	if responseMD != nil {
-		callOptions = []grpc.CallOption{grpc.Header(responseMD)}
+		callOptions = append(callOptions, grpc.Header(responseMD))
 	}
-	if err := grpc.Invoke(
+	// The ClientConn is shared between outbounds with different tracers so
+	// the tracing interceptor is applied to each call instead.
+	interceptor := otgrpc.OpenTracingClientInterceptor(o.outboundOptions.getTracer())
+	if err := interceptor(
 		metadata.NewContext(ctx, md),
 		fullMethod,
 		&requestBody,
 		responseBody,
-		o.clientConn,
+		clientConn,
+		grpc.Invoke,
 		callOptions...,
 	); err != nil {
-		return errorToGRPCError(ctx, request, start, err)
+		return errorToGRPCError(ctx, request, start, responseTrailer, err)
 	}
 	return nil
 }
 
-func (o *Outbound) start() error {
-	// TODO: redial
-	clientConn, err := grpc.Dial(
-		o.address,
-		grpc.WithInsecure(),
-		grpc.WithCodec(customCodec{}),
-		// TODO: does this actually work for yarpc
-		// this needs a lot of review
-		grpc.WithUnaryInterceptor(otgrpc.OpenTracingClientInterceptor(o.outboundOptions.getTracer())),
-		grpc.WithUserAgent(UserAgent),
-	)
-	if err != nil {
-		return err
-	}
-	o.lock.Lock()
-	defer o.lock.Unlock()
-	o.clientConn = clientConn
-	return nil
-}
-
-func (o *Outbound) stop() error {
-	o.lock.Lock()
-	defer o.lock.Unlock()
-	if o.clientConn != nil {
-		return o.clientConn.Close()
+func errorToGRPCError(ctx context.Context, request *transport.Request, start time.Time, trailer metadata.MD, err error) error {
+	// YARPC servers report the type of their errors in the trailers.
+	errorType, _ := getFromMetadata(trailer, errorTypeHeader)
+	switch errorType {
+	case errorTypeBadRequest:
+		return errors.RemoteBadRequestError(grpc.ErrorDesc(err))
+	case errorTypeTimeout:
+		return errors.RemoteTimeoutError(grpc.ErrorDesc(err))
+	case errorTypeUnexpected:
+		return errors.RemoteUnexpectedError(grpc.ErrorDesc(err))
 	}
-	return nil
-}
 
-func errorToGRPCError(ctx context.Context, request *transport.Request, start time.Time, err error) error {
 	deadline, _ := ctx.Deadline()
 	ttl := deadline.Sub(start)
 	switch grpc.Code(err) {
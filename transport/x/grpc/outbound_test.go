@@ -0,0 +1,178 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errFoo = fmt.Errorf("foo")
+
+// unaryHandlerFunc adapts a function into a transport.UnaryHandler.
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+	return f(ctx, req, rw)
+}
+
+// startServerAndClient starts a dispatcher serving the given procedures over
+// gRPC and returns a started client dispatcher with an outbound for it.
+func startServerAndClient(t *testing.T, procedures []transport.Procedure) (client *yarpc.Dispatcher, stop func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := yarpc.NewDispatcher(yarpc.Config{
+		Name:     "server",
+		Inbounds: yarpc.Inbounds{NewInbound(listener)},
+	})
+	server.Register(procedures)
+	require.NoError(t, server.Start())
+
+	outbound := NewSingleOutbound(listener.Addr().String())
+	client = yarpc.NewDispatcher(yarpc.Config{
+		Name: "client",
+		Outbounds: yarpc.Outbounds{
+			"server": {Unary: outbound, Oneway: outbound},
+		},
+	})
+	require.NoError(t, client.Start())
+
+	return client, func() {
+		assert.NoError(t, client.Stop())
+		assert.NoError(t, server.Stop())
+	}
+}
+
+func TestOutboundApplicationError(t *testing.T) {
+	client, stop := startServerAndClient(t, []transport.Procedure{{
+		Name: "hello",
+		HandlerSpec: transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+			func(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+				rw.SetApplicationError()
+				_, err := rw.Write([]byte("oops"))
+				return err
+			})),
+	}})
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	res, err := client.ClientConfig("server").GetUnaryOutbound().Call(ctx, &transport.Request{
+		Caller:    "client",
+		Service:   "server",
+		Encoding:  raw.Encoding,
+		Procedure: "hello",
+		Body:      bytes.NewReader(nil),
+	})
+	require.NoError(t, err)
+	assert.True(t, res.ApplicationError, "response must be an application error")
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "oops", string(body))
+}
+
+func TestOutboundErrorTypes(t *testing.T) {
+	tests := []struct {
+		desc      string
+		err       error
+		wantCheck func(error) bool
+		wantMsg   string
+	}{
+		{
+			desc:      "bad request",
+			err:       errors.HandlerBadRequestError(errFoo),
+			wantCheck: transport.IsBadRequestError,
+			wantMsg:   `BadRequest: foo`,
+		},
+		{
+			desc:      "timeout",
+			err:       errors.HandlerTimeoutError("client", "server", "hello", time.Second),
+			wantCheck: transport.IsTimeoutError,
+			wantMsg:   `Timeout: call to procedure "hello" of service "server" from caller "client" timed out after 1s`,
+		},
+		{
+			desc:      "unexpected",
+			err:       errFoo,
+			wantCheck: transport.IsUnexpectedError,
+			wantMsg:   `UnexpectedError: error for procedure "hello" of service "server": foo`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			client, stop := startServerAndClient(t, raw.Procedure("hello",
+				func(context.Context, []byte) ([]byte, error) {
+					return nil, tt.err
+				}))
+			defer stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			_, err := raw.New(client.ClientConfig("server")).Call(ctx, "hello", nil)
+			require.Error(t, err)
+			assert.True(t, tt.wantCheck(err), "unexpected error type %T: %v", err, err)
+			assert.Contains(t, err.Error(), tt.wantMsg)
+		})
+	}
+}
+
+func TestOutboundOneway(t *testing.T) {
+	received := make(chan string, 1)
+	client, stop := startServerAndClient(t, raw.OnewayProcedure("hello",
+		func(ctx context.Context, body []byte) error {
+			// The handler must not be affected by the end of the request.
+			assert.NoError(t, ctx.Err())
+			received <- string(body)
+			return nil
+		}))
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ack, err := raw.New(client.ClientConfig("server")).CallOneway(ctx, "hello", []byte("world"))
+	require.NoError(t, err)
+	assert.NotNil(t, ack)
+
+	select {
+	case body := <-received:
+		assert.Equal(t, "world", body)
+	case <-time.After(time.Second):
+		t.Fatal("oneway handler was not called")
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/hostport"
+
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+)
+
+var _ peer.Peer = (*grpcPeer)(nil)
+
+// grpcPeer is a peer.Peer backed by a grpc.ClientConn to a single hostport.
+//
+// The connection status of the peer follows the connections the ClientConn
+// makes to the peer: it is Connecting while a connection is being
+// established, Available while one is open, and Unavailable otherwise.
+type grpcPeer struct {
+	hostport.PeerIdentifier
+
+	clientConn *grpc.ClientConn
+
+	lock        sync.Mutex
+	subscribers map[peer.Subscriber]struct{}
+	dialing     int
+	open        int
+
+	pending atomic.Int32
+	status  atomic.Int32
+}
+
+func newPeer(pid hostport.PeerIdentifier) (*grpcPeer, error) {
+	p := &grpcPeer{
+		PeerIdentifier: pid,
+		subscribers:    make(map[peer.Subscriber]struct{}),
+	}
+	p.status.Store(int32(peer.Unavailable))
+
+	clientConn, err := grpc.Dial(
+		pid.Identifier(),
+		grpc.WithInsecure(),
+		grpc.WithCodec(customCodec{}),
+		grpc.WithDialer(p.dial),
+		grpc.WithUserAgent(UserAgent),
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.clientConn = clientConn
+	return p, nil
+}
+
+// dial is used by the ClientConn to make connections to the peer.
+func (p *grpcPeer) dial(addr string, timeout time.Duration) (net.Conn, error) {
+	p.updateConnections(1, 0)
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		p.updateConnections(-1, 0)
+		return nil, err
+	}
+	p.updateConnections(-1, 1)
+	return &trackedConn{Conn: conn, onClose: func() { p.updateConnections(0, -1) }}, nil
+}
+
+// updateConnections adjusts the number of connections being dialed and open,
+// and notifies subscribers if the connection status of the peer changed.
+func (p *grpcPeer) updateConnections(dialing, open int) {
+	p.lock.Lock()
+	p.dialing += dialing
+	p.open += open
+
+	status := peer.Unavailable
+	if p.open > 0 {
+		status = peer.Available
+	} else if p.dialing > 0 {
+		status = peer.Connecting
+	}
+	changed := p.status.Swap(int32(status)) != int32(status)
+	p.lock.Unlock()
+
+	if changed {
+		p.notifyStatusChanged()
+	}
+}
+
+func (p *grpcPeer) close() error {
+	return p.clientConn.Close()
+}
+
+// Status returns the current status of the peer.
+func (p *grpcPeer) Status() peer.Status {
+	return peer.Status{
+		PendingRequestCount: int(p.pending.Load()),
+		ConnectionStatus:    peer.ConnectionStatus(p.status.Load()),
+	}
+}
+
+// StartRequest runs at the beginning of a request.
+func (p *grpcPeer) StartRequest() {
+	p.pending.Inc()
+	p.notifyStatusChanged()
+}
+
+// EndRequest runs after a request has finished.
+func (p *grpcPeer) EndRequest() {
+	p.pending.Dec()
+	p.notifyStatusChanged()
+}
+
+func (p *grpcPeer) subscribe(sub peer.Subscriber) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.subscribers[sub] = struct{}{}
+}
+
+// unsubscribe removes the subscriber from the peer and returns the number of
+// remaining subscribers.
+func (p *grpcPeer) unsubscribe(sub peer.Subscriber) (int, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.subscribers[sub]; !ok {
+		return 0, peer.ErrPeerHasNoReferenceToSubscriber{
+			PeerIdentifier: p.PeerIdentifier,
+			PeerSubscriber: sub,
+		}
+	}
+	delete(p.subscribers, sub)
+	return len(p.subscribers), nil
+}
+
+// notifyStatusChanged notifies all subscribers of the peer without holding
+// the lock of the peer, since subscribers may call back into the transport.
+func (p *grpcPeer) notifyStatusChanged() {
+	p.lock.Lock()
+	subs := make([]peer.Subscriber, 0, len(p.subscribers))
+	for sub := range p.subscribers {
+		subs = append(subs, sub)
+	}
+	p.lock.Unlock()
+
+	for _, sub := range subs {
+		sub.NotifyStatusChanged(p.PeerIdentifier)
+	}
+}
+
+// trackedConn is a net.Conn which reports when it is closed.
+type trackedConn struct {
+	net.Conn
+
+	once    sync.Once
+	onClose func()
+}
+
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.onClose)
+	return err
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"sync"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	intsync "go.uber.org/yarpc/internal/sync"
+	"go.uber.org/yarpc/peer/hostport"
+
+	"go.uber.org/multierr"
+)
+
+var (
+	_ transport.Transport = (*Transport)(nil)
+	_ peer.Transport      = (*Transport)(nil)
+)
+
+// Transport is a gRPC transport suitable for use with YARPC's peer selection
+// system. It maintains a grpc.ClientConn for each peer retained by its
+// outbounds' peer choosers.
+type Transport struct {
+	lock sync.Mutex
+	once intsync.LifecycleOnce
+
+	peers map[string]*grpcPeer
+}
+
+// NewTransport returns a new Transport.
+func NewTransport() *Transport {
+	return &Transport{
+		once:  intsync.Once(),
+		peers: make(map[string]*grpcPeer),
+	}
+}
+
+// Start implements transport.Lifecycle#Start.
+func (t *Transport) Start() error {
+	return t.once.Start(nil)
+}
+
+// Stop implements transport.Lifecycle#Stop.
+func (t *Transport) Stop() error {
+	return t.once.Stop(t.stop)
+}
+
+// IsRunning implements transport.Lifecycle#IsRunning.
+func (t *Transport) IsRunning() bool {
+	return t.once.IsRunning()
+}
+
+func (t *Transport) stop() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var err error
+	for id, p := range t.peers {
+		err = multierr.Append(err, p.close())
+		delete(t.peers, id)
+	}
+	return err
+}
+
+// RetainPeer retains the peer with the given hostport.PeerIdentifier for the
+// subscriber, connecting to it if it was not already retained.
+func (t *Transport) RetainPeer(pid peer.Identifier, sub peer.Subscriber) (peer.Peer, error) {
+	hppid, ok := pid.(hostport.PeerIdentifier)
+	if !ok {
+		return nil, peer.ErrInvalidPeerType{
+			ExpectedType:   "hostport.PeerIdentifier",
+			PeerIdentifier: pid,
+		}
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	p, ok := t.peers[hppid.Identifier()]
+	if !ok {
+		var err error
+		p, err = newPeer(hppid)
+		if err != nil {
+			return nil, err
+		}
+		t.peers[hppid.Identifier()] = p
+	}
+	p.subscribe(sub)
+	return p, nil
+}
+
+// ReleasePeer releases the peer from the subscriber, closing its connection
+// if no other subscribers retain it.
+func (t *Transport) ReleasePeer(pid peer.Identifier, sub peer.Subscriber) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	p, ok := t.peers[pid.Identifier()]
+	if !ok {
+		return peer.ErrTransportHasNoReferenceToPeer{
+			TransportName:  "grpc.Transport",
+			PeerIdentifier: pid.Identifier(),
+		}
+	}
+
+	remaining, err := p.unsubscribe(sub)
+	if err != nil {
+		return err
+	}
+	if remaining == 0 {
+		delete(t.peers, pid.Identifier())
+		return p.close()
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/peer"
+	. "go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/encoding/raw"
+	peerchooser "go.uber.org/yarpc/peer"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/x/roundrobin"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSubscriber is a peer.Subscriber which counts notifications.
+type fakeSubscriber struct {
+	sync.Mutex
+
+	notifications int
+}
+
+func (s *fakeSubscriber) NotifyStatusChanged(peer.Identifier) {
+	s.Lock()
+	s.notifications++
+	s.Unlock()
+}
+
+func waitForStatus(t *testing.T, p peer.Peer, want peer.ConnectionStatus) {
+	deadline := time.Now().Add(time.Second)
+	for p.Status().ConnectionStatus != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("peer status is %v, expected %v", p.Status().ConnectionStatus, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTransportRetainWithInvalidPeerIdentifierType(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	transport := NewTransport()
+	pid := NewMockIdentifier(mockCtrl)
+
+	expectedErr := peer.ErrInvalidPeerType{
+		ExpectedType:   "hostport.PeerIdentifier",
+		PeerIdentifier: pid,
+	}
+
+	_, err := transport.RetainPeer(pid, NewMockSubscriber(mockCtrl))
+	assert.Equal(t, expectedErr, err, "did not return error on invalid peer identifier")
+}
+
+func TestTransportRetainRelease(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	transport := NewTransport()
+	require.NoError(t, transport.Start())
+	defer transport.Stop()
+
+	pid := hostport.PeerIdentifier(listener.Addr().String())
+	s1, s2 := new(fakeSubscriber), new(fakeSubscriber)
+
+	p1, err := transport.RetainPeer(pid, s1)
+	require.NoError(t, err)
+	p2, err := transport.RetainPeer(pid, s2)
+	require.NoError(t, err)
+	assert.True(t, p1 == p2, "peers for the same identifier must be shared")
+
+	// The status follows the connection of the ClientConn.
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	waitForStatus(t, p1, peer.Available)
+
+	conn.Close()
+	listener.Close()
+	waitForStatus(t, p1, peer.Unavailable)
+
+	s1.Lock()
+	assert.True(t, s1.notifications > 0, "subscribers must be notified of status changes")
+	s1.Unlock()
+
+	require.NoError(t, transport.ReleasePeer(pid, s1))
+	assert.Equal(t, peer.ErrPeerHasNoReferenceToSubscriber{
+		PeerIdentifier: pid,
+		PeerSubscriber: s1,
+	}, transport.ReleasePeer(pid, s1))
+
+	require.NoError(t, transport.ReleasePeer(pid, s2))
+	assert.Equal(t, peer.ErrTransportHasNoReferenceToPeer{
+		TransportName:  "grpc.Transport",
+		PeerIdentifier: string(pid),
+	}, transport.ReleasePeer(pid, s2))
+}
+
+func TestOutboundRoundRobin(t *testing.T) {
+	var addrs []peer.Identifier
+	for _, name := range []string{"server1", "server2"} {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addrs = append(addrs, hostport.PeerIdentifier(listener.Addr().String()))
+
+		server := yarpc.NewDispatcher(yarpc.Config{
+			Name:     "server",
+			Inbounds: yarpc.Inbounds{NewInbound(listener)},
+		})
+		name := name
+		server.Register(raw.Procedure("hello", func(context.Context, []byte) ([]byte, error) {
+			return []byte(name), nil
+		}))
+		require.NoError(t, server.Start())
+		defer server.Stop()
+	}
+
+	transport := NewTransport()
+	chooser := peerchooser.Bind(roundrobin.New(transport), peerchooser.BindPeers(addrs))
+	client := yarpc.NewDispatcher(yarpc.Config{
+		Name: "client",
+		Outbounds: yarpc.Outbounds{
+			"server": {Unary: transport.NewOutbound(chooser)},
+		},
+	})
+	require.NoError(t, client.Start())
+	defer client.Stop()
+
+	rawClient := raw.New(client.ClientConfig("server"))
+	got := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		res, err := rawClient.Call(ctx, "hello", nil)
+		cancel()
+		require.NoError(t, err)
+		got[string(res)]++
+	}
+	assert.Len(t, got, 2, "requests must be spread across both servers: %v", got)
+}
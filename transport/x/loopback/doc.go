@@ -0,0 +1,49 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package loopback implements a YARPC transport which connects inbounds and
+// outbounds in the same process without sockets. It allows a graph of
+// services to be exercised hermetically in a single test.
+//
+// Inbounds are registered with a Transport under a name, and outbounds built
+// by the same Transport send requests to inbounds by that name.
+//
+// 	trans := loopback.NewTransport()
+// 	server := yarpc.NewDispatcher(yarpc.Config{
+// 		Name:     "users",
+// 		Inbounds: yarpc.Inbounds{trans.NewInbound("users")},
+// 	})
+// 	client := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "frontend",
+// 		Outbounds: yarpc.Outbounds{
+// 			"users": {Unary: trans.NewSingleOutbound("users")},
+// 		},
+// 	})
+//
+// Requests behave as they would over a network: headers and bodies are
+// copied, TTLs are propagated from the caller's deadline while other context
+// values are not, callers stop waiting when their deadline passes, and
+// handler failures are returned as remote errors. Requests pass through the
+// middleware of both the client and the server dispatcher.
+//
+// Peer choosers may be used with outbounds built by NewOutbound. Peers are
+// identified by inbound names using hostport.PeerIdentifier, and are
+// available only while an inbound with that name is running.
+package loopback
@@ -0,0 +1,224 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loopback
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	yarpcerrors "go.uber.org/yarpc/internal/errors"
+	"go.uber.org/yarpc/internal/iopool"
+	"go.uber.org/yarpc/internal/request"
+	intsync "go.uber.org/yarpc/internal/sync"
+)
+
+var errRouterNotSet = errors.New("loopback: router not set")
+
+// Inbound receives requests sent by loopback outbounds to its name.
+type Inbound struct {
+	once      intsync.LifecycleOnce
+	transport *Transport
+	name      string
+	router    transport.Router
+}
+
+var _ transport.Inbound = (*Inbound)(nil)
+
+// NewInbound builds an inbound which receives requests addressed to the
+// given name. Only one inbound with a given name may run at a time.
+func (t *Transport) NewInbound(name string) *Inbound {
+	return &Inbound{
+		once:      intsync.Once(),
+		transport: t,
+		name:      name,
+	}
+}
+
+// Name returns the name under which the inbound receives requests.
+func (i *Inbound) Name() string {
+	return i.name
+}
+
+// SetRouter configures a router to handle incoming requests.
+// This satisfies the transport.Inbound interface, and would be called
+// by a dispatcher when it starts.
+func (i *Inbound) SetRouter(router transport.Router) {
+	i.router = router
+}
+
+// Transports returns the inbound's loopback transport.
+func (i *Inbound) Transports() []transport.Transport {
+	return []transport.Transport{i.transport}
+}
+
+// Start registers the inbound with its transport.
+func (i *Inbound) Start() error {
+	return i.once.Start(func() error {
+		if i.router == nil {
+			return errRouterNotSet
+		}
+		return i.transport.register(i)
+	})
+}
+
+// Stop unregisters the inbound. Requests sent to it after it has stopped
+// fail.
+func (i *Inbound) Stop() error {
+	return i.once.Stop(func() error {
+		i.transport.unregister(i)
+		return nil
+	})
+}
+
+// IsRunning returns whether the inbound is running.
+func (i *Inbound) IsRunning() bool {
+	return i.once.IsRunning()
+}
+
+// handle dispatches a unary request to the handler for its procedure and
+// returns the buffered response. The request must have been copied with
+// copyRequest.
+func (i *Inbound) handle(ctx context.Context, treq *transport.Request, start time.Time) (*transport.Response, error) {
+	// Like a request received over the network, the handler's context carries
+	// the caller's deadline but none of its values.
+	hctx := context.Background()
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		hctx, cancel = context.WithDeadline(hctx, deadline)
+		defer cancel()
+	}
+
+	rw := &responseWriter{headers: transport.NewHeaders()}
+	err := i.dispatchUnary(hctx, treq, start, rw)
+	if err != nil {
+		return nil, remoteError(treq, err)
+	}
+	return &transport.Response{
+		Headers:          rw.headers,
+		Body:             ioutil.NopCloser(&rw.body),
+		ApplicationError: rw.appErr,
+	}, nil
+}
+
+func (i *Inbound) dispatchUnary(ctx context.Context, treq *transport.Request, start time.Time, rw *responseWriter) error {
+	if err := transport.ValidateRequest(treq); err != nil {
+		return err
+	}
+	spec, err := i.router.Choose(ctx, treq)
+	if err != nil {
+		return err
+	}
+	if spec.Type() != transport.Unary {
+		return yarpcerrors.UnsupportedTypeError{Transport: "loopback", Type: spec.Type().String()}
+	}
+	if err := request.ValidateUnaryContext(ctx); err != nil {
+		return err
+	}
+	return transport.DispatchUnaryHandler(ctx, spec.Unary(), start, treq, rw)
+}
+
+// handleOneway dispatches a oneway request to the handler for its procedure
+// in the background, once the request has been validated.
+func (i *Inbound) handleOneway(treq *transport.Request) error {
+	treq, err := copyRequest(treq)
+	if err != nil {
+		return err
+	}
+	err = i.dispatchOneway(treq)
+	if err != nil {
+		return remoteError(treq, err)
+	}
+	return nil
+}
+
+func (i *Inbound) dispatchOneway(treq *transport.Request) error {
+	if err := transport.ValidateRequest(treq); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	spec, err := i.router.Choose(ctx, treq)
+	if err != nil {
+		return err
+	}
+	if spec.Type() != transport.Oneway {
+		return yarpcerrors.UnsupportedTypeError{Transport: "loopback", Type: spec.Type().String()}
+	}
+	go transport.DispatchOnewayHandler(ctx, spec.Oneway(), treq)
+	return nil
+}
+
+// copyRequest copies the request so that the handler shares no state with
+// the caller.
+func copyRequest(treq *transport.Request) (*transport.Request, error) {
+	req := *treq
+	req.Headers = transport.NewHeadersWithCapacity(treq.Headers.Len())
+	for k, v := range treq.Headers.Items() {
+		req.Headers = req.Headers.With(k, v)
+	}
+
+	var body bytes.Buffer
+	if treq.Body != nil {
+		if _, err := iopool.Copy(&body, treq.Body); err != nil {
+			return nil, err
+		}
+	}
+	req.Body = &body
+	return &req, nil
+}
+
+// remoteError converts an error returned by a handler into the error the
+// caller would receive from a remote service.
+func remoteError(treq *transport.Request, err error) error {
+	err = yarpcerrors.AsHandlerError(treq.Service, treq.Procedure, err)
+	switch {
+	case transport.IsBadRequestError(err):
+		return yarpcerrors.RemoteBadRequestError(err.Error())
+	case transport.IsTimeoutError(err):
+		return yarpcerrors.RemoteTimeoutError(err.Error())
+	default:
+		return yarpcerrors.RemoteUnexpectedError(err.Error())
+	}
+}
+
+// responseWriter buffers a unary response.
+type responseWriter struct {
+	headers transport.Headers
+	body    bytes.Buffer
+	appErr  bool
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	return rw.body.Write(p)
+}
+
+func (rw *responseWriter) AddHeaders(h transport.Headers) {
+	for k, v := range h.Items() {
+		rw.headers = rw.headers.With(k, v)
+	}
+}
+
+func (rw *responseWriter) SetApplicationError() {
+	rw.appErr = true
+}
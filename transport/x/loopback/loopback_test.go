@@ -0,0 +1,346 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loopback
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	yarpcerrors "go.uber.org/yarpc/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+	return f(ctx, req, rw)
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+// staticRouter routes requests by procedure name.
+type staticRouter map[string]transport.HandlerSpec
+
+func (r staticRouter) Procedures() []transport.Procedure {
+	var procs []transport.Procedure
+	for name, spec := range r {
+		procs = append(procs, transport.Procedure{Name: name, HandlerSpec: spec})
+	}
+	return procs
+}
+
+func (r staticRouter) Choose(_ context.Context, req *transport.Request) (transport.HandlerSpec, error) {
+	spec, ok := r[req.Procedure]
+	if !ok {
+		return transport.HandlerSpec{}, yarpcerrors.RouterUnrecognizedProcedureError(req.Service, req.Procedure)
+	}
+	return spec, nil
+}
+
+func startInbound(t *testing.T, trans *Transport, name string, router transport.Router) *Inbound {
+	i := trans.NewInbound(name)
+	i.SetRouter(router)
+	require.NoError(t, i.Start(), "failed to start inbound")
+	return i
+}
+
+func startOutbound(t *testing.T, trans *Transport, name string) *Outbound {
+	o := trans.NewSingleOutbound(name)
+	require.NoError(t, o.Start(), "failed to start outbound")
+	return o
+}
+
+func TestCall(t *testing.T) {
+	type key struct{}
+
+	trans := NewTransport()
+	inbound := startInbound(t, trans, "server", staticRouter{
+		"echo": transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+			func(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+				_, hasDeadline := ctx.Deadline()
+				assert.True(t, hasDeadline, "handler context must have a deadline")
+				assert.Nil(t, ctx.Value(key{}), "context values must not be propagated")
+				assert.Equal(t, "caller", req.Caller)
+				assert.Equal(t, "shard", req.ShardKey)
+
+				v, _ := req.Headers.Get("foo")
+				rw.AddHeaders(transport.NewHeaders().With("foo", v+"-response"))
+				req.Headers.Del("foo")
+
+				body, err := ioutil.ReadAll(req.Body)
+				require.NoError(t, err)
+				if string(body) == "fail" {
+					rw.SetApplicationError()
+				}
+				_, err = rw.Write(body)
+				return err
+			},
+		)),
+	})
+	defer inbound.Stop()
+	out := startOutbound(t, trans, "server")
+	defer out.Stop()
+
+	tests := []struct {
+		body       string
+		wantAppErr bool
+	}{
+		{body: "hello"},
+		{body: "fail", wantAppErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), key{}, "value"), time.Second)
+			defer cancel()
+
+			headers := transport.NewHeaders().With("foo", "bar")
+			res, err := out.Call(ctx, &transport.Request{
+				Caller:    "caller",
+				Service:   "server",
+				Procedure: "echo",
+				Encoding:  "raw",
+				ShardKey:  "shard",
+				Headers:   headers,
+				Body:      bytes.NewBufferString(tt.body),
+			})
+			require.NoError(t, err)
+
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+			assert.Equal(t, tt.wantAppErr, res.ApplicationError)
+			assert.Equal(t, transport.NewHeaders().With("foo", "bar-response"), res.Headers)
+
+			v, ok := headers.Get("foo")
+			assert.True(t, ok && v == "bar", "caller's headers must not be modified by the handler")
+		})
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	trans := NewTransport()
+	inbound := startInbound(t, trans, "server", staticRouter{
+		"bad": transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+			func(context.Context, *transport.Request, transport.ResponseWriter) error {
+				return yarpcerrors.HandlerBadRequestError(errors.New("missing field"))
+			},
+		)),
+		"unexpected": transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+			func(context.Context, *transport.Request, transport.ResponseWriter) error {
+				return errors.New("great sadness")
+			},
+		)),
+		"slow": transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+			func(ctx context.Context, _ *transport.Request, _ transport.ResponseWriter) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		)),
+		"oneway": transport.NewOnewayHandlerSpec(onewayHandlerFunc(
+			func(context.Context, *transport.Request) error { return nil },
+		)),
+	})
+	defer inbound.Stop()
+	out := startOutbound(t, trans, "server")
+	defer out.Stop()
+
+	tests := []struct {
+		procedure string
+		check     func(error) bool
+		wantErr   string
+	}{
+		{
+			procedure: "bad",
+			check:     transport.IsBadRequestError,
+			wantErr:   "missing field",
+		},
+		{
+			procedure: "unexpected",
+			check:     transport.IsUnexpectedError,
+			wantErr:   "great sadness",
+		},
+		{
+			procedure: "slow",
+			check:     transport.IsTimeoutError,
+			wantErr:   `procedure "slow"`,
+		},
+		{
+			procedure: "oneway",
+			check:     transport.IsUnexpectedError,
+			wantErr:   "loopback",
+		},
+		{
+			procedure: "unknown",
+			check:     transport.IsBadRequestError,
+			wantErr:   `unrecognized procedure "unknown"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.procedure, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			_, err := out.Call(ctx, &transport.Request{
+				Caller:    "caller",
+				Service:   "server",
+				Procedure: tt.procedure,
+				Encoding:  "raw",
+				Body:      &bytes.Buffer{},
+			})
+			require.Error(t, err)
+			assert.True(t, tt.check(err), "unexpected error type %T: %v", err, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestCallOneway(t *testing.T) {
+	trans := NewTransport()
+	received := make(chan string, 1)
+	inbound := startInbound(t, trans, "server", staticRouter{
+		"notify": transport.NewOnewayHandlerSpec(onewayHandlerFunc(
+			func(_ context.Context, req *transport.Request) error {
+				body, err := ioutil.ReadAll(req.Body)
+				received <- string(body)
+				return err
+			},
+		)),
+	})
+	defer inbound.Stop()
+	out := startOutbound(t, trans, "server")
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ack, err := out.CallOneway(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "server",
+		Procedure: "notify",
+		Encoding:  "raw",
+		Body:      bytes.NewBufferString("hello"),
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, ack)
+
+	select {
+	case body := <-received:
+		assert.Equal(t, "hello", body)
+	case <-time.After(time.Second):
+		t.Fatal("oneway handler was not called")
+	}
+}
+
+func TestInboundRegistration(t *testing.T) {
+	trans := NewTransport()
+	out := startOutbound(t, trans, "server")
+	defer out.Stop()
+
+	request := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, err := out.Call(ctx, &transport.Request{
+			Caller:    "caller",
+			Service:   "server",
+			Procedure: "hello",
+			Encoding:  "raw",
+			Body:      &bytes.Buffer{},
+		})
+		return err
+	}
+
+	err := request()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `no inbound named "server"`)
+
+	router := staticRouter{
+		"hello": transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+			func(context.Context, *transport.Request, transport.ResponseWriter) error { return nil },
+		)),
+	}
+	inbound := startInbound(t, trans, "server", router)
+	assert.NoError(t, request())
+
+	duplicate := trans.NewInbound("server")
+	duplicate.SetRouter(router)
+	assert.Error(t, duplicate.Start(), "expected duplicate inbound names to fail")
+
+	require.NoError(t, inbound.Stop())
+	assert.Error(t, request(), "expected requests to a stopped inbound to fail")
+}
+
+func TestInboundWithoutRouter(t *testing.T) {
+	assert.Equal(t, errRouterNotSet, NewTransport().NewInbound("server").Start())
+}
+
+func ExampleTransport() {
+	trans := NewTransport()
+
+	inbound := trans.NewInbound("greeter")
+	inbound.SetRouter(staticRouter{
+		"hello": transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+			func(_ context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+				_, err := fmt.Fprintf(rw, "hello from %v", req.Service)
+				return err
+			},
+		)),
+	})
+	if err := inbound.Start(); err != nil {
+		panic(err)
+	}
+	defer inbound.Stop()
+
+	out := trans.NewSingleOutbound("greeter")
+	if err := out.Start(); err != nil {
+		panic(err)
+	}
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res, err := out.Call(ctx, &transport.Request{
+		Caller:    "example",
+		Service:   "greeter",
+		Procedure: "hello",
+		Encoding:  "raw",
+		Body:      &bytes.Buffer{},
+	})
+	if err != nil {
+		panic(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	fmt.Println(string(body))
+	// Output: hello from greeter
+}
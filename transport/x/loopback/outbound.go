@@ -0,0 +1,181 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loopback
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/errors"
+	intsync "go.uber.org/yarpc/internal/sync"
+	peerchooser "go.uber.org/yarpc/peer"
+	"go.uber.org/yarpc/peer/hostport"
+)
+
+var (
+	_ transport.UnaryOutbound  = (*Outbound)(nil)
+	_ transport.OnewayOutbound = (*Outbound)(nil)
+)
+
+// Outbound sends requests to loopback inbounds chosen by a peer.Chooser.
+type Outbound struct {
+	once      intsync.LifecycleOnce
+	chooser   peer.Chooser
+	transport *Transport
+}
+
+// NewOutbound builds an outbound which sends requests to the inbounds
+// supplied by the given peer.Chooser. Peers are identified by inbound names.
+//
+// Peer Choosers used with the loopback outbound MUST yield *hostport.Peer
+// objects retained from this Transport.
+func (t *Transport) NewOutbound(chooser peer.Chooser) *Outbound {
+	return &Outbound{
+		once:      intsync.Once(),
+		chooser:   chooser,
+		transport: t,
+	}
+}
+
+// NewSingleOutbound builds an outbound which sends requests to the inbound
+// with the given name.
+func (t *Transport) NewSingleOutbound(name string) *Outbound {
+	return t.NewOutbound(peerchooser.NewSingle(hostport.PeerIdentifier(name), t))
+}
+
+// Transports returns the outbound's loopback transport.
+func (o *Outbound) Transports() []transport.Transport {
+	return []transport.Transport{o.transport}
+}
+
+// Start starts the outbound and its peer chooser.
+func (o *Outbound) Start() error {
+	return o.once.Start(o.chooser.Start)
+}
+
+// Stop stops the outbound and its peer chooser.
+func (o *Outbound) Stop() error {
+	return o.once.Stop(o.chooser.Stop)
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *Outbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// Call sends a unary request to an inbound and waits for its response or for
+// the context's deadline, whichever comes first.
+func (o *Outbound) Call(ctx context.Context, treq *transport.Request) (*transport.Response, error) {
+	if err := o.once.WhenRunning(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	inbound, onFinish, err := o.choose(ctx, treq)
+	if err != nil {
+		return nil, err
+	}
+
+	// The request is copied before handing it off so that the caller may
+	// reuse it as soon as Call returns, even if the handler is still running.
+	hreq, err := copyRequest(treq)
+	if err != nil {
+		onFinish(err)
+		return nil, err
+	}
+
+	type result struct {
+		res *transport.Response
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		res, err := inbound.handle(ctx, hreq, start)
+		done <- result{res, err}
+	}()
+
+	select {
+	case r := <-done:
+		onFinish(r.err)
+		return r.res, r.err
+	case <-ctx.Done():
+		err := ctx.Err()
+		if err == context.DeadlineExceeded {
+			err = errors.ClientTimeoutError(treq.Service, treq.Procedure, time.Since(start))
+		}
+		onFinish(err)
+		return nil, err
+	}
+}
+
+// CallOneway sends a oneway request to an inbound. It returns once the
+// inbound has accepted the request, without waiting for the handler.
+func (o *Outbound) CallOneway(ctx context.Context, treq *transport.Request) (transport.Ack, error) {
+	if err := o.once.WhenRunning(ctx); err != nil {
+		return nil, err
+	}
+
+	inbound, onFinish, err := o.choose(ctx, treq)
+	if err != nil {
+		return nil, err
+	}
+
+	err = inbound.handleOneway(treq)
+	onFinish(err)
+	if err != nil {
+		return nil, err
+	}
+	return time.Now(), nil
+}
+
+func (o *Outbound) choose(ctx context.Context, treq *transport.Request) (*Inbound, func(error), error) {
+	p, onFinish, err := o.chooser.Choose(ctx, treq)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hpPeer, ok := p.(*hostport.Peer)
+	if !ok {
+		onFinish(nil)
+		return nil, nil, peer.ErrInvalidPeerConversion{
+			Peer:         p,
+			ExpectedType: "*hostport.Peer",
+		}
+	}
+
+	t, ok := hpPeer.Transport().(*Transport)
+	if !ok {
+		onFinish(nil)
+		return nil, nil, peer.ErrInvalidTransportConversion{
+			Transport:    hpPeer.Transport(),
+			ExpectedType: "*loopback.Transport",
+		}
+	}
+
+	inbound, err := t.lookup(hpPeer.HostPort())
+	if err != nil {
+		onFinish(err)
+		return nil, nil, err
+	}
+	return inbound, onFinish, nil
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loopback
+
+import (
+	"fmt"
+	"sync"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	intsync "go.uber.org/yarpc/internal/sync"
+	"go.uber.org/yarpc/peer/hostport"
+)
+
+// Transport is a registry of in-process inbounds, keyed by name. Outbounds
+// built by a Transport send requests to the inbounds registered with it.
+type Transport struct {
+	lock sync.RWMutex
+	once intsync.LifecycleOnce
+
+	inbounds map[string]*Inbound
+	peers    map[string]*hostport.Peer
+}
+
+var (
+	_ transport.Transport = (*Transport)(nil)
+	_ peer.Transport      = (*Transport)(nil)
+)
+
+// NewTransport builds a new loopback transport with an empty registry.
+func NewTransport() *Transport {
+	return &Transport{
+		once:     intsync.Once(),
+		inbounds: make(map[string]*Inbound),
+		peers:    make(map[string]*hostport.Peer),
+	}
+}
+
+// Start starts the loopback transport.
+func (t *Transport) Start() error {
+	return t.once.Start(nil)
+}
+
+// Stop stops the loopback transport.
+func (t *Transport) Stop() error {
+	return t.once.Stop(nil)
+}
+
+// IsRunning returns whether the loopback transport is running.
+func (t *Transport) IsRunning() bool {
+	return t.once.IsRunning()
+}
+
+// RetainPeer gets or creates a Peer for the inbound with the given name. The
+// peer is available while an inbound with that name is running.
+func (t *Transport) RetainPeer(pid peer.Identifier, sub peer.Subscriber) (peer.Peer, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	hppid, ok := pid.(hostport.PeerIdentifier)
+	if !ok {
+		return nil, peer.ErrInvalidPeerType{
+			ExpectedType:   "hostport.PeerIdentifier",
+			PeerIdentifier: pid,
+		}
+	}
+
+	p, ok := t.peers[hppid.Identifier()]
+	if !ok {
+		p = hostport.NewPeer(hppid, t)
+		if _, running := t.inbounds[hppid.Identifier()]; running {
+			p.SetStatus(peer.Available)
+		}
+		t.peers[hppid.Identifier()] = p
+	}
+	p.Subscribe(sub)
+	return p, nil
+}
+
+// ReleasePeer releases a peer from the peer.Subscriber and removes that peer
+// from the Transport if nothing is listening to it.
+func (t *Transport) ReleasePeer(pid peer.Identifier, sub peer.Subscriber) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	p, ok := t.peers[pid.Identifier()]
+	if !ok {
+		return peer.ErrTransportHasNoReferenceToPeer{
+			TransportName:  "loopback.Transport",
+			PeerIdentifier: pid.Identifier(),
+		}
+	}
+
+	if err := p.Unsubscribe(sub); err != nil {
+		return err
+	}
+
+	if p.NumSubscribers() == 0 {
+		delete(t.peers, pid.Identifier())
+	}
+	return nil
+}
+
+// register makes the inbound reachable under its name.
+func (t *Transport) register(i *Inbound) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.inbounds[i.name]; ok {
+		return fmt.Errorf("loopback: an inbound named %q is already running", i.name)
+	}
+	t.inbounds[i.name] = i
+	if p, ok := t.peers[i.name]; ok {
+		p.SetStatus(peer.Available)
+	}
+	return nil
+}
+
+func (t *Transport) unregister(i *Inbound) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.inbounds[i.name] != i {
+		return
+	}
+	delete(t.inbounds, i.name)
+	if p, ok := t.peers[i.name]; ok {
+		p.SetStatus(peer.Unavailable)
+	}
+}
+
+func (t *Transport) lookup(name string) (*Inbound, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	i, ok := t.inbounds[name]
+	if !ok {
+		return nil, fmt.Errorf("loopback: no inbound named %q is running", name)
+	}
+	return i, nil
+}
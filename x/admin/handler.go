@@ -0,0 +1,432 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package admin provides an HTTP handler which reports the state of YARPC
+// dispatchers as JSON and changes some of their behavior at runtime.
+//
+// Unlike the /debug/yarpc page, the handler is not registered on any
+// ServeMux by default and may be mounted anywhere.
+//
+// 	h := admin.NewHandler(admin.Config{
+// 		Dispatchers: []*yarpc.Dispatcher{dispatcher},
+// 		Level:       &level,
+// 		Logger:      logger,
+// 		PeerLists:   map[string]peer.List{"keyvalue": list},
+// 	})
+// 	mux.Handle("/admin/yarpc/", http.StripPrefix("/admin/yarpc", h))
+//
+// The handler serves the following endpoints, relative to where it is
+// mounted. Changes are made with PUT or POST requests with JSON bodies, and
+// every endpoint responds with its current state.
+//
+// 	/         Dispatchers with their inbounds, outbounds, choosers, peers
+// 	          and procedures, and package versions. Read-only.
+// 	/level    Log level, changed with {"level": "debug"}.
+// 	/logging  Procedures whose requests are logged, changed with
+// 	          {"procedure": "get", "enabled": true}.
+// 	/peers    Peers forced out of rotation by peer list, changed with
+// 	          {"list": "keyvalue", "peer": "127.0.0.1:8080", "enabled": false}.
+//
+// Requests to procedures are logged only if the Handler is installed as
+// inbound middleware.
+//
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  h,
+// 			Oneway: h,
+// 		},
+// 	})
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/peer/hostport"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	_ http.Handler             = (*Handler)(nil)
+	_ middleware.UnaryInbound  = (*Handler)(nil)
+	_ middleware.OnewayInbound = (*Handler)(nil)
+)
+
+// Config configures a Handler.
+type Config struct {
+	// Dispatchers reported by the handler.
+	Dispatchers []*yarpc.Dispatcher
+
+	// Level of the logger used by the dispatchers. The log level cannot be
+	// changed if this is nil.
+	Level *zap.AtomicLevel
+
+	// Logger used to log requests to procedures for which request logging
+	// was enabled. Defaults to a no-op logger.
+	Logger *zap.Logger
+
+	// Peer lists whose peers may be forced out of rotation, by name. Peers
+	// are identified by their host:port addresses.
+	PeerLists map[string]peer.List
+}
+
+// Handler is an http.Handler which serves the state of dispatchers as JSON
+// and allows changing their behavior at runtime. Handlers must be built with
+// NewHandler.
+type Handler struct {
+	dispatchers []*yarpc.Dispatcher
+	level       *zap.AtomicLevel
+	logger      *zap.Logger
+	lists       map[string]peer.List
+
+	mu      sync.RWMutex
+	logged  map[string]struct{}
+	removed map[string]map[string]struct{} // list -> peers
+}
+
+// NewHandler builds a new Handler.
+func NewHandler(cfg Config) *Handler {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Handler{
+		dispatchers: cfg.Dispatchers,
+		level:       cfg.Level,
+		logger:      logger,
+		lists:       cfg.PeerLists,
+		logged:      make(map[string]struct{}),
+		removed:     make(map[string]map[string]struct{}),
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var (
+		res interface{}
+		err error
+	)
+	switch req.URL.Path {
+	case "", "/":
+		if err = allowMethods(req, "GET"); err == nil {
+			res = h.status()
+		}
+	case "/level":
+		res, err = h.serveLevel(req)
+	case "/logging":
+		res, err = h.serveLogging(req)
+	case "/peers":
+		res, err = h.servePeers(req)
+	default:
+		err = httpError{Code: http.StatusNotFound, Message: fmt.Sprintf("unknown path %q", req.URL.Path)}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		code := http.StatusInternalServerError
+		if e, ok := err.(httpError); ok {
+			code = e.Code
+		}
+		w.WriteHeader(code)
+		res = errorResponse{Error: err.Error()}
+	}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.logger.Warn("Failed to write admin response.", zap.Error(err))
+	}
+}
+
+type httpError struct {
+	Code    int
+	Message string
+}
+
+func (e httpError) Error() string { return e.Message }
+
+func badRequestf(format string, args ...interface{}) error {
+	return httpError{Code: http.StatusBadRequest, Message: fmt.Sprintf(format, args...)}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// allowMethods returns an error if the request does not use one of the
+// given methods.
+func allowMethods(req *http.Request, methods ...string) error {
+	for _, m := range methods {
+		if req.Method == m {
+			return nil
+		}
+	}
+	return httpError{
+		Code:    http.StatusMethodNotAllowed,
+		Message: fmt.Sprintf("method %v is not allowed for %q", req.Method, req.URL.Path),
+	}
+}
+
+// decodeChange decodes the body of a request that changes the state of the
+// Handler. It returns false if the request only reads the state.
+func decodeChange(req *http.Request, body interface{}) (bool, error) {
+	if err := allowMethods(req, "GET", "PUT", "POST"); err != nil {
+		return false, err
+	}
+	if req.Method == "GET" {
+		return false, nil
+	}
+	if err := json.NewDecoder(req.Body).Decode(body); err != nil {
+		return false, badRequestf("failed to decode request body: %v", err)
+	}
+	return true, nil
+}
+
+type statusResponse struct {
+	Dispatchers     []introspection.DispatcherStatus `json:"dispatchers"`
+	PackageVersions []introspection.PackageVersion   `json:"packageVersions"`
+	PeerLists       []peerListStatus                 `json:"peerLists,omitempty"`
+}
+
+func (h *Handler) status() *statusResponse {
+	res := &statusResponse{
+		Dispatchers:     make([]introspection.DispatcherStatus, 0, len(h.dispatchers)),
+		PackageVersions: yarpc.PackageVersions,
+		PeerLists:       h.peerLists(),
+	}
+	for _, d := range h.dispatchers {
+		res.Dispatchers = append(res.Dispatchers, d.Introspect())
+	}
+	return res
+}
+
+type levelBody struct {
+	Level string `json:"level"`
+}
+
+func (h *Handler) serveLevel(req *http.Request) (interface{}, error) {
+	if h.level == nil {
+		return nil, httpError{Code: http.StatusNotFound, Message: "log level is not configured"}
+	}
+
+	var body levelBody
+	changed, err := decodeChange(req, &body)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(body.Level)); err != nil {
+			return nil, badRequestf("invalid log level %q: %v", body.Level, err)
+		}
+		h.level.SetLevel(level)
+	}
+	return levelBody{Level: h.level.Level().String()}, nil
+}
+
+type loggingRequest struct {
+	Procedure string `json:"procedure"`
+	Enabled   bool   `json:"enabled"`
+}
+
+type loggingResponse struct {
+	Procedures []string `json:"procedures"`
+}
+
+func (h *Handler) serveLogging(req *http.Request) (interface{}, error) {
+	var body loggingRequest
+	changed, err := decodeChange(req, &body)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		if body.Procedure == "" {
+			return nil, badRequestf("procedure is required")
+		}
+		h.SetRequestLogging(body.Procedure, body.Enabled)
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	res := loggingResponse{Procedures: make([]string, 0, len(h.logged))}
+	for p := range h.logged {
+		res.Procedures = append(res.Procedures, p)
+	}
+	sort.Strings(res.Procedures)
+	return res, nil
+}
+
+// SetRequestLogging enables or disables logging of every request to the
+// given procedure.
+func (h *Handler) SetRequestLogging(procedure string, enabled bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if enabled {
+		h.logged[procedure] = struct{}{}
+	} else {
+		delete(h.logged, procedure)
+	}
+}
+
+func (h *Handler) shouldLog(procedure string) bool {
+	h.mu.RLock()
+	_, ok := h.logged[procedure]
+	h.mu.RUnlock()
+	return ok
+}
+
+// Handle implements middleware.UnaryInbound, logging requests to procedures
+// for which request logging was enabled.
+func (h *Handler) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, next transport.UnaryHandler) error {
+	if !h.shouldLog(req.Procedure) {
+		return next.Handle(ctx, req, w)
+	}
+	start := time.Now()
+	err := next.Handle(ctx, req, w)
+	h.logRequest("unary", req, time.Since(start), err)
+	return err
+}
+
+// HandleOneway implements middleware.OnewayInbound, logging requests to
+// procedures for which request logging was enabled.
+func (h *Handler) HandleOneway(ctx context.Context, req *transport.Request, next transport.OnewayHandler) error {
+	if !h.shouldLog(req.Procedure) {
+		return next.HandleOneway(ctx, req)
+	}
+	start := time.Now()
+	err := next.HandleOneway(ctx, req)
+	h.logRequest("oneway", req, time.Since(start), err)
+	return err
+}
+
+func (h *Handler) logRequest(rpcType string, req *transport.Request, elapsed time.Duration, err error) {
+	h.logger.Info("Handled inbound request.",
+		zap.String("rpcType", rpcType),
+		zap.Object("request", req),
+		zap.Duration("latency", elapsed),
+		zap.Bool("successful", err == nil),
+		zap.Error(err),
+	)
+}
+
+type peerRequest struct {
+	List    string `json:"list"`
+	Peer    string `json:"peer"`
+	Enabled bool   `json:"enabled"`
+}
+
+type peerListStatus struct {
+	Name    string                       `json:"name"`
+	Chooser *introspection.ChooserStatus `json:"chooser,omitempty"`
+
+	// Peers forced out of rotation.
+	Removed []string `json:"removed"`
+}
+
+type peersResponse struct {
+	PeerLists []peerListStatus `json:"peerLists"`
+}
+
+func (h *Handler) servePeers(req *http.Request) (interface{}, error) {
+	var body peerRequest
+	changed, err := decodeChange(req, &body)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		if err := h.SetPeerEnabled(body.List, body.Peer, body.Enabled); err != nil {
+			return nil, err
+		}
+	}
+	return peersResponse{PeerLists: h.peerLists()}, nil
+}
+
+// SetPeerEnabled forces the peer with the given host:port address out of
+// rotation in the named peer list by removing it from the list, or returns a
+// peer that was forced out of rotation to the list.
+func (h *Handler) SetPeerEnabled(list, addr string, enabled bool) error {
+	pl, ok := h.lists[list]
+	if !ok {
+		return badRequestf("unknown peer list %q", list)
+	}
+	if addr == "" {
+		return badRequestf("peer is required")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	removed := h.removed[list]
+	_, isRemoved := removed[addr]
+	pid := []peer.Identifier{hostport.PeerIdentifier(addr)}
+	switch {
+	case enabled && isRemoved:
+		if err := pl.Update(peer.ListUpdates{Additions: pid}); err != nil {
+			return badRequestf("failed to add peer %q to peer list %q: %v", addr, list, err)
+		}
+		delete(removed, addr)
+	case !enabled && !isRemoved:
+		if err := pl.Update(peer.ListUpdates{Removals: pid}); err != nil {
+			return badRequestf("failed to remove peer %q from peer list %q: %v", addr, list, err)
+		}
+		if removed == nil {
+			removed = make(map[string]struct{})
+			h.removed[list] = removed
+		}
+		removed[addr] = struct{}{}
+	}
+	return nil
+}
+
+func (h *Handler) peerLists() []peerListStatus {
+	names := make([]string, 0, len(h.lists))
+	for name := range h.lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	statuses := make([]peerListStatus, 0, len(names))
+	for _, name := range names {
+		status := peerListStatus{Name: name, Removed: make([]string, 0, len(h.removed[name]))}
+		if i, ok := h.lists[name].(introspection.IntrospectableChooser); ok {
+			chooser := i.Introspect()
+			status.Chooser = &chooser
+		}
+		for addr := range h.removed[name] {
+			status.Removed = append(status.Removed, addr)
+		}
+		sort.Strings(status.Removed)
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeList is a peer.List which records the peers in it.
+type fakeList struct {
+	peers map[string]struct{}
+}
+
+func (l *fakeList) Update(updates peer.ListUpdates) error {
+	for _, pid := range updates.Removals {
+		if _, ok := l.peers[pid.Identifier()]; !ok {
+			return errors.New("peer not in list")
+		}
+		delete(l.peers, pid.Identifier())
+	}
+	for _, pid := range updates.Additions {
+		l.peers[pid.Identifier()] = struct{}{}
+	}
+	return nil
+}
+
+func serve(t *testing.T, h http.Handler, method, path, body string) (int, map[string]interface{}) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	assert.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+
+	var res map[string]interface{}
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &res), "invalid JSON: %s", rw.Body.String())
+	return rw.Code, res
+}
+
+func TestStatus(t *testing.T) {
+	d := yarpc.NewDispatcher(yarpc.Config{Name: "myservice"})
+	h := NewHandler(Config{Dispatchers: []*yarpc.Dispatcher{d}})
+
+	code, res := serve(t, h, "GET", "/", "")
+	assert.Equal(t, http.StatusOK, code)
+	dispatchers, ok := res["dispatchers"].([]interface{})
+	require.True(t, ok, "dispatchers must be a list: %v", res)
+	require.Len(t, dispatchers, 1)
+	assert.Equal(t, "myservice", dispatchers[0].(map[string]interface{})["name"])
+	assert.NotEmpty(t, res["packageVersions"])
+
+	code, res = serve(t, h, "POST", "/", "{}")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+	assert.Equal(t, `method POST is not allowed for "/"`, res["error"])
+
+	code, res = serve(t, h, "GET", "/foo", "")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, `unknown path "/foo"`, res["error"])
+}
+
+func TestLevel(t *testing.T) {
+	code, res := serve(t, NewHandler(Config{}), "GET", "/level", "")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "log level is not configured", res["error"])
+
+	level := zap.NewAtomicLevelAt(zap.InfoLevel)
+	h := NewHandler(Config{Level: &level})
+
+	code, res = serve(t, h, "GET", "/level", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "info", res["level"])
+
+	code, res = serve(t, h, "PUT", "/level", `{"level": "debug"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "debug", res["level"])
+	assert.Equal(t, zap.DebugLevel, level.Level())
+
+	code, res = serve(t, h, "PUT", "/level", `{"level": "loud"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, res["error"], `invalid log level "loud"`)
+
+	code, res = serve(t, h, "PUT", "/level", `{`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, res["error"], "failed to decode request body")
+}
+
+func TestRequestLogging(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	h := NewHandler(Config{Logger: zap.New(core)})
+
+	unary := transporttest.NewMockUnaryHandler(mockCtrl)
+	unary.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	oneway := transporttest.NewMockOnewayHandler(mockCtrl)
+	oneway.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).Return(errors.New("great sadness"))
+
+	ctx := context.Background()
+	get := &transport.Request{Service: "keyvalue", Procedure: "get"}
+	notify := &transport.Request{Service: "keyvalue", Procedure: "notify"}
+
+	require.NoError(t, h.Handle(ctx, get, nil, unary))
+	assert.Equal(t, 0, logs.Len(), "requests must not be logged by default")
+
+	code, res := serve(t, h, "PUT", "/logging", `{"procedure": "get", "enabled": true}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{"get"}, res["procedures"])
+	h.SetRequestLogging("notify", true)
+
+	require.NoError(t, h.Handle(ctx, get, nil, unary))
+	require.Error(t, h.HandleOneway(ctx, notify, oneway))
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "unary", entries[0].ContextMap()["rpcType"])
+	assert.Equal(t, true, entries[0].ContextMap()["successful"])
+	assert.Equal(t, "oneway", entries[1].ContextMap()["rpcType"])
+	assert.Equal(t, "great sadness", entries[1].ContextMap()["error"])
+
+	code, res = serve(t, h, "POST", "/logging", `{"procedure": "get", "enabled": false}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{"notify"}, res["procedures"])
+
+	code, res = serve(t, h, "POST", "/logging", `{"enabled": true}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "procedure is required", res["error"])
+}
+
+func TestPeers(t *testing.T) {
+	list := &fakeList{peers: map[string]struct{}{"127.0.0.1:8080": {}, "127.0.0.1:8081": {}}}
+	h := NewHandler(Config{PeerLists: map[string]peer.List{"keyvalue": list}})
+
+	code, res := serve(t, h, "PUT", "/peers", `{"list": "keyvalue", "peer": "127.0.0.1:8080", "enabled": false}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"name":    "keyvalue",
+		"removed": []interface{}{"127.0.0.1:8080"},
+	}}, res["peerLists"])
+	assert.Equal(t, map[string]struct{}{"127.0.0.1:8081": {}}, list.peers)
+
+	// Removing the peer again has no effect.
+	code, _ = serve(t, h, "PUT", "/peers", `{"list": "keyvalue", "peer": "127.0.0.1:8080", "enabled": false}`)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, res = serve(t, h, "PUT", "/peers", `{"list": "keyvalue", "peer": "127.0.0.1:9000", "enabled": false}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, `failed to remove peer "127.0.0.1:9000" from peer list "keyvalue": peer not in list`, res["error"])
+
+	code, res = serve(t, h, "PUT", "/peers", `{"list": "users", "peer": "127.0.0.1:8080"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, `unknown peer list "users"`, res["error"])
+
+	require.NoError(t, h.SetPeerEnabled("keyvalue", "127.0.0.1:8080", true))
+	assert.Len(t, list.peers, 2)
+
+	code, res = serve(t, h, "GET", "/peers", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"name":    "keyvalue",
+		"removed": []interface{}{},
+	}}, res["peerLists"])
+}
@@ -0,0 +1,402 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package cache provides outbound middleware which caches the responses of
+// unary requests to idempotent procedures.
+//
+// Only the responses of procedures which match one of the configured rules
+// are cached, for the TTL of the first matching rule. Responses are looked up
+// by service, procedure, encoding, body and the configured request headers.
+// Concurrent identical requests are coalesced into a single request. If the
+// caller of that request gives up on it, the callers waiting for it send it
+// again.
+//
+// 	c, err := cache.New(cache.Config{
+// 		Size: 10000,
+// 		Procedures: []cache.Procedure{
+// 			{Service: "keyvalue", Procedure: "KeyValue::getValue", TTL: time.Minute},
+// 		},
+// 	})
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name:               "myservice",
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{Unary: c},
+// 	})
+//
+// Failed requests and application errors are never cached. Servers may
+// control caching of their responses with a Cache-Control response header,
+// set with WriteResponseHeader:
+//
+// 	call := yarpc.CallFromContext(ctx)
+// 	call.WriteResponseHeader("cache-control", "max-age=30")
+//
+// The "no-store" and "no-cache" directives prevent the response from being
+// cached, and "max-age" overrides the TTL of the response in seconds.
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+
+	"go.uber.org/atomic"
+)
+
+var _ middleware.UnaryOutbound = (*Cache)(nil)
+
+// CacheControlHeader is the name of the response header with which servers
+// control the caching of their responses.
+const CacheControlHeader = "cache-control"
+
+const defaultSize = 1000
+
+// Config configures a Cache.
+type Config struct {
+	// Maximum number of responses held by the cache. The least recently used
+	// responses are evicted first. Defaults to 1000.
+	Size int
+
+	// Procedures whose responses are cached. Requests are matched against
+	// them in order, and only the first match applies.
+	Procedures []Procedure
+
+	// Names of the request headers which, in addition to the service,
+	// procedure, encoding, and body, distinguish requests.
+	Headers []string
+}
+
+// Procedure matches the requests whose responses are cached for the given
+// TTL. A request matches a Procedure if it matches all of its non-empty
+// names.
+type Procedure struct {
+	Service   string
+	Procedure string
+
+	// How long responses stay in the cache. Must be positive.
+	TTL time.Duration
+}
+
+func (p Procedure) matches(req *transport.Request) bool {
+	return (p.Service == "" || p.Service == req.Service) &&
+		(p.Procedure == "" || p.Procedure == req.Procedure)
+}
+
+// Stats counts the requests seen by a Cache.
+type Stats struct {
+	// Requests answered from the cache.
+	Hits int64
+
+	// Requests sent to the outbound.
+	Misses int64
+
+	// Requests which waited for an identical request already in flight.
+	Coalesced int64
+
+	// Responses evicted from the cache to make room for others.
+	Evictions int64
+}
+
+// response is a response read in full so that it may be returned more than
+// once.
+type response struct {
+	headers          transport.Headers
+	body             []byte
+	applicationError bool
+}
+
+func (r response) transportResponse() *transport.Response {
+	headers := transport.NewHeadersWithCapacity(r.headers.Len())
+	for k, v := range r.headers.Items() {
+		headers = headers.With(k, v)
+	}
+	return &transport.Response{
+		Headers:          headers,
+		Body:             ioutil.NopCloser(bytes.NewReader(r.body)),
+		ApplicationError: r.applicationError,
+	}
+}
+
+type entry struct {
+	key     string
+	res     response
+	expires time.Time
+}
+
+// call is a request in flight which identical requests wait for.
+type call struct {
+	done chan struct{}
+	res  response
+	err  error
+
+	// Whether the request failed because the context of its caller ended.
+	// Waiters send the request again instead of sharing the failure.
+	abandoned bool
+}
+
+// Cache is unary outbound middleware which caches responses.
+type Cache struct {
+	size       int
+	procedures []Procedure
+	headers    []string
+
+	mu      sync.Mutex
+	lru     *list.List // of *entry, most recently used first
+	entries map[string]*list.Element
+	calls   map[string]*call
+
+	hits, misses, coalesced, evictions atomic.Int64
+
+	// Overridden in tests.
+	now func() time.Time
+}
+
+// New builds a Cache with the given configuration.
+func New(cfg Config) (*Cache, error) {
+	if cfg.Size < 0 {
+		return nil, fmt.Errorf("size must not be negative, got %v", cfg.Size)
+	}
+	if cfg.Size == 0 {
+		cfg.Size = defaultSize
+	}
+	for _, p := range cfg.Procedures {
+		if p.TTL <= 0 {
+			return nil, fmt.Errorf("TTL for procedure %q of service %q must be positive, got %v",
+				p.Procedure, p.Service, p.TTL)
+		}
+	}
+	headers := make([]string, len(cfg.Headers))
+	for i, h := range cfg.Headers {
+		headers[i] = transport.CanonicalizeHeaderKey(h)
+	}
+	return &Cache{
+		size:       cfg.Size,
+		procedures: append([]Procedure(nil), cfg.Procedures...),
+		headers:    headers,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+		calls:      make(map[string]*call),
+		now:        time.Now,
+	}, nil
+}
+
+// Call serves the request from the cache if possible, and sends it through
+// the given outbound otherwise.
+func (c *Cache) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	ttl, ok := c.ttl(req)
+	if !ok {
+		return out.Call(ctx, req)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	r := *req
+	r.Body = bytes.NewReader(body)
+	key := c.key(&r, body)
+
+	for {
+		c.mu.Lock()
+		if res, ok := c.get(key); ok {
+			c.mu.Unlock()
+			c.hits.Inc()
+			return res.transportResponse(), nil
+		}
+		cl, ok := c.calls[key]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+		c.coalesced.Inc()
+		select {
+		case <-cl.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if cl.abandoned {
+			// The caller which sent the request gave up on it. Send it again
+			// rather than failing with the context error of that caller.
+			continue
+		}
+		if cl.err != nil {
+			return nil, cl.err
+		}
+		return cl.res.transportResponse(), nil
+	}
+	cl := &call{done: make(chan struct{})}
+	c.calls[key] = cl
+	c.mu.Unlock()
+	c.misses.Inc()
+
+	cl.res, cl.err = c.send(ctx, &r, out)
+	cl.abandoned = cl.err != nil && ctx.Err() != nil
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	if cl.err == nil && !cl.res.applicationError {
+		if ttl, ok := responseTTL(cl.res.headers, ttl); ok {
+			c.add(key, cl.res, c.now().Add(ttl))
+		}
+	}
+	c.mu.Unlock()
+	close(cl.done)
+
+	if cl.err != nil {
+		return nil, cl.err
+	}
+	return cl.res.transportResponse(), nil
+}
+
+// send sends the request through the outbound and reads its response.
+func (c *Cache) send(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (response, error) {
+	res, err := out.Call(ctx, req)
+	if err != nil {
+		return response{}, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return response{}, err
+	}
+	return response{
+		headers:          res.Headers,
+		body:             body,
+		applicationError: res.ApplicationError,
+	}, nil
+}
+
+// Invalidate removes all responses from the cache.
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+// Stats returns the number of requests served by the cache so far.
+func (c *Cache) Stats() Stats {
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Coalesced: c.coalesced.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+// ttl returns the TTL of the first procedure the request matches.
+func (c *Cache) ttl(req *transport.Request) (time.Duration, bool) {
+	for _, p := range c.procedures {
+		if p.matches(req) {
+			return p.TTL, true
+		}
+	}
+	return 0, false
+}
+
+// key hashes the parts of a request which distinguish it from others.
+func (c *Cache) key(req *transport.Request, body []byte) string {
+	h := sha256.New()
+	write := func(s []byte) {
+		var n [8]byte
+		binary.BigEndian.PutUint64(n[:], uint64(len(s)))
+		h.Write(n[:])
+		h.Write(s)
+	}
+	write([]byte(req.Service))
+	write([]byte(req.Procedure))
+	write([]byte(req.Encoding))
+	write(body)
+	for _, k := range c.headers {
+		if v, ok := req.Headers.Get(k); ok {
+			write([]byte(k))
+			write([]byte(v))
+		}
+	}
+	return string(h.Sum(nil))
+}
+
+// get returns the unexpired response for the given key. c.mu must be held.
+func (c *Cache) get(key string) (response, bool) {
+	el, ok := c.entries[key]
+	if !ok {
+		return response{}, false
+	}
+	e := el.Value.(*entry)
+	if !c.now().Before(e.expires) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return response{}, false
+	}
+	c.lru.MoveToFront(el)
+	return e.res, true
+}
+
+// add caches a response until the given time, evicting the least recently
+// used responses if the cache is full. c.mu must be held.
+func (c *Cache) add(key string, res response, expires time.Time) {
+	if el, ok := c.entries[key]; ok {
+		c.lru.Remove(el)
+	}
+	c.entries[key] = c.lru.PushFront(&entry{key: key, res: res, expires: expires})
+	for c.lru.Len() > c.size {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+		delete(c.entries, el.Value.(*entry).key)
+		c.evictions.Inc()
+	}
+}
+
+// responseTTL applies the Cache-Control header of a response to the given
+// TTL, returning false if the response must not be cached.
+func responseTTL(headers transport.Headers, ttl time.Duration) (time.Duration, bool) {
+	value, ok := headers.Get(CacheControlHeader)
+	if !ok {
+		return ttl, true
+	}
+	for _, directive := range strings.Split(value, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store", directive == "no-cache":
+			return 0, false
+		case strings.HasPrefix(directive, "max-age="):
+			seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+			if err != nil || seconds < 0 {
+				continue
+			}
+			ttl = time.Duration(seconds) * time.Second
+		}
+	}
+	return ttl, ttl > 0
+}
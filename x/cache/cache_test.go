@@ -0,0 +1,383 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+// counter responds to requests with bodies counting the requests it
+// answered.
+type counter struct {
+	calls   atomic.Int64
+	headers map[string]string
+	appErr  bool
+
+	// If non-nil, requests block until it is closed or their context ends.
+	block chan struct{}
+}
+
+func (c *counter) respond(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if c.block != nil {
+		select {
+		case <-c.block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return &transport.Response{
+		Headers:          transport.HeadersFromMap(c.headers),
+		Body:             ioutil.NopCloser(bytes.NewReader([]byte(fmt.Sprint(c.calls.Inc())))),
+		ApplicationError: c.appErr,
+	}, nil
+}
+
+func send(t *testing.T, c *Cache, out transport.UnaryOutbound, req *transport.Request) (string, error) {
+	res, err := c.Call(context.Background(), req, out)
+	if err != nil {
+		return "", err
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(body), nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		desc    string
+		cfg     Config
+		wantErr string
+	}{
+		{desc: "empty"},
+		{
+			desc:    "negative size",
+			cfg:     Config{Size: -1},
+			wantErr: "size must not be negative, got -1",
+		},
+		{
+			desc:    "missing TTL",
+			cfg:     Config{Procedures: []Procedure{{Service: "foo", Procedure: "bar"}}},
+			wantErr: `TTL for procedure "bar" of service "foo" must be positive, got 0s`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCall(t *testing.T) {
+	type step struct {
+		// Request to send. The service is always "svc".
+		procedure string
+		body      string
+		headers   map[string]string
+
+		// Time elapsed since the previous step.
+		advance time.Duration
+
+		// Expected response body.
+		want string
+	}
+
+	tests := []struct {
+		desc string
+		cfg  Config
+
+		// Response of the outbound.
+		headers map[string]string
+		appErr  bool
+
+		steps []step
+	}{
+		{
+			desc: "hit",
+			steps: []step{
+				{procedure: "get", body: "a", want: "1"},
+				{procedure: "get", body: "a", want: "1"},
+				{procedure: "get", body: "b", want: "2"},
+				{procedure: "get", body: "a", want: "1"},
+			},
+		},
+		{
+			desc: "procedure not cached",
+			steps: []step{
+				{procedure: "set", body: "a", want: "1"},
+				{procedure: "set", body: "a", want: "2"},
+			},
+		},
+		{
+			desc: "expired",
+			steps: []step{
+				{procedure: "get", want: "1"},
+				{procedure: "get", advance: 59 * time.Second, want: "1"},
+				{procedure: "get", advance: time.Second, want: "2"},
+				{procedure: "get", want: "2"},
+			},
+		},
+		{
+			desc: "headers",
+			cfg:  Config{Headers: []string{"Tenant"}},
+			steps: []step{
+				{procedure: "get", headers: map[string]string{"tenant": "x"}, want: "1"},
+				{procedure: "get", headers: map[string]string{"tenant": "x", "other": "y"}, want: "1"},
+				{procedure: "get", headers: map[string]string{"tenant": "y"}, want: "2"},
+				{procedure: "get", want: "3"},
+				{procedure: "get", headers: map[string]string{"tenant": ""}, want: "4"},
+			},
+		},
+		{
+			desc: "evicted",
+			cfg:  Config{Size: 2},
+			steps: []step{
+				{procedure: "get", body: "a", want: "1"},
+				{procedure: "get", body: "b", want: "2"},
+				{procedure: "get", body: "a", want: "1"},
+				{procedure: "get", body: "c", want: "3"},
+				{procedure: "get", body: "a", want: "1"},
+				{procedure: "get", body: "b", want: "4"},
+			},
+		},
+		{
+			desc:   "application error",
+			appErr: true,
+			steps: []step{
+				{procedure: "get", want: "1"},
+				{procedure: "get", want: "2"},
+			},
+		},
+		{
+			desc:    "no-store",
+			headers: map[string]string{CacheControlHeader: "private, no-store"},
+			steps: []step{
+				{procedure: "get", want: "1"},
+				{procedure: "get", want: "2"},
+			},
+		},
+		{
+			desc:    "max-age",
+			headers: map[string]string{"Cache-Control": "max-age=300"},
+			steps: []step{
+				{procedure: "get", want: "1"},
+				{procedure: "get", advance: 299 * time.Second, want: "1"},
+				{procedure: "get", advance: time.Second, want: "2"},
+			},
+		},
+		{
+			desc:    "max-age zero",
+			headers: map[string]string{CacheControlHeader: "max-age=0"},
+			steps: []step{
+				{procedure: "get", want: "1"},
+				{procedure: "get", want: "2"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Procedures = []Procedure{
+				{Service: "svc", Procedure: "get", TTL: time.Minute},
+			}
+			c, err := New(cfg)
+			require.NoError(t, err)
+
+			now := time.Unix(1000, 0)
+			c.now = func() time.Time { return now }
+
+			cnt := &counter{headers: tt.headers, appErr: tt.appErr}
+			out := yarpctest.NewFakeOutbound(t)
+			defer out.Finish()
+			out.Expect("svc", "get").AnyTimes().Do(cnt.respond)
+			out.Expect("svc", "set").AnyTimes().Do(cnt.respond)
+
+			for i, s := range tt.steps {
+				now = now.Add(s.advance)
+				got, err := send(t, c, out, &transport.Request{
+					Service:   "svc",
+					Procedure: s.procedure,
+					Headers:   transport.HeadersFromMap(s.headers),
+					Body:      bytes.NewReader([]byte(s.body)),
+				})
+				require.NoError(t, err)
+				assert.Equal(t, s.want, got, "unexpected response for step %d", i)
+			}
+		})
+	}
+}
+
+func TestCallError(t *testing.T) {
+	c, err := New(Config{Procedures: []Procedure{{TTL: time.Minute}}})
+	require.NoError(t, err)
+
+	out := yarpctest.NewFakeOutbound(t)
+	defer out.Finish()
+	// Errors must not be cached.
+	out.Expect("", "").Times(2).ReturnsError(errors.New("great sadness"))
+
+	for i := 0; i < 2; i++ {
+		_, err := send(t, c, out, &transport.Request{Body: &bytes.Buffer{}})
+		assert.EqualError(t, err, "great sadness")
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	c, err := New(Config{Procedures: []Procedure{{TTL: time.Minute}}})
+	require.NoError(t, err)
+
+	var cnt counter
+	out := yarpctest.NewFakeOutbound(t)
+	defer out.Finish()
+	out.Expect("", "").Times(2).Do(cnt.respond)
+
+	for _, want := range []string{"1", "1"} {
+		got, err := send(t, c, out, &transport.Request{Body: &bytes.Buffer{}})
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	c.Invalidate()
+	got, err := send(t, c, out, &transport.Request{Body: &bytes.Buffer{}})
+	require.NoError(t, err)
+	assert.Equal(t, "2", got, "expected request to be sent after invalidation")
+	assert.Equal(t, Stats{Hits: 1, Misses: 2}, c.Stats())
+}
+
+func TestCoalescing(t *testing.T) {
+	c, err := New(Config{Procedures: []Procedure{{TTL: time.Minute}}})
+	require.NoError(t, err)
+
+	const n = 10
+	cnt := counter{block: make(chan struct{})}
+	out := yarpctest.NewFakeOutbound(t)
+	defer out.Finish()
+	// Concurrent requests must be coalesced.
+	out.Expect("", "").Do(cnt.respond)
+
+	results := make(chan string, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			got, err := send(t, c, out, &transport.Request{Body: bytes.NewReader([]byte("a"))})
+			assert.NoError(t, err)
+			results <- got
+		}()
+	}
+
+	// Wait for all requests to reach the cache before letting the first one
+	// through.
+	for c.Stats().Misses+c.Stats().Coalesced < n {
+		time.Sleep(time.Millisecond)
+	}
+	close(cnt.block)
+
+	for i := 0; i < n; i++ {
+		assert.Equal(t, "1", <-results)
+	}
+	assert.Equal(t, Stats{Misses: 1, Coalesced: n - 1}, c.Stats())
+}
+
+func TestCoalescedContextCanceled(t *testing.T) {
+	c, err := New(Config{Procedures: []Procedure{{TTL: time.Minute}}})
+	require.NoError(t, err)
+
+	cnt := counter{block: make(chan struct{})}
+	out := yarpctest.NewFakeOutbound(t)
+	defer out.Finish()
+	out.Expect("", "").Do(cnt.respond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Call(context.Background(), &transport.Request{Body: &bytes.Buffer{}}, out)
+	}()
+	for c.Stats().Misses == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Call(ctx, &transport.Request{Body: &bytes.Buffer{}}, out)
+	assert.Equal(t, context.Canceled, err)
+
+	close(cnt.block)
+	<-done
+}
+
+func TestCoalescedLeaderCanceled(t *testing.T) {
+	c, err := New(Config{Procedures: []Procedure{{TTL: time.Minute}}})
+	require.NoError(t, err)
+
+	cnt := counter{block: make(chan struct{})}
+	out := yarpctest.NewFakeOutbound(t)
+	defer out.Finish()
+	// The request is sent again once its first caller gives up on it.
+	out.Expect("", "").Times(2).Do(cnt.respond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := c.Call(ctx, &transport.Request{Body: &bytes.Buffer{}}, out)
+		leaderErr <- err
+	}()
+	for c.Stats().Misses == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	results := make(chan string, 1)
+	go func() {
+		got, err := send(t, c, out, &transport.Request{Body: &bytes.Buffer{}})
+		assert.NoError(t, err, "waiters must not fail with the context error of the leader")
+		results <- got
+	}()
+	for c.Stats().Coalesced == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-leaderErr)
+	for c.Stats().Misses < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(cnt.block)
+
+	assert.Equal(t, "1", <-results)
+	assert.Equal(t, Stats{Misses: 2, Coalesced: 1}, c.Stats())
+}
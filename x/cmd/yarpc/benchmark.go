@@ -0,0 +1,153 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// benchmarkPercentiles are the latency percentiles reported by benchmark.
+var benchmarkPercentiles = []float64{0.5, 0.9, 0.95, 0.99, 0.999, 1}
+
+// benchmark sends opts.Requests requests using opts.Concurrency goroutines
+// and reports the latency distribution of successful requests.
+func benchmark(client client, body []byte, opts *options, stdout io.Writer) error {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		latencies = make([]time.Duration, 0, opts.Requests)
+		errs      = make(map[string]int)
+	)
+
+	requests := make(chan struct{}, opts.Requests)
+	for i := 0; i < opts.Requests; i++ {
+		requests <- struct{}{}
+	}
+	close(requests)
+
+	start := time.Now()
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range requests {
+				latency, err := timeCall(client, body, opts)
+				mu.Lock()
+				if err != nil {
+					errs[err.Error()]++
+				} else {
+					latencies = append(latencies, latency)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return writeJSON(stdout, summarize(latencies, errs, time.Since(start)))
+}
+
+func timeCall(client client, body []byte, opts *options) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	var err error
+	if opts.Oneway {
+		_, err = client.CallOneway(ctx, body, opts.callOptions()...)
+	} else {
+		_, err = client.Call(ctx, body, opts.callOptions()...)
+	}
+	return time.Since(start), err
+}
+
+type benchmarkSummary struct {
+	Requests  int               `json:"requests"`
+	Errors    int               `json:"errors"`
+	Elapsed   string            `json:"elapsed"`
+	RPS       float64           `json:"rps"`
+	Latencies map[string]string `json:"latencies,omitempty"`
+	ErrorsBy  map[string]int    `json:"errorsByMessage,omitempty"`
+}
+
+func summarize(latencies []time.Duration, errs map[string]int, elapsed time.Duration) benchmarkSummary {
+	var numErrs int
+	for _, n := range errs {
+		numErrs += n
+	}
+	total := len(latencies) + numErrs
+
+	summary := benchmarkSummary{
+		Requests: total,
+		Errors:   numErrs,
+		Elapsed:  elapsed.String(),
+	}
+	if elapsed > 0 {
+		summary.RPS = float64(total) / elapsed.Seconds()
+	}
+	if numErrs > 0 {
+		summary.ErrorsBy = errs
+	}
+	if len(latencies) > 0 {
+		summary.Latencies = make(map[string]string, len(benchmarkPercentiles))
+		for p, latency := range percentiles(latencies, benchmarkPercentiles) {
+			summary.Latencies[percentileName(p)] = latency.String()
+		}
+	}
+	return summary
+}
+
+// percentiles returns the latency at each of the given percentiles using the
+// nearest-rank method. latencies is sorted in place.
+func percentiles(latencies []time.Duration, ps []float64) map[float64]time.Duration {
+	sort.Sort(durations(latencies))
+
+	out := make(map[float64]time.Duration, len(ps))
+	for _, p := range ps {
+		rank := int(p*float64(len(latencies))+0.5) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		if rank >= len(latencies) {
+			rank = len(latencies) - 1
+		}
+		out[p] = latencies[rank]
+	}
+	return out
+}
+
+func percentileName(p float64) string {
+	if p == 1 {
+		return "max"
+	}
+	return fmt.Sprintf("p%g", p*100)
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
@@ -0,0 +1,58 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentiles(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i > 0; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	got := percentiles(latencies, []float64{0, 0.5, 0.9, 0.99, 1})
+	assert.Equal(t, map[float64]time.Duration{
+		0:    time.Millisecond,
+		0.5:  50 * time.Millisecond,
+		0.9:  90 * time.Millisecond,
+		0.99: 99 * time.Millisecond,
+		1:    100 * time.Millisecond,
+	}, got)
+}
+
+func TestSummarize(t *testing.T) {
+	summary := summarize(
+		[]time.Duration{time.Millisecond, 3 * time.Millisecond, 2 * time.Millisecond},
+		map[string]int{"timeout": 1},
+		time.Second,
+	)
+	assert.Equal(t, 4, summary.Requests)
+	assert.Equal(t, 1, summary.Errors)
+	assert.Equal(t, float64(4), summary.RPS)
+	assert.Equal(t, map[string]int{"timeout": 1}, summary.ErrorsBy)
+	assert.Equal(t, "2ms", summary.Latencies["p50"])
+	assert.Equal(t, "3ms", summary.Latencies["max"])
+}
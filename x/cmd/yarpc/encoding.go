@@ -0,0 +1,281 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"go.uber.org/yarpc"
+	encodingapi "go.uber.org/yarpc/api/encoding"
+	"go.uber.org/yarpc/api/transport"
+	yjson "go.uber.org/yarpc/encoding/json"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/encoding"
+
+	"gopkg.in/yaml.v2"
+)
+
+// procsProcedure is the yarpcmeta procedure listing the procedures of a
+// service.
+const procsProcedure = "yarpc::procedures"
+
+// requestEncoding builds clients that send request bodies specified on the
+// command line in a specific encoding.
+type requestEncoding interface {
+	NewClient(cc transport.ClientConfig, procedure string) client
+}
+
+// client sends a request body read from the command line and returns the
+// response in a form that can be serialized to JSON.
+type client interface {
+	Call(ctx context.Context, body []byte, opts ...yarpc.CallOption) (interface{}, error)
+	CallOneway(ctx context.Context, body []byte, opts ...yarpc.CallOption) (transport.Ack, error)
+}
+
+func newEncoding(opts *options) (requestEncoding, error) {
+	switch opts.Encoding {
+	case "raw":
+		return rawEncoding{}, nil
+	case "json":
+		return jsonEncoding{}, nil
+	case "thrift":
+		if opts.ThriftFile == "" {
+			return nil, fmt.Errorf("-thrift is required for the thrift encoding")
+		}
+		return newThriftEncoding(opts.ThriftFile, opts.Procedure)
+	case "protobuf":
+		if opts.ProtoFile == "" {
+			return nil, fmt.Errorf("-proto is required for the protobuf encoding")
+		}
+		return newProtobufEncoding(opts.ProtoFile, opts.Procedure)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q: must be one of raw, json, thrift or protobuf", opts.Encoding)
+	}
+}
+
+type rawEncoding struct{}
+
+func (rawEncoding) NewClient(cc transport.ClientConfig, procedure string) client {
+	return rawClient{client: raw.New(cc), procedure: procedure}
+}
+
+type rawClient struct {
+	client    raw.Client
+	procedure string
+}
+
+func (c rawClient) Call(ctx context.Context, body []byte, opts ...yarpc.CallOption) (interface{}, error) {
+	res, err := c.client.Call(ctx, c.procedure, body, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return string(res), nil
+}
+
+func (c rawClient) CallOneway(ctx context.Context, body []byte, opts ...yarpc.CallOption) (transport.Ack, error) {
+	return c.client.CallOneway(ctx, c.procedure, body, opts...)
+}
+
+type jsonEncoding struct{}
+
+func (jsonEncoding) NewClient(cc transport.ClientConfig, procedure string) client {
+	return jsonClient{client: yjson.New(cc), procedure: procedure}
+}
+
+type jsonClient struct {
+	client    yjson.Client
+	procedure string
+}
+
+func (c jsonClient) Call(ctx context.Context, body []byte, opts ...yarpc.CallOption) (interface{}, error) {
+	req, err := parseValue(body)
+	if err != nil {
+		return nil, err
+	}
+	var res interface{}
+	if err := c.client.Call(ctx, c.procedure, req, &res, opts...); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c jsonClient) CallOneway(ctx context.Context, body []byte, opts ...yarpc.CallOption) (transport.Ack, error) {
+	req, err := parseValue(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.client.CallOneway(ctx, c.procedure, req, opts...)
+}
+
+// parseValue parses a JSON or YAML request body into a value that may be
+// serialized to JSON. An empty body is parsed as an empty object.
+func parseValue(body []byte) (interface{}, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(body, &v); err != nil {
+		return nil, fmt.Errorf("failed to parse request body as JSON or YAML: %v", err)
+	}
+	if v == nil {
+		return map[string]interface{}{}, nil
+	}
+	return normalizeValue(v), nil
+}
+
+// normalizeValue replaces the map[interface{}]interface{} values produced by
+// the YAML decoder with map[string]interface{} so that they may be
+// serialized to JSON.
+func normalizeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = normalizeValue(item)
+		}
+		return m
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = normalizeValue(item)
+		}
+		return items
+	default:
+		return v
+	}
+}
+
+func toInt(v interface{}, min, max int64) (int64, error) {
+	var i int64
+	switch n := v.(type) {
+	case int:
+		i = int64(n)
+	case int64:
+		i = n
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, fmt.Errorf("expected an integer, got %v", n)
+		}
+		i = int64(n)
+	default:
+		return 0, fmt.Errorf("expected an integer, got %T", v)
+	}
+	if i < min || i > max {
+		return 0, fmt.Errorf("%d is out of range [%d, %d]", i, min, max)
+	}
+	return i, nil
+}
+
+// encodedClient sends request bodies that have already been serialized in
+// the given encoding.
+type encodedClient struct {
+	cc        transport.ClientConfig
+	encoding  transport.Encoding
+	procedure string
+
+	// withHeaders, if set, adds encoding-specific headers to requests.
+	withHeaders func(transport.Headers) transport.Headers
+}
+
+// Call sends the given request body and returns the response body and
+// whether the response was an application error.
+func (c encodedClient) Call(ctx context.Context, body []byte, opts ...yarpc.CallOption) ([]byte, bool, error) {
+	call := encodingapi.NewOutboundCall(encoding.FromOptions(opts)...)
+	treq := c.request(body)
+
+	ctx, err := c.writeToRequest(ctx, call, treq)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tres, err := c.cc.GetUnaryOutbound().Call(ctx, treq)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tres.Body.Close()
+
+	if _, err := call.ReadFromResponse(ctx, tres); err != nil {
+		return nil, false, err
+	}
+
+	resBody, err := ioutil.ReadAll(tres.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	return resBody, tres.ApplicationError, nil
+}
+
+func (c encodedClient) CallOneway(ctx context.Context, body []byte, opts ...yarpc.CallOption) (transport.Ack, error) {
+	call := encodingapi.NewOutboundCall(encoding.FromOptions(opts)...)
+	treq := c.request(body)
+
+	ctx, err := c.writeToRequest(ctx, call, treq)
+	if err != nil {
+		return nil, err
+	}
+	return c.cc.GetOnewayOutbound().CallOneway(ctx, treq)
+}
+
+func (c encodedClient) writeToRequest(ctx context.Context, call *encodingapi.OutboundCall, treq *transport.Request) (context.Context, error) {
+	ctx, err := call.WriteToRequest(ctx, treq)
+	if err != nil {
+		return ctx, err
+	}
+	if c.withHeaders != nil {
+		treq.Headers = c.withHeaders(treq.Headers)
+	}
+	return ctx, nil
+}
+
+func (c encodedClient) request(body []byte) *transport.Request {
+	return &transport.Request{
+		Caller:    c.cc.Caller(),
+		Service:   c.cc.Service(),
+		Procedure: c.procedure,
+		Encoding:  c.encoding,
+		Body:      bytes.NewReader(body),
+	}
+}
+
+// listProcedures prints the procedures registered by a service that exposes
+// the x/yarpcmeta procedures.
+func listProcedures(cc transport.ClientConfig, opts *options, stdout io.Writer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	var res interface{}
+	if err := yjson.New(cc).Call(ctx, procsProcedure, map[string]interface{}{}, &res, opts.callOptions()...); err != nil {
+		return fmt.Errorf("failed to list procedures, is x/yarpcmeta registered? %v", err)
+	}
+	return writeJSON(stdout, res)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
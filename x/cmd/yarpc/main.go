@@ -0,0 +1,297 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Command yarpc sends requests to YARPC services from the command line.
+//
+// It builds a Dispatcher with a single HTTP, TChannel or gRPC outbound and
+// sends a request in the raw, JSON, Thrift or Protobuf encoding.
+//
+// 	yarpc -peer localhost:8080 -service keyvalue -procedure get -r '{"key": "foo"}'
+//
+// Thrift requests are built from an IDL file and a JSON or YAML request
+// body.
+//
+// 	yarpc -transport tchannel -peer localhost:4040 -service keyvalue \
+// 		-thrift kv.thrift -procedure KeyValue::getValue -r '{"key": "foo"}'
+//
+// Protobuf requests are built from a FileDescriptorSet generated with
+// `protoc --include_imports -o` and a JSON or YAML request body.
+//
+// 	yarpc -transport grpc -peer localhost:5050 -service keyvalue \
+// 		-proto kv.pb -procedure uber.yarpc.KeyValue::GetValue -r 'key: foo'
+//
+// Services that registered the procedures in x/yarpcmeta may be inspected
+// with -list.
+//
+// 	yarpc -peer localhost:8080 -service keyvalue -list
+//
+// Passing -n sends the request repeatedly and reports latency percentiles
+// instead of the response.
+//
+// 	yarpc -peer localhost:8080 -service keyvalue -procedure get \
+// 		-r '{"key": "foo"}' -n 10000 -c 50
+//
+// The validate subcommand checks a YAML configuration for x/config without
+// building a Dispatcher or opening any sockets. Errors and warnings are
+// reported with their location in the configuration, and the command fails
+// if any errors are found, or any warnings with -strict. Only transports with
+// an x/config TransportSpec may be configured, which so far is only HTTP.
+//
+// 	yarpc validate -service keyvalue -strict config.yaml
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+)
+
+var (
+	errMissingPeer      = errors.New("-peer is required")
+	errMissingService   = errors.New("-service is required")
+	errMissingProcedure = errors.New("-procedure is required")
+)
+
+// options holds the parsed command line flags.
+type options struct {
+	Transport       string
+	Peer            string
+	Caller          string
+	Service         string
+	Procedure       string
+	Encoding        string
+	ThriftFile      string
+	ProtoFile       string
+	Request         string
+	RequestFile     string
+	Headers         headers
+	ShardKey        string
+	RoutingKey      string
+	RoutingDelegate string
+	Timeout         time.Duration
+	Oneway          bool
+	List            bool
+	Requests        int
+	Concurrency     int
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) > 0 && args[0] == "validate" {
+		return runValidate(args[1:], stdout)
+	}
+
+	opts, err := parseOptions(args)
+	if err != nil {
+		return err
+	}
+
+	if opts.List {
+		opts.Encoding = "json"
+		opts.Procedure = procsProcedure
+	}
+
+	enc, err := newEncoding(opts)
+	if err != nil {
+		return err
+	}
+
+	outbounds, err := newOutbounds(opts)
+	if err != nil {
+		return err
+	}
+
+	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+		Name:      opts.Caller,
+		Outbounds: yarpc.Outbounds{opts.Service: outbounds},
+	})
+	if err := dispatcher.Start(); err != nil {
+		return err
+	}
+	defer dispatcher.Stop()
+
+	if opts.List {
+		return listProcedures(dispatcher.ClientConfig(opts.Service), opts, stdout)
+	}
+
+	body, err := readRequest(opts, stdin)
+	if err != nil {
+		return err
+	}
+
+	client := enc.NewClient(dispatcher.ClientConfig(opts.Service), opts.Procedure)
+	if opts.Requests > 0 {
+		return benchmark(client, body, opts, stdout)
+	}
+	return callOnce(client, body, opts, stdout)
+}
+
+func parseOptions(args []string) (*options, error) {
+	var opts options
+	flags := flag.NewFlagSet("yarpc", flag.ContinueOnError)
+	flags.StringVar(&opts.Transport, "transport", "http", "Transport used to send the request: http, tchannel or grpc")
+	flags.StringVar(&opts.Peer, "peer", "", "Address of the service as host:port, or an HTTP URL")
+	flags.StringVar(&opts.Caller, "caller", "yarpc", "Name of the calling service")
+	flags.StringVar(&opts.Service, "service", "", "Name of the service being called")
+	flags.StringVar(&opts.Procedure, "procedure", "", "Name of the procedure being called")
+	flags.StringVar(&opts.Encoding, "encoding", "", "Encoding of the request: raw, json, thrift or protobuf (inferred from -thrift and -proto if unset)")
+	flags.StringVar(&opts.ThriftFile, "thrift", "", "Thrift IDL file defining the service")
+	flags.StringVar(&opts.ProtoFile, "proto", "", "Protobuf FileDescriptorSet defining the service")
+	flags.StringVar(&opts.Request, "r", "", "Request body")
+	flags.StringVar(&opts.RequestFile, "f", "", "File containing the request body, or - for stdin")
+	flags.Var(&opts.Headers, "H", "Request header as key:value; may be repeated")
+	flags.StringVar(&opts.ShardKey, "shard-key", "", "Shard key for the request")
+	flags.StringVar(&opts.RoutingKey, "routing-key", "", "Routing key for the request")
+	flags.StringVar(&opts.RoutingDelegate, "routing-delegate", "", "Routing delegate for the request")
+	flags.DurationVar(&opts.Timeout, "timeout", time.Second, "Timeout for each request")
+	flags.BoolVar(&opts.Oneway, "oneway", false, "Send the request as a oneway request")
+	flags.BoolVar(&opts.List, "list", false, "List the procedures registered by the service through yarpcmeta")
+	flags.IntVar(&opts.Requests, "n", 0, "Number of requests to send as a benchmark")
+	flags.IntVar(&opts.Concurrency, "c", 1, "Number of concurrent requests in benchmark mode")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if opts.Peer == "" {
+		return nil, errMissingPeer
+	}
+	if opts.Service == "" {
+		return nil, errMissingService
+	}
+	if opts.Procedure == "" && !opts.List {
+		return nil, errMissingProcedure
+	}
+	if opts.Concurrency < 1 {
+		return nil, fmt.Errorf("-c must be positive, got %d", opts.Concurrency)
+	}
+	if opts.Encoding == "" {
+		switch {
+		case opts.ThriftFile != "":
+			opts.Encoding = "thrift"
+		case opts.ProtoFile != "":
+			opts.Encoding = "protobuf"
+		default:
+			opts.Encoding = "json"
+		}
+	}
+	return &opts, nil
+}
+
+// callOptions returns the yarpc.CallOptions for the headers, shard key and
+// routing parameters specified on the command line.
+func (o *options) callOptions() []yarpc.CallOption {
+	var opts []yarpc.CallOption
+	for _, h := range o.Headers {
+		opts = append(opts, yarpc.WithHeader(h.Key, h.Value))
+	}
+	if o.ShardKey != "" {
+		opts = append(opts, yarpc.WithShardKey(o.ShardKey))
+	}
+	if o.RoutingKey != "" {
+		opts = append(opts, yarpc.WithRoutingKey(o.RoutingKey))
+	}
+	if o.RoutingDelegate != "" {
+		opts = append(opts, yarpc.WithRoutingDelegate(o.RoutingDelegate))
+	}
+	return opts
+}
+
+func readRequest(opts *options, stdin io.Reader) ([]byte, error) {
+	switch opts.RequestFile {
+	case "":
+		return []byte(opts.Request), nil
+	case "-":
+		return ioutil.ReadAll(stdin)
+	default:
+		return ioutil.ReadFile(opts.RequestFile)
+	}
+}
+
+func callOnce(client client, body []byte, opts *options, stdout io.Writer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	if opts.Oneway {
+		ack, err := client.CallOneway(ctx, body, opts.callOptions()...)
+		if err != nil {
+			return err
+		}
+		return writeJSON(stdout, map[string]interface{}{"ack": ackString(ack)})
+	}
+
+	var resHeaders map[string]string
+	callOpts := append(opts.callOptions(), yarpc.ResponseHeaders(&resHeaders))
+	res, err := client.Call(ctx, body, callOpts...)
+	if err != nil {
+		return err
+	}
+	out := map[string]interface{}{"body": res}
+	if len(resHeaders) > 0 {
+		out["headers"] = resHeaders
+	}
+	return writeJSON(stdout, out)
+}
+
+func ackString(ack transport.Ack) string {
+	if ack == nil {
+		return ""
+	}
+	return ack.String()
+}
+
+// headers is a flag.Value that collects repeated key:value pairs.
+type headers []header
+
+type header struct {
+	Key   string
+	Value string
+}
+
+func (hs *headers) String() string {
+	pairs := make([]string, len(*hs))
+	for i, h := range *hs {
+		pairs[i] = h.Key + ":" + h.Value
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (hs *headers) Set(s string) error {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("header %q must be of the form key:value", s)
+	}
+	*hs = append(*hs, header{Key: strings.TrimSpace(parts[0]), Value: strings.TrimSpace(parts[1])})
+	return nil
+}
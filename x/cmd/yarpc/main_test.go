@@ -0,0 +1,230 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc"
+	yjson "go.uber.org/yarpc/encoding/json"
+	"go.uber.org/yarpc/transport/http"
+	"go.uber.org/yarpc/x/yarpcmeta"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		desc    string
+		args    []string
+		want    *options
+		wantErr string
+	}{
+		{
+			desc:    "missing peer",
+			args:    []string{"-service", "foo", "-procedure", "bar"},
+			wantErr: "-peer is required",
+		},
+		{
+			desc:    "missing service",
+			args:    []string{"-peer", "localhost:8080", "-procedure", "bar"},
+			wantErr: "-service is required",
+		},
+		{
+			desc:    "missing procedure",
+			args:    []string{"-peer", "localhost:8080", "-service", "foo"},
+			wantErr: "-procedure is required",
+		},
+		{
+			desc:    "invalid header",
+			args:    []string{"-peer", "localhost:8080", "-service", "foo", "-procedure", "bar", "-H", "nope"},
+			wantErr: `header "nope" must be of the form key:value`,
+		},
+		{
+			desc:    "invalid concurrency",
+			args:    []string{"-peer", "localhost:8080", "-service", "foo", "-procedure", "bar", "-c", "0"},
+			wantErr: "-c must be positive, got 0",
+		},
+		{
+			desc: "list without procedure",
+			args: []string{"-peer", "localhost:8080", "-service", "foo", "-list"},
+			want: &options{
+				Transport:   "http",
+				Peer:        "localhost:8080",
+				Caller:      "yarpc",
+				Service:     "foo",
+				Encoding:    "json",
+				Timeout:     time.Second,
+				List:        true,
+				Concurrency: 1,
+			},
+		},
+		{
+			desc: "thrift inferred",
+			args: []string{
+				"-transport", "tchannel", "-peer", "localhost:4040", "-service", "foo",
+				"-procedure", "Foo::bar", "-thrift", "foo.thrift",
+				"-H", "a:1", "-H", "b: 2", "-shard-key", "sk", "-timeout", "5s",
+			},
+			want: &options{
+				Transport:   "tchannel",
+				Peer:        "localhost:4040",
+				Caller:      "yarpc",
+				Service:     "foo",
+				Procedure:   "Foo::bar",
+				Encoding:    "thrift",
+				ThriftFile:  "foo.thrift",
+				Headers:     headers{{"a", "1"}, {"b", "2"}},
+				ShardKey:    "sk",
+				Timeout:     5 * time.Second,
+				Concurrency: 1,
+			},
+		},
+		{
+			desc: "protobuf inferred",
+			args: []string{"-peer", "localhost:5050", "-service", "foo", "-procedure", "foo.Foo::Bar", "-proto", "foo.pb"},
+			want: &options{
+				Transport:   "http",
+				Peer:        "localhost:5050",
+				Caller:      "yarpc",
+				Service:     "foo",
+				Procedure:   "foo.Foo::Bar",
+				Encoding:    "protobuf",
+				ProtoFile:   "foo.pb",
+				Timeout:     time.Second,
+				Concurrency: 1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			opts, err := parseOptions(tt.args)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, opts)
+		})
+	}
+}
+
+func TestRunHTTP(t *testing.T) {
+	inbound := http.NewTransport().NewInbound("127.0.0.1:0")
+	server := yarpc.NewDispatcher(yarpc.Config{
+		Name:     "server",
+		Inbounds: yarpc.Inbounds{inbound},
+	})
+	server.Register(yjson.Procedure("echo", func(ctx context.Context, req map[string]interface{}) (map[string]interface{}, error) {
+		call := yarpc.CallFromContext(ctx)
+		if err := call.WriteResponseHeader("caller", call.Caller()); err != nil {
+			return nil, err
+		}
+		req["token"] = call.Header("token")
+		return req, nil
+	}))
+	yarpcmeta.Register(server)
+	require.NoError(t, server.Start())
+	defer server.Stop()
+
+	peer := inbound.Addr().String()
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		err := run([]string{
+			"-peer", peer, "-service", "server", "-procedure", "echo",
+			"-r", "hello: world", "-H", "token:42",
+		}, nil, &out)
+		require.NoError(t, err)
+
+		var res map[string]interface{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &res))
+		assert.Equal(t, map[string]interface{}{
+			"body":    map[string]interface{}{"hello": "world", "token": "42"},
+			"headers": map[string]interface{}{"caller": "yarpc"},
+		}, res)
+	})
+
+	t.Run("request from stdin", func(t *testing.T) {
+		var out bytes.Buffer
+		err := run([]string{
+			"-peer", "http://" + peer, "-service", "server", "-procedure", "echo", "-f", "-",
+		}, bytes.NewBufferString(`{"hello": "stdin"}`), &out)
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), `"hello": "stdin"`)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		var out bytes.Buffer
+		err := run([]string{"-peer", peer, "-service", "server", "-list"}, nil, &out)
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), `"name": "echo"`)
+	})
+
+	t.Run("benchmark", func(t *testing.T) {
+		var out bytes.Buffer
+		err := run([]string{
+			"-peer", peer, "-service", "server", "-procedure", "echo",
+			"-r", "{}", "-n", "20", "-c", "4",
+		}, nil, &out)
+		require.NoError(t, err)
+
+		var summary benchmarkSummary
+		require.NoError(t, json.Unmarshal(out.Bytes(), &summary))
+		assert.Equal(t, 20, summary.Requests)
+		assert.Equal(t, 0, summary.Errors)
+		assert.Contains(t, summary.Latencies, "p99")
+		assert.Contains(t, summary.Latencies, "max")
+	})
+
+	t.Run("unknown procedure", func(t *testing.T) {
+		err := run([]string{"-peer", peer, "-service", "server", "-procedure", "nope"}, nil, &bytes.Buffer{})
+		assert.Error(t, err)
+	})
+}
+
+func TestNewOutbounds(t *testing.T) {
+	for _, transportName := range []string{"http", "tchannel", "grpc"} {
+		t.Run(transportName, func(t *testing.T) {
+			outbounds, err := newOutbounds(&options{
+				Transport: transportName,
+				Caller:    "yarpc",
+				Peer:      "127.0.0.1:1",
+			})
+			require.NoError(t, err)
+			assert.NotNil(t, outbounds.Unary, "unary outbound")
+			assert.NotNil(t, outbounds.Oneway, "oneway outbound")
+		})
+	}
+
+	t.Run("unknown transport", func(t *testing.T) {
+		_, err := newOutbounds(&options{Transport: "nope", Peer: "127.0.0.1:1"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown transport "nope"`)
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/transport/http"
+	"go.uber.org/yarpc/transport/tchannel"
+	"go.uber.org/yarpc/transport/x/grpc"
+)
+
+// newOutbounds builds the unary and oneway outbounds for the transport
+// requested on the command line.
+func newOutbounds(opts *options) (transport.Outbounds, error) {
+	switch opts.Transport {
+	case "http":
+		url := opts.Peer
+		if !strings.Contains(url, "://") {
+			url = "http://" + url
+		}
+		out := http.NewTransport().NewSingleOutbound(url)
+		return transport.Outbounds{Unary: out, Oneway: out}, nil
+	case "tchannel":
+		t, err := tchannel.NewChannelTransport(tchannel.ServiceName(opts.Caller))
+		if err != nil {
+			return transport.Outbounds{}, err
+		}
+		out := t.NewSingleOutbound(opts.Peer)
+		return transport.Outbounds{Unary: out, Oneway: out}, nil
+	case "grpc":
+		out := grpc.NewSingleOutbound(opts.Peer)
+		return transport.Outbounds{Unary: out, Oneway: out}, nil
+	default:
+		return transport.Outbounds{}, fmt.Errorf("unknown transport %q: must be one of http, tchannel or grpc", opts.Transport)
+	}
+}
@@ -0,0 +1,611 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/x/protobuf"
+	"go.uber.org/yarpc/internal/procedure"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+)
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// protobufEncoding builds Protobuf requests for a single method of a service
+// defined in a FileDescriptorSet.
+type protobufEncoding struct {
+	types    *protoTypes
+	request  *descriptor.DescriptorProto
+	response *descriptor.DescriptorProto
+}
+
+func newProtobufEncoding(file, proc string) (protobufEncoding, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return protobufEncoding{}, err
+	}
+	var set descriptor.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return protobufEncoding{}, fmt.Errorf("failed to parse FileDescriptorSet %q: %v", file, err)
+	}
+
+	serviceName, methodName := procedure.FromName(proc)
+	if methodName == "" {
+		return protobufEncoding{}, fmt.Errorf("procedure %q must be of the form package.Service::Method", proc)
+	}
+
+	types := newProtoTypes(&set)
+	for _, f := range set.File {
+		for _, s := range f.Service {
+			if qualifiedName(f.GetPackage(), s.GetName()) != serviceName {
+				continue
+			}
+			for _, m := range s.Method {
+				if m.GetName() != methodName {
+					continue
+				}
+				req, err := types.message(m.GetInputType())
+				if err != nil {
+					return protobufEncoding{}, err
+				}
+				res, err := types.message(m.GetOutputType())
+				if err != nil {
+					return protobufEncoding{}, err
+				}
+				return protobufEncoding{types: types, request: req, response: res}, nil
+			}
+			return protobufEncoding{}, fmt.Errorf("method %q not found in service %q", methodName, serviceName)
+		}
+	}
+	return protobufEncoding{}, fmt.Errorf("service %q not found in %q", serviceName, file)
+}
+
+func (e protobufEncoding) NewClient(cc transport.ClientConfig, proc string) client {
+	return protobufClient{
+		encoding: e,
+		client: encodedClient{
+			cc:        cc,
+			encoding:  protobuf.Encoding,
+			procedure: proc,
+			// Ask for the response message directly rather than wrapped
+			// in the envelope used between YARPC clients and servers.
+			withHeaders: protobuf.SetRawResponse,
+		},
+	}
+}
+
+type protobufClient struct {
+	encoding protobufEncoding
+	client   encodedClient
+}
+
+func (c protobufClient) Call(ctx context.Context, body []byte, opts ...yarpc.CallOption) (interface{}, error) {
+	req, err := c.encode(body)
+	if err != nil {
+		return nil, err
+	}
+	res, _, err := c.client.Call(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.encoding.types.decodeMessage(c.encoding.response, res)
+}
+
+func (c protobufClient) CallOneway(ctx context.Context, body []byte, opts ...yarpc.CallOption) (transport.Ack, error) {
+	req, err := c.encode(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.client.CallOneway(ctx, req, opts...)
+}
+
+func (c protobufClient) encode(body []byte) ([]byte, error) {
+	v, err := parseValue(body)
+	if err != nil {
+		return nil, err
+	}
+	b, err := c.encoding.types.encodeMessage(c.encoding.request, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request for %q: %v", c.encoding.request.GetName(), err)
+	}
+	return b, nil
+}
+
+// protoTypes indexes the messages and enums of a FileDescriptorSet by their
+// fully qualified names, with a leading period.
+type protoTypes struct {
+	messages map[string]*descriptor.DescriptorProto
+	enums    map[string]*descriptor.EnumDescriptorProto
+}
+
+func newProtoTypes(set *descriptor.FileDescriptorSet) *protoTypes {
+	t := &protoTypes{
+		messages: make(map[string]*descriptor.DescriptorProto),
+		enums:    make(map[string]*descriptor.EnumDescriptorProto),
+	}
+	for _, f := range set.File {
+		prefix := "." + qualifiedName(f.GetPackage(), "")
+		for _, e := range f.EnumType {
+			t.enums[prefix+e.GetName()] = e
+		}
+		for _, m := range f.MessageType {
+			t.addMessage(prefix, m)
+		}
+	}
+	return t
+}
+
+func (t *protoTypes) addMessage(prefix string, m *descriptor.DescriptorProto) {
+	name := prefix + m.GetName()
+	t.messages[name] = m
+	for _, e := range m.EnumType {
+		t.enums[name+"."+e.GetName()] = e
+	}
+	for _, nested := range m.NestedType {
+		t.addMessage(name+".", nested)
+	}
+}
+
+func (t *protoTypes) message(name string) (*descriptor.DescriptorProto, error) {
+	m, ok := t.messages[name]
+	if !ok {
+		return nil, fmt.Errorf("message %q not found", name)
+	}
+	return m, nil
+}
+
+func qualifiedName(pkg, name string) string {
+	if pkg == "" {
+		return name
+	}
+	if name == "" {
+		return pkg + "."
+	}
+	return pkg + "." + name
+}
+
+func (t *protoTypes) encodeMessage(m *descriptor.DescriptorProto, v interface{}) ([]byte, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %T", v)
+	}
+
+	used := 0
+	var buf []byte
+	for _, f := range m.Field {
+		fv, ok := obj[f.GetName()]
+		if !ok && f.GetJsonName() != "" {
+			fv, ok = obj[f.GetJsonName()]
+		}
+		if !ok || fv == nil {
+			continue
+		}
+		used++
+
+		var err error
+		switch {
+		case t.isMap(f):
+			buf, err = t.appendMap(buf, f, fv)
+		case f.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REPEATED:
+			items, ok := fv.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("field %q: expected a list, got %T", f.GetName(), fv)
+			}
+			for _, item := range items {
+				if buf, err = t.appendField(buf, f, item); err != nil {
+					break
+				}
+			}
+		default:
+			buf, err = t.appendField(buf, f, fv)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", f.GetName(), err)
+		}
+	}
+	if used != len(obj) {
+		return nil, fmt.Errorf("unknown fields in %v", sortedKeys(obj))
+	}
+	return buf, nil
+}
+
+func (t *protoTypes) isMap(f *descriptor.FieldDescriptorProto) bool {
+	if f.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE {
+		return false
+	}
+	m, ok := t.messages[f.GetTypeName()]
+	return ok && m.GetOptions().GetMapEntry()
+}
+
+func (t *protoTypes) appendMap(buf []byte, f *descriptor.FieldDescriptorProto, v interface{}) ([]byte, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %T", v)
+	}
+	entry := t.messages[f.GetTypeName()]
+	key, value := entry.Field[0], entry.Field[1]
+	for _, k := range sortedKeys(obj) {
+		kv, err := parseMapKey(key, k)
+		if err != nil {
+			return nil, err
+		}
+		entryBuf, err := t.appendField(nil, key, kv)
+		if err != nil {
+			return nil, err
+		}
+		if entryBuf, err = t.appendField(entryBuf, value, obj[k]); err != nil {
+			return nil, err
+		}
+		buf = appendTag(buf, f.GetNumber(), wireBytes)
+		buf = appendBytes(buf, entryBuf)
+	}
+	return buf, nil
+}
+
+// parseMapKey converts a JSON object key into a value for the given map key
+// field.
+func parseMapKey(f *descriptor.FieldDescriptorProto, k string) (interface{}, error) {
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		return k, nil
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		return strconv.ParseBool(k)
+	default:
+		return strconv.ParseFloat(k, 64)
+	}
+}
+
+func (t *protoTypes) appendField(buf []byte, f *descriptor.FieldDescriptorProto, v interface{}) ([]byte, error) {
+	n := f.GetNumber()
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_DOUBLE:
+		x, err := toFloat(v)
+		buf = appendTag(buf, n, wireFixed64)
+		return appendFixed64(buf, math.Float64bits(x)), err
+	case descriptor.FieldDescriptorProto_TYPE_FLOAT:
+		x, err := toFloat(v)
+		buf = appendTag(buf, n, wireFixed32)
+		return appendFixed32(buf, math.Float32bits(float32(x))), err
+	case descriptor.FieldDescriptorProto_TYPE_INT32, descriptor.FieldDescriptorProto_TYPE_INT64,
+		descriptor.FieldDescriptorProto_TYPE_UINT32, descriptor.FieldDescriptorProto_TYPE_UINT64:
+		x, err := toInt(v, math.MinInt64, math.MaxInt64)
+		buf = appendTag(buf, n, wireVarint)
+		return appendVarint(buf, uint64(x)), err
+	case descriptor.FieldDescriptorProto_TYPE_SINT32, descriptor.FieldDescriptorProto_TYPE_SINT64:
+		x, err := toInt(v, math.MinInt64, math.MaxInt64)
+		buf = appendTag(buf, n, wireVarint)
+		return appendVarint(buf, uint64((x<<1)^(x>>63))), err
+	case descriptor.FieldDescriptorProto_TYPE_FIXED32, descriptor.FieldDescriptorProto_TYPE_SFIXED32:
+		x, err := toInt(v, math.MinInt32, math.MaxUint32)
+		buf = appendTag(buf, n, wireFixed32)
+		return appendFixed32(buf, uint32(x)), err
+	case descriptor.FieldDescriptorProto_TYPE_FIXED64, descriptor.FieldDescriptorProto_TYPE_SFIXED64:
+		x, err := toInt(v, math.MinInt64, math.MaxInt64)
+		buf = appendTag(buf, n, wireFixed64)
+		return appendFixed64(buf, uint64(x)), err
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a bool, got %T", v)
+		}
+		var x uint64
+		if b {
+			x = 1
+		}
+		buf = appendTag(buf, n, wireVarint)
+		return appendVarint(buf, x), nil
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		x, err := t.enumValue(f.GetTypeName(), v)
+		buf = appendTag(buf, n, wireVarint)
+		return appendVarint(buf, uint64(x)), err
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %T", v)
+		}
+		buf = appendTag(buf, n, wireBytes)
+		return appendBytes(buf, []byte(s)), nil
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a base64 string, got %T", v)
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		buf = appendTag(buf, n, wireBytes)
+		return appendBytes(buf, b), err
+	case descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		m, err := t.message(f.GetTypeName())
+		if err != nil {
+			return nil, err
+		}
+		b, err := t.encodeMessage(m, v)
+		buf = appendTag(buf, n, wireBytes)
+		return appendBytes(buf, b), err
+	default:
+		return nil, fmt.Errorf("unsupported field type %v", f.GetType())
+	}
+}
+
+func (t *protoTypes) enumValue(name string, v interface{}) (int64, error) {
+	s, ok := v.(string)
+	if !ok {
+		return toInt(v, math.MinInt32, math.MaxInt32)
+	}
+	e, ok := t.enums[name]
+	if !ok {
+		return 0, fmt.Errorf("enum %q not found", name)
+	}
+	for _, value := range e.Value {
+		if value.GetName() == s {
+			return int64(value.GetNumber()), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown value %q for enum %q", s, name)
+}
+
+func (t *protoTypes) decodeMessage(m *descriptor.DescriptorProto, data []byte) (map[string]interface{}, error) {
+	fields := make(map[int32]*descriptor.FieldDescriptorProto, len(m.Field))
+	for _, f := range m.Field {
+		fields[f.GetNumber()] = f
+	}
+
+	out := make(map[string]interface{})
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid field tag")
+		}
+		data = data[n:]
+		number, wireType := int32(tag>>3), int(tag&7)
+
+		raw, rest, err := readWireValue(data, wireType)
+		if err != nil {
+			return nil, err
+		}
+		data = rest
+
+		f, ok := fields[number]
+		if !ok {
+			// Unknown fields are skipped.
+			continue
+		}
+		if err := t.decodeField(out, f, wireType, raw); err != nil {
+			return nil, fmt.Errorf("field %q: %v", f.GetName(), err)
+		}
+	}
+	return out, nil
+}
+
+// readWireValue splits the value of the given wire type from the front of
+// data. Varints and fixed-width values are returned as their raw bytes.
+func readWireValue(data []byte, wireType int) (value []byte, rest []byte, err error) {
+	switch wireType {
+	case wireVarint:
+		_, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, nil, fmt.Errorf("invalid varint")
+		}
+		return data[:n], data[n:], nil
+	case wireFixed64:
+		if len(data) < 8 {
+			return nil, nil, fmt.Errorf("truncated fixed64")
+		}
+		return data[:8], data[8:], nil
+	case wireFixed32:
+		if len(data) < 4 {
+			return nil, nil, fmt.Errorf("truncated fixed32")
+		}
+		return data[:4], data[4:], nil
+	case wireBytes:
+		l, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < l {
+			return nil, nil, fmt.Errorf("truncated length-delimited value")
+		}
+		return data[n : n+int(l)], data[n+int(l):], nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported wire type %d", wireType)
+	}
+}
+
+func (t *protoTypes) decodeField(out map[string]interface{}, f *descriptor.FieldDescriptorProto, wireType int, raw []byte) error {
+	name := f.GetName()
+
+	if t.isMap(f) {
+		entry, err := t.decodeMessage(t.messages[f.GetTypeName()], raw)
+		if err != nil {
+			return err
+		}
+		m, _ := out[name].(map[string]interface{})
+		if m == nil {
+			m = make(map[string]interface{})
+			out[name] = m
+		}
+		m[fmt.Sprint(entry["key"])] = entry["value"]
+		return nil
+	}
+
+	if f.GetLabel() != descriptor.FieldDescriptorProto_LABEL_REPEATED {
+		v, err := t.decodeScalar(f, wireType, raw)
+		out[name] = v
+		return err
+	}
+
+	items, _ := out[name].([]interface{})
+	if wireType == wireBytes && isPackable(f) {
+		// Packed repeated fields hold all their items in one value.
+		itemType := wireVarint
+		switch f.GetType() {
+		case descriptor.FieldDescriptorProto_TYPE_DOUBLE, descriptor.FieldDescriptorProto_TYPE_FIXED64,
+			descriptor.FieldDescriptorProto_TYPE_SFIXED64:
+			itemType = wireFixed64
+		case descriptor.FieldDescriptorProto_TYPE_FLOAT, descriptor.FieldDescriptorProto_TYPE_FIXED32,
+			descriptor.FieldDescriptorProto_TYPE_SFIXED32:
+			itemType = wireFixed32
+		}
+		for len(raw) > 0 {
+			itemRaw, rest, err := readWireValue(raw, itemType)
+			if err != nil {
+				return err
+			}
+			raw = rest
+			v, err := t.decodeScalar(f, itemType, itemRaw)
+			if err != nil {
+				return err
+			}
+			items = append(items, v)
+		}
+		out[name] = items
+		return nil
+	}
+
+	v, err := t.decodeScalar(f, wireType, raw)
+	out[name] = append(items, v)
+	return err
+}
+
+func isPackable(f *descriptor.FieldDescriptorProto) bool {
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_STRING, descriptor.FieldDescriptorProto_TYPE_BYTES,
+		descriptor.FieldDescriptorProto_TYPE_MESSAGE, descriptor.FieldDescriptorProto_TYPE_GROUP:
+		return false
+	default:
+		return true
+	}
+}
+
+func (t *protoTypes) decodeScalar(f *descriptor.FieldDescriptorProto, wireType int, raw []byte) (interface{}, error) {
+	var x uint64
+	switch wireType {
+	case wireVarint:
+		x, _ = binary.Uvarint(raw)
+	case wireFixed64:
+		x = binary.LittleEndian.Uint64(raw)
+	case wireFixed32:
+		x = uint64(binary.LittleEndian.Uint32(raw))
+	}
+
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_DOUBLE:
+		return math.Float64frombits(x), nil
+	case descriptor.FieldDescriptorProto_TYPE_FLOAT:
+		return math.Float32frombits(uint32(x)), nil
+	case descriptor.FieldDescriptorProto_TYPE_INT64, descriptor.FieldDescriptorProto_TYPE_SFIXED64:
+		return int64(x), nil
+	case descriptor.FieldDescriptorProto_TYPE_INT32, descriptor.FieldDescriptorProto_TYPE_SFIXED32:
+		return int32(x), nil
+	case descriptor.FieldDescriptorProto_TYPE_UINT64, descriptor.FieldDescriptorProto_TYPE_FIXED64:
+		return x, nil
+	case descriptor.FieldDescriptorProto_TYPE_UINT32, descriptor.FieldDescriptorProto_TYPE_FIXED32:
+		return uint32(x), nil
+	case descriptor.FieldDescriptorProto_TYPE_SINT32, descriptor.FieldDescriptorProto_TYPE_SINT64:
+		return int64(x>>1) ^ -int64(x&1), nil
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		return x != 0, nil
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		if e, ok := t.enums[f.GetTypeName()]; ok {
+			for _, value := range e.Value {
+				if int64(value.GetNumber()) == int64(int32(x)) {
+					return value.GetName(), nil
+				}
+			}
+		}
+		return int32(x), nil
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		return string(raw), nil
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		return append([]byte(nil), raw...), nil
+	case descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		m, err := t.message(f.GetTypeName())
+		if err != nil {
+			return nil, err
+		}
+		return t.decodeMessage(m, raw)
+	default:
+		return nil, fmt.Errorf("unsupported field type %v", f.GetType())
+	}
+}
+
+func appendTag(buf []byte, number int32, wireType int) []byte {
+	return appendVarint(buf, uint64(number)<<3|uint64(wireType))
+}
+
+func appendVarint(buf []byte, x uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], x)
+	return append(buf, b[:n]...)
+}
+
+func appendFixed64(buf []byte, x uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], x)
+	return append(buf, b[:]...)
+}
+
+func appendFixed32(buf []byte, x uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], x)
+	return append(buf, b[:]...)
+}
+
+func appendBytes(buf []byte, b []byte) []byte {
+	buf = appendVarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case int:
+		return float64(n), nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(n), 64)
+	}
+	return 0, fmt.Errorf("expected a number, got %T", v)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFileDescriptorSet() *descriptor.FileDescriptorSet {
+	field := func(name string, number int32, typ descriptor.FieldDescriptorProto_Type, label descriptor.FieldDescriptorProto_Label, typeName string) *descriptor.FieldDescriptorProto {
+		f := &descriptor.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Type:   typ.Enum(),
+			Label:  label.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	optional := descriptor.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptor.FieldDescriptorProto_LABEL_REPEATED
+
+	return &descriptor.FileDescriptorSet{
+		File: []*descriptor.FileDescriptorProto{{
+			Name:    proto.String("kv.proto"),
+			Package: proto.String("test.kv"),
+			EnumType: []*descriptor.EnumDescriptorProto{{
+				Name: proto.String("Color"),
+				Value: []*descriptor.EnumValueDescriptorProto{
+					{Name: proto.String("RED"), Number: proto.Int32(0)},
+					{Name: proto.String("BLUE"), Number: proto.Int32(1)},
+				},
+			}},
+			MessageType: []*descriptor.DescriptorProto{{
+				Name: proto.String("Request"),
+				Field: []*descriptor.FieldDescriptorProto{
+					field("key", 1, descriptor.FieldDescriptorProto_TYPE_STRING, optional, ""),
+					field("count", 2, descriptor.FieldDescriptorProto_TYPE_INT64, optional, ""),
+					field("ids", 3, descriptor.FieldDescriptorProto_TYPE_SINT32, repeated, ""),
+					field("color", 4, descriptor.FieldDescriptorProto_TYPE_ENUM, optional, ".test.kv.Color"),
+					field("inner", 5, descriptor.FieldDescriptorProto_TYPE_MESSAGE, optional, ".test.kv.Request.Inner"),
+					field("labels", 6, descriptor.FieldDescriptorProto_TYPE_MESSAGE, repeated, ".test.kv.Request.LabelsEntry"),
+					field("ratio", 7, descriptor.FieldDescriptorProto_TYPE_DOUBLE, optional, ""),
+					field("data", 8, descriptor.FieldDescriptorProto_TYPE_BYTES, optional, ""),
+				},
+				NestedType: []*descriptor.DescriptorProto{
+					{
+						Name: proto.String("Inner"),
+						Field: []*descriptor.FieldDescriptorProto{
+							field("ok", 1, descriptor.FieldDescriptorProto_TYPE_BOOL, optional, ""),
+						},
+					},
+					{
+						Name:    proto.String("LabelsEntry"),
+						Options: &descriptor.MessageOptions{MapEntry: proto.Bool(true)},
+						Field: []*descriptor.FieldDescriptorProto{
+							field("key", 1, descriptor.FieldDescriptorProto_TYPE_STRING, optional, ""),
+							field("value", 2, descriptor.FieldDescriptorProto_TYPE_INT32, optional, ""),
+						},
+					},
+				},
+			}},
+			Service: []*descriptor.ServiceDescriptorProto{{
+				Name: proto.String("KeyValue"),
+				Method: []*descriptor.MethodDescriptorProto{{
+					Name:       proto.String("Get"),
+					InputType:  proto.String(".test.kv.Request"),
+					OutputType: proto.String(".test.kv.Request"),
+				}},
+			}},
+		}},
+	}
+}
+
+func writeTestFileDescriptorSet(t *testing.T) string {
+	data, err := proto.Marshal(testFileDescriptorSet())
+	require.NoError(t, err)
+
+	f, err := ioutil.TempFile("", "yarpc-cli")
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.Write(data)
+	require.NoError(t, err)
+	return f.Name()
+}
+
+func TestProtobufEncoding(t *testing.T) {
+	file := writeTestFileDescriptorSet(t)
+	defer os.Remove(file)
+
+	t.Run("unknown service", func(t *testing.T) {
+		_, err := newProtobufEncoding(file, "test.kv.Nope::Get")
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		_, err := newProtobufEncoding(file, "test.kv.KeyValue::Nope")
+		assert.Error(t, err)
+	})
+
+	enc, err := newProtobufEncoding(file, "test.kv.KeyValue::Get")
+	require.NoError(t, err)
+
+	client := protobufClient{encoding: enc}
+	body, err := client.encode([]byte(`
+key: foo
+count: 42
+ids: [-1, 2, -3]
+color: BLUE
+inner: {ok: true}
+labels: {a: 1, b: 2}
+ratio: 0.5
+data: aGVsbG8=
+`))
+	require.NoError(t, err)
+
+	got, err := enc.types.decodeMessage(enc.response, body)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"key":    "foo",
+		"count":  int64(42),
+		"ids":    []interface{}{int64(-1), int64(2), int64(-3)},
+		"color":  "BLUE",
+		"inner":  map[string]interface{}{"ok": true},
+		"labels": map[string]interface{}{"a": int32(1), "b": int32(2)},
+		"ratio":  0.5,
+		"data":   []byte("hello"),
+	}, got)
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := client.encode([]byte(`{"nope": 1}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown fields in [nope]")
+	})
+
+	t.Run("invalid enum", func(t *testing.T) {
+		_, err := client.encode([]byte(`{"color": "GREEN"}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown value "GREEN"`)
+	})
+}
+
+func TestDecodePackedRepeated(t *testing.T) {
+	types := newProtoTypes(testFileDescriptorSet())
+	m, err := types.message(".test.kv.Request")
+	require.NoError(t, err)
+
+	// ids = [-1, 2] as a packed field: zigzag encoded 1 and 4.
+	got, err := types.decodeMessage(m, []byte{0x1a, 0x02, 0x01, 0x04})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ids": []interface{}{int64(-1), int64(2)}}, got)
+}
@@ -0,0 +1,408 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/thrift"
+	"go.uber.org/yarpc/internal/procedure"
+
+	"go.uber.org/thriftrw/compile"
+	"go.uber.org/thriftrw/protocol"
+	"go.uber.org/thriftrw/wire"
+)
+
+// thriftEncoding builds Thrift requests for a single function of a service
+// defined in a Thrift IDL file.
+type thriftEncoding struct {
+	function *compile.FunctionSpec
+}
+
+func newThriftEncoding(file, proc string) (thriftEncoding, error) {
+	module, err := compile.Compile(file)
+	if err != nil {
+		return thriftEncoding{}, fmt.Errorf("failed to parse Thrift file %q: %v", file, err)
+	}
+
+	serviceName, functionName := procedure.FromName(proc)
+	if functionName == "" {
+		return thriftEncoding{}, fmt.Errorf("procedure %q must be of the form Service::method", proc)
+	}
+
+	service, ok := module.Services[serviceName]
+	if !ok {
+		return thriftEncoding{}, fmt.Errorf("service %q not found in %q", serviceName, file)
+	}
+	for s := service; s != nil; s = s.Parent {
+		if f, ok := s.Functions[functionName]; ok {
+			return thriftEncoding{function: f}, nil
+		}
+	}
+	return thriftEncoding{}, fmt.Errorf("function %q not found in service %q", functionName, serviceName)
+}
+
+func (e thriftEncoding) NewClient(cc transport.ClientConfig, proc string) client {
+	return thriftClient{
+		function: e.function,
+		client:   encodedClient{cc: cc, encoding: thrift.Encoding, procedure: proc},
+	}
+}
+
+type thriftClient struct {
+	function *compile.FunctionSpec
+	client   encodedClient
+}
+
+func (c thriftClient) Call(ctx context.Context, body []byte, opts ...yarpc.CallOption) (interface{}, error) {
+	req, err := c.encode(body)
+	if err != nil {
+		return nil, err
+	}
+	res, _, err := c.client.Call(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.decode(res)
+}
+
+func (c thriftClient) CallOneway(ctx context.Context, body []byte, opts ...yarpc.CallOption) (transport.Ack, error) {
+	req, err := c.encode(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.client.CallOneway(ctx, req, opts...)
+}
+
+// encode converts a JSON or YAML request body into the serialized arguments
+// struct of the function.
+func (c thriftClient) encode(body []byte) ([]byte, error) {
+	args, err := parseValue(body)
+	if err != nil {
+		return nil, err
+	}
+	v, err := structToWire(compile.FieldGroup(c.function.ArgsSpec), args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid arguments for %q: %v", c.function.Name, err)
+	}
+	var buf bytes.Buffer
+	if err := protocol.Binary.Encode(v, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decode converts a serialized result struct into a value that may be
+// serialized to JSON. Exceptions are returned as errors.
+func (c thriftClient) decode(body []byte) (interface{}, error) {
+	v, err := protocol.Binary.Decode(bytes.NewReader(body), wire.TStruct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response for %q: %v", c.function.Name, err)
+	}
+
+	result := c.function.ResultSpec
+	for _, f := range v.GetStruct().Fields {
+		if f.ID == 0 && result != nil && result.ReturnType != nil {
+			return wireToValue(result.ReturnType, f.Value)
+		}
+		if result == nil {
+			continue
+		}
+		for _, ex := range result.Exceptions {
+			if ex.ID != f.ID {
+				continue
+			}
+			exValue, err := wireToValue(ex.Type, f.Value)
+			if err != nil {
+				return nil, err
+			}
+			return nil, thriftException{Name: ex.Type.ThriftName(), Value: exValue}
+		}
+	}
+	return nil, nil
+}
+
+// thriftException is returned when a Thrift function returns one of its
+// declared exceptions.
+type thriftException struct {
+	Name  string
+	Value interface{}
+}
+
+func (e thriftException) Error() string {
+	return fmt.Sprintf("%s: %v", e.Name, e.Value)
+}
+
+func rootType(spec compile.TypeSpec) compile.TypeSpec {
+	for {
+		typedef, ok := spec.(*compile.TypedefSpec)
+		if !ok {
+			return spec
+		}
+		spec = typedef.Target
+	}
+}
+
+func structToWire(fields compile.FieldGroup, v interface{}) (wire.Value, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return wire.Value{}, fmt.Errorf("expected an object, got %T", v)
+	}
+
+	known := make(map[string]struct{}, len(fields))
+	var wireFields []wire.Field
+	for _, f := range fields {
+		known[f.Name] = struct{}{}
+		fv, ok := m[f.Name]
+		if !ok || fv == nil {
+			if f.Required {
+				return wire.Value{}, fmt.Errorf("missing required field %q", f.Name)
+			}
+			continue
+		}
+		value, err := toWire(f.Type, fv)
+		if err != nil {
+			return wire.Value{}, fmt.Errorf("field %q: %v", f.Name, err)
+		}
+		wireFields = append(wireFields, wire.Field{ID: f.ID, Value: value})
+	}
+	for name := range m {
+		if _, ok := known[name]; !ok {
+			return wire.Value{}, fmt.Errorf("unknown field %q", name)
+		}
+	}
+	return wire.NewValueStruct(wire.Struct{Fields: wireFields}), nil
+}
+
+func toWire(spec compile.TypeSpec, v interface{}) (wire.Value, error) {
+	spec = rootType(spec)
+	switch s := spec.(type) {
+	case *compile.StructSpec:
+		return structToWire(s.Fields, v)
+	case *compile.EnumSpec:
+		return enumToWire(s, v)
+	case *compile.MapSpec:
+		return mapToWire(s, v)
+	case *compile.ListSpec:
+		items, err := listToWire(s.ValueSpec, v)
+		if err != nil {
+			return wire.Value{}, err
+		}
+		return wire.NewValueList(items), nil
+	case *compile.SetSpec:
+		items, err := listToWire(s.ValueSpec, v)
+		if err != nil {
+			return wire.Value{}, err
+		}
+		return wire.NewValueSet(items), nil
+	}
+
+	switch spec.TypeCode() {
+	case wire.TBool:
+		b, ok := v.(bool)
+		if !ok {
+			return wire.Value{}, fmt.Errorf("expected a bool, got %T", v)
+		}
+		return wire.NewValueBool(b), nil
+	case wire.TI8:
+		i, err := toInt(v, math.MinInt8, math.MaxInt8)
+		return wire.NewValueI8(int8(i)), err
+	case wire.TI16:
+		i, err := toInt(v, math.MinInt16, math.MaxInt16)
+		return wire.NewValueI16(int16(i)), err
+	case wire.TI32:
+		i, err := toInt(v, math.MinInt32, math.MaxInt32)
+		return wire.NewValueI32(int32(i)), err
+	case wire.TI64:
+		i, err := toInt(v, math.MinInt64, math.MaxInt64)
+		return wire.NewValueI64(i), err
+	case wire.TDouble:
+		switch f := v.(type) {
+		case float64:
+			return wire.NewValueDouble(f), nil
+		case int:
+			return wire.NewValueDouble(float64(f)), nil
+		}
+		return wire.Value{}, fmt.Errorf("expected a number, got %T", v)
+	case wire.TBinary:
+		s, ok := v.(string)
+		if !ok {
+			return wire.Value{}, fmt.Errorf("expected a string, got %T", v)
+		}
+		if spec == compile.BinarySpec {
+			return wire.NewValueBinary([]byte(s)), nil
+		}
+		return wire.NewValueString(s), nil
+	}
+	return wire.Value{}, fmt.Errorf("unsupported type %v", spec.ThriftName())
+}
+
+func enumToWire(spec *compile.EnumSpec, v interface{}) (wire.Value, error) {
+	if name, ok := v.(string); ok {
+		for _, item := range spec.Items {
+			if item.Name == name {
+				return wire.NewValueI32(item.Value), nil
+			}
+		}
+		return wire.Value{}, fmt.Errorf("unknown value %q for enum %v", name, spec.Name)
+	}
+	i, err := toInt(v, math.MinInt32, math.MaxInt32)
+	return wire.NewValueI32(int32(i)), err
+}
+
+func mapToWire(spec *compile.MapSpec, v interface{}) (wire.Value, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return wire.Value{}, fmt.Errorf("expected an object, got %T", v)
+	}
+
+	// Sort the keys so that identical requests produce identical bodies.
+	items := make([]wire.MapItem, 0, len(m))
+	for _, k := range sortedKeys(m) {
+		key, err := mapKeyToWire(spec.KeySpec, k)
+		if err != nil {
+			return wire.Value{}, fmt.Errorf("key %q: %v", k, err)
+		}
+		value, err := toWire(spec.ValueSpec, m[k])
+		if err != nil {
+			return wire.Value{}, fmt.Errorf("key %q: %v", k, err)
+		}
+		items = append(items, wire.MapItem{Key: key, Value: value})
+	}
+	return wire.NewValueMap(wire.MapItemListFromSlice(
+		spec.KeySpec.TypeCode(), spec.ValueSpec.TypeCode(), items)), nil
+}
+
+// mapKeyToWire converts the key of a JSON or YAML object into a Thrift map
+// key. Object keys are always strings so keys of other types, such as
+// integers, enums or structs, are parsed as JSON or YAML values.
+func mapKeyToWire(spec compile.TypeSpec, k string) (wire.Value, error) {
+	if rootType(spec).TypeCode() == wire.TBinary {
+		return toWire(spec, k)
+	}
+	key, err := parseValue([]byte(k))
+	if err != nil {
+		return wire.Value{}, err
+	}
+	return toWire(spec, key)
+}
+
+func listToWire(spec compile.TypeSpec, v interface{}) (wire.ValueList, error) {
+	l, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list, got %T", v)
+	}
+	items := make([]wire.Value, len(l))
+	for i, item := range l {
+		value, err := toWire(spec, item)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %v", i, err)
+		}
+		items[i] = value
+	}
+	return wire.ValueListFromSlice(spec.TypeCode(), items), nil
+}
+
+func wireToValue(spec compile.TypeSpec, v wire.Value) (interface{}, error) {
+	spec = rootType(spec)
+	switch s := spec.(type) {
+	case *compile.StructSpec:
+		out := make(map[string]interface{})
+		for _, f := range v.GetStruct().Fields {
+			for _, field := range s.Fields {
+				if field.ID != f.ID {
+					continue
+				}
+				fv, err := wireToValue(field.Type, f.Value)
+				if err != nil {
+					return nil, fmt.Errorf("field %q: %v", field.Name, err)
+				}
+				out[field.Name] = fv
+			}
+		}
+		return out, nil
+	case *compile.EnumSpec:
+		i := v.GetI32()
+		for _, item := range s.Items {
+			if item.Value == i {
+				return item.Name, nil
+			}
+		}
+		return i, nil
+	case *compile.MapSpec:
+		out := make(map[string]interface{})
+		for _, item := range wire.MapItemListToSlice(v.GetMap()) {
+			key, err := wireToValue(s.KeySpec, item.Key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := wireToValue(s.ValueSpec, item.Value)
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(key)] = value
+		}
+		return out, nil
+	case *compile.ListSpec:
+		return listToValue(s.ValueSpec, v.GetList())
+	case *compile.SetSpec:
+		return listToValue(s.ValueSpec, v.GetSet())
+	}
+
+	switch spec.TypeCode() {
+	case wire.TBool:
+		return v.GetBool(), nil
+	case wire.TI8:
+		return v.GetI8(), nil
+	case wire.TI16:
+		return v.GetI16(), nil
+	case wire.TI32:
+		return v.GetI32(), nil
+	case wire.TI64:
+		return v.GetI64(), nil
+	case wire.TDouble:
+		return v.GetDouble(), nil
+	case wire.TBinary:
+		if spec == compile.BinarySpec {
+			return v.GetBinary(), nil
+		}
+		return v.GetString(), nil
+	}
+	return nil, fmt.Errorf("unsupported type %v", spec.ThriftName())
+}
+
+func listToValue(spec compile.TypeSpec, l wire.ValueList) (interface{}, error) {
+	items := wire.ValueListToSlice(l)
+	out := make([]interface{}, len(items))
+	for i, item := range items {
+		v, err := wireToValue(spec, item)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/thriftrw/compile"
+	"go.uber.org/thriftrw/protocol"
+	"go.uber.org/thriftrw/wire"
+)
+
+const testThrift = `
+enum Color { RED, BLUE }
+
+struct Inner {
+	1: required bool ok
+}
+
+exception NotFound {
+	1: optional string key
+}
+
+service KeyValue {
+	map<string, i32> get(
+		1: required string key
+		2: optional list<i64> ids
+		3: optional Color color
+		4: optional Inner inner
+		5: optional set<string> tags
+		6: optional map<i64, string> names
+		7: optional map<Color, bool> enabled
+	) throws (1: NotFound notFound)
+}
+
+service Extended extends KeyValue {}
+`
+
+func writeTestThrift(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "yarpc-cli")
+	require.NoError(t, err)
+	file := filepath.Join(dir, "kv.thrift")
+	require.NoError(t, ioutil.WriteFile(file, []byte(testThrift), 0644))
+	return file, func() { os.RemoveAll(dir) }
+}
+
+func encodeWire(t *testing.T, v wire.Value) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, protocol.Binary.Encode(v, &buf))
+	return buf.Bytes()
+}
+
+func TestThriftEncoding(t *testing.T) {
+	file, cleanup := writeTestThrift(t)
+	defer cleanup()
+
+	t.Run("unknown service", func(t *testing.T) {
+		_, err := newThriftEncoding(file, "Nope::get")
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown function", func(t *testing.T) {
+		_, err := newThriftEncoding(file, "KeyValue::nope")
+		assert.Error(t, err)
+	})
+
+	t.Run("missing function", func(t *testing.T) {
+		_, err := newThriftEncoding(file, "KeyValue")
+		assert.Error(t, err)
+	})
+
+	// Functions are looked up in parent services too.
+	enc, err := newThriftEncoding(file, "Extended::get")
+	require.NoError(t, err)
+	client := thriftClient{function: enc.function}
+
+	body, err := client.encode([]byte(`
+key: foo
+ids: [1, 2]
+color: BLUE
+inner: {ok: true}
+tags: [a]
+names: {1: one, "2": two}
+enabled: {BLUE: true}
+`))
+	require.NoError(t, err)
+
+	args, err := protocol.Binary.Decode(bytes.NewReader(body), wire.TStruct)
+	require.NoError(t, err)
+	got, err := wireToValue(&compile.StructSpec{Fields: compile.FieldGroup(enc.function.ArgsSpec)}, args)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"key":     "foo",
+		"ids":     []interface{}{int64(1), int64(2)},
+		"color":   "BLUE",
+		"inner":   map[string]interface{}{"ok": true},
+		"tags":    []interface{}{"a"},
+		"names":   map[string]interface{}{"1": "one", "2": "two"},
+		"enabled": map[string]interface{}{"BLUE": true},
+	}, got)
+
+	t.Run("invalid map key", func(t *testing.T) {
+		_, err := client.encode([]byte(`{"key": "foo", "names": {"one": "two"}}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `field "names": key "one": expected an integer, got string`)
+	})
+
+	t.Run("missing required field", func(t *testing.T) {
+		_, err := client.encode([]byte(`{"ids": [1]}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `missing required field "key"`)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := client.encode([]byte(`{"key": "foo", "nope": 1}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown field "nope"`)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		res := encodeWire(t, wire.NewValueStruct(wire.Struct{Fields: []wire.Field{{
+			ID: 0,
+			Value: wire.NewValueMap(wire.MapItemListFromSlice(wire.TBinary, wire.TI32, []wire.MapItem{
+				{Key: wire.NewValueString("a"), Value: wire.NewValueI32(1)},
+			})),
+		}}}))
+		got, err := client.decode(res)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"a": int32(1)}, got)
+	})
+
+	t.Run("exception", func(t *testing.T) {
+		res := encodeWire(t, wire.NewValueStruct(wire.Struct{Fields: []wire.Field{{
+			ID: 1,
+			Value: wire.NewValueStruct(wire.Struct{Fields: []wire.Field{
+				{ID: 1, Value: wire.NewValueString("foo")},
+			}}),
+		}}}))
+		_, err := client.decode(res)
+		require.Error(t, err)
+		assert.Equal(t, thriftException{
+			Name:  "NotFound",
+			Value: map[string]interface{}{"key": "foo"},
+		}, err)
+	})
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"go.uber.org/yarpc/transport/http"
+	"go.uber.org/yarpc/x/config"
+)
+
+var errMissingConfig = errors.New("a configuration file is required")
+
+// validateOptions holds the parsed command line flags for the validate
+// subcommand.
+type validateOptions struct {
+	Service string
+	Strict  bool
+	File    string
+}
+
+func parseValidateOptions(args []string) (*validateOptions, error) {
+	var opts validateOptions
+	flags := flag.NewFlagSet("yarpc validate", flag.ContinueOnError)
+	flags.StringVar(&opts.Service, "service", "yarpc", "Name of the service the configuration is for")
+	flags.BoolVar(&opts.Strict, "strict", false, "Fail if any warnings are found")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if flags.NArg() != 1 {
+		return nil, errMissingConfig
+	}
+	opts.File = flags.Arg(0)
+	return &opts, nil
+}
+
+// runValidate validates the YAML configuration file named in args and
+// writes the problems found to stdout.
+func runValidate(args []string, stdout io.Writer) error {
+	opts, err := parseValidateOptions(args)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(opts.File)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	v, err := newValidationConfigurator().ValidateYAML(opts.Service, f)
+	if err != nil {
+		return fmt.Errorf("failed to parse %v: %v", opts.File, err)
+	}
+
+	if s := v.String(); s != "" {
+		fmt.Fprintln(stdout, s)
+	}
+	if len(v.Errors) > 0 {
+		return fmt.Errorf("%v: found %d error(s)", opts.File, len(v.Errors))
+	}
+	if opts.Strict && len(v.Warnings) > 0 {
+		return fmt.Errorf("%v: found %d warning(s)", opts.File, len(v.Warnings))
+	}
+	return nil
+}
+
+// newValidationConfigurator builds a Configurator with the specs of the
+// transports which provide one. Only the HTTP transport does so far.
+// Validation only decodes configuration so nothing is built with them.
+func newValidationConfigurator() *config.Configurator {
+	c := config.New()
+	c.MustRegisterTransport(http.TransportSpec())
+	return c
+}
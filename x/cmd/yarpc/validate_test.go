@@ -0,0 +1,105 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunValidate(t *testing.T) {
+	tests := []struct {
+		desc       string
+		args       []string
+		give       string
+		wantOutput string
+		wantErr    string
+	}{
+		{
+			desc: "valid",
+			give: "inbounds:\n  http: {address: \":8080\"}\noutbounds:\n  keyvalue:\n    http: {url: \"http://127.0.0.1:4040\"}\n",
+		},
+		{
+			desc: "errors",
+			give: "outbounds:\n  keyvalue:\n    http: {peer: \"127.0.0.1:8080\"}\n",
+			wantOutput: `error: outbounds.keyvalue: failed to add outbound "keyvalue": ` +
+				`failed to decode unary outbound configuration: failed to decode`,
+			wantErr: "found 1 error(s)",
+		},
+		{
+			desc: "unsupported transport",
+			give: "outbounds:\n  keyvalue:\n    tchannel: {peer: \"127.0.0.1:4040\"}\n",
+			wantOutput: `error: outbounds.keyvalue: failed to load configuration for outbound "keyvalue": ` +
+				`unknown transport "tchannel"`,
+			wantErr: "found 1 error(s)",
+		},
+		{
+			desc:       "warnings",
+			give:       "transports:\n  http: {keepAlive: 30s}\n",
+			wantOutput: `warning: transports.http: transport "http" is not used by any inbound or outbound`,
+		},
+		{
+			desc:       "strict",
+			args:       []string{"-strict"},
+			give:       "transports:\n  http: {keepAlive: 30s}\n",
+			wantOutput: `warning: transports.http: transport "http" is not used by any inbound or outbound`,
+			wantErr:    "found 1 warning(s)",
+		},
+		{
+			desc:    "invalid YAML",
+			give:    "{",
+			wantErr: "failed to parse",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "yarpc-validate")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "config.yaml")
+			require.NoError(t, ioutil.WriteFile(path, []byte(tt.give), 0644))
+
+			var out bytes.Buffer
+			args := append([]string{"validate"}, tt.args...)
+			err = run(append(args, path), nil, &out)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Contains(t, out.String(), tt.wantOutput)
+		})
+	}
+}
+
+func TestParseValidateOptionsMissingConfig(t *testing.T) {
+	_, err := parseValidateOptions([]string{"-service", "foo"})
+	assert.Equal(t, errMissingConfig, err)
+}
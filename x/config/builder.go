@@ -25,7 +25,7 @@ import (
 
 	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/api/transport"
-	"go.uber.org/yarpc/internal/interpolate"
+	"go.uber.org/yarpc/x/routing"
 
 	"go.uber.org/multierr"
 )
@@ -34,6 +34,16 @@ type buildableOutbounds struct {
 	Service string
 	Unary   *buildableOutbound
 	Oneway  *buildableOutbound
+
+	// Routing is set instead of Unary and Oneway for outbounds which route
+	// requests to one of several outbounds.
+	Routing *buildableRouting
+}
+
+type buildableRouting struct {
+	Outbounds map[string]*buildableOutbounds
+	Rules     []routing.Rule
+	Default   string
 }
 
 type buildableInbound struct {
@@ -59,21 +69,36 @@ type builder struct {
 	clients    map[string]*buildableOutbounds
 
 	// Used to resolve interpolated variables.
-	resolver interpolate.VariableResolver
+	interpolator interpolator
+
+	// Transports and outbounds from an earlier build which are used as-is
+	// instead of being built again. These are set by Updater for those parts
+	// of the configuration which did not change.
+	reuseTransports map[string]transport.Transport
+	reuseOutbounds  map[string]transport.Outbounds
 }
 
-func newBuilder(name string, kit *Kit, resolver interpolate.VariableResolver) *builder {
+func newBuilder(name string, kit *Kit, interpolator interpolator) *builder {
 	return &builder{
-		Name:           name,
-		kit:            kit,
-		needTransports: make(map[string]*compiledTransportSpec),
-		transports:     make(map[string]*buildable),
-		clients:        make(map[string]*buildableOutbounds),
-		resolver:       resolver,
+		Name:            name,
+		kit:             kit,
+		needTransports:  make(map[string]*compiledTransportSpec),
+		transports:      make(map[string]*buildable),
+		clients:         make(map[string]*buildableOutbounds),
+		interpolator:    interpolator,
+		reuseTransports: make(map[string]transport.Transport),
+		reuseOutbounds:  make(map[string]transport.Outbounds),
 	}
 }
 
 func (b *builder) Build() (yarpc.Config, error) {
+	cfg, _, err := b.build()
+	return cfg, err
+}
+
+// build builds a yarpc.Config along with the transports it uses, indexed by
+// name.
+func (b *builder) build() (yarpc.Config, map[string]transport.Transport, error) {
 	var (
 		transports = make(map[string]transport.Transport)
 		cfg        = yarpc.Config{Name: b.Name}
@@ -81,20 +106,25 @@ func (b *builder) Build() (yarpc.Config, error) {
 	)
 
 	for name, spec := range b.needTransports {
+		if t, ok := b.reuseTransports[name]; ok {
+			transports[name] = t
+			continue
+		}
+
 		cv, ok := b.transports[name]
 
 		var err error
 		if !ok {
 			// No configuration provided for the transport. Use an empty map.
-			cv, err = spec.Transport.Decode(attributeMap{}, interpolateWith(b.resolver))
+			cv, err = b.decode("transports."+name, spec.Transport, attributeMap{})
 			if err != nil {
-				return yarpc.Config{}, err
+				return yarpc.Config{}, nil, err
 			}
 		}
 
 		transports[name], err = buildTransport(cv, b.kit)
 		if err != nil {
-			return yarpc.Config{}, err
+			return yarpc.Config{}, nil, err
 		}
 	}
 
@@ -109,26 +139,18 @@ func (b *builder) Build() (yarpc.Config, error) {
 
 	outbounds := make(yarpc.Outbounds, len(b.clients))
 	for ccname, c := range b.clients {
-		var err error
-
-		var ob transport.Outbounds
-		if c.Service != ccname {
-			ob.ServiceName = c.Service
+		if ob, ok := b.reuseOutbounds[ccname]; ok {
+			outbounds[ccname] = ob
+			continue
 		}
 
-		if o := c.Unary; o != nil {
-			ob.Unary, err = buildUnaryOutbound(o.Value, transports[o.Transport], b.kit)
-			if err != nil {
-				errs = multierr.Append(errs, err)
-				continue
-			}
+		ob, err := buildOutbounds(c, transports, b.kit)
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
 		}
-		if o := c.Oneway; o != nil {
-			ob.Oneway, err = buildOnewayOutbound(o.Value, transports[o.Transport], b.kit)
-			if err != nil {
-				errs = multierr.Append(errs, err)
-				continue
-			}
+		if c.Service != ccname {
+			ob.ServiceName = c.Service
 		}
 
 		outbounds[ccname] = ob
@@ -137,7 +159,7 @@ func (b *builder) Build() (yarpc.Config, error) {
 		cfg.Outbounds = outbounds
 	}
 
-	return cfg, errs
+	return cfg, transports, errs
 }
 
 // buildTransport builds a Transport from the given value. This will panic if
@@ -160,6 +182,70 @@ func buildInbound(cv *buildable, t transport.Transport, k *Kit) (transport.Inbou
 	return result.(transport.Inbound), nil
 }
 
+// buildOutbounds builds the unary and oneway outbounds for the given value.
+func buildOutbounds(c *buildableOutbounds, transports map[string]transport.Transport, k *Kit) (transport.Outbounds, error) {
+	var (
+		ob  transport.Outbounds
+		err error
+	)
+
+	if r := c.Routing; r != nil {
+		return buildRoutingOutbounds(r, transports, k)
+	}
+
+	if o := c.Unary; o != nil {
+		ob.Unary, err = buildUnaryOutbound(o.Value, transports[o.Transport], k)
+		if err != nil {
+			return ob, err
+		}
+	}
+	if o := c.Oneway; o != nil {
+		ob.Oneway, err = buildOnewayOutbound(o.Value, transports[o.Transport], k)
+		if err != nil {
+			return ob, err
+		}
+	}
+	return ob, nil
+}
+
+// buildRoutingOutbounds builds a routing.Outbound over the given outbounds.
+// The routing.Outbound is used for unary and oneway requests only if at least
+// one of the outbounds supports them.
+func buildRoutingOutbounds(r *buildableRouting, transports map[string]transport.Transport, k *Kit) (transport.Outbounds, error) {
+	var (
+		ob                  transport.Outbounds
+		hasUnary, hasOneway bool
+	)
+
+	routes := make(map[string]transport.Outbounds, len(r.Outbounds))
+	for name, c := range r.Outbounds {
+		route, err := buildOutbounds(c, transports, k)
+		if err != nil {
+			return ob, err
+		}
+		hasUnary = hasUnary || route.Unary != nil
+		hasOneway = hasOneway || route.Oneway != nil
+		routes[name] = route
+	}
+
+	router, err := routing.NewOutbound(routing.Config{
+		Outbounds: routes,
+		Rules:     r.Rules,
+		Default:   r.Default,
+	})
+	if err != nil {
+		return ob, fmt.Errorf("failed to build routing outbound: %v", err)
+	}
+
+	if hasUnary {
+		ob.Unary = router
+	}
+	if hasOneway {
+		ob.Oneway = router
+	}
+	return ob, nil
+}
+
 // buildUnaryOutbound builds an UnaryOutbound from the given value. This will panic
 // if the output type for this is not transport.UnaryOutbound.
 func buildUnaryOutbound(cv *buildable, t transport.Transport, k *Kit) (transport.UnaryOutbound, error) {
@@ -180,8 +266,19 @@ func buildOnewayOutbound(cv *buildable, t transport.Transport, k *Kit) (transpor
 	return result.(transport.OnewayOutbound), nil
 }
 
-func (b *builder) AddTransportConfig(spec *compiledTransportSpec, attrs attributeMap) error {
-	cv, err := spec.Transport.Decode(attrs, interpolateWith(b.resolver))
+// decode decodes attributes found at the given path of the configuration with
+// the given configSpec, interpolating variables.
+//
+// All variables that cannot be resolved are reported along with their paths.
+func (b *builder) decode(path string, spec *configSpec, attrs attributeMap) (*buildable, error) {
+	if err := b.interpolator.checkVariables(path, spec.inputType, attrs); err != nil {
+		return nil, err
+	}
+	return spec.Decode(attrs, interpolateWith(b.interpolator))
+}
+
+func (b *builder) AddTransportConfig(path string, spec *compiledTransportSpec, attrs attributeMap) error {
+	cv, err := b.decode(path, spec.Transport, attrs)
 	if err != nil {
 		return fmt.Errorf("failed to decode transport configuration: %v", err)
 	}
@@ -190,13 +287,13 @@ func (b *builder) AddTransportConfig(spec *compiledTransportSpec, attrs attribut
 	return nil
 }
 
-func (b *builder) AddInboundConfig(spec *compiledTransportSpec, attrs attributeMap) error {
+func (b *builder) AddInboundConfig(path string, spec *compiledTransportSpec, attrs attributeMap) error {
 	if spec.Inbound == nil {
 		return fmt.Errorf("transport %q does not support inbound requests", spec.Name)
 	}
 
 	b.needTransport(spec)
-	cv, err := spec.Inbound.Decode(attrs, interpolateWith(b.resolver))
+	cv, err := b.decode(path, spec.Inbound, attrs)
 	if err != nil {
 		return fmt.Errorf("failed to decode inbound configuration: %v", err)
 	}
@@ -208,22 +305,32 @@ func (b *builder) AddInboundConfig(spec *compiledTransportSpec, attrs attributeM
 	return nil
 }
 
+// Outbounds returns the outbounds being built for the given outbound key.
+func (b *builder) Outbounds(outboundKey, service string) *buildableOutbounds {
+	cc, ok := b.clients[outboundKey]
+	if !ok {
+		cc = &buildableOutbounds{Service: service}
+		b.clients[outboundKey] = cc
+	}
+	return cc
+}
+
 func (b *builder) AddImplicitOutbound(
-	spec *compiledTransportSpec, outboundKey, service string, attrs attributeMap,
+	path string, spec *compiledTransportSpec, cc *buildableOutbounds, attrs attributeMap,
 ) error {
 	var errs error
 	supportsOutbound := false
 
 	if spec.SupportsUnaryOutbound() {
 		supportsOutbound = true
-		if err := b.AddUnaryOutbound(spec, outboundKey, service, attrs); err != nil {
+		if err := b.AddUnaryOutbound(path, spec, cc, attrs); err != nil {
 			errs = multierr.Append(errs, err)
 		}
 	}
 
 	if spec.SupportsOnewayOutbound() {
 		supportsOutbound = true
-		if err := b.AddOnewayOutbound(spec, outboundKey, service, attrs); err != nil {
+		if err := b.AddOnewayOutbound(path, spec, cc, attrs); err != nil {
 			errs = multierr.Append(errs, err)
 		}
 	}
@@ -236,47 +343,35 @@ func (b *builder) AddImplicitOutbound(
 }
 
 func (b *builder) AddUnaryOutbound(
-	spec *compiledTransportSpec, outboundKey, service string, attrs attributeMap,
+	path string, spec *compiledTransportSpec, cc *buildableOutbounds, attrs attributeMap,
 ) error {
 	if spec.UnaryOutbound == nil {
 		return fmt.Errorf("transport %q does not support unary outbound requests", spec.Name)
 	}
 
 	b.needTransport(spec)
-	cv, err := spec.UnaryOutbound.Decode(attrs, interpolateWith(b.resolver))
+	cv, err := b.decode(path, spec.UnaryOutbound, attrs)
 	if err != nil {
 		return fmt.Errorf("failed to decode unary outbound configuration: %v", err)
 	}
 
-	cc, ok := b.clients[outboundKey]
-	if !ok {
-		cc = &buildableOutbounds{Service: service}
-		b.clients[outboundKey] = cc
-	}
-
 	cc.Unary = &buildableOutbound{Transport: spec.Name, Value: cv}
 	return nil
 }
 
 func (b *builder) AddOnewayOutbound(
-	spec *compiledTransportSpec, outboundKey, service string, attrs attributeMap,
+	path string, spec *compiledTransportSpec, cc *buildableOutbounds, attrs attributeMap,
 ) error {
 	if spec.OnewayOutbound == nil {
 		return fmt.Errorf("transport %q does not support oneway outbound requests", spec.Name)
 	}
 
 	b.needTransport(spec)
-	cv, err := spec.OnewayOutbound.Decode(attrs, interpolateWith(b.resolver))
+	cv, err := b.decode(path, spec.OnewayOutbound, attrs)
 	if err != nil {
 		return fmt.Errorf("failed to decode oneway outbound configuration: %v", err)
 	}
 
-	cc, ok := b.clients[outboundKey]
-	if !ok {
-		cc = &buildableOutbounds{Service: service}
-		b.clients[outboundKey] = cc
-	}
-
 	cc.Oneway = &buildableOutbound{Transport: spec.Name, Value: cv}
 	return nil
 }
@@ -24,14 +24,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"os"
 
 	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/internal/interpolate"
+	"go.uber.org/yarpc/x/routing"
 
 	"go.uber.org/multierr"
-	"gopkg.in/yaml.v2"
 )
 
 // Configurator helps build Dispatchers using runtime configuration.
@@ -44,6 +42,7 @@ type Configurator struct {
 	knownChoosers   map[string]*compiledChooserSpec
 	knownBinders    map[string]*compiledBinderSpec
 	resolver        interpolate.VariableResolver
+	sources         map[string]interpolate.Source
 }
 
 // New sets up a new empty Configurator. The returned Configurator does not
@@ -56,7 +55,8 @@ func New(opts ...Option) *Configurator {
 		knownTransports: make(map[string]*compiledTransportSpec),
 		knownChoosers:   make(map[string]*compiledChooserSpec),
 		knownBinders:    make(map[string]*compiledBinderSpec),
-		resolver:        os.LookupEnv,
+		resolver:        EnvResolver,
+		sources:         map[string]interpolate.Source{"file": FileSource},
 	}
 
 	for _, opt := range opts {
@@ -160,15 +160,10 @@ func (c *Configurator) MustRegisterBinder(s BinderSpec) {
 // have your own map[string]interface{} or map[interface{}]interface{} to
 // provide.
 func (c *Configurator) LoadConfigFromYAML(serviceName string, r io.Reader) (yarpc.Config, error) {
-	b, err := ioutil.ReadAll(r)
+	data, err := readYAML(r)
 	if err != nil {
 		return yarpc.Config{}, err
 	}
-
-	var data map[string]interface{}
-	if err := yaml.Unmarshal(b, &data); err != nil {
-		return yarpc.Config{}, err
-	}
 	return c.LoadConfig(serviceName, data)
 }
 
@@ -204,9 +199,20 @@ func (c *Configurator) NewDispatcher(serviceName string, data interface{}) (*yar
 	return yarpc.NewDispatcher(cfg), nil
 }
 
-func (c *Configurator) load(serviceName string, cfg *yarpcConfig) (_ yarpc.Config, err error) {
-	b := newBuilder(serviceName, &Kit{name: serviceName, c: c}, c.resolver)
+func (c *Configurator) load(serviceName string, cfg *yarpcConfig) (yarpc.Config, error) {
+	b := c.newBuilder(serviceName)
+	if err := c.loadInto(b, cfg); err != nil {
+		return yarpc.Config{}, err
+	}
+	return b.Build()
+}
+
+func (c *Configurator) newBuilder(serviceName string) *builder {
+	return newBuilder(serviceName, &Kit{name: serviceName, c: c},
+		interpolator{resolve: c.resolver, sources: c.sources})
+}
 
+func (c *Configurator) loadInto(b *builder, cfg *yarpcConfig) (err error) {
 	for _, inbound := range cfg.Inbounds {
 		if e := c.loadInboundInto(b, inbound); e != nil {
 			err = multierr.Append(err, e)
@@ -225,11 +231,7 @@ func (c *Configurator) load(serviceName string, cfg *yarpcConfig) (_ yarpc.Confi
 		}
 	}
 
-	if err != nil {
-		return yarpc.Config{}, err
-	}
-
-	return b.Build()
+	return err
 }
 
 func (c *Configurator) loadInboundInto(b *builder, i inbound) error {
@@ -245,39 +247,49 @@ func (c *Configurator) loadInboundInto(b *builder, i inbound) error {
 		return fmt.Errorf("failed to load inbound: %v", err)
 	}
 
-	return b.AddInboundConfig(spec, i.Attributes)
+	return b.AddInboundConfig("inbounds."+i.Name, spec, i.Attributes)
 }
 
 func (c *Configurator) loadOutboundInto(b *builder, name string, cfg outbounds) error {
+	return c.loadOutbounds(b, b.Outbounds(name, cfg.Service), name, "outbounds."+name, cfg)
+}
+
+// loadOutbounds loads the outbounds configured at the given path of the
+// configuration.
+func (c *Configurator) loadOutbounds(b *builder, cc *buildableOutbounds, name, path string, cfg outbounds) error {
 	// This matches the signature of builder.AddImplicitOutbound,
 	// AddUnaryOutbound and AddOnewayOutbound
-	type adder func(*compiledTransportSpec, string, string, attributeMap) error
+	type adder func(string, *compiledTransportSpec, *buildableOutbounds, attributeMap) error
 
-	loadUsing := func(o *outbound, adder adder) error {
+	loadUsing := func(path string, o *outbound, adder adder) error {
 		spec, err := c.spec(o.Type)
 		if err != nil {
 			return fmt.Errorf("failed to load configuration for outbound %q: %v", name, err)
 		}
 
-		if err := adder(spec, name, cfg.Service, o.Attributes); err != nil {
+		if err := adder(path+"."+o.Type, spec, cc, o.Attributes); err != nil {
 			return fmt.Errorf("failed to add outbound %q: %v", name, err)
 		}
 
 		return nil
 	}
 
+	if r := cfg.Routing; r != nil {
+		return c.loadRoutingOutbounds(b, cc, name, path+".routing", r)
+	}
+
 	if implicit := cfg.Implicit; implicit != nil {
-		return loadUsing(implicit, b.AddImplicitOutbound)
+		return loadUsing(path, implicit, b.AddImplicitOutbound)
 	}
 
 	if unary := cfg.Unary; unary != nil {
-		if err := loadUsing(unary, b.AddUnaryOutbound); err != nil {
+		if err := loadUsing(path+".unary", unary, b.AddUnaryOutbound); err != nil {
 			return err
 		}
 	}
 
 	if oneway := cfg.Oneway; oneway != nil {
-		if err := loadUsing(oneway, b.AddOnewayOutbound); err != nil {
+		if err := loadUsing(path+".oneway", oneway, b.AddOnewayOutbound); err != nil {
 			return err
 		}
 	}
@@ -285,13 +297,55 @@ func (c *Configurator) loadOutboundInto(b *builder, name string, cfg outbounds)
 	return nil
 }
 
+func (c *Configurator) loadRoutingOutbounds(b *builder, cc *buildableOutbounds, name, path string, cfg *routingOutbounds) error {
+	r := &buildableRouting{
+		Outbounds: make(map[string]*buildableOutbounds, len(cfg.Outbounds)),
+		Default:   cfg.Default,
+	}
+	for _, rule := range cfg.Rules {
+		r.Rules = append(r.Rules, routing.Rule{
+			Outbound:   rule.Outbound,
+			Procedure:  rule.Procedure,
+			ShardKey:   rule.ShardKey,
+			RoutingKey: rule.RoutingKey,
+			Headers:    rule.Headers,
+		})
+	}
+
+	var err error
+	for routeName, outs := range cfg.Outbounds {
+		fullName := name + "." + routeName
+		if outs.Routing != nil {
+			err = multierr.Append(err, fmt.Errorf(
+				"failed to add outbound %q: routing outbounds cannot be nested", fullName))
+			continue
+		}
+		if outs.Service != "" {
+			err = multierr.Append(err, fmt.Errorf(
+				"failed to add outbound %q: routes cannot set a service name, "+
+					"set it on the routing outbound instead", fullName))
+			continue
+		}
+
+		route := &buildableOutbounds{}
+		if e := c.loadOutbounds(b, route, fullName, path+".outbounds."+routeName, outs); e != nil {
+			err = multierr.Append(err, e)
+			continue
+		}
+		r.Outbounds[routeName] = route
+	}
+
+	cc.Routing = r
+	return err
+}
+
 func (c *Configurator) loadTransportInto(b *builder, name string, attrs attributeMap) error {
 	spec, err := c.spec(name)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration for transport %q: %v", name, err)
 	}
 
-	return b.AddTransportConfig(spec, attrs)
+	return b.AddTransportConfig("transports."+name, spec, attrs)
 }
 
 // Returns the compiled spec for the transport with the given name or an error
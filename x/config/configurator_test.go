@@ -28,6 +28,7 @@ import (
 
 	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/introspection"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -762,6 +763,78 @@ func TestConfigurator(t *testing.T) {
 				return
 			},
 		},
+		{
+			desc: "routing outbound nested",
+			test: func(t *testing.T, mockCtrl *gomock.Controller) (tt testCase) {
+				tt.give = expand(`
+					outbounds:
+						myservice:
+							routing:
+								outbounds:
+									inner:
+										routing:
+											outbounds: {}
+				`)
+				tt.wantErr = []string{
+					`failed to add outbound "myservice.inner": routing outbounds cannot be nested`,
+				}
+				return
+			},
+		},
+		{
+			desc: "routing outbound route with service",
+			test: func(t *testing.T, mockCtrl *gomock.Controller) (tt testCase) {
+				tt.give = expand(`
+					outbounds:
+						myservice:
+							routing:
+								outbounds:
+									staging:
+										service: myservice-staging
+										http: {url: "http://staging"}
+				`)
+				tt.wantErr = []string{
+					`failed to add outbound "myservice.staging": routes cannot set a service name, set it on the routing outbound instead`,
+				}
+				return
+			},
+		},
+		{
+			desc: "routing outbound unknown rule outbound",
+			test: func(t *testing.T, mockCtrl *gomock.Controller) (tt testCase) {
+				type outboundConfig struct{ URL string }
+				tt.give = expand(`
+					outbounds:
+						myservice:
+							routing:
+								outbounds:
+									primary:
+										http: {url: "http://primary"}
+								rules:
+									- procedure: "Read*"
+									  outbound: replica
+				`)
+
+				http := mockTransportSpecBuilder{
+					Name:                "http",
+					TransportConfig:     _typeOfEmptyStruct,
+					UnaryOutboundConfig: reflect.TypeOf(outboundConfig{}),
+				}.Build(mockCtrl)
+
+				transport := transporttest.NewMockTransport(mockCtrl)
+				outbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+				http.EXPECT().BuildTransport(struct{}{}, anyKit).Return(transport, nil)
+				http.EXPECT().
+					BuildUnaryOutbound(outboundConfig{URL: "http://primary"}, transport, anyKit).
+					Return(outbound, nil)
+
+				tt.specs = []TransportSpec{http.Spec()}
+				tt.wantErr = []string{
+					`failed to build routing outbound: invalid rule 0: unknown outbound "replica"`,
+				}
+				return
+			},
+		},
 		{
 			desc: "implicit outbound service name override",
 			test: func(t *testing.T, mockCtrl *gomock.Controller) (tt testCase) {
@@ -1021,9 +1094,54 @@ func TestConfigurator(t *testing.T) {
 				tt.specs = []TransportSpec{http.Spec()}
 				tt.wantErr = []string{
 					"failed to decode inbound configuration:",
-					`error reading into field "Address":`,
-					`failed to render ":${HTTP_PORT}" with environment variables:`,
-					`variable "HTTP_PORT" does not have a value or a default`,
+					`inbounds.http.address: variable "HTTP_PORT" does not have a value or a default`,
+				}
+
+				return
+			},
+		},
+		{
+			desc: "missing envvars",
+			test: func(t *testing.T, mockCtrl *gomock.Controller) (tt testCase) {
+				type peerConfig struct {
+					Address string `config:",interpolate"`
+				}
+				type outboundConfig struct {
+					URL   string       `config:"url,interpolate"`
+					Peers []peerConfig `config:"peers"`
+				}
+				type inboundConfig struct {
+					Address string `config:",interpolate"`
+				}
+
+				tt.serviceName = "hi"
+				tt.give = expand(`
+					inbounds:
+						http:
+							address: ${HOST}:${HTTP_PORT}
+					outbounds:
+						myservice:
+							unary:
+								http:
+									url: http://${MYSERVICE_HOST}/
+									peers:
+										- address: ${PEER:127.0.0.1}
+										- address: ${OTHER_PEER}
+				`)
+				tt.env = map[string]string{"HOST": "localhost"}
+
+				http := mockTransportSpecBuilder{
+					Name:                "http",
+					TransportConfig:     _typeOfEmptyStruct,
+					InboundConfig:       reflect.TypeOf(inboundConfig{}),
+					UnaryOutboundConfig: reflect.TypeOf(outboundConfig{}),
+				}.Build(mockCtrl)
+
+				tt.specs = []TransportSpec{http.Spec()}
+				tt.wantErr = []string{
+					`inbounds.http.address: variable "HTTP_PORT" does not have a value or a default`,
+					`outbounds.myservice.unary.http.url: variable "MYSERVICE_HOST" does not have a value or a default`,
+					`outbounds.myservice.unary.http.peers[1].address: variable "OTHER_PEER" does not have a value or a default`,
 				}
 
 				return
@@ -1121,3 +1239,87 @@ func TestConfigurator(t *testing.T) {
 		})
 	}
 }
+
+func TestConfiguratorRoutingOutbound(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	type outboundConfig struct{ URL string }
+	http := mockTransportSpecBuilder{
+		Name:                "http",
+		TransportConfig:     _typeOfEmptyStruct,
+		UnaryOutboundConfig: reflect.TypeOf(outboundConfig{}),
+	}.Build(mockCtrl)
+
+	type redisOutboundConfig struct{ Queue string }
+	redis := mockTransportSpecBuilder{
+		Name:                 "redis",
+		TransportConfig:      _typeOfEmptyStruct,
+		OnewayOutboundConfig: reflect.TypeOf(redisOutboundConfig{}),
+	}.Build(mockCtrl)
+
+	httpTransport := transporttest.NewMockTransport(mockCtrl)
+	redisTransport := transporttest.NewMockTransport(mockCtrl)
+	primary := transporttest.NewMockUnaryOutbound(mockCtrl)
+	replica := transporttest.NewMockUnaryOutbound(mockCtrl)
+	queue := transporttest.NewMockOnewayOutbound(mockCtrl)
+
+	http.EXPECT().BuildTransport(struct{}{}, anyKit).Return(httpTransport, nil)
+	redis.EXPECT().BuildTransport(struct{}{}, anyKit).Return(redisTransport, nil)
+	http.EXPECT().
+		BuildUnaryOutbound(outboundConfig{URL: "http://primary"}, httpTransport, anyKit).
+		Return(primary, nil)
+	http.EXPECT().
+		BuildUnaryOutbound(outboundConfig{URL: "http://replica"}, httpTransport, anyKit).
+		Return(replica, nil)
+	redis.EXPECT().
+		BuildOnewayOutbound(redisOutboundConfig{Queue: "events"}, redisTransport, anyKit).
+		Return(queue, nil)
+
+	cfg := New()
+	require.NoError(t, cfg.RegisterTransport(http.Spec()))
+	require.NoError(t, cfg.RegisterTransport(redis.Spec()))
+
+	c, err := cfg.LoadConfigFromYAML("myservice", strings.NewReader(expand(`
+		outbounds:
+			keyvalue:
+				service: kv
+				routing:
+					outbounds:
+						primary:
+							http: {url: "http://primary"}
+						replica:
+							unary:
+								http: {url: "http://replica"}
+						events:
+							redis: {queue: events}
+					rules:
+						- procedure: "KeyValue::Read*"
+						  outbound: replica
+						- headers: {tenant: beta}
+						  shardKey: "cold-*"
+						  outbound: replica
+						- procedure: "KeyValue::Notify"
+						  outbound: events
+					default: primary
+	`)))
+	require.NoError(t, err)
+
+	outs := c.Outbounds["keyvalue"]
+	assert.Equal(t, "kv", outs.ServiceName)
+	require.NotNil(t, outs.Unary, "expected a unary outbound")
+	require.NotNil(t, outs.Oneway, "expected a oneway outbound")
+
+	status := outs.Unary.(introspection.IntrospectableOutbound).Introspect()
+	assert.Equal(t, "routing", status.Transport)
+	var matches []string
+	for _, r := range status.Routes {
+		matches = append(matches, r.Match+" => "+r.Outbound)
+	}
+	assert.Equal(t, []string{
+		`procedure="KeyValue::Read*" => replica`,
+		`shard-key="cold-*" header[tenant]="beta" => replica`,
+		`procedure="KeyValue::Notify" => events`,
+		`default => primary`,
+	}, matches)
+}
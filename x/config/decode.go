@@ -79,6 +79,7 @@ func (is *inbounds) Decode(into mapdecode.Into) error {
 	}
 
 	for k, v := range items {
+		v.Name = k
 		if v.Type == "" {
 			v.Type = k
 		}
@@ -88,6 +89,7 @@ func (is *inbounds) Decode(into mapdecode.Into) error {
 }
 
 type inbound struct {
+	Name       string
 	Type       string
 	Disabled   bool
 	Attributes attributeMap
@@ -138,6 +140,10 @@ type outbounds struct {
 	Unary    *outbound
 	Oneway   *outbound
 	Implicit *outbound
+
+	// Routing is set instead of all of the above if requests are routed to
+	// one of several outbounds.
+	Routing *routingOutbounds
 }
 
 func (o *outbounds) Decode(into mapdecode.Into) error {
@@ -152,6 +158,19 @@ func (o *outbounds) Decode(into mapdecode.Into) error {
 		return fmt.Errorf("failed to read service name for outbound: %v", err)
 	}
 
+	hasRouting, err := attrs.Pop("routing", &o.Routing)
+	if err != nil {
+		return fmt.Errorf("failed to decode routing outbound configuration: %v", err)
+	}
+	if hasRouting {
+		var empty struct{}
+		if err := attrs.Decode(&empty); err != nil {
+			return fmt.Errorf(
+				"too many attributes in routing outbound configuration: %v", err)
+		}
+		return nil
+	}
+
 	hasUnary, err := attrs.Pop("unary", &o.Unary)
 	if err != nil {
 		return fmt.Errorf("failed to unary outbound configuration: %v", err)
@@ -178,6 +197,22 @@ func (o *outbounds) Decode(into mapdecode.Into) error {
 	return nil
 }
 
+// routingOutbounds configures an outbound which routes requests to one of
+// several named outbounds based on a list of rules.
+type routingOutbounds struct {
+	Outbounds map[string]outbounds `config:"outbounds"`
+	Rules     []routingRule        `config:"rules"`
+	Default   string               `config:"default"`
+}
+
+type routingRule struct {
+	Outbound   string            `config:"outbound"`
+	Procedure  string            `config:"procedure"`
+	ShardKey   string            `config:"shardKey"`
+	RoutingKey string            `config:"routingKey"`
+	Headers    map[string]string `config:"headers"`
+}
+
 type outbound struct {
 	Type       string
 	Attributes attributeMap
@@ -56,6 +56,14 @@
 //
 // 	dispatcher, err := cfg.NewDispatcherFromYAML("myservice", yamlConfig)
 //
+// To change the outbounds of a running Dispatcher when the configuration
+// changes, build it with NewUpdater or NewUpdaterFromYAML instead and feed
+// new configurations to the Updater.
+//
+// 	updater, err := cfg.NewUpdaterFromYAML("myservice", yamlConfig)
+// 	// ...
+// 	err = updater.UpdateFromYAML(newYAMLConfig)
+//
 // Configuration parameters for the different transports, inbounds, and
 // outbounds are defined in the TransportSpecs that were registered against
 // the Configurator. A TransportSpec uses this information to build the
@@ -179,6 +187,32 @@
 // 	  oneway:
 // 	    # ...
 //
+// Requests for a service may be routed to one of several outbounds based on
+// the procedure, shard key, routing key, or headers of each request using the
+// 'routing' key. Each of the named 'outbounds' is configured like any other
+// outbound. The 'rules' are evaluated in order and requests are sent to the
+// outbound of the first rule that matches all of its patterns, or to the
+// 'default' outbound if none do. Patterns use the syntax accepted by
+// path.Match. See the package go.uber.org/yarpc/x/routing for details.
+//
+// 	keyvalue:
+// 	  routing:
+// 	    outbounds:
+// 	      replica:
+// 	        http:
+// 	          # ...
+// 	      primary:
+// 	        tchannel:
+// 	          # ...
+// 	    rules:
+// 	      - procedure: "KeyValue::Read*"
+// 	        outbound: replica
+// 	      - shardKey: "cold-*"
+// 	        routingKey: keyvalue
+// 	        headers: {tenant: beta}
+// 	        outbound: replica
+// 	    default: primary
+//
 // Transport Configuration
 //
 // The 'transports' attribute configures the Transport objects that are shared
@@ -210,4 +244,19 @@
 // This transport will be configured under the 'mytransport' key in the
 // parsed configuration data. See documentation for TransportSpec for details
 // on what each field of TransportSpec means and how it behaves.
+//
+// Validating a Configuration
+//
+// A configuration may be checked with Validate or ValidateYAML without
+// building any transports, inbounds, or outbounds. All errors are reported
+// with their location in the configuration, along with warnings for unused
+// transports and shadowed routing rules.
+//
+// 	v, err := cfg.ValidateYAML("myservice", f)
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	for _, p := range v.Errors {
+// 		log.Println(p)
+// 	}
 package config
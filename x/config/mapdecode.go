@@ -23,10 +23,13 @@ package config
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"go.uber.org/yarpc/internal/interpolate"
 	"go.uber.org/yarpc/internal/mapdecode"
+
+	"go.uber.org/multierr"
 )
 
 const (
@@ -39,19 +42,16 @@ func decodeInto(dst interface{}, src interface{}, opts ...mapdecode.Option) erro
 	return mapdecode.Decode(dst, src, opts...)
 }
 
-func interpolateWith(resolver interpolate.VariableResolver) mapdecode.Option {
-	return mapdecode.FieldHook(func(from reflect.Type, to reflect.StructField, data reflect.Value) (reflect.Value, error) {
-		shouldInterpolate := false
-
-		options := strings.Split(to.Tag.Get(_tagName), ",")[1:]
-		for _, option := range options {
-			if option == _interpolateOption {
-				shouldInterpolate = true
-				break
-			}
-		}
+// interpolator resolves variables in fields marked with the interpolate
+// option.
+type interpolator struct {
+	resolve interpolate.VariableResolver
+	sources map[string]interpolate.Source
+}
 
-		if !shouldInterpolate {
+func interpolateWith(i interpolator) mapdecode.Option {
+	return mapdecode.FieldHook(func(from reflect.Type, to reflect.StructField, data reflect.Value) (reflect.Value, error) {
+		if !shouldInterpolate(to) {
 			return data, nil
 		}
 
@@ -70,7 +70,7 @@ func interpolateWith(resolver interpolate.VariableResolver) mapdecode.Option {
 			return data, fmt.Errorf("failed to parse %q for interpolation: %v", v, err)
 		}
 
-		newV, err := s.Render(resolver)
+		newV, err := s.RenderWithSources(i.resolve, i.sources)
 		if err != nil {
 			return data, fmt.Errorf("failed to render %q with environment variables: %v", v, err)
 		}
@@ -78,3 +78,144 @@ func interpolateWith(resolver interpolate.VariableResolver) mapdecode.Option {
 		return reflect.ValueOf(newV), nil
 	})
 }
+
+func shouldInterpolate(field reflect.StructField) bool {
+	return hasTagOption(field, _interpolateOption)
+}
+
+func hasTagOption(field reflect.StructField, option string) bool {
+	options := strings.Split(field.Tag.Get(_tagName), ",")[1:]
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// checkVariables walks data the way it would be decoded into a value of type
+// t and reports every interpolated field that references variables which
+// cannot be resolved.
+//
+// Errors are prefixed with the path of the field, relative to the given path,
+// in the form "foo.bar[0].baz".
+func (i interpolator) checkVariables(path string, t reflect.Type, data interface{}) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	v := reflect.ValueOf(data)
+	if !v.IsValid() {
+		return nil
+	}
+
+	var errs error
+	switch t.Kind() {
+	case reflect.Struct:
+		if v.Kind() != reflect.Map {
+			return nil
+		}
+
+		for idx := 0; idx < t.NumField(); idx++ {
+			field := t.Field(idx)
+			if field.PkgPath != "" && !field.Anonymous {
+				continue
+			}
+
+			if hasTagOption(field, "squash") {
+				errs = multierr.Append(errs, i.checkVariables(path, field.Type, data))
+				continue
+			}
+
+			name := field.Name
+			if tagName := strings.Split(field.Tag.Get(_tagName), ",")[0]; tagName != "" {
+				name = tagName
+			}
+
+			key, value, ok := lookupField(v, name)
+			if !ok {
+				continue
+			}
+
+			fieldPath := joinPath(path, key)
+			if s, isString := value.(string); isString && shouldInterpolate(field) {
+				errs = multierr.Append(errs, i.checkString(fieldPath, s))
+				continue
+			}
+			errs = multierr.Append(errs, i.checkVariables(fieldPath, field.Type, value))
+		}
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil
+		}
+
+		for idx := 0; idx < v.Len(); idx++ {
+			itemPath := fmt.Sprintf("%v[%d]", path, idx)
+			errs = multierr.Append(errs, i.checkVariables(itemPath, t.Elem(), v.Index(idx).Interface()))
+		}
+
+	case reflect.Map:
+		if v.Kind() != reflect.Map {
+			return nil
+		}
+
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			key := fmt.Sprint(k.Interface())
+			keys = append(keys, key)
+			values[key] = v.MapIndex(k).Interface()
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			errs = multierr.Append(errs, i.checkVariables(joinPath(path, key), t.Elem(), values[key]))
+		}
+	}
+
+	return errs
+}
+
+// checkString reports the variables referenced by the given string which
+// cannot be resolved.
+func (i interpolator) checkString(path, v string) error {
+	s, err := interpolate.Parse(v)
+	if err != nil {
+		// Invalid strings are reported when decoding.
+		return nil
+	}
+
+	if _, err := s.RenderWithSources(i.resolve, i.sources); err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+	return nil
+}
+
+// lookupField looks up the value for the given field name in the map the same
+// way decoding does: the name is matched exactly or, failing that,
+// case-insensitively.
+func lookupField(m reflect.Value, name string) (key string, value interface{}, ok bool) {
+	if k := m.Type().Key().Kind(); k != reflect.String && k != reflect.Interface {
+		return "", nil, false
+	}
+
+	if v := m.MapIndex(reflect.ValueOf(name)); v.IsValid() {
+		return name, v.Interface(), true
+	}
+
+	for _, k := range m.MapKeys() {
+		key, ok := k.Interface().(string)
+		if ok && strings.EqualFold(key, name) {
+			return key, m.MapIndex(k).Interface(), true
+		}
+	}
+	return "", nil, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -188,7 +188,7 @@ func TestInterpolateHook(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
 			var dest someStruct
-			err := decodeInto(&dest, tt.give, interpolateWith(mapVariableResolver(tt.env)))
+			err := decodeInto(&dest, tt.give, interpolateWith(interpolator{resolve: mapVariableResolver(tt.env)}))
 
 			if len(tt.wantErrors) > 0 {
 				require.Error(t, err)
@@ -32,8 +32,23 @@ type Option func(*Configurator)
 // name of that field.
 //
 // See TransportSpec documentation for more information.
+//
+// Use ChainResolver to combine multiple resolvers.
 func InterpolationResolver(f func(k string) (v string, ok bool)) Option {
 	return func(c *Configurator) {
 		c.resolver = f
 	}
 }
+
+// InterpolationSource registers a named source of values for interpolated
+// variables. Variables in the form "${name:key}" are resolved by looking up
+// key with the given function instead of using it as the default value of
+// the variable, and the configuration fails to load if the lookup fails.
+//
+// A source which reads files is registered as "file" by default. See
+// FileSource.
+func InterpolationSource(name string, f func(key string) (v string, err error)) Option {
+	return func(c *Configurator) {
+		c.sources[name] = f
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// EnvResolver resolves interpolated variables using environment variables.
+// This is the default InterpolationResolver.
+func EnvResolver(name string) (value string, ok bool) {
+	return os.LookupEnv(name)
+}
+
+// ChainResolver builds a resolver for interpolated variables which tries
+// each of the given resolvers in-order and uses the first value found.
+//
+// For example, the following uses values from a map before falling back to
+// environment variables.
+//
+// 	config.New(config.InterpolationResolver(config.ChainResolver(
+// 		func(name string) (string, bool) {
+// 			v, ok := overrides[name]
+// 			return v, ok
+// 		},
+// 		config.EnvResolver,
+// 	)))
+func ChainResolver(resolvers ...func(name string) (value string, ok bool)) func(name string) (value string, ok bool) {
+	return func(name string) (string, bool) {
+		for _, resolve := range resolvers {
+			if value, ok := resolve(name); ok {
+				return value, true
+			}
+		}
+		return "", false
+	}
+}
+
+// FileSource is a source of values for interpolated variables which reads
+// the file at the given path. Trailing newlines are removed from the
+// contents of the file.
+//
+// FileSource is registered as the "file" InterpolationSource by default, so
+// "${file:/etc/secret}" is replaced by the contents of /etc/secret.
+func FileSource(path string) (string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(contents), "\r\n"), nil
+}
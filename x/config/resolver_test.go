@@ -0,0 +1,182 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport/transporttest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainResolver(t *testing.T) {
+	resolve := ChainResolver(
+		mapVariableResolver(map[string]string{"foo": "first"}),
+		mapVariableResolver(map[string]string{"foo": "second", "bar": "second"}),
+	)
+
+	tests := []struct {
+		name      string
+		wantValue string
+		wantOK    bool
+	}{
+		{name: "foo", wantValue: "first", wantOK: true},
+		{name: "bar", wantValue: "second", wantOK: true},
+		{name: "baz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := resolve(tt.name)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantValue, value)
+		})
+	}
+
+	_, ok := ChainResolver()("foo")
+	assert.False(t, ok, "empty chain must not resolve anything")
+}
+
+func TestFileSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpc-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "secret")
+	require.NoError(t, ioutil.WriteFile(path, []byte("hunter2\n"), 0600))
+
+	value, err := FileSource(path)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+
+	_, err = FileSource(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestInterpolationSources(t *testing.T) {
+	type inboundConfig struct {
+		Address  string `config:",interpolate"`
+		Password string `config:",interpolate"`
+		Token    string `config:",interpolate"`
+	}
+
+	dir, err := ioutil.TempDir("", "yarpc-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	secretPath := filepath.Join(dir, "secret")
+	require.NoError(t, ioutil.WriteFile(secretPath, []byte("hunter2\n"), 0600))
+
+	vault := func(key string) (string, error) {
+		if key == "token" {
+			return "s3cr3t", nil
+		}
+		return "", fmt.Errorf("unknown key %q", key)
+	}
+
+	tests := []struct {
+		desc       string
+		give       string
+		wantConfig inboundConfig
+		wantErrors []string
+	}{
+		{
+			desc: "success",
+			give: expand(fmt.Sprintf(`
+				inbounds:
+					http:
+						address: ${HOST:localhost}:8080
+						password: ${file:%v}
+						token: ${vault:token}
+			`, secretPath)),
+			wantConfig: inboundConfig{
+				Address:  "localhost:8080",
+				Password: "hunter2",
+				Token:    "s3cr3t",
+			},
+		},
+		{
+			desc: "failures",
+			give: expand(fmt.Sprintf(`
+				inbounds:
+					http:
+						address: ${HOST}:${PORT}
+						password: ${file:%v}
+						token: ${vault:other}
+			`, filepath.Join(dir, "missing"))),
+			wantErrors: []string{
+				`inbounds.http.address: variables "HOST", "PORT" do not have values or defaults`,
+				fmt.Sprintf(`inbounds.http.password: failed to read %q from "file"`, filepath.Join(dir, "missing")),
+				`inbounds.http.token: failed to read "other" from "vault": unknown key "other"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			http := mockTransportSpecBuilder{
+				Name:            "http",
+				TransportConfig: _typeOfEmptyStruct,
+				InboundConfig:   reflect.TypeOf(inboundConfig{}),
+			}.Build(mockCtrl)
+
+			cfg := New(
+				InterpolationResolver(mapVariableResolver(nil)),
+				InterpolationSource("vault", vault),
+			)
+			require.NoError(t, cfg.RegisterTransport(http.Spec()))
+
+			var inbound *transporttest.MockInbound
+			if len(tt.wantErrors) == 0 {
+				kit := kitMatcher{ServiceName: "foo"}
+				trans := transporttest.NewMockTransport(mockCtrl)
+				inbound = transporttest.NewMockInbound(mockCtrl)
+				http.EXPECT().BuildTransport(struct{}{}, kit).Return(trans, nil)
+				http.EXPECT().BuildInbound(tt.wantConfig, trans, kit).Return(inbound, nil)
+			}
+
+			got, err := cfg.LoadConfigFromYAML("foo", strings.NewReader(tt.give))
+			if len(tt.wantErrors) > 0 {
+				require.Error(t, err)
+				for _, msg := range tt.wantErrors {
+					assert.Contains(t, err.Error(), msg)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, yarpc.Inbounds{inbound}, got.Inbounds)
+		})
+	}
+}
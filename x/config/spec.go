@@ -157,6 +157,14 @@ import (
 // timeout of 5 seconds should be used by default, unless the REQUEST_TIMEOUT
 // environment variable is set in which case the timeout specified in the
 // environment variable should be used.
+//
+// Values may also be read from named sources with the form ${source:key}. By
+// default, ${file:/etc/secret} is replaced by the contents of /etc/secret.
+// More sources may be added with the InterpolationSource option.
+//
+// Configurations which reference variables that have neither a value nor a
+// default fail to load with an error listing all such variables along with
+// their paths in the configuration.
 type TransportSpec struct {
 	// Name of the transport
 	Name string
@@ -0,0 +1,259 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sync"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Updater builds a Dispatcher from configuration and reconfigures its
+// outbounds in place when the configuration changes.
+//
+// 	updater, err := cfg.NewUpdaterFromYAML("myservice", yamlConfig)
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	dispatcher := updater.Dispatcher()
+// 	// ...
+// 	if err := updater.UpdateFromYAML(newYAMLConfig); err != nil {
+// 		log.Print(err)
+// 	}
+//
+// Only outbounds and the transports they use may change between updates.
+// Outbounds and transports whose configuration did not change are retained
+// as-is. Variables are interpolated again on every update but the
+// configuration is compared before interpolation, so a change to the value
+// of a variable alone does not rebuild anything.
+type Updater struct {
+	c          *Configurator
+	dispatcher *yarpc.Dispatcher
+
+	mu         sync.Mutex
+	cfg        *yarpcConfig
+	transports map[string]transport.Transport
+	outbounds  yarpc.Outbounds
+}
+
+// NewUpdaterFromYAML builds an Updater and its Dispatcher from the given YAML
+// configuration.
+func (c *Configurator) NewUpdaterFromYAML(serviceName string, r io.Reader) (*Updater, error) {
+	data, err := readYAML(r)
+	if err != nil {
+		return nil, err
+	}
+	return c.NewUpdater(serviceName, data)
+}
+
+// NewUpdater builds an Updater and its Dispatcher from the given
+// configuration data.
+func (c *Configurator) NewUpdater(serviceName string, data interface{}) (*Updater, error) {
+	cfg, current, err := decodeTwice(data)
+	if err != nil {
+		return nil, err
+	}
+
+	b := c.newBuilder(serviceName)
+	if err := c.loadInto(b, cfg); err != nil {
+		return nil, err
+	}
+	yc, transports, err := b.build()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Updater{
+		c:          c,
+		dispatcher: yarpc.NewDispatcher(yc),
+		cfg:        current,
+		transports: transports,
+		outbounds:  yc.Outbounds,
+	}, nil
+}
+
+// Dispatcher returns the Dispatcher managed by this Updater.
+func (u *Updater) Dispatcher() *yarpc.Dispatcher {
+	return u.dispatcher
+}
+
+// UpdateFromYAML reconfigures the Dispatcher with the given YAML
+// configuration.
+func (u *Updater) UpdateFromYAML(r io.Reader) error {
+	data, err := readYAML(r)
+	if err != nil {
+		return err
+	}
+	return u.Update(data)
+}
+
+// Update reconfigures the outbounds of the Dispatcher with the given
+// configuration data using Dispatcher.UpdateOutbounds.
+//
+// An error is returned if the configuration of the inbounds, or of the
+// transports they use, changed. The Dispatcher is left unchanged if the
+// update fails.
+func (u *Updater) Update(data interface{}) error {
+	cfg, next, err := decodeTwice(data)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !sameInbounds(u.cfg.Inbounds, next.Inbounds) {
+		return errors.New("inbounds cannot be changed without restarting the dispatcher")
+	}
+
+	b := u.c.newBuilder(u.dispatcher.Name())
+
+	// Inbounds were already built and their transports must be retained.
+	inboundTransports := make(map[string]struct{})
+	for _, i := range next.Inbounds {
+		if !i.Disabled {
+			inboundTransports[i.Type] = struct{}{}
+		}
+	}
+	cfg.Inbounds = nil
+	if err := u.c.loadInto(b, cfg); err != nil {
+		return err
+	}
+
+	for name, t := range u.transports {
+		if reflect.DeepEqual(u.cfg.Transports[name], next.Transports[name]) {
+			b.reuseTransports[name] = t
+			continue
+		}
+		if _, ok := inboundTransports[name]; ok {
+			return fmt.Errorf("transport %q is used by inbounds and cannot be changed "+
+				"without restarting the dispatcher", name)
+		}
+	}
+
+	for name, o := range next.Outbounds {
+		old, ok := u.cfg.Outbounds[name]
+		if !ok || !reflect.DeepEqual(old, o) {
+			continue
+		}
+		if reusable(o, b.reuseTransports) {
+			b.reuseOutbounds[name] = u.outbounds[name]
+		}
+	}
+
+	yc, transports, err := b.build()
+	if err != nil {
+		return err
+	}
+	if err := u.dispatcher.UpdateOutbounds(yc.Outbounds); err != nil {
+		return err
+	}
+
+	for name := range inboundTransports {
+		if _, ok := transports[name]; !ok {
+			transports[name] = u.transports[name]
+		}
+	}
+	u.cfg = next
+	u.transports = transports
+	u.outbounds = yc.Outbounds
+	return nil
+}
+
+// reusable returns true if all transports used by the given outbound
+// configuration are being reused.
+func reusable(o outbounds, transports map[string]transport.Transport) bool {
+	if r := o.Routing; r != nil {
+		for _, route := range r.Outbounds {
+			if !reusable(route, transports) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, ob := range []*outbound{o.Unary, o.Oneway, o.Implicit} {
+		if ob == nil {
+			continue
+		}
+		if _, ok := transports[ob.Type]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// sameInbounds returns true if the two lists contain the same inbounds in any
+// order.
+func sameInbounds(l, r inbounds) bool {
+	if len(l) != len(r) {
+		return false
+	}
+	matched := make([]bool, len(r))
+	for _, i := range l {
+		found := false
+		for j, o := range r {
+			if !matched[j] && reflect.DeepEqual(i, o) {
+				matched[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeTwice decodes the given configuration data into two independent
+// copies. The first is consumed while building the configuration and the
+// second is retained to compare against future configurations.
+func decodeTwice(data interface{}) (*yarpcConfig, *yarpcConfig, error) {
+	var cfg, retained yarpcConfig
+	if err := decodeInto(&cfg, data); err != nil {
+		return nil, nil, err
+	}
+	if err := decodeInto(&retained, data); err != nil {
+		return nil, nil, err
+	}
+	return &cfg, &retained, nil
+}
+
+func readYAML(r io.Reader) (map[string]interface{}, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]interface{}
+	if err := yaml.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdater(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	type httpTransportConfig struct{ KeepAlive time.Duration }
+	type httpInboundConfig struct{ Address string }
+	type httpOutboundConfig struct{ URL string }
+	http := mockTransportSpecBuilder{
+		Name:                "http",
+		TransportConfig:     reflect.TypeOf(httpTransportConfig{}),
+		InboundConfig:       reflect.TypeOf(httpInboundConfig{}),
+		UnaryOutboundConfig: reflect.TypeOf(httpOutboundConfig{}),
+	}.Build(mockCtrl)
+
+	type redisTransportConfig struct{ Address string }
+	type redisOutboundConfig struct{ Queue string }
+	redis := mockTransportSpecBuilder{
+		Name:                 "redis",
+		TransportConfig:      reflect.TypeOf(redisTransportConfig{}),
+		OnewayOutboundConfig: reflect.TypeOf(redisOutboundConfig{}),
+	}.Build(mockCtrl)
+
+	cfg := New()
+	require.NoError(t, cfg.RegisterTransport(http.Spec()))
+	require.NoError(t, cfg.RegisterTransport(redis.Spec()))
+
+	httpTransport := transporttest.NewMockTransport(mockCtrl)
+	redisTransport := transporttest.NewMockTransport(mockCtrl)
+	inbound := transporttest.NewMockInbound(mockCtrl)
+	inbound.EXPECT().Transports().Return([]transport.Transport{httpTransport}).AnyTimes()
+	fooOutbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+	fooOutbound.EXPECT().Transports().Return([]transport.Transport{httpTransport}).AnyTimes()
+	barOutbound := transporttest.NewMockOnewayOutbound(mockCtrl)
+	barOutbound.EXPECT().Transports().Return([]transport.Transport{redisTransport}).AnyTimes()
+
+	http.EXPECT().BuildTransport(httpTransportConfig{}, anyKit).Return(httpTransport, nil)
+	http.EXPECT().BuildInbound(httpInboundConfig{Address: ":80"}, httpTransport, anyKit).Return(inbound, nil)
+	http.EXPECT().BuildUnaryOutbound(httpOutboundConfig{URL: "http://foo"}, httpTransport, anyKit).Return(fooOutbound, nil)
+	redis.EXPECT().BuildTransport(redisTransportConfig{Address: "localhost:6379"}, anyKit).Return(redisTransport, nil)
+	redis.EXPECT().BuildOnewayOutbound(redisOutboundConfig{Queue: "bar"}, redisTransport, anyKit).Return(barOutbound, nil)
+
+	updater, err := cfg.NewUpdaterFromYAML("myservice", strings.NewReader(expand(`
+		inbounds:
+			http: {address: ":80"}
+		outbounds:
+			foo:
+				http: {url: "http://foo"}
+			bar:
+				redis: {queue: bar}
+		transports:
+			redis: {address: "localhost:6379"}
+	`)))
+	require.NoError(t, err)
+	dispatcher := updater.Dispatcher()
+	fooConfig := dispatcher.ClientConfig("foo")
+
+	// Only the outbounds that changed are built again.
+	newFooOutbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+	newFooOutbound.EXPECT().Transports().Return([]transport.Transport{httpTransport}).AnyTimes()
+	bazOutbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+	bazOutbound.EXPECT().Transports().Return([]transport.Transport{httpTransport}).AnyTimes()
+	http.EXPECT().BuildUnaryOutbound(httpOutboundConfig{URL: "http://foo:8080"}, httpTransport, anyKit).Return(newFooOutbound, nil)
+	http.EXPECT().BuildUnaryOutbound(httpOutboundConfig{URL: "http://baz"}, httpTransport, anyKit).Return(bazOutbound, nil)
+
+	require.NoError(t, updater.UpdateFromYAML(strings.NewReader(expand(`
+		inbounds:
+			http: {address: ":80"}
+		outbounds:
+			foo:
+				http: {url: "http://foo:8080"}
+			bar:
+				redis: {queue: bar}
+			baz:
+				http: {url: "http://baz"}
+		transports:
+			redis: {address: "localhost:6379"}
+	`))))
+
+	req := &transport.Request{
+		Caller:    "myservice",
+		Service:   "foo",
+		Procedure: "hello",
+		Encoding:  "raw",
+		Body:      &bytes.Buffer{},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	newFooOutbound.EXPECT().Call(gomock.Any(), req).Return(&transport.Response{}, nil)
+	_, err = fooConfig.GetUnaryOutbound().Call(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "baz", dispatcher.ClientConfig("baz").Service())
+
+	// Outbounds are rebuilt if the transport they use changed.
+	newRedisTransport := transporttest.NewMockTransport(mockCtrl)
+	newBarOutbound := transporttest.NewMockOnewayOutbound(mockCtrl)
+	newBarOutbound.EXPECT().Transports().Return([]transport.Transport{newRedisTransport}).AnyTimes()
+	redis.EXPECT().BuildTransport(redisTransportConfig{Address: "localhost:6380"}, anyKit).Return(newRedisTransport, nil)
+	redis.EXPECT().BuildOnewayOutbound(redisOutboundConfig{Queue: "bar"}, newRedisTransport, anyKit).Return(newBarOutbound, nil)
+
+	require.NoError(t, updater.UpdateFromYAML(strings.NewReader(expand(`
+		inbounds:
+			http: {address: ":80"}
+		outbounds:
+			foo:
+				http: {url: "http://foo:8080"}
+			bar:
+				redis: {queue: bar}
+		transports:
+			redis: {address: "localhost:6380"}
+	`))))
+	assert.Panics(t, func() { dispatcher.ClientConfig("baz") })
+}
+
+func TestUpdaterRejectsInboundChanges(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	type httpTransportConfig struct{ KeepAlive time.Duration }
+	type httpInboundConfig struct{ Address string }
+	http := mockTransportSpecBuilder{
+		Name:            "http",
+		TransportConfig: reflect.TypeOf(httpTransportConfig{}),
+		InboundConfig:   reflect.TypeOf(httpInboundConfig{}),
+	}.Build(mockCtrl)
+
+	cfg := New()
+	require.NoError(t, cfg.RegisterTransport(http.Spec()))
+
+	httpTransport := transporttest.NewMockTransport(mockCtrl)
+	inbound := transporttest.NewMockInbound(mockCtrl)
+	inbound.EXPECT().Transports().Return([]transport.Transport{httpTransport}).AnyTimes()
+	http.EXPECT().BuildTransport(httpTransportConfig{}, anyKit).Return(httpTransport, nil)
+	http.EXPECT().BuildInbound(httpInboundConfig{Address: ":80"}, httpTransport, anyKit).Return(inbound, nil)
+
+	updater, err := cfg.NewUpdaterFromYAML("myservice", strings.NewReader(expand(`
+		inbounds:
+			http: {address: ":80"}
+	`)))
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc    string
+		give    string
+		wantErr string
+	}{
+		{
+			desc: "inbound",
+			give: expand(`
+				inbounds:
+					http: {address: ":81"}
+			`),
+			wantErr: "inbounds cannot be changed without restarting the dispatcher",
+		},
+		{
+			desc: "inbound transport",
+			give: expand(`
+				inbounds:
+					http: {address: ":80"}
+				transports:
+					http: {keepAlive: 5s}
+			`),
+			wantErr: `transport "http" is used by inbounds and cannot be changed without restarting the dispatcher`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := updater.UpdateFromYAML(strings.NewReader(tt.give))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/x/routing"
+)
+
+// Problem is an issue found while validating a configuration.
+type Problem struct {
+	// Path to the section of the configuration in which the problem was
+	// found. For example, "outbounds.keyvalue.unary.http".
+	Path string
+
+	// Description of the problem.
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Path == "" {
+		return p.Message
+	}
+	return p.Path + ": " + p.Message
+}
+
+// Validation is the result of validating a configuration.
+type Validation struct {
+	// Errors that would prevent the configuration from being loaded.
+	Errors []Problem
+
+	// Warnings about parts of the configuration which are valid but likely
+	// mistakes.
+	Warnings []Problem
+}
+
+// Err returns an error describing all errors found during validation, or nil
+// if the configuration is valid. Warnings are not included.
+func (v *Validation) Err() (err error) {
+	for _, p := range v.Errors {
+		err = multierr.Append(err, fmt.Errorf("%v", p))
+	}
+	return err
+}
+
+// String formats the problems found during validation, errors first, one per
+// line.
+func (v *Validation) String() string {
+	lines := make([]string, 0, len(v.Errors)+len(v.Warnings))
+	for _, p := range v.Errors {
+		lines = append(lines, "error: "+p.String())
+	}
+	for _, p := range v.Warnings {
+		lines = append(lines, "warning: "+p.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (v *Validation) addError(path string, err error) {
+	for _, e := range multierr.Errors(err) {
+		v.Errors = append(v.Errors, Problem{Path: path, Message: e.Error()})
+	}
+}
+
+func (v *Validation) warnf(path string, format string, args ...interface{}) {
+	v.Warnings = append(v.Warnings, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateYAML validates the given YAML configuration. An error is returned
+// only if the YAML could not be parsed. See Validate for details.
+func (c *Configurator) ValidateYAML(serviceName string, r io.Reader) (*Validation, error) {
+	data, err := readYAML(r)
+	if err != nil {
+		return nil, err
+	}
+	return c.Validate(serviceName, data), nil
+}
+
+// Validate validates a configuration in the same shape as accepted by
+// LoadConfig without building it.
+//
+// All inbounds, outbounds, and transports are decoded using the registered
+// specs, and every error found is reported along with its path in the
+// configuration. Warnings are reported for transports which are configured
+// but unused, and for routing rules and outbounds which can never receive
+// requests because they are shadowed by other rules.
+//
+// No transports, inbounds, or outbounds are constructed so Validate does not
+// open any connections or sockets.
+func (c *Configurator) Validate(serviceName string, data interface{}) *Validation {
+	var v Validation
+
+	var cfg yarpcConfig
+	if err := decodeInto(&cfg, data); err != nil {
+		v.addError("", err)
+		return &v
+	}
+
+	b := c.newBuilder(serviceName)
+	sort.Sort(inboundsByName(cfg.Inbounds))
+	for _, i := range cfg.Inbounds {
+		if err := c.loadInboundInto(b, i); err != nil {
+			v.addError("inbounds."+i.Name, err)
+		}
+	}
+
+	for _, name := range sortedOutboundNames(cfg.Outbounds) {
+		path := "outbounds." + name
+		o := cfg.Outbounds[name]
+		if err := c.loadOutboundInto(b, name, o); err != nil {
+			v.addError(path, err)
+		}
+		if o.Routing != nil {
+			validateRouting(&v, path+".routing", o.Routing)
+		}
+	}
+
+	transports := make([]string, 0, len(cfg.Transports))
+	for name := range cfg.Transports {
+		transports = append(transports, name)
+	}
+	sort.Strings(transports)
+	for _, name := range transports {
+		path := "transports." + name
+		if err := c.loadTransportInto(b, name, cfg.Transports[name]); err != nil {
+			v.addError(path, err)
+			continue
+		}
+		if _, ok := b.needTransports[name]; !ok {
+			v.warnf(path, "transport %q is not used by any inbound or outbound", name)
+		}
+	}
+
+	// Transports used without any configuration are decoded from an empty
+	// map when the configuration is built. Do the same here so that missing
+	// required attributes are reported.
+	needed := make([]string, 0, len(b.needTransports))
+	for name := range b.needTransports {
+		if _, ok := b.transports[name]; !ok {
+			needed = append(needed, name)
+		}
+	}
+	sort.Strings(needed)
+	for _, name := range needed {
+		path := "transports." + name
+		if _, err := b.decode(path, b.needTransports[name].Transport, attributeMap{}); err != nil {
+			v.addError(path, fmt.Errorf("failed to decode transport configuration: %v", err))
+		}
+	}
+
+	return &v
+}
+
+// validateRouting reports errors in the rules of a routing outbound which
+// would otherwise be found only when the outbound is built, and warns about
+// rules and outbounds which will never be used.
+func validateRouting(v *Validation, section string, cfg *routingOutbounds) {
+	used := make(map[string]struct{}, len(cfg.Outbounds))
+	if cfg.Default != "" {
+		used[cfg.Default] = struct{}{}
+		if _, ok := cfg.Outbounds[cfg.Default]; !ok {
+			v.addError(section+".default", fmt.Errorf("unknown default outbound %q", cfg.Default))
+		}
+	}
+
+	rules := make([]routing.Rule, len(cfg.Rules))
+	for i, r := range cfg.Rules {
+		rulePath := fmt.Sprintf("%v.rules[%d]", section, i)
+		rules[i] = routing.Rule{
+			Outbound:   r.Outbound,
+			Procedure:  r.Procedure,
+			ShardKey:   r.ShardKey,
+			RoutingKey: r.RoutingKey,
+			Headers:    r.Headers,
+		}
+
+		used[r.Outbound] = struct{}{}
+		if _, ok := cfg.Outbounds[r.Outbound]; !ok {
+			v.addError(rulePath, fmt.Errorf("unknown outbound %q", r.Outbound))
+		}
+		for _, p := range rulePatterns(rules[i]) {
+			if _, err := path.Match(p, ""); err != nil {
+				v.addError(rulePath, fmt.Errorf("invalid pattern %q: %v", p, err))
+			}
+		}
+
+		for j := 0; j < i; j++ {
+			if shadows(rules[j], rules[i]) {
+				v.warnf(rulePath, "rule (%v) is never used: all matching requests are sent to %q by rules[%d] (%v)",
+					rules[i], rules[j].Outbound, j, rules[j])
+				break
+			}
+		}
+	}
+
+	for _, name := range sortedOutboundNames(cfg.Outbounds) {
+		if _, ok := used[name]; !ok {
+			v.warnf(section+".outbounds."+name, "outbound %q is not used by any rule or as the default", name)
+		}
+	}
+}
+
+// shadows returns true if every request matched by rule b is also matched by
+// rule a. This only considers patterns that are empty or identical so it may
+// miss some rules that are shadowed by wildcards.
+func shadows(a, b routing.Rule) bool {
+	if !shadowsPattern(a.Procedure, b.Procedure) ||
+		!shadowsPattern(a.ShardKey, b.ShardKey) ||
+		!shadowsPattern(a.RoutingKey, b.RoutingKey) {
+		return false
+	}
+	for k, pattern := range a.Headers {
+		other, ok := b.Headers[k]
+		if !ok || !shadowsPattern(pattern, other) {
+			return false
+		}
+	}
+	return true
+}
+
+func shadowsPattern(a, b string) bool {
+	return a == "" || a == b
+}
+
+func rulePatterns(r routing.Rule) []string {
+	patterns := []string{r.Procedure, r.ShardKey, r.RoutingKey}
+	for _, p := range r.Headers {
+		patterns = append(patterns, p)
+	}
+	return patterns
+}
+
+type inboundsByName []inbound
+
+func (is inboundsByName) Len() int           { return len(is) }
+func (is inboundsByName) Less(i, j int) bool { return is[i].Name < is[j].Name }
+func (is inboundsByName) Swap(i, j int)      { is[i], is[j] = is[j], is[i] }
+
+func sortedOutboundNames(outbounds map[string]outbounds) []string {
+	names := make([]string, 0, len(outbounds))
+	for name := range outbounds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
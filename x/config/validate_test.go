@@ -0,0 +1,191 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	type transportConfig struct{ Workers int }
+	type inboundConfig struct{ Address string }
+	type outboundConfig struct {
+		URL string `config:",interpolate"`
+	}
+
+	tests := []struct {
+		desc         string
+		give         string
+		env          map[string]string
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			desc: "valid",
+			give: `
+				inbounds:
+					http: {address: ":80"}
+				outbounds:
+					keyvalue:
+						http: {url: "http://localhost:8080"}
+				transports:
+					http: {workers: 10}
+			`,
+		},
+		{
+			desc: "errors",
+			give: `
+				inbounds:
+					http: {address: [1, 2]}
+					grpc: {address: ":81"}
+				outbounds:
+					keyvalue:
+						unary:
+							http: {url: "${KEYVALUE_URL}"}
+					users:
+						unary:
+							tchannel: {}
+				transports:
+					http: {workers: "many"}
+			`,
+			wantErrors: []string{
+				`inbounds.grpc: failed to load inbound: unknown transport "grpc"`,
+				`inbounds.http: failed to decode inbound configuration:`,
+				`outbounds.keyvalue: failed to add outbound "keyvalue": failed to decode unary outbound configuration: ` +
+					`outbounds.keyvalue.unary.http.url: variable "KEYVALUE_URL" does not have a value or a default`,
+				`outbounds.users: failed to load configuration for outbound "users": unknown transport "tchannel"`,
+				`transports.http: failed to decode transport configuration:`,
+			},
+		},
+		{
+			desc: "unused transport",
+			give: `
+				outbounds:
+					keyvalue:
+						http: {url: "http://localhost:8080"}
+				transports:
+					http: {workers: 10}
+					other: {}
+			`,
+			wantWarnings: []string{
+				`transports.other: transport "other" is not used by any inbound or outbound`,
+			},
+		},
+		{
+			desc: "routing",
+			give: `
+				outbounds:
+					keyvalue:
+						routing:
+							outbounds:
+								primary:
+									http: {url: "http://primary"}
+								beta:
+									http: {url: "http://beta"}
+								writes:
+									http: {url: "http://writes"}
+								unused:
+									http: {url: "http://unused"}
+							rules:
+								- outbound: beta
+								  headers: {tenant: beta}
+								- outbound: writes
+								  procedure: "Set*"
+								- outbound: primary
+								  procedure: "Set*"
+								  headers: {tenant: "*"}
+								- outbound: missing
+								- outbound: primary
+								  shardKey: "[a-"
+							default: primary
+			`,
+			wantErrors: []string{
+				`outbounds.keyvalue.routing.rules[3]: unknown outbound "missing"`,
+				`outbounds.keyvalue.routing.rules[4]: invalid pattern "[a-": syntax error in pattern`,
+			},
+			wantWarnings: []string{
+				`outbounds.keyvalue.routing.rules[2]: rule (procedure="Set*" header[tenant]="*") is never used: ` +
+					`all matching requests are sent to "writes" by rules[1] (procedure="Set*")`,
+				`outbounds.keyvalue.routing.rules[4]: rule (shard-key="[a-") is never used: ` +
+					`all matching requests are sent to "missing" by rules[3] (*)`,
+				`outbounds.keyvalue.routing.outbounds.unused: outbound "unused" is not used by any rule or as the default`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			// No expectations are set on the spec so the test fails if
+			// anything is built.
+			http := mockTransportSpecBuilder{
+				Name:                "http",
+				TransportConfig:     reflect.TypeOf(&transportConfig{}),
+				InboundConfig:       reflect.TypeOf(&inboundConfig{}),
+				UnaryOutboundConfig: reflect.TypeOf(&outboundConfig{}),
+			}.Build(mockCtrl)
+			other := mockTransportSpecBuilder{
+				Name:            "other",
+				TransportConfig: reflect.TypeOf(&transportConfig{}),
+			}.Build(mockCtrl)
+
+			cfg := New(InterpolationResolver(mapVariableResolver(tt.env)))
+			require.NoError(t, cfg.RegisterTransport(http.Spec()))
+			require.NoError(t, cfg.RegisterTransport(other.Spec()))
+
+			v, err := cfg.ValidateYAML("myservice", strings.NewReader(expand(tt.give)))
+			require.NoError(t, err)
+
+			var gotErrors, gotWarnings []string
+			for _, p := range v.Errors {
+				gotErrors = append(gotErrors, p.String())
+			}
+			for _, p := range v.Warnings {
+				gotWarnings = append(gotWarnings, p.String())
+			}
+
+			require.Len(t, gotErrors, len(tt.wantErrors), "errors: %v", gotErrors)
+			for i, want := range tt.wantErrors {
+				assert.Contains(t, gotErrors[i], want)
+			}
+			assert.Equal(t, tt.wantWarnings, gotWarnings)
+
+			if len(tt.wantErrors) > 0 {
+				assert.Error(t, v.Err())
+			} else {
+				assert.NoError(t, v.Err())
+			}
+		})
+	}
+}
+
+func TestValidateYAMLParseError(t *testing.T) {
+	_, err := New().ValidateYAML("myservice", strings.NewReader("{"))
+	assert.Error(t, err)
+}
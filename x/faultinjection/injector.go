@@ -0,0 +1,390 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package faultinjection provides middleware which injects latency, errors,
+// and dropped oneway requests into requests which match a set of rules. It
+// may be used to test how services behave when their dependencies misbehave.
+//
+// The same Injector may be used as inbound and outbound middleware.
+//
+// 	injector, err := faultinjection.New(faultinjection.Rule{
+// 		Service:    "keyvalue",
+// 		Procedure:  "get",
+// 		Percentage: 10,
+// 		Latency:    500 * time.Millisecond,
+// 	})
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  injector,
+// 			Oneway: injector,
+// 		},
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary:  injector,
+// 			Oneway: injector,
+// 		},
+// 	})
+//
+// The rules may be changed at runtime with SetRules, or remotely through the
+// procedures returned by Procedures.
+//
+// 	dispatcher.Register(injector.Procedures())
+package faultinjection
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/errors"
+)
+
+var (
+	_ middleware.UnaryInbound   = (*Injector)(nil)
+	_ middleware.OnewayInbound  = (*Injector)(nil)
+	_ middleware.UnaryOutbound  = (*Injector)(nil)
+	_ middleware.OnewayOutbound = (*Injector)(nil)
+)
+
+// ErrorType is the type of error injected into a request.
+type ErrorType string
+
+const (
+	// ErrorTimeout fails requests with a timeout error.
+	ErrorTimeout ErrorType = "timeout"
+
+	// ErrorBadRequest fails requests with a bad request error.
+	ErrorBadRequest ErrorType = "bad-request"
+
+	// ErrorUnexpected fails requests with an unexpected error.
+	ErrorUnexpected ErrorType = "unexpected"
+
+	// ErrorApplication answers unary requests with the ApplicationErrorBody
+	// of the Rule as an application error. Oneway requests are not affected.
+	ErrorApplication ErrorType = "application"
+)
+
+// Rule injects faults into a percentage of the requests it matches. A
+// request matches a Rule if it matches all of the Rule's non-empty fields.
+type Rule struct {
+	// Caller, service, and procedure names that requests must have.
+	Caller    string
+	Service   string
+	Procedure string
+
+	// Headers which requests must have with the given values.
+	Headers map[string]string
+
+	// Percentage of the matching requests into which faults are injected,
+	// between 0 and 100.
+	Percentage float64
+
+	// Latency added before requests are handled or sent.
+	Latency time.Duration
+
+	// Error with which requests fail, if any.
+	Error ErrorType
+
+	// ApplicationErrorBody is the body of the responses to requests failed
+	// with ErrorApplication. It is required for ErrorApplication and must be
+	// a valid application error for the encoding of the matching requests,
+	// for example a JSON error payload or a Thrift result with an exception
+	// set, for clients to decode it.
+	ApplicationErrorBody []byte
+
+	// Whether oneway requests are dropped. Dropped requests are acknowledged
+	// without being handled or sent.
+	DropOneway bool
+}
+
+func (r Rule) validate() error {
+	if r.Percentage < 0 || r.Percentage > 100 {
+		return fmt.Errorf("percentage must be between 0 and 100, got %v", r.Percentage)
+	}
+	if r.Latency < 0 {
+		return fmt.Errorf("latency must not be negative, got %v", r.Latency)
+	}
+	switch r.Error {
+	case "", ErrorTimeout, ErrorBadRequest, ErrorUnexpected, ErrorApplication:
+	default:
+		return fmt.Errorf("unknown error type %q", r.Error)
+	}
+	if r.Error == ErrorApplication && len(r.ApplicationErrorBody) == 0 {
+		return fmt.Errorf("application errors require an application error body")
+	}
+	if r.Latency == 0 && r.Error == "" && !r.DropOneway {
+		return fmt.Errorf("rule does not inject any faults")
+	}
+	return nil
+}
+
+// constraint checks a single field of a request.
+type constraint func(*transport.Request) bool
+
+// rule is a Rule along with the constraints a request must satisfy to match
+// it.
+type rule struct {
+	Rule
+
+	constraints []constraint
+}
+
+func newRule(r Rule) rule {
+	compiled := rule{Rule: r, constraints: make([]constraint, 0, 3+len(r.Headers))}
+	if r.Caller != "" {
+		compiled.constraints = append(compiled.constraints, func(req *transport.Request) bool {
+			return r.Caller == req.Caller
+		})
+	}
+	if r.Service != "" {
+		compiled.constraints = append(compiled.constraints, func(req *transport.Request) bool {
+			return r.Service == req.Service
+		})
+	}
+	if r.Procedure != "" {
+		compiled.constraints = append(compiled.constraints, func(req *transport.Request) bool {
+			return r.Procedure == req.Procedure
+		})
+	}
+	for k, v := range r.Headers {
+		k, v := k, v
+		compiled.constraints = append(compiled.constraints, func(req *transport.Request) bool {
+			got, ok := req.Headers.Get(k)
+			return ok && got == v
+		})
+	}
+	return compiled
+}
+
+func (r rule) matches(req *transport.Request) bool {
+	for _, check := range r.constraints {
+		if !check(req) {
+			return false
+		}
+	}
+	return true
+}
+
+// Injector is middleware for all RPC types which injects faults into
+// requests according to its rules.
+//
+// Rules are evaluated in order. Only the first rule matching a request is
+// applied to it.
+type Injector struct {
+	mu    sync.RWMutex
+	rules []rule
+
+	// Returns a number in [0, 100). Overridden in tests.
+	rand func() float64
+}
+
+// New builds an Injector with the given rules.
+func New(rules ...Rule) (*Injector, error) {
+	i := &Injector{rand: func() float64 { return rand.Float64() * 100 }}
+	if err := i.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// Rules returns the current rules of the Injector.
+func (i *Injector) Rules() []Rule {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	rules := make([]Rule, len(i.rules))
+	for idx, r := range i.rules {
+		rules[idx] = r.Rule
+	}
+	return rules
+}
+
+// SetRules replaces the rules of the Injector. The rules are left unchanged
+// if any of the given rules is invalid.
+func (i *Injector) SetRules(rules []Rule) error {
+	compiled := make([]rule, len(rules))
+	for idx, r := range rules {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("invalid fault injection rule %d: %v", idx, err)
+		}
+		compiled[idx] = newRule(r)
+	}
+
+	i.mu.Lock()
+	i.rules = compiled
+	i.mu.Unlock()
+	return nil
+}
+
+// fault returns the rule whose faults should be injected into the given
+// request, if any.
+func (i *Injector) fault(req *transport.Request) (Rule, bool) {
+	if isAdminProcedure(req.Procedure) {
+		return Rule{}, false
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	for _, r := range i.rules {
+		if r.matches(req) {
+			return r.Rule, i.rand() < r.Percentage
+		}
+	}
+	return Rule{}, false
+}
+
+// delay waits for the latency of the rule, failing if the context finishes
+// first.
+func delay(ctx context.Context, latency time.Duration) error {
+	if latency <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func faultMessage(req *transport.Request) string {
+	return fmt.Sprintf("fault injected into procedure %q of service %q", req.Procedure, req.Service)
+}
+
+func handlerError(req *transport.Request, r Rule) error {
+	switch r.Error {
+	case ErrorTimeout:
+		return errors.HandlerTimeoutError(req.Caller, req.Service, req.Procedure, r.Latency)
+	case ErrorBadRequest:
+		return errors.HandlerBadRequestError(fmt.Errorf("%s", faultMessage(req)))
+	case ErrorUnexpected:
+		return errors.HandlerUnexpectedError(fmt.Errorf("%s", faultMessage(req)))
+	}
+	return nil
+}
+
+func remoteError(req *transport.Request, r Rule) error {
+	msg := faultMessage(req)
+	switch r.Error {
+	case ErrorTimeout:
+		return errors.RemoteTimeoutError(msg)
+	case ErrorBadRequest:
+		return errors.RemoteBadRequestError(msg)
+	case ErrorUnexpected:
+		return errors.RemoteUnexpectedError(msg)
+	}
+	return nil
+}
+
+// Handle implements middleware.UnaryInbound.
+func (i *Injector) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	r, ok := i.fault(req)
+	if !ok {
+		return h.Handle(ctx, req, w)
+	}
+	if err := delay(ctx, r.Latency); err != nil {
+		return errors.HandlerTimeoutError(req.Caller, req.Service, req.Procedure, r.Latency)
+	}
+	if r.Error == ErrorApplication {
+		w.SetApplicationError()
+		_, err := w.Write(r.ApplicationErrorBody)
+		return err
+	}
+	if err := handlerError(req, r); err != nil {
+		return err
+	}
+	return h.Handle(ctx, req, w)
+}
+
+// Call implements middleware.UnaryOutbound.
+func (i *Injector) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	r, ok := i.fault(req)
+	if !ok {
+		return out.Call(ctx, req)
+	}
+	if err := delay(ctx, r.Latency); err != nil {
+		return nil, errors.ClientTimeoutError(req.Service, req.Procedure, r.Latency)
+	}
+	if r.Error == ErrorApplication {
+		return &transport.Response{
+			Body:             ioutil.NopCloser(bytes.NewReader(r.ApplicationErrorBody)),
+			ApplicationError: true,
+		}, nil
+	}
+	if err := remoteError(req, r); err != nil {
+		return nil, err
+	}
+	return out.Call(ctx, req)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (i *Injector) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	r, ok := i.fault(req)
+	if !ok {
+		return h.HandleOneway(ctx, req)
+	}
+	if err := delay(ctx, r.Latency); err != nil {
+		return errors.HandlerTimeoutError(req.Caller, req.Service, req.Procedure, r.Latency)
+	}
+	if err := handlerError(req, r); err != nil {
+		return err
+	}
+	if r.DropOneway {
+		return nil
+	}
+	return h.HandleOneway(ctx, req)
+}
+
+// CallOneway implements middleware.OnewayOutbound.
+func (i *Injector) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	r, ok := i.fault(req)
+	if !ok {
+		return out.CallOneway(ctx, req)
+	}
+	if err := delay(ctx, r.Latency); err != nil {
+		return nil, errors.ClientTimeoutError(req.Service, req.Procedure, r.Latency)
+	}
+	if err := remoteError(req, r); err != nil {
+		return nil, err
+	}
+	if r.DropOneway {
+		return droppedAck{}, nil
+	}
+	return out.CallOneway(ctx, req)
+}
+
+// droppedAck acknowledges oneway requests that were dropped.
+type droppedAck struct{}
+
+func (droppedAck) String() string { return "dropped by fault injection" }
@@ -0,0 +1,341 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package faultinjection
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/errors"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInvalidRules(t *testing.T) {
+	tests := []struct {
+		desc    string
+		rule    Rule
+		wantErr string
+	}{
+		{
+			desc:    "percentage too large",
+			rule:    Rule{Percentage: 101, DropOneway: true},
+			wantErr: "invalid fault injection rule 0: percentage must be between 0 and 100, got 101",
+		},
+		{
+			desc:    "negative latency",
+			rule:    Rule{Percentage: 10, Latency: -time.Second},
+			wantErr: "invalid fault injection rule 0: latency must not be negative, got -1s",
+		},
+		{
+			desc:    "unknown error",
+			rule:    Rule{Percentage: 10, Error: "oops"},
+			wantErr: `invalid fault injection rule 0: unknown error type "oops"`,
+		},
+		{
+			desc:    "application error without body",
+			rule:    Rule{Percentage: 10, Error: ErrorApplication},
+			wantErr: "invalid fault injection rule 0: application errors require an application error body",
+		},
+		{
+			desc:    "no faults",
+			rule:    Rule{Percentage: 10},
+			wantErr: "invalid fault injection rule 0: rule does not inject any faults",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := New(tt.rule)
+			require.Error(t, err)
+			assert.Equal(t, tt.wantErr, err.Error())
+		})
+	}
+}
+
+func TestSetRulesKeepsRulesOnError(t *testing.T) {
+	rule := Rule{Service: "foo", Percentage: 50, Error: ErrorUnexpected}
+	i, err := New(rule)
+	require.NoError(t, err)
+
+	assert.Error(t, i.SetRules([]Rule{{Percentage: 200, DropOneway: true}}))
+	assert.Equal(t, []Rule{rule}, i.Rules())
+
+	require.NoError(t, i.SetRules(nil))
+	assert.Empty(t, i.Rules())
+}
+
+func TestUnary(t *testing.T) {
+	tests := []struct {
+		desc string
+		rule Rule
+		roll float64
+		req  transport.Request
+
+		// Whether the request reaches the handler or outbound.
+		wantCalled bool
+
+		wantAppError    bool
+		wantBody        string
+		wantInboundErr  error
+		wantOutboundErr error
+	}{
+		{
+			desc:       "no match",
+			rule:       Rule{Service: "bar", Percentage: 100, Error: ErrorUnexpected},
+			req:        transport.Request{Service: "foo"},
+			wantCalled: true,
+		},
+		{
+			desc:       "header mismatch",
+			rule:       Rule{Headers: map[string]string{"tenant": "beta"}, Percentage: 100, Error: ErrorUnexpected},
+			req:        transport.Request{Service: "foo", Headers: transport.NewHeaders().With("tenant", "prod")},
+			wantCalled: true,
+		},
+		{
+			desc:       "outside percentage",
+			rule:       Rule{Service: "foo", Percentage: 10, Error: ErrorUnexpected},
+			roll:       10,
+			req:        transport.Request{Service: "foo"},
+			wantCalled: true,
+		},
+		{
+			desc:           "timeout",
+			rule:           Rule{Caller: "bar", Service: "foo", Procedure: "baz", Percentage: 10, Error: ErrorTimeout},
+			roll:           9.9,
+			req:            transport.Request{Caller: "bar", Service: "foo", Procedure: "baz"},
+			wantInboundErr: errors.HandlerTimeoutError("bar", "foo", "baz", 0),
+			wantOutboundErr: errors.RemoteTimeoutError(
+				`fault injected into procedure "baz" of service "foo"`),
+		},
+		{
+			desc: "bad request",
+			rule: Rule{Headers: map[string]string{"tenant": "beta"}, Percentage: 100, Error: ErrorBadRequest},
+			req: transport.Request{
+				Service:   "foo",
+				Procedure: "baz",
+				Headers:   transport.NewHeaders().With("Tenant", "beta"),
+			},
+			wantInboundErr: errors.HandlerBadRequestError(fmt.Errorf(`fault injected into procedure "baz" of service "foo"`)),
+			wantOutboundErr: errors.RemoteBadRequestError(
+				`fault injected into procedure "baz" of service "foo"`),
+		},
+		{
+			desc:           "unexpected",
+			rule:           Rule{Procedure: "baz", Percentage: 100, Error: ErrorUnexpected},
+			req:            transport.Request{Service: "foo", Procedure: "baz"},
+			wantInboundErr: errors.HandlerUnexpectedError(fmt.Errorf(`fault injected into procedure "baz" of service "foo"`)),
+			wantOutboundErr: errors.RemoteUnexpectedError(
+				`fault injected into procedure "baz" of service "foo"`),
+		},
+		{
+			desc: "application error",
+			rule: Rule{
+				Percentage:           100,
+				Error:                ErrorApplication,
+				ApplicationErrorBody: []byte(`{"message":"great sadness"}`),
+			},
+			req:          transport.Request{Service: "foo", Procedure: "baz"},
+			wantAppError: true,
+			wantBody:     `{"message":"great sadness"}`,
+		},
+		{
+			desc:       "latency",
+			rule:       Rule{Percentage: 100, Latency: time.Millisecond},
+			req:        transport.Request{Service: "foo", Procedure: "baz"},
+			wantCalled: true,
+		},
+		{
+			desc:       "admin procedures are exempt",
+			rule:       Rule{Percentage: 100, Error: ErrorUnexpected},
+			req:        transport.Request{Service: "foo", Procedure: "yarpc::faultinjection::rules"},
+			wantCalled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			i, err := New(tt.rule)
+			require.NoError(t, err)
+			i.rand = func() float64 { return tt.roll }
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			t.Run("inbound", func(t *testing.T) {
+				req := tt.req
+				h := transporttest.NewMockUnaryHandler(mockCtrl)
+				if tt.wantCalled {
+					h.EXPECT().Handle(ctx, &req, gomock.Any()).Return(nil)
+				}
+
+				w := new(transporttest.FakeResponseWriter)
+				err := i.Handle(ctx, &req, w, h)
+				assert.Equal(t, tt.wantInboundErr, err)
+				assert.Equal(t, tt.wantAppError, w.IsApplicationError)
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			})
+
+			t.Run("outbound", func(t *testing.T) {
+				req := tt.req
+				out := transporttest.NewMockUnaryOutbound(mockCtrl)
+				if tt.wantCalled {
+					out.EXPECT().Call(ctx, &req).Return(&transport.Response{}, nil)
+				}
+
+				res, err := i.Call(ctx, &req, out)
+				assert.Equal(t, tt.wantOutboundErr, err)
+				if err == nil {
+					assert.Equal(t, tt.wantAppError, res.ApplicationError)
+				}
+				if res != nil && res.Body != nil {
+					body, err := ioutil.ReadAll(res.Body)
+					require.NoError(t, err)
+					assert.Equal(t, tt.wantBody, string(body))
+				}
+			})
+		})
+	}
+}
+
+func TestLatencyTimeout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	i, err := New(Rule{Percentage: 100, Latency: time.Minute})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	req := &transport.Request{Caller: "bar", Service: "foo", Procedure: "baz"}
+
+	err = i.Handle(ctx, req, new(transporttest.FakeResponseWriter), transporttest.NewMockUnaryHandler(mockCtrl))
+	assert.Equal(t, errors.HandlerTimeoutError("bar", "foo", "baz", time.Minute), err)
+
+	_, err = i.Call(ctx, req, transporttest.NewMockUnaryOutbound(mockCtrl))
+	assert.Equal(t, errors.ClientTimeoutError("foo", "baz", time.Minute), err)
+}
+
+func TestOneway(t *testing.T) {
+	tests := []struct {
+		desc string
+		rule Rule
+
+		wantCalled      bool
+		wantDropped     bool
+		wantInboundErr  error
+		wantOutboundErr error
+	}{
+		{
+			desc:        "drop",
+			rule:        Rule{Percentage: 100, DropOneway: true},
+			wantDropped: true,
+		},
+		{
+			desc:       "application errors are ignored",
+			rule:       Rule{Percentage: 100, Error: ErrorApplication, ApplicationErrorBody: []byte("{}")},
+			wantCalled: true,
+		},
+		{
+			desc:           "unexpected",
+			rule:           Rule{Percentage: 100, Error: ErrorUnexpected, DropOneway: true},
+			wantInboundErr: errors.HandlerUnexpectedError(fmt.Errorf(`fault injected into procedure "baz" of service "foo"`)),
+			wantOutboundErr: errors.RemoteUnexpectedError(
+				`fault injected into procedure "baz" of service "foo"`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			i, err := New(tt.rule)
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			req := &transport.Request{Service: "foo", Procedure: "baz"}
+
+			h := transporttest.NewMockOnewayHandler(mockCtrl)
+			out := transporttest.NewMockOnewayOutbound(mockCtrl)
+			if tt.wantCalled {
+				h.EXPECT().HandleOneway(ctx, req).Return(nil)
+				out.EXPECT().CallOneway(ctx, req).Return(nil, nil)
+			}
+
+			assert.Equal(t, tt.wantInboundErr, i.HandleOneway(ctx, req, h))
+
+			ack, err := i.CallOneway(ctx, req, out)
+			assert.Equal(t, tt.wantOutboundErr, err)
+			if tt.wantDropped {
+				assert.Equal(t, droppedAck{}, ack)
+			}
+		})
+	}
+}
+
+func TestProcedures(t *testing.T) {
+	i, err := New()
+	require.NoError(t, err)
+
+	procs := i.Procedures()
+	require.Len(t, procs, 2)
+	assert.Equal(t, "yarpc::faultinjection::rules", procs[0].Name)
+	assert.Equal(t, "yarpc::faultinjection::setRules", procs[1].Name)
+
+	res, err := i.setRules(context.Background(), &setRulesRequest{Rules: []jsonRule{
+		{Service: "foo", Percentage: 50, Latency: "1.5s", Error: ErrorTimeout},
+	}})
+	require.NoError(t, err)
+	want := &rulesResponse{Rules: []jsonRule{
+		{Service: "foo", Percentage: 50, Latency: "1.5s", Error: ErrorTimeout},
+	}}
+	assert.Equal(t, want, res)
+	assert.Equal(t, []Rule{
+		{Service: "foo", Percentage: 50, Latency: 1500 * time.Millisecond, Error: ErrorTimeout},
+	}, i.Rules())
+
+	res, err = i.getRules(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, want, res)
+
+	_, err = i.setRules(context.Background(), &setRulesRequest{Rules: []jsonRule{
+		{Percentage: 50, Latency: "soon"},
+	}})
+	assert.True(t, transport.IsBadRequestError(err), "expected bad request error, got %v", err)
+
+	_, err = i.setRules(context.Background(), &setRulesRequest{Rules: []jsonRule{
+		{Percentage: 50},
+	}})
+	assert.True(t, transport.IsBadRequestError(err), "expected bad request error, got %v", err)
+	assert.Len(t, i.Rules(), 1, "rules must not change on error")
+}
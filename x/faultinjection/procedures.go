@@ -0,0 +1,158 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package faultinjection
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/json"
+	"go.uber.org/yarpc/internal/errors"
+)
+
+// Prefix of the names of the procedures returned by Procedures. Faults are
+// never injected into requests for these procedures.
+const adminProcedurePrefix = "yarpc::faultinjection::"
+
+func isAdminProcedure(name string) bool {
+	return strings.HasPrefix(name, adminProcedurePrefix)
+}
+
+// jsonRule is the representation of a Rule used by the admin procedures.
+type jsonRule struct {
+	Caller     string            `json:"caller,omitempty"`
+	Service    string            `json:"service,omitempty"`
+	Procedure  string            `json:"procedure,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Percentage float64           `json:"percentage"`
+	Latency    string            `json:"latency,omitempty"`
+	Error      ErrorType         `json:"error,omitempty"`
+	DropOneway bool              `json:"dropOneway,omitempty"`
+
+	// ApplicationErrorBody is base64-encoded in JSON.
+	ApplicationErrorBody []byte `json:"applicationErrorBody,omitempty"`
+}
+
+func toJSONRule(r Rule) jsonRule {
+	jr := jsonRule{
+		Caller:     r.Caller,
+		Service:    r.Service,
+		Procedure:  r.Procedure,
+		Headers:    r.Headers,
+		Percentage: r.Percentage,
+		Error:      r.Error,
+		DropOneway: r.DropOneway,
+
+		ApplicationErrorBody: r.ApplicationErrorBody,
+	}
+	if r.Latency != 0 {
+		jr.Latency = r.Latency.String()
+	}
+	return jr
+}
+
+func (jr jsonRule) toRule() (Rule, error) {
+	r := Rule{
+		Caller:     jr.Caller,
+		Service:    jr.Service,
+		Procedure:  jr.Procedure,
+		Headers:    jr.Headers,
+		Percentage: jr.Percentage,
+		Error:      jr.Error,
+		DropOneway: jr.DropOneway,
+
+		ApplicationErrorBody: jr.ApplicationErrorBody,
+	}
+	if jr.Latency != "" {
+		latency, err := time.ParseDuration(jr.Latency)
+		if err != nil {
+			return Rule{}, err
+		}
+		r.Latency = latency
+	}
+	return r, nil
+}
+
+type rulesResponse struct {
+	Rules []jsonRule `json:"rules"`
+}
+
+type setRulesRequest struct {
+	Rules []jsonRule `json:"rules"`
+}
+
+func (i *Injector) rulesResponse() *rulesResponse {
+	rules := i.Rules()
+	res := &rulesResponse{Rules: make([]jsonRule, len(rules))}
+	for idx, r := range rules {
+		res.Rules[idx] = toJSONRule(r)
+	}
+	return res
+}
+
+func (i *Injector) getRules(ctx context.Context, body interface{}) (*rulesResponse, error) {
+	return i.rulesResponse(), nil
+}
+
+func (i *Injector) setRules(ctx context.Context, body *setRulesRequest) (*rulesResponse, error) {
+	rules := make([]Rule, len(body.Rules))
+	for idx, jr := range body.Rules {
+		r, err := jr.toRule()
+		if err != nil {
+			return nil, errors.HandlerBadRequestError(err)
+		}
+		rules[idx] = r
+	}
+	if err := i.SetRules(rules); err != nil {
+		return nil, errors.HandlerBadRequestError(err)
+	}
+	return i.rulesResponse(), nil
+}
+
+// Procedures returns JSON procedures which list and replace the rules of the
+// Injector, to register on a dispatcher.
+//
+// 	yarpc::faultinjection::rules() {"rules": [...]}
+// 	yarpc::faultinjection::setRules({"rules": [...]}) {"rules": [...]}
+//
+// Latencies are specified as strings accepted by time.ParseDuration, and
+// application error bodies as base64-encoded strings.
+func (i *Injector) Procedures() []transport.Procedure {
+	methods := []struct {
+		Name      string
+		Handler   interface{}
+		Signature string
+	}{
+		{adminProcedurePrefix + "rules", i.getRules,
+			`rules() {"rules": [{"service": "...", "percentage": 0, "latency": "...", "error": "..."}]}`},
+		{adminProcedurePrefix + "setRules", i.setRules,
+			`setRules({"rules": [...]}) {"rules": [...]}`},
+	}
+	var r []transport.Procedure
+	for _, m := range methods {
+		p := json.Procedure(m.Name, m.Handler)[0]
+		p.Signature = m.Signature
+		r = append(r, p)
+	}
+	return r
+}
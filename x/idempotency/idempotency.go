@@ -0,0 +1,328 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package idempotency provides inbound middleware which handles requests
+// with the same idempotency key only once.
+//
+// Callers set the idempotency-key header on requests which must not be
+// handled more than once, and keep it when retrying them. Requests redelivered
+// by queue-based transports keep their headers too.
+//
+// 	m, err := idempotency.New(idempotency.Config{Window: time.Hour})
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  m,
+// 			Oneway: m,
+// 		},
+// 	})
+//
+// The first request with a key is handled. Duplicates of it received within
+// the Window are answered with its response, or acknowledged in the case of
+// oneway requests, without being handled. Duplicates received while it is
+// still being handled wait for it to finish. If the handler fails with an
+// error, the key is forgotten so that the request may be retried.
+//
+// Keys are scoped to the caller, service, and procedure of requests.
+// Requests are recorded in a Store, which may be shared by several processes
+// to deduplicate requests made to any of them.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+)
+
+// KeyHeader is the name of the request header holding the idempotency key.
+const KeyHeader = "idempotency-key"
+
+const (
+	defaultWindow       = 10 * time.Minute
+	defaultPollInterval = 100 * time.Millisecond
+
+	// How long the result of a request may take to be recorded in the store.
+	storeTimeout = 5 * time.Second
+)
+
+// Config configures a Middleware.
+type Config struct {
+	// Store in which requests are recorded. Defaults to a MemoryStore with
+	// the default size.
+	Store Store
+
+	// How long duplicates of a request are answered with its result.
+	// Defaults to 10 minutes.
+	Window time.Duration
+
+	// How often duplicates of a request which another process is handling
+	// check whether it finished. Defaults to 100 milliseconds.
+	PollInterval time.Duration
+}
+
+// Middleware is unary and oneway inbound middleware which deduplicates
+// requests by idempotency key.
+type Middleware struct {
+	store  Store
+	window time.Duration
+	poll   time.Duration
+
+	mu sync.Mutex
+	// Closed when the request which claimed the key in this process
+	// finishes.
+	inflight map[string]chan struct{}
+}
+
+// New builds a Middleware with the given configuration.
+func New(cfg Config) (*Middleware, error) {
+	if cfg.Window < 0 {
+		return nil, fmt.Errorf("window must not be negative, got %v", cfg.Window)
+	}
+	if cfg.PollInterval < 0 {
+		return nil, fmt.Errorf("poll interval must not be negative, got %v", cfg.PollInterval)
+	}
+	if cfg.Store == nil {
+		store, err := NewMemoryStore(0)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Store = store
+	}
+	if cfg.Window == 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	return &Middleware{
+		store:    cfg.Store,
+		window:   cfg.Window,
+		poll:     cfg.PollInterval,
+		inflight: make(map[string]chan struct{}),
+	}, nil
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	key, ok := requestKey(req)
+	if !ok {
+		return h.Handle(ctx, req, resw)
+	}
+
+	res, err := m.claim(ctx, key)
+	if err != nil {
+		return err
+	}
+	if res != nil {
+		return replay(res, resw)
+	}
+
+	var result *Result
+	defer func() { m.finish(key, result, recover()) }()
+
+	rec := recorder{ResponseWriter: resw}
+	if err := h.Handle(ctx, req, &rec); err != nil {
+		return err
+	}
+	result = rec.result()
+	return nil
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	key, ok := requestKey(req)
+	if !ok {
+		return h.HandleOneway(ctx, req)
+	}
+
+	res, err := m.claim(ctx, key)
+	if err != nil || res != nil {
+		return err
+	}
+
+	var result *Result
+	defer func() { m.finish(key, result, recover()) }()
+
+	if err := h.HandleOneway(ctx, req); err != nil {
+		return err
+	}
+	result = &Result{}
+	return nil
+}
+
+// claim claims the key for the current request, returning nil, or returns
+// the result of the request which claimed it, waiting for it if necessary.
+func (m *Middleware) claim(ctx context.Context, key string) (*Result, error) {
+	for {
+		claimed, res, err := m.store.Claim(ctx, key, m.window)
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			m.mu.Lock()
+			m.inflight[key] = make(chan struct{})
+			m.mu.Unlock()
+			return nil, nil
+		}
+		if res != nil {
+			return res, nil
+		}
+
+		// The request which claimed the key is still being handled, by this
+		// process or another one.
+		m.mu.Lock()
+		done := m.inflight[key]
+		m.mu.Unlock()
+		if err := m.wait(ctx, done); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait waits for the given channel to be closed if it is non-nil, or for
+// the poll interval otherwise.
+func (m *Middleware) wait(ctx context.Context, done <-chan struct{}) error {
+	if done == nil {
+		timer := time.NewTimer(m.poll)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// finish stores the result of the request which claimed the key, or releases
+// the key if the result is nil, and wakes up the duplicates waiting for it.
+//
+// It is deferred by the request which claimed the key with the value
+// recovered from its handler, so that the key is released if the handler
+// panics. The panic is then resumed.
+func (m *Middleware) finish(key string, res *Result, recovered interface{}) {
+	if recovered != nil {
+		res = nil
+	}
+
+	// The store is updated even if the request was canceled or timed out,
+	// but not for longer than storeTimeout.
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if res == nil || m.store.Complete(ctx, key, *res) != nil {
+		// If the result could not be stored, let the next duplicate be
+		// handled rather than have all of them wait until the window ends.
+		_ = m.store.Release(ctx, key)
+	}
+
+	m.mu.Lock()
+	done := m.inflight[key]
+	delete(m.inflight, key)
+	m.mu.Unlock()
+	if done != nil {
+		close(done)
+	}
+
+	if recovered != nil {
+		panic(recovered)
+	}
+}
+
+// requestKey returns the key under which the request is recorded, if it
+// has an idempotency key.
+func requestKey(req *transport.Request) (string, bool) {
+	key, ok := req.Headers.Get(KeyHeader)
+	if !ok || key == "" {
+		return "", false
+	}
+	return fmt.Sprintf("%q %q %q %q", req.Caller, req.Service, req.Procedure, key), true
+}
+
+// replay writes a stored result to the response.
+func replay(res *Result, resw transport.ResponseWriter) error {
+	if len(res.Headers) > 0 {
+		resw.AddHeaders(transport.HeadersFromMap(res.Headers))
+	}
+	if res.ApplicationError {
+		resw.SetApplicationError()
+	}
+	_, err := resw.Write(res.Body)
+	return err
+}
+
+// recorder is a ResponseWriter which records the response it writes.
+type recorder struct {
+	transport.ResponseWriter
+
+	headers          map[string]string
+	body             bytes.Buffer
+	applicationError bool
+}
+
+func (r *recorder) AddHeaders(h transport.Headers) {
+	r.ResponseWriter.AddHeaders(h)
+	if h.Len() == 0 {
+		return
+	}
+	if r.headers == nil {
+		r.headers = make(map[string]string, h.Len())
+	}
+	for k, v := range h.Items() {
+		r.headers[k] = v
+	}
+}
+
+func (r *recorder) SetApplicationError() {
+	r.ResponseWriter.SetApplicationError()
+	r.applicationError = true
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+func (r *recorder) result() *Result {
+	return &Result{
+		Headers:          r.headers,
+		Body:             r.body.Bytes(),
+		ApplicationError: r.applicationError,
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+func request(caller, key string) *transport.Request {
+	headers := transport.NewHeaders()
+	if key != "" {
+		headers = headers.With(KeyHeader, key)
+	}
+	return &transport.Request{
+		Caller:    caller,
+		Service:   "svc",
+		Procedure: "proc",
+		Headers:   headers,
+		Body:      &bytes.Buffer{},
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		desc    string
+		cfg     Config
+		wantErr string
+	}{
+		{desc: "defaults"},
+		{
+			desc:    "negative window",
+			cfg:     Config{Window: -time.Second},
+			wantErr: "window must not be negative, got -1s",
+		},
+		{
+			desc:    "negative poll interval",
+			cfg:     Config{PollInterval: -time.Second},
+			wantErr: "poll interval must not be negative, got -1s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			m, err := New(tt.cfg)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, defaultWindow, m.window)
+			assert.Equal(t, defaultPollInterval, m.poll)
+			assert.IsType(t, &MemoryStore{}, m.store)
+		})
+	}
+}
+
+func TestHandle(t *testing.T) {
+	tests := []struct {
+		desc string
+
+		// Caller and idempotency key of each request.
+		requests [][2]string
+
+		// Number of times the handler is expected to run.
+		wantCalls int
+	}{
+		{
+			desc:      "no key",
+			requests:  [][2]string{{"foo", ""}, {"foo", ""}},
+			wantCalls: 2,
+		},
+		{
+			desc:      "duplicate",
+			requests:  [][2]string{{"foo", "k"}, {"foo", "k"}, {"foo", "k"}},
+			wantCalls: 1,
+		},
+		{
+			desc:      "different keys",
+			requests:  [][2]string{{"foo", "k1"}, {"foo", "k2"}},
+			wantCalls: 2,
+		},
+		{
+			desc:      "different callers",
+			requests:  [][2]string{{"foo", "k"}, {"bar", "k"}},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			m, err := New(Config{})
+			require.NoError(t, err)
+
+			var calls int
+			h := unaryHandlerFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+				calls++
+				resw.AddHeaders(transport.NewHeaders().With("foo", "bar"))
+				resw.SetApplicationError()
+				_, err := resw.Write([]byte("hello"))
+				return err
+			})
+
+			for _, r := range tt.requests {
+				resw := new(transporttest.FakeResponseWriter)
+				require.NoError(t, m.Handle(context.Background(), request(r[0], r[1]), resw, h))
+				assert.Equal(t, "hello", resw.Body.String())
+				assert.Equal(t, transport.NewHeaders().With("foo", "bar"), resw.Headers)
+				assert.True(t, resw.IsApplicationError, "expected application error")
+			}
+			assert.Equal(t, tt.wantCalls, calls, "unexpected number of handler calls")
+		})
+	}
+}
+
+func TestHandleError(t *testing.T) {
+	m, err := New(Config{})
+	require.NoError(t, err)
+
+	var calls int
+	h := unaryHandlerFunc(func(context.Context, *transport.Request, transport.ResponseWriter) error {
+		calls++
+		if calls == 1 {
+			return errors.New("great sadness")
+		}
+		return nil
+	})
+
+	resw := new(transporttest.FakeResponseWriter)
+	assert.EqualError(t, m.Handle(context.Background(), request("foo", "k"), resw, h), "great sadness")
+	assert.NoError(t, m.Handle(context.Background(), request("foo", "k"), resw, h))
+	assert.NoError(t, m.Handle(context.Background(), request("foo", "k"), resw, h))
+	assert.Equal(t, 2, calls, "expected request to be retried after an error only")
+}
+
+func TestHandleOneway(t *testing.T) {
+	m, err := New(Config{})
+	require.NoError(t, err)
+
+	var calls int
+	h := onewayHandlerFunc(func(context.Context, *transport.Request) error {
+		calls++
+		if calls == 1 {
+			return errors.New("great sadness")
+		}
+		return nil
+	})
+
+	assert.Error(t, m.HandleOneway(context.Background(), request("foo", "k"), h))
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, m.HandleOneway(context.Background(), request("foo", "k"), h))
+	}
+	assert.NoError(t, m.HandleOneway(context.Background(), request("foo", ""), h))
+	assert.Equal(t, 3, calls, "unexpected number of handler calls")
+}
+
+func TestHandlePanic(t *testing.T) {
+	m, err := New(Config{})
+	require.NoError(t, err)
+
+	var calls int
+	h := unaryHandlerFunc(func(context.Context, *transport.Request, transport.ResponseWriter) error {
+		calls++
+		if calls == 1 {
+			panic("great sadness")
+		}
+		return nil
+	})
+	oh := onewayHandlerFunc(func(context.Context, *transport.Request) error {
+		calls++
+		if calls == 3 {
+			panic("great sadness")
+		}
+		return nil
+	})
+
+	resw := new(transporttest.FakeResponseWriter)
+	assert.Panics(t, func() { m.Handle(context.Background(), request("foo", "k"), resw, h) })
+	assert.NoError(t, m.Handle(context.Background(), request("foo", "k"), resw, h))
+
+	assert.Panics(t, func() { m.HandleOneway(context.Background(), request("foo", "k2"), oh) })
+	assert.NoError(t, m.HandleOneway(context.Background(), request("foo", "k2"), oh))
+
+	assert.Equal(t, 4, calls, "expected requests to be retried after a panic")
+	assert.Empty(t, m.inflight, "requests must not be left in flight")
+}
+
+// ctxStore is a Store which fails when used with a context which ended.
+type ctxStore struct{ Store }
+
+func (s ctxStore) Complete(ctx context.Context, key string, res Result) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Store.Complete(ctx, key, res)
+}
+
+func (s ctxStore) Release(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Store.Release(ctx, key)
+}
+
+func TestHandleContextCanceled(t *testing.T) {
+	store, err := NewMemoryStore(0)
+	require.NoError(t, err)
+	m, err := New(Config{Store: ctxStore{store}})
+	require.NoError(t, err)
+
+	var calls int
+	h := unaryHandlerFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+		calls++
+		_, err := resw.Write([]byte("hello"))
+		return err
+	})
+
+	// The result is stored even if the request ended while it was handled.
+	ctx, cancel := context.WithCancel(context.Background())
+	resw := new(transporttest.FakeResponseWriter)
+	require.NoError(t, m.Handle(ctx, request("foo", "k"), resw, unaryHandlerFunc(
+		func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+			defer cancel()
+			return h(ctx, req, resw)
+		})))
+
+	resw = new(transporttest.FakeResponseWriter)
+	require.NoError(t, m.Handle(context.Background(), request("foo", "k"), resw, h))
+	assert.Equal(t, "hello", resw.Body.String())
+	assert.Equal(t, 1, calls, "expected the stored result to be replayed")
+}
+
+func TestConcurrentDuplicates(t *testing.T) {
+	tests := []struct {
+		desc string
+		// Whether duplicates are received by another process sharing the
+		// store, which must poll it.
+		remote bool
+	}{
+		{desc: "local"},
+		{desc: "remote", remote: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			store, err := NewMemoryStore(0)
+			require.NoError(t, err)
+			first, err := New(Config{Store: store, PollInterval: time.Millisecond})
+			require.NoError(t, err)
+			others := first
+			if tt.remote {
+				others, err = New(Config{Store: store, PollInterval: time.Millisecond})
+				require.NoError(t, err)
+			}
+
+			var calls atomic.Int32
+			unblock := make(chan struct{})
+			h := unaryHandlerFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+				calls.Inc()
+				<-unblock
+				_, err := resw.Write([]byte("hello"))
+				return err
+			})
+
+			started := make(chan struct{})
+			var wg sync.WaitGroup
+			handle := func(m *Middleware) {
+				defer wg.Done()
+				resw := new(transporttest.FakeResponseWriter)
+				assert.NoError(t, m.Handle(context.Background(), request("foo", "k"), resw, h))
+				assert.Equal(t, "hello", resw.Body.String())
+			}
+
+			wg.Add(1)
+			go func() {
+				close(started)
+				handle(first)
+			}()
+			<-started
+			for calls.Load() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go handle(others)
+			}
+			time.Sleep(10 * time.Millisecond)
+			close(unblock)
+			wg.Wait()
+
+			assert.Equal(t, int32(1), calls.Load(), "expected duplicates to wait for the first request")
+		})
+	}
+}
+
+func TestWaitContextCanceled(t *testing.T) {
+	m, err := New(Config{})
+	require.NoError(t, err)
+
+	unblock := make(chan struct{})
+	defer close(unblock)
+	var started atomic.Bool
+	h := onewayHandlerFunc(func(context.Context, *transport.Request) error {
+		started.Store(true)
+		<-unblock
+		return nil
+	})
+	go m.HandleOneway(context.Background(), request("foo", "k"), h)
+	for !started.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, m.HandleOneway(ctx, request("foo", "k"), h))
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package idempotency
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of a request stored for its duplicates.
+type Result struct {
+	// Headers, body, and whether the response was an application error.
+	// These are empty for oneway requests.
+	Headers          map[string]string
+	Body             []byte
+	ApplicationError bool
+}
+
+// Store records which requests were handled, and their results, by
+// idempotency key.
+//
+// Implementations must be safe for concurrent use. Stores shared by several
+// processes, backed by a database for example, deduplicate requests made to
+// any of them.
+type Store interface {
+	// Claim records that the request with the given key is being handled,
+	// unless the key was claimed less than window ago and not released.
+	//
+	// If the key was already claimed, Claim returns false and the result
+	// of the request which claimed it, or nil if it is still being handled.
+	Claim(ctx context.Context, key string, window time.Duration) (claimed bool, res *Result, err error)
+
+	// Complete stores the result of the request which claimed the key.
+	Complete(ctx context.Context, key string, res Result) error
+
+	// Release forgets the claim on the key, so that the next request with it
+	// is handled.
+	Release(ctx context.Context, key string) error
+}
+
+const defaultMemoryStoreSize = 10000
+
+type memoryEntry struct {
+	key     string
+	expires time.Time
+	res     *Result // nil while in flight
+}
+
+// MemoryStore is a Store which keeps the keys of a bounded number of
+// requests in memory. Once full, the keys of the least recently claimed
+// requests are forgotten first.
+type MemoryStore struct {
+	size int
+
+	mu      sync.Mutex
+	lru     *list.List // of *memoryEntry, most recently claimed first
+	entries map[string]*list.Element
+
+	// Overridden in tests.
+	now func() time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore builds a MemoryStore which holds up to size keys. Defaults
+// to 10000 keys if size is zero.
+func NewMemoryStore(size int) (*MemoryStore, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("size must not be negative, got %v", size)
+	}
+	if size == 0 {
+		size = defaultMemoryStoreSize
+	}
+	return &MemoryStore{
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}, nil
+}
+
+// Claim implements Store.
+func (s *MemoryStore) Claim(ctx context.Context, key string, window time.Duration) (bool, *Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if el, ok := s.entries[key]; ok {
+		e := el.Value.(*memoryEntry)
+		if now.Before(e.expires) {
+			return false, e.res, nil
+		}
+		s.lru.Remove(el)
+	}
+
+	s.entries[key] = s.lru.PushFront(&memoryEntry{key: key, expires: now.Add(window)})
+	for s.lru.Len() > s.size {
+		el := s.lru.Back()
+		s.lru.Remove(el)
+		delete(s.entries, el.Value.(*memoryEntry).key)
+	}
+	return true, nil, nil
+}
+
+// Complete implements Store.
+func (s *MemoryStore) Complete(ctx context.Context, key string, res Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[key]; ok {
+		el.Value.(*memoryEntry).res = &res
+	}
+	return nil
+}
+
+// Release implements Store.
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[key]; ok {
+		s.lru.Remove(el)
+		delete(s.entries, key)
+	}
+	return nil
+}
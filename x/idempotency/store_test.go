@@ -0,0 +1,84 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMemoryStore(t *testing.T) {
+	_, err := NewMemoryStore(-1)
+	assert.EqualError(t, err, "size must not be negative, got -1")
+
+	s, err := NewMemoryStore(0)
+	require.NoError(t, err)
+	assert.Equal(t, defaultMemoryStoreSize, s.size)
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewMemoryStore(2)
+	require.NoError(t, err)
+
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+
+	claim := func(key string) (bool, *Result) {
+		claimed, res, err := s.Claim(ctx, key, time.Minute)
+		require.NoError(t, err)
+		return claimed, res
+	}
+
+	claimed, _ := claim("a")
+	assert.True(t, claimed, "expected first claim to succeed")
+
+	claimed, res := claim("a")
+	assert.False(t, claimed, "expected duplicate claim to fail")
+	assert.Nil(t, res, "expected no result while in flight")
+
+	require.NoError(t, s.Complete(ctx, "a", Result{Body: []byte("hello")}))
+	claimed, res = claim("a")
+	assert.False(t, claimed, "expected claim of completed key to fail")
+	require.NotNil(t, res, "expected result of completed key")
+	assert.Equal(t, "hello", string(res.Body))
+
+	now = now.Add(time.Minute)
+	claimed, _ = claim("a")
+	assert.True(t, claimed, "expected claim to succeed after the window")
+
+	require.NoError(t, s.Release(ctx, "a"))
+	claimed, _ = claim("a")
+	assert.True(t, claimed, "expected claim to succeed after release")
+
+	claimed, _ = claim("b")
+	assert.True(t, claimed)
+	claimed, _ = claim("c")
+	assert.True(t, claimed)
+	claimed, _ = claim("a")
+	assert.True(t, claimed, "expected least recently claimed key to be evicted")
+	claimed, _ = claim("c")
+	assert.False(t, claimed, "expected recently claimed key to be kept")
+}
@@ -0,0 +1,371 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package routing provides an outbound which sends each request through one
+// of several named outbounds based on the procedure, shard key, routing key,
+// or headers of the request.
+//
+// This makes it possible to send some procedures of a service to a different
+// cluster or over a different transport than the rest.
+//
+// 	router, err := routing.NewOutbound(routing.Config{
+// 		Outbounds: map[string]transport.Outbounds{
+// 			"replica": {Unary: httpTransport.NewSingleOutbound(replicaURL)},
+// 			"primary": {Unary: tchannelTransport.NewSingleOutbound(primaryAddr)},
+// 		},
+// 		Rules: []routing.Rule{
+// 			{Procedure: "KeyValue::Read*", Outbound: "replica"},
+// 		},
+// 		Default: "primary",
+// 	})
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name:      "myservice",
+// 		Outbounds: yarpc.Outbounds{"keyvalue": {Unary: router}},
+// 	})
+//
+// Clients built from dispatcher.ClientConfig("keyvalue") are unaware of the
+// routing.
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	intsync "go.uber.org/yarpc/internal/sync"
+
+	"go.uber.org/multierr"
+)
+
+var (
+	_ transport.UnaryOutbound  = (*Outbound)(nil)
+	_ transport.OnewayOutbound = (*Outbound)(nil)
+)
+
+// Rule routes requests to the named outbound. A request matches a Rule if
+// it matches all of the Rule's non-empty patterns.
+//
+// Patterns use the syntax accepted by path.Match. For example, "Read*"
+// matches all procedures with names starting with "Read".
+type Rule struct {
+	// Name of the outbound to which matching requests are sent.
+	Outbound string
+
+	// Patterns for the procedure name, shard key, and routing key of the
+	// request.
+	Procedure  string
+	ShardKey   string
+	RoutingKey string
+
+	// Patterns for the values of request headers. A request matches only if
+	// it has all of these headers.
+	Headers map[string]string
+}
+
+func (r Rule) validate(outbounds map[string]transport.Outbounds) error {
+	if _, ok := outbounds[r.Outbound]; !ok {
+		return fmt.Errorf("unknown outbound %q", r.Outbound)
+	}
+
+	patterns := []string{r.Procedure, r.ShardKey, r.RoutingKey}
+	for _, v := range r.Headers {
+		patterns = append(patterns, v)
+	}
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", p, err)
+		}
+	}
+	return nil
+}
+
+func (r Rule) matches(req *transport.Request) bool {
+	if !match(r.Procedure, req.Procedure) ||
+		!match(r.ShardKey, req.ShardKey) ||
+		!match(r.RoutingKey, req.RoutingKey) {
+		return false
+	}
+	for k, pattern := range r.Headers {
+		v, ok := req.Headers.Get(k)
+		if !ok || !match(pattern, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// String describes the patterns of the rule, for example,
+// `procedure="Read*" header[tenant]="beta"`.
+func (r Rule) String() string {
+	var parts []string
+	add := func(name, pattern string) {
+		if pattern != "" {
+			parts = append(parts, fmt.Sprintf("%s=%q", name, pattern))
+		}
+	}
+	add("procedure", r.Procedure)
+	add("shard-key", r.ShardKey)
+	add("routing-key", r.RoutingKey)
+
+	keys := make([]string, 0, len(r.Headers))
+	for k := range r.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		add("header["+k+"]", r.Headers[k])
+	}
+
+	if len(parts) == 0 {
+		return "*"
+	}
+	return strings.Join(parts, " ")
+}
+
+func match(pattern, s string) bool {
+	if pattern == "" {
+		return true
+	}
+	// The pattern was validated in NewOutbound.
+	ok, _ := path.Match(pattern, s)
+	return ok
+}
+
+// Config configures a routing Outbound.
+type Config struct {
+	// Outbounds to which requests may be routed, indexed by name.
+	Outbounds map[string]transport.Outbounds
+
+	// Rules are evaluated in order. Requests are sent to the outbound of the
+	// first Rule they match.
+	Rules []Rule
+
+	// Name of the outbound to which requests that match no Rule are sent. If
+	// empty, such requests fail.
+	Default string
+}
+
+// Outbound is a transport.UnaryOutbound and transport.OnewayOutbound which
+// sends requests through one of several outbounds based on a list of Rules.
+//
+// The Outbound owns the lifecycle of the outbounds it routes to.
+type Outbound struct {
+	once      intsync.LifecycleOnce
+	outbounds map[string]transport.Outbounds
+	rules     []Rule
+	def       string
+}
+
+// NewOutbound builds a new routing Outbound. An error is returned if a Rule
+// refers to an unknown outbound or has an invalid pattern.
+func NewOutbound(cfg Config) (*Outbound, error) {
+	if len(cfg.Outbounds) == 0 {
+		return nil, errors.New("at least one outbound is required")
+	}
+	for name, outs := range cfg.Outbounds {
+		if outs.Unary == nil && outs.Oneway == nil {
+			return nil, fmt.Errorf("no outbound set for %q", name)
+		}
+	}
+	for i, r := range cfg.Rules {
+		if err := r.validate(cfg.Outbounds); err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %v", i, err)
+		}
+	}
+	if cfg.Default != "" {
+		if _, ok := cfg.Outbounds[cfg.Default]; !ok {
+			return nil, fmt.Errorf("unknown default outbound %q", cfg.Default)
+		}
+	}
+
+	return &Outbound{
+		once:      intsync.Once(),
+		outbounds: cfg.Outbounds,
+		rules:     cfg.Rules,
+		def:       cfg.Default,
+	}, nil
+}
+
+// route returns the name of the outbound to which the request must be sent.
+func (o *Outbound) route(req *transport.Request) (string, error) {
+	for _, r := range o.rules {
+		if r.matches(req) {
+			return r.Outbound, nil
+		}
+	}
+	if o.def != "" {
+		return o.def, nil
+	}
+	return "", fmt.Errorf("no outbound matches procedure %q of service %q", req.Procedure, req.Service)
+}
+
+// Call sends the unary request through the outbound chosen by the routing
+// rules.
+func (o *Outbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if err := o.once.WhenRunning(ctx); err != nil {
+		return nil, err
+	}
+	name, err := o.route(req)
+	if err != nil {
+		return nil, err
+	}
+	out := o.outbounds[name].Unary
+	if out == nil {
+		return nil, fmt.Errorf("outbound %q does not support unary requests", name)
+	}
+	return out.Call(ctx, req)
+}
+
+// CallOneway sends the oneway request through the outbound chosen by the
+// routing rules.
+func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if err := o.once.WhenRunning(ctx); err != nil {
+		return nil, err
+	}
+	name, err := o.route(req)
+	if err != nil {
+		return nil, err
+	}
+	out := o.outbounds[name].Oneway
+	if out == nil {
+		return nil, fmt.Errorf("outbound %q does not support oneway requests", name)
+	}
+	return out.CallOneway(ctx, req)
+}
+
+// lifecycles returns the unique outbounds to which requests may be routed.
+func (o *Outbound) lifecycles() []transport.Lifecycle {
+	seen := make(map[transport.Lifecycle]struct{})
+	var ls []transport.Lifecycle
+	add := func(l transport.Lifecycle) {
+		if _, ok := seen[l]; ok {
+			return
+		}
+		seen[l] = struct{}{}
+		ls = append(ls, l)
+	}
+	for _, outs := range o.outbounds {
+		if outs.Unary != nil {
+			add(outs.Unary)
+		}
+		if outs.Oneway != nil {
+			add(outs.Oneway)
+		}
+	}
+	return ls
+}
+
+// Transports returns the transports used by all of the routed outbounds.
+func (o *Outbound) Transports() []transport.Transport {
+	seen := make(map[transport.Transport]struct{})
+	var ts []transport.Transport
+	for _, outs := range o.outbounds {
+		if outs.Unary != nil {
+			for _, t := range outs.Unary.Transports() {
+				seen[t] = struct{}{}
+			}
+		}
+		if outs.Oneway != nil {
+			for _, t := range outs.Oneway.Transports() {
+				seen[t] = struct{}{}
+			}
+		}
+	}
+	for t := range seen {
+		ts = append(ts, t)
+	}
+	return ts
+}
+
+// Start starts all routed outbounds.
+func (o *Outbound) Start() error {
+	return o.once.Start(o.start)
+}
+
+func (o *Outbound) start() error {
+	wait := intsync.ErrorWaiter{}
+	for _, l := range o.lifecycles() {
+		wait.Submit(l.Start)
+	}
+	return multierr.Combine(wait.Wait()...)
+}
+
+// Stop stops all routed outbounds.
+func (o *Outbound) Stop() error {
+	return o.once.Stop(o.stop)
+}
+
+func (o *Outbound) stop() error {
+	wait := intsync.ErrorWaiter{}
+	for _, l := range o.lifecycles() {
+		wait.Submit(l.Stop)
+	}
+	return multierr.Combine(wait.Wait()...)
+}
+
+// IsRunning returns whether the Outbound is running.
+func (o *Outbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// Introspect returns the state of the Outbound and the outbounds for each of
+// its rules.
+func (o *Outbound) Introspect() introspection.OutboundStatus {
+	state := "Stopped"
+	if o.IsRunning() {
+		state = "Running"
+	}
+
+	routes := make([]introspection.RouteStatus, 0, len(o.rules)+1)
+	for _, r := range o.rules {
+		routes = append(routes, o.introspectRoute(r.String(), r.Outbound))
+	}
+	if o.def != "" {
+		routes = append(routes, o.introspectRoute("default", o.def))
+	}
+
+	return introspection.OutboundStatus{
+		Transport: "routing",
+		State:     state,
+		Routes:    routes,
+	}
+}
+
+func (o *Outbound) introspectRoute(match, name string) introspection.RouteStatus {
+	outs := o.outbounds[name]
+	var out interface{} = outs.Unary
+	if outs.Unary == nil {
+		out = outs.Oneway
+	}
+
+	status := introspection.OutboundStatusNotSupported
+	if i, ok := out.(introspection.IntrospectableOutbound); ok {
+		status = i.Introspect()
+	}
+	return introspection.RouteStatus{Match: match, Outbound: name, Status: status}
+}
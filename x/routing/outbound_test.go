@@ -0,0 +1,256 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package routing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/introspection"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOutboundErrors(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+
+	tests := []struct {
+		desc    string
+		give    Config
+		wantErr string
+	}{
+		{
+			desc:    "no outbounds",
+			wantErr: "at least one outbound is required",
+		},
+		{
+			desc: "empty outbound",
+			give: Config{
+				Outbounds: map[string]transport.Outbounds{"foo": {}},
+			},
+			wantErr: `no outbound set for "foo"`,
+		},
+		{
+			desc: "unknown outbound in rule",
+			give: Config{
+				Outbounds: map[string]transport.Outbounds{"foo": {Unary: out}},
+				Rules:     []Rule{{Outbound: "bar"}},
+			},
+			wantErr: `invalid rule 0: unknown outbound "bar"`,
+		},
+		{
+			desc: "invalid pattern",
+			give: Config{
+				Outbounds: map[string]transport.Outbounds{"foo": {Unary: out}},
+				Rules:     []Rule{{Outbound: "foo", Procedure: "[Read"}},
+			},
+			wantErr: `invalid rule 0: invalid pattern "[Read": syntax error in pattern`,
+		},
+		{
+			desc: "unknown default",
+			give: Config{
+				Outbounds: map[string]transport.Outbounds{"foo": {Unary: out}},
+				Default:   "bar",
+			},
+			wantErr: `unknown default outbound "bar"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := NewOutbound(tt.give)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestOutboundRouting(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	primary := transporttest.NewMockUnaryOutbound(mockCtrl)
+	replica := transporttest.NewMockUnaryOutbound(mockCtrl)
+	beta := transporttest.NewMockUnaryOutbound(mockCtrl)
+	queue := transporttest.NewMockOnewayOutbound(mockCtrl)
+
+	o, err := NewOutbound(Config{
+		Outbounds: map[string]transport.Outbounds{
+			"primary": {Unary: primary},
+			"replica": {Unary: replica},
+			"beta":    {Unary: beta},
+			"queue":   {Oneway: queue},
+		},
+		Rules: []Rule{
+			{Outbound: "beta", Headers: map[string]string{"tenant": "beta-*"}},
+			{Outbound: "replica", Procedure: "KeyValue::Read*"},
+			{Outbound: "replica", ShardKey: "cold-*", RoutingKey: "kv"},
+			{Outbound: "queue", Procedure: "KeyValue::Notify"},
+		},
+		Default: "primary",
+	})
+	require.NoError(t, err)
+
+	primary.EXPECT().Start().Return(nil)
+	replica.EXPECT().Start().Return(nil)
+	beta.EXPECT().Start().Return(nil)
+	queue.EXPECT().Start().Return(nil)
+	require.NoError(t, o.Start())
+
+	tests := []struct {
+		desc       string
+		procedure  string
+		shardKey   string
+		routingKey string
+		headers    transport.Headers
+		want       *transporttest.MockUnaryOutbound
+		wantErr    string
+	}{
+		{desc: "default", procedure: "KeyValue::Write", want: primary},
+		{desc: "procedure", procedure: "KeyValue::ReadMany", want: replica},
+		{
+			desc:       "shard and routing key",
+			procedure:  "KeyValue::Write",
+			shardKey:   "cold-42",
+			routingKey: "kv",
+			want:       replica,
+		},
+		{
+			desc:      "shard key without routing key",
+			procedure: "KeyValue::Write",
+			shardKey:  "cold-42",
+			want:      primary,
+		},
+		{
+			desc:      "header",
+			procedure: "KeyValue::ReadMany",
+			headers:   transport.NewHeaders().With("Tenant", "beta-1"),
+			want:      beta,
+		},
+		{
+			desc:      "oneway only",
+			procedure: "KeyValue::Notify",
+			wantErr:   `outbound "queue" does not support unary requests`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			req := &transport.Request{
+				Service:    "kv",
+				Procedure:  tt.procedure,
+				ShardKey:   tt.shardKey,
+				RoutingKey: tt.routingKey,
+				Headers:    tt.headers,
+				Body:       &bytes.Buffer{},
+			}
+			if tt.want != nil {
+				tt.want.EXPECT().Call(ctx, req).Return(&transport.Response{}, nil)
+			}
+
+			_, err := o.Call(ctx, req)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req := &transport.Request{Service: "kv", Procedure: "KeyValue::Notify", Body: &bytes.Buffer{}}
+	queue.EXPECT().CallOneway(ctx, req).Return(nil, nil)
+	_, err = o.CallOneway(ctx, req)
+	assert.NoError(t, err)
+
+	req = &transport.Request{Service: "kv", Procedure: "KeyValue::Write", Body: &bytes.Buffer{}}
+	_, err = o.CallOneway(ctx, req)
+	assert.EqualError(t, err, `outbound "primary" does not support oneway requests`)
+
+	primary.EXPECT().Stop().Return(nil)
+	replica.EXPECT().Stop().Return(nil)
+	beta.EXPECT().Stop().Return(nil)
+	queue.EXPECT().Stop().Return(nil)
+	require.NoError(t, o.Stop())
+}
+
+func TestOutboundNoRoute(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	out.EXPECT().Start().Return(nil)
+
+	o, err := NewOutbound(Config{
+		Outbounds: map[string]transport.Outbounds{"foo": {Unary: out}},
+		Rules:     []Rule{{Outbound: "foo", Procedure: "Read*"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.Start())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = o.Call(ctx, &transport.Request{Service: "kv", Procedure: "Write"})
+	assert.EqualError(t, err, `no outbound matches procedure "Write" of service "kv"`)
+}
+
+func TestOutboundIntrospect(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	foo := transporttest.NewMockUnaryOutbound(mockCtrl)
+	bar := transporttest.NewMockOnewayOutbound(mockCtrl)
+
+	o, err := NewOutbound(Config{
+		Outbounds: map[string]transport.Outbounds{
+			"foo": {Unary: foo},
+			"bar": {Oneway: bar},
+		},
+		Rules: []Rule{
+			{Outbound: "bar", Procedure: "Notify*", Headers: map[string]string{"b": "2", "a": "1"}},
+			{Outbound: "bar"},
+		},
+		Default: "foo",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, introspection.OutboundStatus{
+		Transport: "routing",
+		State:     "Stopped",
+		Routes: []introspection.RouteStatus{
+			{Match: `procedure="Notify*" header[a]="1" header[b]="2"`, Outbound: "bar"},
+			{Match: "*", Outbound: "bar"},
+			{Match: "default", Outbound: "foo"},
+		},
+	}, o.Introspect())
+}
@@ -0,0 +1,446 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package shadow provides an outbound which mirrors a sample of requests to a
+// secondary outbound, for example to exercise a new version of a service with
+// production traffic before cutting over to it.
+//
+// 	mirror, err := shadow.NewOutbound(shadow.Config{
+// 		Primary:    httpTransport.NewSingleOutbound(currentURL),
+// 		Shadow:     httpTransport.NewSingleOutbound(candidateURL),
+// 		Percentage: 5,
+// 		OnMismatch: func(m shadow.Mismatch) {
+// 			logger.Warn("shadow response differs", zap.String("procedure", m.Procedure))
+// 		},
+// 	})
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name:      "myservice",
+// 		Outbounds: yarpc.Outbounds{"keyvalue": {Unary: mirror}},
+// 	})
+//
+// Callers only ever receive the responses and errors of the primary outbound.
+// Shadow requests are sent in the background with their own timeout, and
+// their responses are discarded after being compared with those of the
+// primary.
+package shadow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	intsync "go.uber.org/yarpc/internal/sync"
+
+	"go.uber.org/atomic"
+	"go.uber.org/multierr"
+)
+
+var _ transport.UnaryOutbound = (*Outbound)(nil)
+
+const (
+	defaultTimeout    = time.Second
+	defaultMaxPending = 100
+)
+
+// Result is the outcome of a request sent to the primary or shadow outbound.
+type Result struct {
+	Headers          transport.Headers
+	Body             []byte
+	ApplicationError bool
+
+	// Error with which the request failed, if any.
+	Err error
+}
+
+// Mismatch describes a request for which the primary and shadow outbounds
+// produced different results.
+type Mismatch struct {
+	Service   string
+	Procedure string
+
+	Primary Result
+	Shadow  Result
+}
+
+// Config configures a shadowing Outbound.
+type Config struct {
+	// Outbound whose responses are returned to callers. Required.
+	Primary transport.UnaryOutbound
+
+	// Outbound to which copies of sampled requests are sent. Required.
+	Shadow transport.UnaryOutbound
+
+	// Percentage of requests copied to the shadow outbound, between 0 and
+	// 100.
+	Percentage float64
+
+	// Timeout for requests to the shadow outbound, independent of the
+	// deadline of the caller. Defaults to one second.
+	Timeout time.Duration
+
+	// Maximum number of shadow requests in flight. Sampled requests beyond
+	// this limit are not copied. Defaults to 100.
+	MaxPending int
+
+	// If set, the responses of the primary and shadow outbounds are compared
+	// and OnMismatch is called from a background goroutine for each request
+	// for which they differ.
+	//
+	// Comparing responses requires the body of primary responses to be read
+	// before they are returned to the caller.
+	OnMismatch func(Mismatch)
+
+	// Compare reports whether the primary and shadow results are equivalent.
+	// By default, results are equivalent if both failed, or if both
+	// succeeded with the same application error flag and body.
+	Compare func(primary, shadow Result) bool
+}
+
+// Stats counts the shadow requests of an Outbound.
+type Stats struct {
+	// Requests sent to the shadow outbound.
+	Sent int64
+
+	// Sampled requests which were not sent because too many shadow requests
+	// were already in flight or the Outbound was stopping.
+	Dropped int64
+
+	// Shadow requests which failed with an error.
+	Failed int64
+
+	// Shadow requests whose results differed from those of the primary.
+	Mismatched int64
+}
+
+// Outbound is a transport.UnaryOutbound which sends requests to a primary
+// outbound and copies a sample of them to a shadow outbound.
+//
+// The Outbound owns the lifecycle of both outbounds.
+type Outbound struct {
+	once       intsync.LifecycleOnce
+	primary    transport.UnaryOutbound
+	shadow     transport.UnaryOutbound
+	percentage float64
+	timeout    time.Duration
+	onMismatch func(Mismatch)
+	compare    func(primary, shadow Result) bool
+	rand       func() float64
+
+	// mu guards stopping and additions to pending so that no shadow request
+	// starts once Stop is waiting for those in flight.
+	mu       sync.Mutex
+	stopping bool
+	pending  sync.WaitGroup
+	slots    chan struct{}
+
+	sent, dropped, failed, mismatched atomic.Int64
+}
+
+// NewOutbound builds a new shadowing Outbound.
+func NewOutbound(cfg Config) (*Outbound, error) {
+	if cfg.Primary == nil {
+		return nil, errors.New("a primary outbound is required")
+	}
+	if cfg.Shadow == nil {
+		return nil, errors.New("a shadow outbound is required")
+	}
+	if cfg.Percentage < 0 || cfg.Percentage > 100 {
+		return nil, fmt.Errorf("percentage must be between 0 and 100, got %v", cfg.Percentage)
+	}
+	if cfg.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative, got %v", cfg.Timeout)
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.MaxPending <= 0 {
+		cfg.MaxPending = defaultMaxPending
+	}
+	if cfg.Compare == nil {
+		cfg.Compare = equivalent
+	}
+
+	return &Outbound{
+		once:       intsync.Once(),
+		primary:    cfg.Primary,
+		shadow:     cfg.Shadow,
+		percentage: cfg.Percentage,
+		timeout:    cfg.Timeout,
+		onMismatch: cfg.OnMismatch,
+		compare:    cfg.Compare,
+		rand:       func() float64 { return rand.Float64() * 100 },
+		slots:      make(chan struct{}, cfg.MaxPending),
+	}, nil
+}
+
+func equivalent(primary, shadow Result) bool {
+	if primary.Err != nil || shadow.Err != nil {
+		return primary.Err != nil && shadow.Err != nil
+	}
+	return primary.ApplicationError == shadow.ApplicationError &&
+		bytes.Equal(primary.Body, shadow.Body)
+}
+
+// Call sends the request to the primary outbound and returns its response.
+// Sampled requests are also sent to the shadow outbound in the background.
+func (o *Outbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if err := o.once.WhenRunning(ctx); err != nil {
+		return nil, err
+	}
+	if o.percentage == 0 || o.rand() >= o.percentage {
+		return o.primary.Call(ctx, req)
+	}
+
+	if !o.reserve() {
+		o.dropped.Inc()
+		return o.primary.Call(ctx, req)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		o.release()
+		return nil, err
+	}
+	primaryReq := copyRequest(req, body)
+	shadowReq := copyRequest(req, body)
+
+	var primaryResult chan Result
+	if o.onMismatch != nil {
+		primaryResult = make(chan Result, 1)
+	}
+
+	go o.callShadow(shadowReq, primaryResult)
+
+	res, err := o.primary.Call(ctx, primaryReq)
+	if primaryResult == nil {
+		return res, err
+	}
+
+	result := Result{Err: err}
+	if res != nil {
+		res, result = bufferResponse(res)
+	}
+	primaryResult <- result
+	return res, err
+}
+
+// reserve reserves a slot for a shadow request. It returns false if too many
+// shadow requests are in flight or the Outbound is stopping.
+func (o *Outbound) reserve() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.stopping {
+		return false
+	}
+	select {
+	case o.slots <- struct{}{}:
+		o.pending.Add(1)
+		return true
+	default:
+		return false
+	}
+}
+
+// release releases a slot reserved with reserve.
+func (o *Outbound) release() {
+	<-o.slots
+	o.pending.Done()
+}
+
+// callShadow sends a request to the shadow outbound and, if primaryResult is
+// non-nil, compares its result with that of the primary outbound.
+func (o *Outbound) callShadow(req *transport.Request, primaryResult <-chan Result) {
+	defer o.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+	defer cancel()
+
+	o.sent.Inc()
+	res, err := o.shadow.Call(ctx, req)
+	result := Result{Err: err}
+	if res != nil {
+		result.Headers = res.Headers
+		result.ApplicationError = res.ApplicationError
+		result.Body, result.Err = ioutil.ReadAll(res.Body)
+		if closeErr := res.Body.Close(); result.Err == nil {
+			result.Err = closeErr
+		}
+	}
+	if result.Err != nil {
+		o.failed.Inc()
+	}
+
+	if primaryResult == nil {
+		return
+	}
+	primary := <-primaryResult
+	if primary.Err == errBodyUnavailable || o.compare(primary, result) {
+		return
+	}
+	o.mismatched.Inc()
+	o.onMismatch(Mismatch{
+		Service:   req.Service,
+		Procedure: req.Procedure,
+		Primary:   primary,
+		Shadow:    result,
+	})
+}
+
+// errBodyUnavailable marks primary results which cannot be compared because
+// their bodies could not be read.
+var errBodyUnavailable = errors.New("response body could not be read")
+
+// bufferResponse reads the body of the response so that it can be compared,
+// and returns a copy of the response which replays it to the caller.
+func bufferResponse(res *transport.Response) (*transport.Response, Result) {
+	body, err := ioutil.ReadAll(res.Body)
+	if closeErr := res.Body.Close(); err == nil {
+		err = closeErr
+	}
+
+	buffered := *res
+	result := Result{
+		Headers:          res.Headers,
+		Body:             body,
+		ApplicationError: res.ApplicationError,
+	}
+	if err != nil {
+		buffered.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+		result.Err = errBodyUnavailable
+	} else {
+		buffered.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return &buffered, result
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func copyRequest(req *transport.Request, body []byte) *transport.Request {
+	headers := transport.NewHeadersWithCapacity(req.Headers.Len())
+	for k, v := range req.Headers.Items() {
+		headers = headers.With(k, v)
+	}
+
+	r := *req
+	r.Headers = headers
+	r.Body = bytes.NewReader(body)
+	return &r
+}
+
+// Stats returns the number of shadow requests sent, dropped, failed, and
+// mismatched so far.
+func (o *Outbound) Stats() Stats {
+	return Stats{
+		Sent:       o.sent.Load(),
+		Dropped:    o.dropped.Load(),
+		Failed:     o.failed.Load(),
+		Mismatched: o.mismatched.Load(),
+	}
+}
+
+// Transports returns the transports used by the primary and shadow
+// outbounds.
+func (o *Outbound) Transports() []transport.Transport {
+	ts := o.primary.Transports()
+	for _, t := range o.shadow.Transports() {
+		var seen bool
+		for _, other := range ts {
+			seen = seen || other == t
+		}
+		if !seen {
+			ts = append(ts, t)
+		}
+	}
+	return ts
+}
+
+// Start starts the primary and shadow outbounds.
+func (o *Outbound) Start() error {
+	return o.once.Start(o.start)
+}
+
+func (o *Outbound) start() error {
+	wait := intsync.ErrorWaiter{}
+	wait.Submit(o.primary.Start)
+	wait.Submit(o.shadow.Start)
+	return multierr.Combine(wait.Wait()...)
+}
+
+// Stop waits for shadow requests in flight and stops the primary and shadow
+// outbounds.
+func (o *Outbound) Stop() error {
+	return o.once.Stop(o.stop)
+}
+
+func (o *Outbound) stop() error {
+	o.mu.Lock()
+	o.stopping = true
+	o.mu.Unlock()
+
+	o.pending.Wait()
+	wait := intsync.ErrorWaiter{}
+	wait.Submit(o.primary.Stop)
+	wait.Submit(o.shadow.Stop)
+	return multierr.Combine(wait.Wait()...)
+}
+
+// IsRunning returns whether the Outbound is running.
+func (o *Outbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// Introspect returns the state of the Outbound and of its primary and shadow
+// outbounds.
+func (o *Outbound) Introspect() introspection.OutboundStatus {
+	state := "Stopped"
+	if o.IsRunning() {
+		state = "Running"
+	}
+	return introspection.OutboundStatus{
+		Transport: "shadow",
+		State:     state,
+		Routes: []introspection.RouteStatus{
+			introspectRoute("*", "primary", o.primary),
+			introspectRoute(fmt.Sprintf("%v%%", o.percentage), "shadow", o.shadow),
+		},
+	}
+}
+
+func introspectRoute(match, name string, out transport.UnaryOutbound) introspection.RouteStatus {
+	status := introspection.OutboundStatusNotSupported
+	if i, ok := out.(introspection.IntrospectableOutbound); ok {
+		status = i.Introspect()
+	}
+	return introspection.RouteStatus{Match: match, Outbound: name, Status: status}
+}
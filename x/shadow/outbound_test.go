@@ -0,0 +1,300 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package shadow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// respond returns an outbound which responds to the requests sent by call
+// with the given body or error.
+func respond(t *testing.T, body string, err error) *yarpctest.FakeOutbound {
+	out := yarpctest.NewFakeOutbound(t)
+	e := out.Expect("service", "procedure").WithHeader("foo", "bar").AnyTimes()
+	if err != nil {
+		e.ReturnsError(err)
+	} else {
+		e.Returns([]byte(body))
+	}
+	return out
+}
+
+// assertRequests checks that the outbound received the given number of
+// requests sent by call.
+func assertRequests(t *testing.T, out *yarpctest.FakeOutbound, n int, msg string) {
+	calls := out.Calls()
+	if assert.Len(t, calls, n, msg) {
+		for _, c := range calls {
+			assert.Equal(t, "request", string(c.Body), "%v: wrong request body", msg)
+		}
+	}
+}
+
+func call(t *testing.T, o *Outbound) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res, err := o.Call(ctx, &transport.Request{
+		Service:   "service",
+		Procedure: "procedure",
+		Headers:   transport.NewHeaders().With("foo", "bar"),
+		Body:      bytes.NewReader([]byte("request")),
+	})
+	if err != nil {
+		return "", err
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	return string(body), nil
+}
+
+func TestNewOutboundErrors(t *testing.T) {
+	out := respond(t, "", nil)
+
+	tests := []struct {
+		desc    string
+		give    Config
+		wantErr string
+	}{
+		{
+			desc:    "no primary",
+			give:    Config{Shadow: out},
+			wantErr: "a primary outbound is required",
+		},
+		{
+			desc:    "no shadow",
+			give:    Config{Primary: out},
+			wantErr: "a shadow outbound is required",
+		},
+		{
+			desc:    "invalid percentage",
+			give:    Config{Primary: out, Shadow: out, Percentage: 101},
+			wantErr: "percentage must be between 0 and 100, got 101",
+		},
+		{
+			desc:    "negative timeout",
+			give:    Config{Primary: out, Shadow: out, Timeout: -time.Second},
+			wantErr: "timeout must not be negative, got -1s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := NewOutbound(tt.give)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestOutboundShadow(t *testing.T) {
+	tests := []struct {
+		desc       string
+		primary    *yarpctest.FakeOutbound
+		shadow     *yarpctest.FakeOutbound
+		wantBody   string
+		wantErr    string
+		wantStats  Stats
+		mismatched bool
+	}{
+		{
+			desc:      "same response",
+			primary:   respond(t, "response", nil),
+			shadow:    respond(t, "response", nil),
+			wantBody:  "response",
+			wantStats: Stats{Sent: 1},
+		},
+		{
+			desc:       "different response",
+			primary:    respond(t, "response", nil),
+			shadow:     respond(t, "other response", nil),
+			wantBody:   "response",
+			wantStats:  Stats{Sent: 1, Mismatched: 1},
+			mismatched: true,
+		},
+		{
+			desc:       "shadow error",
+			primary:    respond(t, "response", nil),
+			shadow:     respond(t, "", errors.New("great sadness")),
+			wantBody:   "response",
+			wantStats:  Stats{Sent: 1, Failed: 1, Mismatched: 1},
+			mismatched: true,
+		},
+		{
+			desc:      "both errors",
+			primary:   respond(t, "", errors.New("great sadness")),
+			shadow:    respond(t, "", errors.New("great sadness")),
+			wantErr:   "great sadness",
+			wantStats: Stats{Sent: 1, Failed: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var mismatches []Mismatch
+			o, err := NewOutbound(Config{
+				Primary:    tt.primary,
+				Shadow:     tt.shadow,
+				Percentage: 100,
+				OnMismatch: func(m Mismatch) { mismatches = append(mismatches, m) },
+			})
+			require.NoError(t, err)
+			require.NoError(t, o.Start())
+
+			body, err := call(t, o)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantBody, body)
+			}
+
+			require.NoError(t, o.Stop(), "failed to stop outbound")
+			assert.Equal(t, tt.wantStats, o.Stats())
+			assertRequests(t, tt.primary, 1, "primary")
+			assertRequests(t, tt.shadow, 1, "shadow")
+			if !tt.mismatched {
+				assert.Empty(t, mismatches, "unexpected mismatches")
+				return
+			}
+			if assert.Len(t, mismatches, 1, "expected a mismatch") {
+				m := mismatches[0]
+				assert.Equal(t, "service", m.Service)
+				assert.Equal(t, "procedure", m.Procedure)
+				assert.Equal(t, "response", string(m.Primary.Body))
+			}
+		})
+	}
+}
+
+func TestOutboundSampling(t *testing.T) {
+	tests := []struct {
+		desc       string
+		percentage float64
+		rand       float64
+		wantSent   int64
+	}{
+		{desc: "disabled", percentage: 0, rand: 0},
+		{desc: "not sampled", percentage: 30, rand: 50},
+		{desc: "sampled", percentage: 30, rand: 10, wantSent: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			shadow := respond(t, "response", nil)
+			o, err := NewOutbound(Config{
+				Primary:    respond(t, "response", nil),
+				Shadow:     shadow,
+				Percentage: tt.percentage,
+			})
+			require.NoError(t, err)
+			o.rand = func() float64 { return tt.rand }
+			require.NoError(t, o.Start())
+
+			body, err := call(t, o)
+			require.NoError(t, err)
+			assert.Equal(t, "response", body)
+
+			require.NoError(t, o.Stop())
+			assert.Equal(t, tt.wantSent, o.Stats().Sent)
+			assertRequests(t, shadow, int(tt.wantSent), "shadow")
+		})
+	}
+}
+
+func TestOutboundShadowIsolation(t *testing.T) {
+	release := make(chan struct{})
+	var (
+		lock        sync.Mutex
+		hasDeadline bool
+	)
+	shadow := yarpctest.NewFakeOutbound(t)
+	defer shadow.Finish()
+	shadow.Expect("service", "procedure").Do(func(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+		_, ok := ctx.Deadline()
+		lock.Lock()
+		hasDeadline = ok
+		lock.Unlock()
+		<-release
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	o, err := NewOutbound(Config{
+		Primary:    respond(t, "response", nil),
+		Shadow:     shadow,
+		Percentage: 100,
+		Timeout:    10 * time.Millisecond,
+		MaxPending: 1,
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.Start())
+
+	// The first call occupies the only slot until the shadow is released;
+	// the second is not copied.
+	for i := 0; i < 2; i++ {
+		body, err := call(t, o)
+		require.NoError(t, err, "shadow must not affect the primary")
+		assert.Equal(t, "response", body)
+	}
+	close(release)
+
+	require.NoError(t, o.Stop())
+	assert.Equal(t, Stats{Sent: 1, Dropped: 1, Failed: 1}, o.Stats())
+	lock.Lock()
+	assert.True(t, hasDeadline, "shadow requests must have a deadline")
+	lock.Unlock()
+}
+
+func TestOutboundStopping(t *testing.T) {
+	shadow := respond(t, "response", nil)
+	o, err := NewOutbound(Config{
+		Primary:    respond(t, "response", nil),
+		Shadow:     shadow,
+		Percentage: 100,
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.Start())
+
+	// Calls which race with Stop must not start shadow requests once Stop is
+	// waiting for those in flight.
+	o.mu.Lock()
+	o.stopping = true
+	o.mu.Unlock()
+
+	body, err := call(t, o)
+	require.NoError(t, err)
+	assert.Equal(t, "response", body)
+
+	require.NoError(t, o.Stop())
+	assert.Equal(t, Stats{Dropped: 1}, o.Stats())
+	assertRequests(t, shadow, 0, "shadow must not receive requests while stopping")
+}
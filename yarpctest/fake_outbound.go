@@ -0,0 +1,383 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpctest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clientconfig"
+	"go.uber.org/yarpc/internal/errors"
+	intsync "go.uber.org/yarpc/internal/sync"
+)
+
+// TestingT is the subset of testing.T used by FakeOutbound to report
+// failures.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// FakeOutbound is a unary and oneway outbound whose responses are programmed
+// by tests. Each call is matched against the expectations declared with
+// Expect, in the order they were declared, and recorded for later
+// inspection.
+//
+// Calls that match no expectation fail and are reported to the TestingT.
+// Call Finish at the end of the test to report expectations that were not
+// met.
+//
+// 	out := yarpctest.NewFakeOutbound(t)
+// 	defer out.Finish()
+// 	out.Expect("users", "getUser").ReturnsJSON(&User{Name: "alice"})
+//
+// 	client := json.New(out.ClientConfig("frontend", "users"))
+type FakeOutbound struct {
+	transport.Lifecycle
+
+	t TestingT
+
+	lock         sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+}
+
+var (
+	_ transport.UnaryOutbound  = (*FakeOutbound)(nil)
+	_ transport.OnewayOutbound = (*FakeOutbound)(nil)
+)
+
+// NewFakeOutbound builds a FakeOutbound which reports failures to the given
+// TestingT.
+func NewFakeOutbound(t TestingT) *FakeOutbound {
+	return &FakeOutbound{
+		Lifecycle: intsync.NewNopLifecycle(),
+		t:         t,
+	}
+}
+
+// Transports returns no transports.
+func (o *FakeOutbound) Transports() []transport.Transport {
+	return nil
+}
+
+// ClientConfig builds a ClientConfig which sends both unary and oneway
+// requests from the given caller to the given service through this outbound.
+func (o *FakeOutbound) ClientConfig(caller, service string) transport.ClientConfig {
+	return clientconfig.MultiOutbound(caller, service, transport.Outbounds{
+		ServiceName: service,
+		Unary:       o,
+		Oneway:      o,
+	})
+}
+
+// Expect declares that a request will be made to the given procedure of the
+// given service. By default, the expectation must be met exactly once and
+// responds with an empty body.
+func (o *FakeOutbound) Expect(service, procedure string) *Expectation {
+	e := &Expectation{service: service, procedure: procedure, min: 1, max: 1}
+
+	o.lock.Lock()
+	o.expectations = append(o.expectations, e)
+	o.lock.Unlock()
+	return e
+}
+
+// Calls returns all calls made through the outbound so far, including calls
+// which did not match any expectation.
+func (o *FakeOutbound) Calls() []Call {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	calls := make([]Call, len(o.calls))
+	copy(calls, o.calls)
+	return calls
+}
+
+// Finish reports all expectations which were not met.
+func (o *FakeOutbound) Finish() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	for _, e := range o.expectations {
+		if e.calls < e.min {
+			o.t.Errorf("missing call to %v: expected %d call(s), got %d", e, e.min, e.calls)
+		}
+	}
+}
+
+// Call responds to a unary request as programmed by the first matching
+// expectation.
+func (o *FakeOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	call, err := o.record(req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := o.match(call)
+	if err != nil {
+		return nil, err
+	}
+	return e.respond(ctx, call, req)
+}
+
+// CallOneway responds to a oneway request as programmed by the first
+// matching expectation. Response bodies and headers are ignored.
+func (o *FakeOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	call, err := o.record(req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := o.match(call)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.respond(ctx, call, req); err != nil {
+		return nil, err
+	}
+	return time.Now(), nil
+}
+
+func (o *FakeOutbound) record(req *transport.Request, oneway bool) (Call, error) {
+	call := Call{
+		Caller:          req.Caller,
+		Service:         req.Service,
+		Procedure:       req.Procedure,
+		Encoding:        req.Encoding,
+		Headers:         make(map[string]string, req.Headers.Len()),
+		ShardKey:        req.ShardKey,
+		RoutingKey:      req.RoutingKey,
+		RoutingDelegate: req.RoutingDelegate,
+		Oneway:          oneway,
+	}
+	for k, v := range req.Headers.Items() {
+		call.Headers[k] = v
+	}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return call, err
+		}
+		call.Body = body
+	}
+
+	o.lock.Lock()
+	o.calls = append(o.calls, call)
+	o.lock.Unlock()
+	return call, nil
+}
+
+func (o *FakeOutbound) match(call Call) (*Expectation, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	for _, e := range o.expectations {
+		if e.matches(call) && (e.max < 0 || e.calls < e.max) {
+			e.calls++
+			return e, nil
+		}
+	}
+
+	err := fmt.Errorf("unexpected call to procedure %q of service %q with headers %v",
+		call.Procedure, call.Service, formatHeaders(call.Headers))
+	o.t.Errorf("%v", err)
+	return nil, err
+}
+
+// Call is a request made through a FakeOutbound.
+type Call struct {
+	Caller          string
+	Service         string
+	Procedure       string
+	Encoding        transport.Encoding
+	Headers         map[string]string
+	ShardKey        string
+	RoutingKey      string
+	RoutingDelegate string
+	Body            []byte
+	Oneway          bool
+}
+
+// Expectation describes a request expected by a FakeOutbound and how to
+// respond to it. Its methods may be chained.
+type Expectation struct {
+	service   string
+	procedure string
+	headers   map[string]string
+
+	body       []byte
+	resHeaders transport.Headers
+	// headers required by the encoding of the body
+	encodingHeaders transport.Headers
+	appErr          bool
+	err             error
+	delay           time.Duration
+	handle          func(context.Context, *transport.Request) (*transport.Response, error)
+
+	min, max int // max < 0 means unlimited
+	calls    int
+}
+
+func (e *Expectation) String() string {
+	s := fmt.Sprintf("procedure %q of service %q", e.procedure, e.service)
+	if len(e.headers) > 0 {
+		s += " with headers " + formatHeaders(e.headers)
+	}
+	return s
+}
+
+// WithHeader restricts the expectation to requests which carry the given
+// header.
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+	if e.headers == nil {
+		e.headers = make(map[string]string)
+	}
+	e.headers[transport.CanonicalizeHeaderKey(key)] = value
+	return e
+}
+
+// Times sets the number of calls expected. It defaults to 1.
+func (e *Expectation) Times(n int) *Expectation {
+	e.min, e.max = n, n
+	return e
+}
+
+// AnyTimes allows the expectation to be met any number of times, including
+// zero.
+func (e *Expectation) AnyTimes() *Expectation {
+	e.min, e.max = 0, -1
+	return e
+}
+
+// Returns responds with the given body.
+func (e *Expectation) Returns(body []byte) *Expectation {
+	e.body = body
+	return e
+}
+
+// ReturnsJSON responds with the JSON encoding of the given value, as
+// expected by clients of the JSON encoding. It panics if the value cannot be
+// encoded.
+func (e *Expectation) ReturnsJSON(v interface{}) *Expectation {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("cannot encode JSON response for %v: %v", e, err))
+	}
+	return e.Returns(body)
+}
+
+// ReturnsHeaders responds with the given application headers.
+func (e *Expectation) ReturnsHeaders(headers transport.Headers) *Expectation {
+	e.resHeaders = headers
+	return e
+}
+
+// ReturnsApplicationError marks the response as an application error.
+func (e *Expectation) ReturnsApplicationError() *Expectation {
+	e.appErr = true
+	return e
+}
+
+// ReturnsError fails the request with the given error.
+func (e *Expectation) ReturnsError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Delay waits for the given duration before responding. Requests whose
+// deadline passes first fail with a timeout error.
+func (e *Expectation) Delay(d time.Duration) *Expectation {
+	e.delay = d
+	return e
+}
+
+// Do responds by calling the given function, after any delay. The request
+// body may be read again.
+func (e *Expectation) Do(f func(context.Context, *transport.Request) (*transport.Response, error)) *Expectation {
+	e.handle = f
+	return e
+}
+
+func (e *Expectation) matches(call Call) bool {
+	if call.Service != e.service || call.Procedure != e.procedure {
+		return false
+	}
+	for k, v := range e.headers {
+		if call.Headers[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Expectation) respond(ctx context.Context, call Call, req *transport.Request) (*transport.Response, error) {
+	if e.delay > 0 {
+		timer := time.NewTimer(e.delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			err := ctx.Err()
+			if err == context.DeadlineExceeded {
+				err = errors.ClientTimeoutError(call.Service, call.Procedure, e.delay)
+			}
+			return nil, err
+		}
+	}
+
+	if e.handle != nil {
+		r := *req
+		r.Body = bytes.NewReader(call.Body)
+		return e.handle(ctx, &r)
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	headers := transport.NewHeadersWithCapacity(e.resHeaders.Len() + e.encodingHeaders.Len())
+	for k, v := range e.resHeaders.Items() {
+		headers = headers.With(k, v)
+	}
+	for k, v := range e.encodingHeaders.Items() {
+		headers = headers.With(k, v)
+	}
+	return &transport.Response{
+		Headers:          headers,
+		Body:             ioutil.NopCloser(bytes.NewReader(e.body)),
+		ApplicationError: e.appErr,
+	}, nil
+}
+
+func formatHeaders(headers map[string]string) string {
+	pairs := make([]string, 0, len(headers))
+	for k, v := range headers {
+		pairs = append(pairs, fmt.Sprintf("%v=%q", k, v))
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpctest
+
+import (
+	"bytes"
+	"fmt"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/x/protobuf"
+
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/thriftrw/protocol"
+	"go.uber.org/thriftrw/wire"
+)
+
+// ReturnsProto responds with the given message, as expected by clients of
+// the protobuf encoding. It panics if the message cannot be encoded.
+func (e *Expectation) ReturnsProto(message proto.Message) *Expectation {
+	body, err := proto.Marshal(message)
+	if err != nil {
+		panic(fmt.Sprintf("cannot encode protobuf response for %v: %v", e, err))
+	}
+	e.encodingHeaders = protobuf.SetRawResponse(transport.NewHeaders())
+	return e.Returns(body)
+}
+
+// ThriftValue is a value which can be converted into its Thrift wire
+// representation, like the generated result struct of a Thrift method.
+type ThriftValue interface {
+	ToWire() (wire.Value, error)
+}
+
+// ReturnsThrift responds with the given Thrift result, as expected by
+// generated Thrift clients using the default protocol without enveloping.
+// The result is usually built with the generated helper for the method.
+//
+// 	result, err := kv.KeyValue_GetValue_Helper.WrapResponse("value", nil)
+// 	out.Expect("keyvalue", "KeyValue::getValue").ReturnsThrift(result)
+//
+// It panics if the result cannot be encoded.
+func (e *Expectation) ReturnsThrift(result ThriftValue) *Expectation {
+	value, err := result.ToWire()
+	if err == nil {
+		var body bytes.Buffer
+		if err = protocol.Binary.Encode(value, &body); err == nil {
+			return e.Returns(body.Bytes())
+		}
+	}
+	panic(fmt.Sprintf("cannot encode Thrift response for %v: %v", e, err))
+}
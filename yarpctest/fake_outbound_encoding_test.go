@@ -0,0 +1,95 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpctest
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/examples/thrift-keyvalue/keyvalue/kv"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/thriftrw/protocol"
+	"go.uber.org/thriftrw/wire"
+)
+
+type testMessage struct {
+	Value string `protobuf:"bytes,1,opt,name=value,proto3"`
+}
+
+func (m *testMessage) Reset()         { *m = testMessage{} }
+func (m *testMessage) String() string { return proto.CompactTextString(m) }
+func (*testMessage) ProtoMessage()    {}
+
+func callFake(t *testing.T, out *FakeOutbound, procedure string) *transport.Response {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res, err := out.Call(ctx, newRequest(procedure, transport.Headers{}))
+	require.NoError(t, err)
+	return res
+}
+
+func TestFakeOutboundReturnsProto(t *testing.T) {
+	out := NewFakeOutbound(t)
+	defer out.Finish()
+	out.Expect("service", "Foo::bar").
+		ReturnsProto(&testMessage{Value: "hello"}).
+		ReturnsHeaders(transport.NewHeaders().With("foo", "bar"))
+
+	res := callFake(t, out, "Foo::bar")
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	var message testMessage
+	require.NoError(t, proto.Unmarshal(body, &message))
+	assert.Equal(t, "hello", message.Value)
+	assert.Equal(t, map[string]string{
+		"foo":                         "bar",
+		"yarpc-protobuf-raw-response": "1",
+	}, res.Headers.Items())
+}
+
+func TestFakeOutboundReturnsThrift(t *testing.T) {
+	result, err := kv.KeyValue_GetValue_Helper.WrapResponse("hello", nil)
+	require.NoError(t, err)
+
+	out := NewFakeOutbound(t)
+	defer out.Finish()
+	out.Expect("service", "KeyValue::getValue").ReturnsThrift(result)
+
+	res := callFake(t, out, "KeyValue::getValue")
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	value, err := protocol.Binary.Decode(bytes.NewReader(body), wire.TStruct)
+	require.NoError(t, err)
+	var got kv.KeyValue_GetValue_Result
+	require.NoError(t, got.FromWire(value))
+	success, err := kv.KeyValue_GetValue_Helper.UnwrapResponse(&got)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", success)
+}
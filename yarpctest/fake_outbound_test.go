@@ -0,0 +1,201 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpctest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingT records failures reported to it.
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func newRequest(procedure string, headers transport.Headers) *transport.Request {
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: procedure,
+		Encoding:  "raw",
+		Headers:   headers,
+		Body:      bytes.NewBufferString("request"),
+	}
+}
+
+func TestFakeOutboundResponses(t *testing.T) {
+	type user struct{ Name string }
+	failure := errors.New("great sadness")
+
+	tests := []struct {
+		desc       string
+		expect     func(*Expectation)
+		wantBody   string
+		wantHeader transport.Headers
+		wantAppErr bool
+		wantErr    error
+	}{
+		{
+			desc:     "canned body",
+			expect:   func(e *Expectation) { e.Returns([]byte("hello")) },
+			wantBody: "hello",
+		},
+		{
+			desc:     "JSON body",
+			expect:   func(e *Expectation) { e.ReturnsJSON(&user{Name: "alice"}) },
+			wantBody: `{"Name":"alice"}`,
+		},
+		{
+			desc: "headers and application error",
+			expect: func(e *Expectation) {
+				e.Returns([]byte("oops")).
+					ReturnsHeaders(transport.NewHeaders().With("foo", "bar")).
+					ReturnsApplicationError()
+			},
+			wantBody:   "oops",
+			wantHeader: transport.NewHeaders().With("foo", "bar"),
+			wantAppErr: true,
+		},
+		{
+			desc:    "error",
+			expect:  func(e *Expectation) { e.ReturnsError(failure) },
+			wantErr: failure,
+		},
+		{
+			desc: "custom handler",
+			expect: func(e *Expectation) {
+				e.Do(func(_ context.Context, req *transport.Request) (*transport.Response, error) {
+					body, err := ioutil.ReadAll(req.Body)
+					return &transport.Response{Body: ioutil.NopCloser(bytes.NewReader(bytes.ToUpper(body)))}, err
+				})
+			},
+			wantBody: "REQUEST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			out := NewFakeOutbound(t)
+			defer out.Finish()
+			tt.expect(out.Expect("service", "procedure"))
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			res, err := out.Call(ctx, newRequest("procedure", transport.Headers{}))
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			require.NoError(t, err)
+
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBody, string(body))
+			assert.Equal(t, tt.wantAppErr, res.ApplicationError)
+			assert.Equal(t, tt.wantHeader.Items(), res.Headers.Items())
+		})
+	}
+}
+
+func TestFakeOutboundExpectations(t *testing.T) {
+	ft := &recordingT{}
+	out := NewFakeOutbound(ft)
+	out.Expect("service", "get").WithHeader("Tenant", "a").Returns([]byte("a"))
+	out.Expect("service", "get").Returns([]byte("default")).Times(2)
+	out.Expect("service", "put").AnyTimes()
+	out.Expect("service", "delete")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	call := func(procedure string, headers transport.Headers) string {
+		res, err := out.Call(ctx, newRequest(procedure, headers))
+		if err != nil {
+			return err.Error()
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	assert.Equal(t, "default", call("get", transport.Headers{}))
+	assert.Equal(t, "a", call("get", transport.NewHeaders().With("tenant", "a")))
+	assert.Equal(t, "default", call("get", transport.NewHeaders().With("tenant", "a")))
+	assert.Equal(t,
+		`unexpected call to procedure "get" of service "service" with headers {}`,
+		call("get", transport.Headers{}))
+
+	_, err := out.CallOneway(ctx, newRequest("put", transport.Headers{}))
+	assert.NoError(t, err)
+
+	out.Finish()
+	assert.Equal(t, []string{
+		`unexpected call to procedure "get" of service "service" with headers {}`,
+		`missing call to procedure "delete" of service "service": expected 1 call(s), got 0`,
+	}, ft.errors)
+
+	calls := out.Calls()
+	require.Len(t, calls, 5)
+	assert.Equal(t, Call{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: "get",
+		Encoding:  "raw",
+		Headers:   map[string]string{"tenant": "a"},
+		Body:      []byte("request"),
+	}, calls[1])
+	assert.True(t, calls[4].Oneway, "expected the last call to be oneway")
+}
+
+func TestFakeOutboundDelay(t *testing.T) {
+	out := NewFakeOutbound(t)
+	defer out.Finish()
+	out.Expect("service", "slow").Delay(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := out.Call(ctx, newRequest("slow", transport.Headers{}))
+	require.Error(t, err)
+	assert.True(t, transport.IsTimeoutError(err), "expected a timeout error, got %v", err)
+}
+
+func TestFakeOutboundClientConfig(t *testing.T) {
+	out := NewFakeOutbound(t)
+	cc := out.ClientConfig("caller", "service")
+	assert.Equal(t, "caller", cc.Caller())
+	assert.Equal(t, "service", cc.Service())
+	assert.Equal(t, out, cc.GetUnaryOutbound())
+	assert.Equal(t, out, cc.GetOnewayOutbound())
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Handle implements the yarpc transport unary inbound middleware interface.
+//
+// In the Overwrite and Append modes, every request the handler serves
+// successfully is recorded along with its response. Requests are not recorded
+// in the Replay mode.
+func (r *Recorder) Handle(
+	ctx context.Context,
+	request *transport.Request,
+	resw transport.ResponseWriter,
+	h transport.UnaryHandler) error {
+	if r.mode == Replay {
+		return h.Handle(ctx, request, resw)
+	}
+
+	requestRecord := r.requestToRequestRecord(request)
+	recordPath := r.makeFilePath(request, r.hashRequestRecord(&requestRecord))
+	if r.mode == Append {
+		if _, err := r.loadRecord(recordPath); err == nil {
+			return h.Handle(ctx, request, resw)
+		}
+	}
+
+	rw := newRecordingResponseWriter(resw)
+	if err := h.Handle(ctx, request, rw); err != nil {
+		return err
+	}
+
+	cachedRecord := record{
+		Version:  currentRecordVersion,
+		Request:  requestRecord,
+		Response: rw.responseRecord(),
+	}
+	r.saveRecord(recordPath, &cachedRecord)
+	return nil
+}
+
+// HandleOneway implements the yarpc transport oneway inbound middleware
+// interface.
+//
+// In the Overwrite and Append modes, every request the handler accepts is
+// recorded. Requests are not recorded in the Replay mode.
+func (r *Recorder) HandleOneway(
+	ctx context.Context,
+	request *transport.Request,
+	h transport.OnewayHandler) error {
+	if r.mode == Replay {
+		return h.HandleOneway(ctx, request)
+	}
+
+	requestRecord := r.requestToRequestRecord(request)
+	requestRecord.Oneway = true
+	recordPath := r.makeFilePath(request, r.hashRequestRecord(&requestRecord))
+	if r.mode == Append {
+		if _, err := r.loadRecord(recordPath); err == nil {
+			return h.HandleOneway(ctx, request)
+		}
+	}
+
+	if err := h.HandleOneway(ctx, request); err != nil {
+		return err
+	}
+
+	cachedRecord := record{
+		Version: currentRecordVersion,
+		Request: requestRecord,
+	}
+	r.saveRecord(recordPath, &cachedRecord)
+	return nil
+}
+
+// ReplayInbound replays every recording of the records directory against the
+// handlers of the given router, regardless of the recorder's mode.
+//
+// It returns an error listing the recordings for which the handler failed or
+// produced a response that differs from the recorded one. Response bodies are
+// compared byte-for-byte and ignored headers are excluded from the
+// comparison.
+//
+// Use yarpc.NewMapRouter to replay the recordings against specific handlers,
+// or Dispatcher.Router to replay them against a whole dispatcher.
+func (r *Recorder) ReplayInbound(ctx context.Context, router transport.Router) error {
+	paths, err := filepath.Glob(filepath.Join(r.recordsDir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for _, path := range paths {
+		cachedRecord, err := r.loadRecord(path)
+		if err == nil {
+			err = r.replayRecord(ctx, router, cachedRecord)
+		}
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("%v: %v", filepath.Base(path), err))
+		}
+	}
+	return errs
+}
+
+func (r *Recorder) replayRecord(ctx context.Context, router transport.Router, cachedRecord *record) error {
+	request := r.recordToRequest(cachedRecord)
+	spec, err := router.Choose(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	switch spec.Type() {
+	case transport.Unary:
+		if cachedRecord.Response == nil {
+			return fmt.Errorf("procedure %q is unary but was recorded as oneway", request.Procedure)
+		}
+		rw := newRecordingResponseWriter(nil)
+		if err := transport.DispatchUnaryHandler(ctx, spec.Unary(), time.Now(), request, rw); err != nil {
+			return err
+		}
+		return r.compareResponses(cachedRecord.Response, rw.responseRecord())
+	case transport.Oneway:
+		if cachedRecord.Response != nil {
+			return fmt.Errorf("procedure %q is oneway but was recorded as unary", request.Procedure)
+		}
+		return transport.DispatchOnewayHandler(ctx, spec.Oneway(), request)
+	default:
+		return fmt.Errorf("procedure %q has unsupported RPC type %v", request.Procedure, spec.Type())
+	}
+}
+
+// compareResponses returns an error describing how the response differs from
+// the recorded one, if it does.
+func (r *Recorder) compareResponses(want, got *responseRecord) error {
+	var errs error
+	if want.ApplicationError != got.ApplicationError {
+		errs = multierr.Append(errs, fmt.Errorf(
+			"expected application error %v, got %v", want.ApplicationError, got.ApplicationError))
+	}
+
+	wantHeaders := r.filterHeaders(want.Headers)
+	gotHeaders := r.filterHeaders(got.Headers)
+	for k, v := range wantHeaders {
+		if gv, ok := gotHeaders[k]; !ok || gv != v {
+			errs = multierr.Append(errs, fmt.Errorf("expected header %q to be %q, got %q", k, v, gv))
+		}
+	}
+	for k, v := range gotHeaders {
+		if _, ok := wantHeaders[k]; !ok {
+			errs = multierr.Append(errs, fmt.Errorf("unexpected header %q: %q", k, v))
+		}
+	}
+
+	if !bytes.Equal(want.Body, got.Body) {
+		errs = multierr.Append(errs, fmt.Errorf("expected body %q, got %q", want.Body, got.Body))
+	}
+	return errs
+}
+
+// filterHeaders returns the given headers without the ignored ones.
+func (r *Recorder) filterHeaders(headers map[string]string) map[string]string {
+	filtered := make(map[string]string, len(headers))
+	for k, v := range headers {
+		k = transport.CanonicalizeHeaderKey(k)
+		if _, ignored := r.ignoredHeaders[k]; !ignored {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+// recordingResponseWriter is a ResponseWriter that keeps a copy of the
+// response written through it.
+type recordingResponseWriter struct {
+	// w is the ResponseWriter the response is forwarded to, if any.
+	w transport.ResponseWriter
+
+	headers          transport.Headers
+	body             bytes.Buffer
+	applicationError bool
+}
+
+func newRecordingResponseWriter(w transport.ResponseWriter) *recordingResponseWriter {
+	return &recordingResponseWriter{w: w, headers: transport.NewHeaders()}
+}
+
+func (rw *recordingResponseWriter) AddHeaders(headers transport.Headers) {
+	for k, v := range headers.Items() {
+		rw.headers = rw.headers.With(k, v)
+	}
+	if rw.w != nil {
+		rw.w.AddHeaders(headers)
+	}
+}
+
+func (rw *recordingResponseWriter) SetApplicationError() {
+	rw.applicationError = true
+	if rw.w != nil {
+		rw.w.SetApplicationError()
+	}
+}
+
+func (rw *recordingResponseWriter) Write(p []byte) (int, error) {
+	if rw.w == nil {
+		return rw.body.Write(p)
+	}
+	n, err := rw.w.Write(p)
+	rw.body.Write(p[:n])
+	return n, err
+}
+
+func (rw *recordingResponseWriter) responseRecord() *responseRecord {
+	return &responseRecord{
+		Headers:          rw.headers.Items(),
+		Body:             rw.body.Bytes(),
+		ApplicationError: rw.applicationError,
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package recorder
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/transport/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func helloProcedure(suffix string) []transport.Procedure {
+	return raw.Procedure("hello",
+		func(ctx context.Context, body []byte) ([]byte, error) {
+			return append(body, []byte(suffix)...), nil
+		})
+}
+
+func notifyProcedure(notified chan<- []byte) []transport.Procedure {
+	return raw.OnewayProcedure("notify",
+		func(ctx context.Context, body []byte) error {
+			notified <- body
+			return nil
+		})
+}
+
+// waitForRecords waits until the given directory contains count recordings.
+func waitForRecords(t *testing.T, dir string, count int) {
+	for i := 0; i < 100; i++ {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+		require.NoError(t, err)
+		if len(paths) >= count {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d recordings in %v", count, dir)
+}
+
+func TestInboundRecordAndReplay(t *testing.T) {
+	tMock := testingTMock{t, 0}
+
+	dir, err := ioutil.TempDir("", "yarpcgorecorder")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // clean up
+
+	recorder := NewRecorder(&tMock, RecordMode(Overwrite), RecordsPath(dir))
+
+	httpTransport := http.NewTransport()
+	serverHTTP := httpTransport.NewInbound(":0")
+	serverDisp := yarpc.NewDispatcher(yarpc.Config{
+		Name:     "server",
+		Inbounds: yarpc.Inbounds{serverHTTP},
+		InboundMiddleware: yarpc.InboundMiddleware{
+			Unary:  recorder,
+			Oneway: recorder,
+		},
+	})
+
+	notified := make(chan []byte, 1)
+	serverDisp.Register(helloProcedure(", World"))
+	serverDisp.Register(notifyProcedure(notified))
+	require.NoError(t, serverDisp.Start())
+	defer serverDisp.Stop()
+
+	outbound := httpTransport.NewSingleOutbound(fmt.Sprintf("http://%s", serverHTTP.Addr()))
+	clientDisp := yarpc.NewDispatcher(yarpc.Config{
+		Name: "client",
+		Outbounds: yarpc.Outbounds{
+			"server": {Unary: outbound, Oneway: outbound},
+		},
+	})
+	require.NoError(t, clientDisp.Start())
+	defer clientDisp.Stop()
+
+	client := raw.New(clientDisp.ClientConfig("server"))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	rbody, err := client.Call(ctx, "hello", []byte("Hello"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("Hello, World"), rbody)
+
+	_, err = client.CallOneway(ctx, "notify", []byte("Hello"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("Hello"), <-notified)
+
+	waitForRecords(t, dir, 2)
+
+	t.Run("same handlers", func(t *testing.T) {
+		router := yarpc.NewMapRouter("server")
+		router.Register(helloProcedure(", World"))
+		router.Register(notifyProcedure(notified))
+
+		assert.NoError(t, recorder.ReplayInbound(ctx, router))
+		assert.Equal(t, []byte("Hello"), <-notified)
+	})
+
+	t.Run("changed handler", func(t *testing.T) {
+		router := yarpc.NewMapRouter("server")
+		router.Register(helloProcedure(", Moon"))
+		router.Register(notifyProcedure(notified))
+
+		err := recorder.ReplayInbound(ctx, router)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `expected body "Hello, World", got "Hello, Moon"`)
+		<-notified
+	})
+
+	t.Run("missing handler", func(t *testing.T) {
+		router := yarpc.NewMapRouter("server")
+		router.Register(helloProcedure(", World"))
+
+		err := recorder.ReplayInbound(ctx, router)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unrecognized procedure "notify" for service "server"`)
+	})
+}
+
+func TestCompareResponses(t *testing.T) {
+	recorder := NewRecorder(t, IgnoreHeaders("request-id"))
+
+	tests := []struct {
+		desc    string
+		want    responseRecord
+		got     responseRecord
+		wantErr []string
+	}{
+		{
+			desc: "equal",
+			want: responseRecord{Headers: map[string]string{"foo": "bar"}, Body: []byte("body")},
+			got:  responseRecord{Headers: map[string]string{"foo": "bar"}, Body: []byte("body")},
+		},
+		{
+			desc: "ignored headers",
+			want: responseRecord{Headers: map[string]string{"request-id": "1"}},
+			got:  responseRecord{Headers: map[string]string{"request-id": "2", "Request-Id": "3"}},
+		},
+		{
+			desc: "different",
+			want: responseRecord{
+				Headers: map[string]string{"foo": "bar", "baz": "qux"},
+				Body:    []byte("body"),
+			},
+			got: responseRecord{
+				Headers:          map[string]string{"foo": "baz", "hello": "world"},
+				Body:             []byte("other body"),
+				ApplicationError: true,
+			},
+			wantErr: []string{
+				`expected application error false, got true`,
+				`expected header "foo" to be "bar", got "baz"`,
+				`expected header "baz" to be "qux", got ""`,
+				`unexpected header "hello": "world"`,
+				`expected body "body", got "other body"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := recorder.compareResponses(&tt.want, &tt.got)
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, msg := range tt.wantErr {
+				assert.Contains(t, err.Error(), msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package recorder
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/thriftrw/protocol"
+	"go.uber.org/thriftrw/wire"
+)
+
+// BodyNormalizer rewrites the body of a request to the given procedure into a
+// canonical form, so that requests which differ only in how they were
+// serialized match the same recording.
+//
+// Requests whose body cannot be normalized are matched byte-for-byte.
+type BodyNormalizer func(procedure string, body []byte) ([]byte, error)
+
+// ThriftBody is a BodyNormalizer for Thrift requests. Struct fields are
+// ordered by field ID and the items of maps and sets by their serialized
+// form, so the order in which they were written doesn't affect matching.
+//
+// Enveloped requests are not supported and are matched byte-for-byte.
+func ThriftBody(procedure string, body []byte) ([]byte, error) {
+	v, err := protocol.Binary.Decode(bytes.NewReader(body), wire.TStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err = canonicalThriftValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return encodeThriftValue(v)
+}
+
+// ProtoBody returns a BodyNormalizer for Protobuf requests. newRequest must
+// return an empty request message for the given procedure, or nil to match
+// the requests of that procedure byte-for-byte.
+//
+// Bodies are decoded into the request message and encoded back, so requests
+// match regardless of the order in which their fields were written.
+func ProtoBody(newRequest func(procedure string) proto.Message) BodyNormalizer {
+	return func(procedure string, body []byte) ([]byte, error) {
+		message := newRequest(procedure)
+		if message == nil {
+			return body, nil
+		}
+		if err := proto.Unmarshal(body, message); err != nil {
+			return nil, err
+		}
+		return proto.Marshal(message)
+	}
+}
+
+func canonicalThriftValue(v wire.Value) (wire.Value, error) {
+	switch v.Type() {
+	case wire.TStruct:
+		fields := make([]wire.Field, len(v.GetStruct().Fields))
+		for i, f := range v.GetStruct().Fields {
+			value, err := canonicalThriftValue(f.Value)
+			if err != nil {
+				return wire.Value{}, err
+			}
+			fields[i] = wire.Field{ID: f.ID, Value: value}
+		}
+		sort.Sort(fieldsByID(fields))
+		return wire.NewValueStruct(wire.Struct{Fields: fields}), nil
+
+	case wire.TList:
+		items, err := canonicalThriftValues(wire.ValueListToSlice(v.GetList()))
+		if err != nil {
+			return wire.Value{}, err
+		}
+		return wire.NewValueList(wire.ValueListFromSlice(v.GetList().ValueType(), items)), nil
+
+	case wire.TSet:
+		items, err := canonicalThriftValues(wire.ValueListToSlice(v.GetSet()))
+		if err != nil {
+			return wire.Value{}, err
+		}
+		keys, err := encodeThriftValues(items)
+		if err != nil {
+			return wire.Value{}, err
+		}
+		sort.Sort(byEncodedKey{keys: keys, swap: func(i, j int) {
+			items[i], items[j] = items[j], items[i]
+		}})
+		return wire.NewValueSet(wire.ValueListFromSlice(v.GetSet().ValueType(), items)), nil
+
+	case wire.TMap:
+		items := wire.MapItemListToSlice(v.GetMap())
+		mapKeys := make([]wire.Value, len(items))
+		for i, item := range items {
+			key, err := canonicalThriftValue(item.Key)
+			if err != nil {
+				return wire.Value{}, err
+			}
+			value, err := canonicalThriftValue(item.Value)
+			if err != nil {
+				return wire.Value{}, err
+			}
+			items[i] = wire.MapItem{Key: key, Value: value}
+			mapKeys[i] = key
+		}
+		keys, err := encodeThriftValues(mapKeys)
+		if err != nil {
+			return wire.Value{}, err
+		}
+		sort.Sort(byEncodedKey{keys: keys, swap: func(i, j int) {
+			items[i], items[j] = items[j], items[i]
+		}})
+		m := v.GetMap()
+		return wire.NewValueMap(wire.MapItemListFromSlice(m.KeyType(), m.ValueType(), items)), nil
+
+	default:
+		return v, nil
+	}
+}
+
+func canonicalThriftValues(values []wire.Value) ([]wire.Value, error) {
+	out := make([]wire.Value, len(values))
+	for i, v := range values {
+		cv, err := canonicalThriftValue(v)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = cv
+	}
+	return out, nil
+}
+
+func encodeThriftValue(v wire.Value) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := protocol.Binary.Encode(v, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeThriftValues(values []wire.Value) ([][]byte, error) {
+	encoded := make([][]byte, len(values))
+	for i, v := range values {
+		b, err := encodeThriftValue(v)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = b
+	}
+	return encoded, nil
+}
+
+type fieldsByID []wire.Field
+
+func (fs fieldsByID) Len() int           { return len(fs) }
+func (fs fieldsByID) Less(i, j int) bool { return fs[i].ID < fs[j].ID }
+func (fs fieldsByID) Swap(i, j int)      { fs[i], fs[j] = fs[j], fs[i] }
+
+// byEncodedKey sorts items by their serialized keys. swap is called to keep
+// the items in the same order as their keys.
+type byEncodedKey struct {
+	keys [][]byte
+	swap func(i, j int)
+}
+
+func (b byEncodedKey) Len() int           { return len(b.keys) }
+func (b byEncodedKey) Less(i, j int) bool { return bytes.Compare(b.keys[i], b.keys[j]) < 0 }
+
+func (b byEncodedKey) Swap(i, j int) {
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+	b.swap(i, j)
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package recorder
+
+import (
+	"bytes"
+	"testing"
+
+	"go.uber.org/yarpc/internal/examples/protobuf/examplepb"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/thriftrw/protocol"
+	"go.uber.org/thriftrw/wire"
+)
+
+func thriftStruct(fields ...wire.Field) wire.Value {
+	return wire.NewValueStruct(wire.Struct{Fields: fields})
+}
+
+func thriftMap(keys ...string) wire.Value {
+	items := make([]wire.MapItem, len(keys))
+	for i, k := range keys {
+		items[i] = wire.MapItem{Key: wire.NewValueString(k), Value: wire.NewValueI32(int32(len(k)))}
+	}
+	return wire.NewValueMap(wire.MapItemListFromSlice(wire.TBinary, wire.TI32, items))
+}
+
+func thriftSet(values ...int32) wire.Value {
+	items := make([]wire.Value, len(values))
+	for i, v := range values {
+		items[i] = wire.NewValueI32(v)
+	}
+	return wire.NewValueSet(wire.ValueListFromSlice(wire.TI32, items))
+}
+
+func thriftList(values ...wire.Value) wire.Value {
+	return wire.NewValueList(wire.ValueListFromSlice(wire.TStruct, values))
+}
+
+func TestThriftBody(t *testing.T) {
+	foo := wire.Field{ID: 1, Value: wire.NewValueString("foo")}
+	bar := wire.Field{ID: 2, Value: wire.NewValueI32(42)}
+	baz := wire.Field{ID: 2, Value: wire.NewValueI32(43)}
+
+	tests := []struct {
+		desc        string
+		left, right wire.Value
+		wantEqual   bool
+	}{
+		{
+			desc:      "field order",
+			left:      thriftStruct(foo, bar),
+			right:     thriftStruct(bar, foo),
+			wantEqual: true,
+		},
+		{
+			desc:      "map order",
+			left:      thriftStruct(wire.Field{ID: 1, Value: thriftMap("a", "bb", "ccc")}),
+			right:     thriftStruct(wire.Field{ID: 1, Value: thriftMap("ccc", "a", "bb")}),
+			wantEqual: true,
+		},
+		{
+			desc:      "set order",
+			left:      thriftStruct(wire.Field{ID: 1, Value: thriftSet(1, 2, 3)}),
+			right:     thriftStruct(wire.Field{ID: 1, Value: thriftSet(3, 1, 2)}),
+			wantEqual: true,
+		},
+		{
+			desc: "nested field order",
+			left: thriftStruct(wire.Field{ID: 1, Value: thriftList(
+				thriftStruct(foo, bar), thriftStruct(foo, baz))}),
+			right: thriftStruct(wire.Field{ID: 1, Value: thriftList(
+				thriftStruct(bar, foo), thriftStruct(baz, foo))}),
+			wantEqual: true,
+		},
+		{
+			desc:  "list order",
+			left:  thriftStruct(wire.Field{ID: 1, Value: thriftList(thriftStruct(foo), thriftStruct(bar))}),
+			right: thriftStruct(wire.Field{ID: 1, Value: thriftList(thriftStruct(bar), thriftStruct(foo))}),
+		},
+		{
+			desc:  "different values",
+			left:  thriftStruct(foo, bar),
+			right: thriftStruct(foo, baz),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var left, right bytes.Buffer
+			require.NoError(t, protocol.Binary.Encode(tt.left, &left))
+			require.NoError(t, protocol.Binary.Encode(tt.right, &right))
+
+			normalizedLeft, err := ThriftBody("hello", left.Bytes())
+			require.NoError(t, err)
+			normalizedRight, err := ThriftBody("hello", right.Bytes())
+			require.NoError(t, err)
+
+			if tt.wantEqual {
+				assert.Equal(t, normalizedLeft, normalizedRight)
+			} else {
+				assert.NotEqual(t, normalizedLeft, normalizedRight)
+			}
+		})
+	}
+
+	_, err := ThriftBody("hello", []byte("not thrift"))
+	assert.Error(t, err)
+}
+
+func TestProtoBody(t *testing.T) {
+	normalize := ProtoBody(func(procedure string) proto.Message {
+		if procedure == "KeyValue::SetValue" {
+			return &examplepb.SetValueRequest{}
+		}
+		return nil
+	})
+
+	inOrder := []byte{0x0a, 0x01, 'k', 0x12, 0x01, 'v'}
+	reversed := []byte{0x12, 0x01, 'v', 0x0a, 0x01, 'k'}
+
+	normalizedInOrder, err := normalize("KeyValue::SetValue", inOrder)
+	require.NoError(t, err)
+	normalizedReversed, err := normalize("KeyValue::SetValue", reversed)
+	require.NoError(t, err)
+	assert.Equal(t, normalizedInOrder, normalizedReversed)
+
+	body, err := normalize("KeyValue::GetValue", reversed)
+	require.NoError(t, err)
+	assert.Equal(t, reversed, body, "bodies of unknown procedures must be left untouched")
+
+	_, err = normalize("KeyValue::SetValue", []byte{0x0a, 0x05, 'k'})
+	assert.Error(t, err)
+}
@@ -18,7 +18,7 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
-// Package recorder records & replay yarpc requests on the client side.
+// Package recorder records & replay yarpc requests.
 //
 // For recording, the client must be connected and able to issue requests to a
 // remote service. Every request and its response is recorded into a YAML file,
@@ -52,6 +52,26 @@
 //
 // The recorded messages will be stored in
 // `./testdata/recordings/*.yaml`.
+//
+// Oneway requests are recorded too when the Recorder is installed as a oneway
+// outbound middleware. Their recordings have no response, and replaying them
+// returns an acknowledgement without contacting the remote service.
+//
+// Requests are matched to recordings by their caller, service, encoding,
+// procedure, headers, shard key, routing key, routing delegate and body.
+// IgnoreHeaders excludes headers that change between runs (request IDs,
+// timestamps...) from the match, and NormalizeBody matches bodies of an
+// encoding semantically rather than byte-for-byte:
+//
+//  recorder.NewRecorder(t,
+//    recorder.IgnoreHeaders("x-request-id"),
+//    recorder.NormalizeBody(thrift.Encoding, recorder.ThriftBody),
+//  )
+//
+// The Recorder is also a yarpc inbound middleware. In the Overwrite and Append
+// modes, it records the requests served by the dispatcher along with their
+// responses. The recordings can later be replayed against a handler with
+// ReplayInbound to check that it still produces the same responses.
 package recorder
 
 import (
@@ -66,6 +86,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"unicode"
 
 	"go.uber.org/yarpc/api/transport"
@@ -78,7 +99,8 @@ var recorderFlag = flag.String("recorder", "replay",
 overwrite: record all request/response pairs, overwriting records.
 append: replay existing and record new request/response pairs.`)
 
-// Recorder records & replay yarpc requests on the client side.
+// Recorder records & replay yarpc requests on the client side. It can also
+// record the requests served by a dispatcher; see Handle and ReplayInbound.
 //
 // For recording, the client must be connected and able to issue requests to a
 // remote service. Every request and its response is recorded into a YAML file,
@@ -88,9 +110,14 @@ append: replay existing and record new request/response pairs.`)
 // request Recorder will return the recorded response. Any new request will
 // abort the test by calling logger.Fatal().
 type Recorder struct {
-	mode       Mode
-	logger     TestingT
-	recordsDir string
+	mode           Mode
+	logger         TestingT
+	recordsDir     string
+	ignoredHeaders map[string]struct{}
+	normalizers    map[transport.Encoding]BodyNormalizer
+
+	// saveLock serializes writes to the records directory.
+	saveLock sync.Mutex
 }
 
 const defaultRecorderDir = "testdata/recordings"
@@ -167,15 +194,17 @@ func NewRecorder(logger TestingT, opts ...Option) *Recorder {
 	if err != nil {
 		logger.Fatal(err)
 	}
-	recorder := &Recorder{
-		logger: logger,
-	}
-
 	var cfg config
 	for _, opt := range opts {
 		opt(&cfg)
 	}
 
+	recorder := &Recorder{
+		logger:         logger,
+		ignoredHeaders: cfg.IgnoredHeaders,
+		normalizers:    cfg.Normalizers,
+	}
+
 	if cfg.RecordsPath != "" {
 		recorder.recordsDir = cfg.RecordsPath
 	} else {
@@ -207,12 +236,38 @@ func RecordsPath(path string) Option {
 	}
 }
 
+// IgnoreHeaders excludes the given request headers when matching requests to
+// recordings. The headers are still saved in new recordings.
+func IgnoreHeaders(keys ...string) Option {
+	return func(cfg *config) {
+		if cfg.IgnoredHeaders == nil {
+			cfg.IgnoredHeaders = make(map[string]struct{}, len(keys))
+		}
+		for _, k := range keys {
+			cfg.IgnoredHeaders[transport.CanonicalizeHeaderKey(k)] = struct{}{}
+		}
+	}
+}
+
+// NormalizeBody matches the bodies of requests with the given encoding
+// through the given BodyNormalizer instead of byte-for-byte.
+func NormalizeBody(encoding transport.Encoding, normalizer BodyNormalizer) Option {
+	return func(cfg *config) {
+		if cfg.Normalizers == nil {
+			cfg.Normalizers = make(map[transport.Encoding]BodyNormalizer)
+		}
+		cfg.Normalizers[encoding] = normalizer
+	}
+}
+
 // Option is the type used for the functional options pattern.
 type Option func(*config)
 
 type config struct {
-	Mode        Mode
-	RecordsPath string
+	Mode           Mode
+	RecordsPath    string
+	IgnoredHeaders map[string]struct{}
+	Normalizers    map[transport.Encoding]BodyNormalizer
 }
 
 // SetMode let you choose enable the different replay and recording modes,
@@ -260,6 +315,9 @@ func (r *Recorder) hashRequestRecord(requestRecord *requestRecord) string {
 
 	orderedHeadersKeys := make([]string, 0, len(requestRecord.Headers))
 	for k := range requestRecord.Headers {
+		if _, ignored := r.ignoredHeaders[k]; ignored {
+			continue
+		}
 		orderedHeadersKeys = append(orderedHeadersKeys, k)
 	}
 	sort.Strings(orderedHeadersKeys)
@@ -272,13 +330,34 @@ func (r *Recorder) hashRequestRecord(requestRecord *requestRecord) string {
 	ha(requestRecord.RoutingKey)
 	ha(requestRecord.RoutingDelegate)
 
-	_, err := hash.Write(requestRecord.Body)
+	if requestRecord.Oneway {
+		ha("oneway")
+	}
+
+	_, err := hash.Write(r.normalizeBody(requestRecord))
 	if err != nil {
 		log.Fatal(err)
 	}
 	return fmt.Sprintf("%x", hash.Sum64())
 }
 
+// normalizeBody returns the body of the request in the form used to match it
+// with recordings.
+func (r *Recorder) normalizeBody(requestRecord *requestRecord) []byte {
+	normalize, ok := r.normalizers[transport.Encoding(requestRecord.Encoding)]
+	if !ok {
+		return requestRecord.Body
+	}
+
+	body, err := normalize(requestRecord.Procedure, requestRecord.Body)
+	if err != nil {
+		r.logger.Logf("recorder could not normalize the body of %q, matching it byte-for-byte: %v",
+			requestRecord.Procedure, err)
+		return requestRecord.Body
+	}
+	return body
+}
+
 func (r *Recorder) makeFilePath(request *transport.Request, hash string) string {
 	s := fmt.Sprintf("%s.%s.%s.yaml", request.Service, request.Procedure, hash)
 	return filepath.Join(r.recordsDir, sanitizeFilename(s))
@@ -327,14 +406,74 @@ func (r *Recorder) Call(
 	}
 }
 
+// CallOneway implements the yarpc transport oneway outbound middleware
+// interface
+func (r *Recorder) CallOneway(
+	ctx context.Context,
+	request *transport.Request,
+	out transport.OnewayOutbound) (transport.Ack, error) {
+	log := r.logger
+
+	requestRecord := r.requestToRequestRecord(request)
+	requestRecord.Oneway = true
+
+	requestHash := r.hashRequestRecord(&requestRecord)
+	filepath := r.makeFilePath(request, requestHash)
+
+	switch r.mode {
+	case Replay:
+		if _, err := r.loadRecord(filepath); err != nil {
+			log.Fatal(err)
+		}
+		return recordedAck{}, nil
+	case Append:
+		if _, err := r.loadRecord(filepath); err == nil {
+			return recordedAck{}, nil
+		}
+		fallthrough
+	case Overwrite:
+		ack, err := out.CallOneway(ctx, request)
+		if err == nil {
+			cachedRecord := record{
+				Version: currentRecordVersion,
+				Request: requestRecord,
+			}
+			r.saveRecord(filepath, &cachedRecord)
+		}
+		return ack, err
+	default:
+		panic(fmt.Sprintf("invalid record mode: %v", r.mode))
+	}
+}
+
+// recordedAck acknowledges replayed oneway requests.
+type recordedAck struct{}
+
+func (recordedAck) String() string { return "replayed from recording" }
+
 func (r *Recorder) recordToResponse(cachedRecord *record) transport.Response {
 	response := transport.Response{
-		Headers: transport.HeadersFromMap(cachedRecord.Response.Headers),
-		Body:    ioutil.NopCloser(bytes.NewReader(cachedRecord.Response.Body)),
+		Headers:          transport.HeadersFromMap(cachedRecord.Response.Headers),
+		Body:             ioutil.NopCloser(bytes.NewReader(cachedRecord.Response.Body)),
+		ApplicationError: cachedRecord.Response.ApplicationError,
 	}
 	return response
 }
 
+func (r *Recorder) recordToRequest(cachedRecord *record) *transport.Request {
+	return &transport.Request{
+		Caller:          cachedRecord.Request.Caller,
+		Service:         cachedRecord.Request.Service,
+		Procedure:       cachedRecord.Request.Procedure,
+		Encoding:        transport.Encoding(cachedRecord.Request.Encoding),
+		Headers:         transport.HeadersFromMap(cachedRecord.Request.Headers),
+		ShardKey:        cachedRecord.Request.ShardKey,
+		RoutingKey:      cachedRecord.Request.RoutingKey,
+		RoutingDelegate: cachedRecord.Request.RoutingDelegate,
+		Body:            bytes.NewReader(cachedRecord.Request.Body),
+	}
+}
+
 func (r *Recorder) requestToRequestRecord(request *transport.Request) requestRecord {
 	requestBody, err := ioutil.ReadAll(request.Body)
 	if err != nil {
@@ -354,15 +493,16 @@ func (r *Recorder) requestToRequestRecord(request *transport.Request) requestRec
 	}
 }
 
-func (r *Recorder) responseToResponseRecord(response *transport.Response) responseRecord {
+func (r *Recorder) responseToResponseRecord(response *transport.Response) *responseRecord {
 	responseBody, err := ioutil.ReadAll(response.Body)
 	if err != nil {
 		r.logger.Fatal(err)
 	}
 	response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
-	return responseRecord{
-		Headers: response.Headers.Items(),
-		Body:    responseBody,
+	return &responseRecord{
+		Headers:          response.Headers.Items(),
+		Body:             responseBody,
+		ApplicationError: response.ApplicationError,
 	}
 }
 
@@ -393,7 +533,10 @@ func (r *Recorder) loadRecord(filepath string) (*record, error) {
 // saveRecord attempts to save a record to the given file, any error fails the
 // current test.
 func (r *Recorder) saveRecord(filepath string, cachedRecord *record) {
-	if err := os.MkdirAll(defaultRecorderDir, 0775); err != nil {
+	r.saveLock.Lock()
+	defer r.saveLock.Unlock()
+
+	if err := os.MkdirAll(r.recordsDir, 0775); err != nil {
 		r.logger.Fatal(err)
 	}
 
@@ -406,6 +549,7 @@ func (r *Recorder) saveRecord(filepath string, cachedRecord *record) {
 	if err != nil {
 		r.logger.Fatal(err)
 	}
+	defer file.Close()
 
 	if _, err := file.Write([]byte(recordComment)); err != nil {
 		r.logger.Fatal(err)
@@ -438,17 +582,21 @@ type requestRecord struct {
 	RoutingKey      string
 	RoutingDelegate string
 	Body            base64blob
+	Oneway          bool `yaml:",omitempty"`
 }
 
 type responseRecord struct {
-	Headers map[string]string
-	Body    base64blob
+	Headers          map[string]string
+	Body             base64blob
+	ApplicationError bool `yaml:"applicationerror,omitempty"`
 }
 
 type record struct {
-	Version  uint
-	Request  requestRecord
-	Response responseRecord
+	Version uint
+	Request requestRecord
+
+	// Response is nil for oneway requests.
+	Response *responseRecord `yaml:",omitempty"`
 }
 
 type base64blob []byte
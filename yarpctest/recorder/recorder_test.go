@@ -165,6 +165,75 @@ func TestHash(t *testing.T) {
 	assert.NotEqual(t, recorder.hashRequestRecord(&requestRecord), referenceHash)
 }
 
+func TestHashMatching(t *testing.T) {
+	rgen := newRandomGenerator(42)
+	request := rgen.Request()
+	request.Headers = request.Headers.With("Request-Id", "1")
+	body, err := ioutil.ReadAll(request.Body)
+	require.NoError(t, err)
+
+	// newRequest returns a copy of the reference request with its own body
+	// and headers so that cases don't affect each other.
+	newRequest := func() transport.Request {
+		r := request
+		r.Headers = transport.NewHeadersWithCapacity(request.Headers.Len())
+		for k, v := range request.Headers.Items() {
+			r.Headers = r.Headers.With(k, v)
+		}
+		r.Body = bytes.NewReader(body)
+		return r
+	}
+
+	recorder := NewRecorder(t,
+		IgnoreHeaders("request-id"),
+		NormalizeBody(request.Encoding, func(procedure string, body []byte) ([]byte, error) {
+			assert.Equal(t, request.Procedure, procedure)
+			return bytes.ToLower(body), nil
+		}),
+	)
+	r := newRequest()
+	requestRecord := recorder.requestToRequestRecord(&r)
+	referenceHash := recorder.hashRequestRecord(&requestRecord)
+
+	// Same request
+	r = newRequest()
+	requestRecord = recorder.requestToRequestRecord(&r)
+	assert.Equal(t, referenceHash, recorder.hashRequestRecord(&requestRecord))
+
+	// Ignored header
+	r = newRequest()
+	r.Headers = r.Headers.With("Request-Id", "2")
+	requestRecord = recorder.requestToRequestRecord(&r)
+	assert.Equal(t, referenceHash, recorder.hashRequestRecord(&requestRecord))
+
+	// Normalized body
+	r = newRequest()
+	r.Body = bytes.NewReader(bytes.ToUpper(body))
+	requestRecord = recorder.requestToRequestRecord(&r)
+	assert.Equal(t, referenceHash, recorder.hashRequestRecord(&requestRecord))
+
+	// Other headers
+	r = newRequest()
+	r.Headers = r.Headers.With("Other", "2")
+	requestRecord = recorder.requestToRequestRecord(&r)
+	assert.NotEqual(t, referenceHash, recorder.hashRequestRecord(&requestRecord))
+
+	// Oneway
+	r = newRequest()
+	requestRecord = recorder.requestToRequestRecord(&r)
+	requestRecord.Oneway = true
+	assert.NotEqual(t, referenceHash, recorder.hashRequestRecord(&requestRecord))
+
+	// Failing normalizer
+	recorder = NewRecorder(t, NormalizeBody(request.Encoding,
+		func(string, []byte) ([]byte, error) {
+			return nil, fmt.Errorf("great sadness")
+		}))
+	r = newRequest()
+	requestRecord = recorder.requestToRequestRecord(&r)
+	assert.Equal(t, body, recorder.normalizeBody(&requestRecord))
+}
+
 var testingTMockFatal = struct{}{}
 
 type testingTMock struct {
@@ -181,16 +250,19 @@ func (t *testingTMock) Fatal(args ...interface{}) {
 
 func withDisconnectedClient(t *testing.T, recorder *Recorder, f func(raw.Client)) {
 	httpTransport := http.NewTransport()
+	outbound := httpTransport.NewSingleOutbound("http://127.0.0.1:65535")
 
 	clientDisp := yarpc.NewDispatcher(yarpc.Config{
 		Name: "client",
 		Outbounds: yarpc.Outbounds{
 			"server": {
-				Unary: httpTransport.NewSingleOutbound("http://127.0.0.1:65535"),
+				Unary:  outbound,
+				Oneway: outbound,
 			},
 		},
 		OutboundMiddleware: yarpc.OutboundMiddleware{
-			Unary: recorder,
+			Unary:  recorder,
+			Oneway: recorder,
 		},
 	})
 	require.NoError(t, clientDisp.Start())
@@ -212,19 +284,26 @@ func withConnectedClient(t *testing.T, recorder *Recorder, f func(raw.Client)) {
 		func(ctx context.Context, body []byte) ([]byte, error) {
 			return append(body, []byte(", World")...), nil
 		}))
+	serverDisp.Register(raw.OnewayProcedure("notify",
+		func(ctx context.Context, body []byte) error {
+			return nil
+		}))
 
 	require.NoError(t, serverDisp.Start())
 	defer serverDisp.Stop()
 
+	outbound := httpTransport.NewSingleOutbound(fmt.Sprintf("http://%s", serverHTTP.Addr()))
 	clientDisp := yarpc.NewDispatcher(yarpc.Config{
 		Name: "client",
 		Outbounds: yarpc.Outbounds{
 			"server": {
-				Unary: httpTransport.NewSingleOutbound(fmt.Sprintf("http://%s", serverHTTP.Addr())),
+				Unary:  outbound,
+				Oneway: outbound,
 			},
 		},
 		OutboundMiddleware: yarpc.OutboundMiddleware{
-			Unary: recorder,
+			Unary:  recorder,
+			Oneway: recorder,
 		},
 	})
 	require.NoError(t, clientDisp.Start())
@@ -374,3 +453,44 @@ func TestReplaying(t *testing.T) {
 		assert.Equal(t, rbody, []byte("Hello, World"))
 	})
 }
+
+func TestOnewayEndToEnd(t *testing.T) {
+	tMock := testingTMock{t, 0}
+
+	dir, err := ioutil.TempDir("", "yarpcgorecorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // clean up
+
+	recorder := NewRecorder(&tMock, RecordMode(Overwrite), RecordsPath(dir))
+
+	withConnectedClient(t, recorder, func(client raw.Client) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		_, err := client.CallOneway(ctx, "notify", []byte("Hello"))
+		require.NoError(t, err)
+	})
+
+	recordContent, err := ioutil.ReadFile(path.Join(dir, `server.notify.2ac2a0d0ef4f807f.yaml`))
+	require.NoError(t, err)
+	assert.Contains(t, string(recordContent), "oneway: true")
+	assert.NotContains(t, string(recordContent), "response:")
+
+	recorder = NewRecorder(&tMock, RecordMode(Replay), RecordsPath(dir))
+
+	withDisconnectedClient(t, recorder, func(client raw.Client) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		ack, err := client.CallOneway(ctx, "notify", []byte("Hello"))
+		require.NoError(t, err)
+		assert.Equal(t, recordedAck{}, ack)
+
+		require.Panics(t, func() {
+			client.CallOneway(ctx, "notify", []byte("Goodbye"))
+		})
+		assert.Equal(t, 1, tMock.fatalCount)
+	})
+}